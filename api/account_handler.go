@@ -0,0 +1,132 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"pansou/plugin/accounts"
+)
+
+// ResolveAccountRequest 由登录标识查找账号ID的请求
+type ResolveAccountRequest struct {
+	Identifier string `json:"identifier"`
+}
+
+// ListAccountPluginsHandler 返回已启用的登录型插件及其支持的账号操作
+func ListAccountPluginsHandler(c *gin.Context) {
+	plugins := make([]accounts.Summary, 0)
+	for _, m := range accounts.Managers() {
+		summary := m.Summary(false)
+		plugins = append(plugins, accounts.Summary{
+			Plugin:    summary.Plugin,
+			RateLimit: summary.RateLimit,
+			Actions:   summary.Actions,
+		})
+	}
+	c.JSON(200, gin.H{"plugins": plugins})
+}
+
+// ResolveAccountHandler 由登录标识（用户名、QQ号等）计算账号ID，账号不存在时exists为false
+func ResolveAccountHandler(c *gin.Context) {
+	m, ok := accountManager(c)
+	if !ok {
+		return
+	}
+	var req ResolveAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Identifier == "" {
+		c.JSON(400, gin.H{"error": "参数错误：identifier不能为空"})
+		return
+	}
+	id, ok := m.HashID(req.Identifier)
+	if !ok {
+		c.JSON(400, gin.H{"error": "插件不支持按登录标识查找账号"})
+		return
+	}
+	_, exists := m.Get(id)
+	c.JSON(200, gin.H{"plugin": m.Plugin(), "id": id, "exists": exists})
+}
+
+// GetAccountHandler 返回单个账号的状态与健康信息（不含Cookie、密码等敏感数据）
+func GetAccountHandler(c *gin.Context) {
+	m, ok := accountManager(c)
+	if !ok {
+		return
+	}
+	r, ok := m.Get(c.Param("id"))
+	if !ok {
+		c.JSON(404, gin.H{"error": "账号不存在"})
+		return
+	}
+	c.JSON(200, m.Info(r))
+}
+
+// AccountActionHandler 执行插件的账号操作（get_status、login、check_login等），请求体为操作参数
+// 响应格式由插件决定，与插件管理页面的接口一致
+func AccountActionHandler(c *gin.Context) {
+	m, ok := accountManager(c)
+	if !ok {
+		return
+	}
+	id := c.Param("id")
+	if !accounts.ValidID(id) {
+		c.JSON(400, gin.H{"error": "账号ID无效"})
+		return
+	}
+	params := make(map[string]interface{})
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&params); err != nil {
+			c.JSON(400, gin.H{"error": "无效的请求格式: " + err.Error()})
+			return
+		}
+	}
+	action := c.Param("action")
+	params["action"] = action
+	if !m.HandleAction(c, id, action, params) {
+		c.JSON(400, gin.H{"error": "未知的操作类型: " + action, "actions": m.ActionNames()})
+	}
+}
+
+// ListAccountsHandler 返回所有登录型插件的账号统计与账号列表（管理员）
+func ListAccountsHandler(c *gin.Context) {
+	plugins := make([]accounts.Summary, 0)
+	for _, m := range accounts.Managers() {
+		plugins = append(plugins, m.Summary(true))
+	}
+	c.JSON(200, gin.H{"plugins": plugins})
+}
+
+// DeleteAccountHandler 删除账号（管理员）
+func DeleteAccountHandler(c *gin.Context) {
+	m, ok := accountManager(c)
+	if !ok {
+		return
+	}
+	id := c.Param("id")
+	if _, exists := m.Get(id); !exists {
+		c.JSON(404, gin.H{"error": "账号不存在"})
+		return
+	}
+	if err := m.Delete(id); err != nil {
+		c.JSON(500, gin.H{"error": "删除账号失败: " + err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"success": true})
+}
+
+// KeepAliveAccountsHandler 立即为插件的所有已登录账号执行一次保活并返回最新状态（管理员）
+func KeepAliveAccountsHandler(c *gin.Context) {
+	m, ok := accountManager(c)
+	if !ok {
+		return
+	}
+	m.KeepAliveAll()
+	c.JSON(200, m.Summary(true))
+}
+
+// accountManager 获取路径参数指定插件的账号管理器，插件未启用或不是登录型插件时返回404
+func accountManager(c *gin.Context) (*accounts.Manager, bool) {
+	m, ok := accounts.GetManager(c.Param("plugin"))
+	if !ok {
+		c.JSON(404, gin.H{"error": "插件不存在或不支持账号管理: " + c.Param("plugin")})
+		return nil, false
+	}
+	return m, true
+}
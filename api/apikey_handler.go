@@ -0,0 +1,109 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"pansou/service"
+	"pansou/util"
+)
+
+// CreateAPIKeyRequest 创建API Key请求结构
+type CreateAPIKeyRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	Role      string   `json:"role"`
+	Plugins   []string `json:"plugins"`
+	CacheOnly bool     `json:"cache_only"`
+}
+
+// CreateAPIKeyHandler 创建API Key（明文Key只在创建时返回一次）
+func CreateAPIKeyHandler(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "参数错误：名称不能为空"})
+		return
+	}
+
+	role := strings.ToLower(strings.TrimSpace(req.Role))
+	if role == "" {
+		role = util.RoleSearch
+	}
+	if !util.IsValidRole(role) {
+		c.JSON(400, gin.H{"error": "参数错误：角色必须是 admin、search 或 readonly"})
+		return
+	}
+
+	plugins := make([]string, 0, len(req.Plugins))
+	for _, p := range req.Plugins {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			plugins = append(plugins, p)
+		}
+	}
+
+	plainKey, key, err := util.GetAPIKeyStore().Create(strings.TrimSpace(req.Name), role, plugins, req.CacheOnly)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "创建API Key失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"key":  plainKey,
+		"info": key.Info(),
+	})
+}
+
+// ListAPIKeysHandler 列出所有API Key
+func ListAPIKeysHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"keys": util.GetAPIKeyStore().List()})
+}
+
+// RevokeAPIKeyHandler 吊销API Key
+func RevokeAPIKeyHandler(c *gin.Context) {
+	if err := util.GetAPIKeyStore().Revoke(c.Param("id")); err != nil {
+		if err == util.ErrAPIKeyNotFound {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(500, gin.H{"error": "吊销API Key失败: " + err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": "已吊销"})
+}
+
+// applyAPIKeyScope 按API Key的限定范围调整搜索请求
+// 返回false表示请求超出Key的权限范围
+func applyAPIKeyScope(c *gin.Context, plugins []string, sourceType string, ext map[string]interface{}) ([]string, string, bool) {
+	value, exists := c.Get("api_key")
+	if !exists {
+		return plugins, sourceType, true
+	}
+	key := value.(*util.APIKey)
+
+	// 只允许读取缓存
+	if key.CacheOnly {
+		ext[service.CacheOnlyExtKey] = true
+	}
+
+	// 限定插件的Key不能搜索TG频道，且只能搜索允许的插件
+	if len(key.Plugins) > 0 {
+		if sourceType == "tg" {
+			return nil, sourceType, false
+		}
+		sourceType = "plugin"
+
+		if len(plugins) == 0 {
+			return key.Plugins, sourceType, true
+		}
+		allowed := make([]string, 0, len(plugins))
+		for _, p := range plugins {
+			if key.AllowsPlugin(p) {
+				allowed = append(allowed, p)
+			}
+		}
+		if len(allowed) == 0 {
+			return nil, sourceType, false
+		}
+		plugins = allowed
+	}
+	return plugins, sourceType, true
+}
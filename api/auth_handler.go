@@ -1,6 +1,9 @@
 package api
 
 import (
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,11 +17,24 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// RefreshRequest 刷新令牌请求结构
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest 退出登录请求结构
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 // LoginResponse 登录响应结构
 type LoginResponse struct {
-	Token     string `json:"token"`
-	ExpiresAt int64  `json:"expires_at"`
-	Username  string `json:"username"`
+	Token            string `json:"token"`
+	ExpiresAt        int64  `json:"expires_at"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresAt int64  `json:"refresh_expires_at"`
+	Username         string `json:"username"`
+	Role             string `json:"role"`
 }
 
 // LoginHandler 处理用户登录
@@ -41,30 +57,87 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
+	// 检查用户名或IP是否因连续失败被锁定
+	guard := util.GetLoginGuard()
+	clientIP := c.ClientIP()
+	if locked, remaining := guard.Check(req.Username, clientIP); locked {
+		retryAfter := int(math.Ceil(remaining.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(429, gin.H{
+			"error":       "登录失败次数过多，请稍后再试",
+			"retry_after": retryAfter,
+		})
+		return
+	}
+
 	// 验证用户名和密码
 	storedPassword, exists := config.AppConfig.AuthUsers[req.Username]
-	if !exists || storedPassword != req.Password {
+	if !exists || !util.VerifyPassword(storedPassword, req.Password) {
+		guard.RecordFailure(req.Username, clientIP)
 		c.JSON(401, gin.H{"error": "用户名或密码错误"})
 		return
 	}
+	guard.RecordSuccess(req.Username)
 
-	// 生成JWT token
+	// 创建登录会话（刷新令牌）
+	session, refreshToken, err := util.GetSessionStore().Create(req.Username, config.AppConfig.AuthTokenExpiry)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "创建会话失败"})
+		return
+	}
+
+	issueTokens(c, session, refreshToken)
+}
+
+// RefreshHandler 使用刷新令牌换取新的访问令牌，刷新令牌同时轮换
+func RefreshHandler(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "参数错误：刷新令牌不能为空"})
+		return
+	}
+
+	if !config.AppConfig.AuthEnabled {
+		c.JSON(403, gin.H{"error": "认证功能未启用"})
+		return
+	}
+
+	session, refreshToken, err := util.GetSessionStore().Rotate(req.RefreshToken)
+	if err != nil {
+		c.JSON(401, gin.H{
+			"error": err.Error(),
+			"code":  "AUTH_REFRESH_INVALID",
+		})
+		return
+	}
+
+	issueTokens(c, session, refreshToken)
+}
+
+// issueTokens 为会话签发访问令牌并返回令牌对
+func issueTokens(c *gin.Context, session *util.AuthSession, refreshToken string) {
+	role := util.UserRole(session.Username)
 	token, err := util.GenerateToken(
-		req.Username,
+		session.Username,
+		role,
+		session.ID,
 		config.AppConfig.AuthJWTSecret,
-		config.AppConfig.AuthTokenExpiry,
+		config.AppConfig.AuthAccessTokenExpiry,
 	)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "生成令牌失败"})
 		return
 	}
 
-	// 返回token和过期时间
-	expiresAt := time.Now().Add(config.AppConfig.AuthTokenExpiry).Unix()
+	// 返回令牌和过期时间
+	expiresAt := time.Now().Add(config.AppConfig.AuthAccessTokenExpiry).Unix()
 	c.JSON(200, LoginResponse{
-		Token:     token,
-		ExpiresAt: expiresAt,
-		Username:  req.Username,
+		Token:            token,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt.Unix(),
+		Username:         session.Username,
+		Role:             role,
 	})
 }
 
@@ -86,15 +159,41 @@ func VerifyHandler(c *gin.Context) {
 		return
 	}
 
+	role, _ := c.Get("role")
 	c.JSON(200, gin.H{
 		"valid":    true,
 		"username": username,
+		"role":     role,
 	})
 }
 
-// LogoutHandler 退出登录（客户端删除token即可）
+// LogoutHandler 退出登录：吊销访问令牌所属的会话及其刷新令牌
 func LogoutHandler(c *gin.Context) {
-	// JWT是无状态的，服务端不需要处理注销
-	// 客户端删除存储的token即可
+	if !config.AppConfig.AuthEnabled {
+		c.JSON(200, gin.H{"message": "退出成功"})
+		return
+	}
+
+	store := util.GetSessionStore()
+
+	// 通过访问令牌定位会话（已过期的访问令牌需同时提交刷新令牌）
+	if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if claims, err := util.ValidateToken(tokenString, config.AppConfig.AuthJWTSecret); err == nil && claims.SessionID != "" {
+			store.Revoke(claims.SessionID)
+		}
+	}
+
+	var req LogoutRequest
+	if err := c.ShouldBindJSON(&req); err == nil && req.RefreshToken != "" {
+		store.RevokeByRefreshToken(req.RefreshToken)
+	}
+
 	c.JSON(200, gin.H{"message": "退出成功"})
 }
+
+// RevokeUserSessionsHandler 吊销指定用户的全部会话（管理员）
+func RevokeUserSessionsHandler(c *gin.Context) {
+	count := util.GetSessionStore().RevokeUser(c.Param("username"))
+	c.JSON(200, gin.H{"revoked": count})
+}
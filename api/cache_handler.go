@@ -0,0 +1,167 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"pansou/plugin"
+	"pansou/service"
+	"pansou/util/cache"
+)
+
+// cacheEntryFilter 从查询参数解析缓存筛选条件
+func cacheEntryFilter(c *gin.Context) cache.CacheEntryFilter {
+	return cache.CacheEntryFilter{
+		Key:     c.Query("key"),
+		Keyword: c.Query("keyword"),
+		Kind:    c.Query("kind"),
+		Plugin:  c.Query("plugin"),
+		Channel: c.Query("channel"),
+	}
+}
+
+// mainCache 获取主缓存，未启用缓存时返回nil
+func mainCache() *cache.EnhancedTwoLevelCache {
+	return service.GetEnhancedTwoLevelCache()
+}
+
+// CacheStatsHandler 返回两级缓存、插件内存缓存与搜索请求合并的统计
+func CacheStatsHandler(c *gin.Context) {
+	response := gin.H{
+		"enabled":      false,
+		"plugin_cache": plugin.GetAPICacheStats(),
+		"coalescing":   service.CoalescingStats(),
+	}
+	if mc := mainCache(); mc != nil {
+		response["enabled"] = true
+		response["main_cache"] = mc.Stats()
+	}
+	if rc := service.GetResponseCache(); rc != nil {
+		response["response_cache"] = rc.Stats()
+	}
+	c.JSON(200, response)
+}
+
+// ListCacheEntriesHandler 列出主缓存项，支持按键、关键词、类型、插件、频道筛选
+func ListCacheEntriesHandler(c *gin.Context) {
+	mc := mainCache()
+	if mc == nil {
+		c.JSON(503, gin.H{"error": "缓存未启用"})
+		return
+	}
+
+	entries := mc.Entries(cacheEntryFilter(c))
+	total := len(entries)
+	if limit := queryLimit(c, 100, 1000); len(entries) > limit {
+		entries = entries[:limit]
+	}
+	c.JSON(200, gin.H{
+		"total":   total,
+		"entries": entries,
+	})
+}
+
+// InvalidateCacheHandler 按键、关键词、插件或频道删除主缓存项
+// 按关键词或插件删除时，同时删除插件内存缓存中对应的项，避免旧结果被重新写回
+func InvalidateCacheHandler(c *gin.Context) {
+	filter := cacheEntryFilter(c)
+	if filter.IsEmpty() {
+		c.JSON(400, gin.H{"error": "至少需要指定key、keyword、kind、plugin、channel中的一个条件"})
+		return
+	}
+
+	response := gin.H{"main_cache": 0, "plugin_cache": 0}
+	if mc := mainCache(); mc != nil {
+		response["main_cache"] = mc.Invalidate(filter)
+	}
+	if filter.Key == "" && filter.Channel == "" && filter.Kind != cache.CacheKindTG && (filter.Keyword != "" || filter.Plugin != "") {
+		response["plugin_cache"] = plugin.InvalidateAPICache("", filter.Plugin, filter.Keyword)
+	}
+	c.JSON(200, response)
+}
+
+// PurgeExpiredCacheHandler 立即清理所有缓存中的过期项
+func PurgeExpiredCacheHandler(c *gin.Context) {
+	response := gin.H{
+		"memory":       0,
+		"disk":         0,
+		"plugin_cache": plugin.PurgeExpiredAPICache(),
+	}
+	if mc := mainCache(); mc != nil {
+		memoryPurged, diskPurged := mc.PurgeExpired()
+		response["memory"] = memoryPurged
+		response["disk"] = diskPurged
+	}
+	c.JSON(200, response)
+}
+
+// ListPluginCacheHandler 列出插件内存缓存项，支持按插件名和关键词筛选
+func ListPluginCacheHandler(c *gin.Context) {
+	entries := plugin.ListAPICache(c.Query("plugin"), c.Query("keyword"))
+	total := len(entries)
+	if limit := queryLimit(c, 100, 1000); len(entries) > limit {
+		entries = entries[:limit]
+	}
+	c.JSON(200, gin.H{
+		"total":   total,
+		"entries": entries,
+	})
+}
+
+// InvalidatePluginCacheHandler 按键、插件名或关键词删除插件内存缓存项
+func InvalidatePluginCacheHandler(c *gin.Context) {
+	key, pluginName, keyword := c.Query("key"), c.Query("plugin"), c.Query("keyword")
+	if key == "" && pluginName == "" && keyword == "" {
+		c.JSON(400, gin.H{"error": "至少需要指定key、plugin、keyword中的一个条件"})
+		return
+	}
+	c.JSON(200, gin.H{"plugin_cache": plugin.InvalidateAPICache(key, pluginName, keyword)})
+}
+
+// CacheWarmKeywordsRequest 设置预热关键词请求
+type CacheWarmKeywordsRequest struct {
+	Keywords []string `json:"keywords"`
+}
+
+// cacheWarmer 获取缓存预热调度器，未创建时返回错误响应
+func cacheWarmer(c *gin.Context) *service.CacheWarmer {
+	warmer := service.GetCacheWarmer()
+	if warmer == nil || mainCache() == nil {
+		c.JSON(503, gin.H{"error": "缓存未启用"})
+		return nil
+	}
+	return warmer
+}
+
+// CacheWarmStatusHandler 返回缓存预热状态、预热关键词和热门关键词排行
+func CacheWarmStatusHandler(c *gin.Context) {
+	if warmer := cacheWarmer(c); warmer != nil {
+		c.JSON(200, warmer.Status())
+	}
+}
+
+// SetCacheWarmKeywordsHandler 设置管理员指定的预热关键词（覆盖原列表，重启后恢复为环境变量配置）
+func SetCacheWarmKeywordsHandler(c *gin.Context) {
+	warmer := cacheWarmer(c)
+	if warmer == nil {
+		return
+	}
+	var req CacheWarmKeywordsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "参数错误：keywords必须是字符串数组"})
+		return
+	}
+	c.JSON(200, gin.H{"keywords": warmer.SetKeywords(req.Keywords)})
+}
+
+// RunCacheWarmHandler 立即在后台执行一轮预热
+func RunCacheWarmHandler(c *gin.Context) {
+	warmer := cacheWarmer(c)
+	if warmer == nil {
+		return
+	}
+	if warmer.Running() {
+		c.JSON(409, gin.H{"error": "预热正在执行中"})
+		return
+	}
+	go warmer.RunOnce()
+	c.JSON(202, gin.H{"message": "预热已开始"})
+}
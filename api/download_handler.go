@@ -0,0 +1,144 @@
+package api
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"pansou/config"
+	"pansou/downloader"
+	"pansou/model"
+	"pansou/service"
+)
+
+// DownloadRequest 推送下载请求：直接传入链接（如merged_by_type中的链接），或传入搜索结果的unique_id
+type DownloadRequest struct {
+	Target   string `json:"target"`
+	URL      string `json:"url"`
+	Password string `json:"password"`
+	Title    string `json:"title"`
+	Note     string `json:"note"` // merged_by_type中链接的标题
+	UniqueID string `json:"unique_id"`
+}
+
+// downloadUser 下载目标和推送记录的归属：启用认证时为当前用户，否则为全局（空字符串）
+func downloadUser(c *gin.Context) string {
+	if !config.AppConfig.AuthEnabled {
+		return ""
+	}
+	return c.GetString("username")
+}
+
+// DownloadHandler 将链接推送到下载目标，后台异步发送，返回推送记录
+func DownloadHandler(c *gin.Context) {
+	var req DownloadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "无效的请求参数: " + err.Error()})
+		return
+	}
+
+	task := downloader.Task{
+		URL:      strings.TrimSpace(req.URL),
+		Password: req.Password,
+		Title:    req.Title,
+		User:     downloadUser(c),
+	}
+	if task.Title == "" {
+		task.Title = req.Note
+	}
+	if task.URL == "" && req.UniqueID != "" {
+		result, ok := service.GetResultIndex().Lookup(req.UniqueID)
+		if !ok {
+			c.JSON(404, gin.H{"error": "搜索结果不存在或已过期，请重新搜索"})
+			return
+		}
+		link := preferredDownloadLink(result.Links)
+		task.URL, task.Password = link.URL, link.Password
+		if task.Title == "" {
+			task.Title = result.Title
+		}
+	}
+	if task.URL == "" {
+		c.JSON(400, gin.H{"error": "url 和 unique_id 不能同时为空"})
+		return
+	}
+
+	target, err := downloader.GetTargetStore().Get(task.User, req.Target)
+	if err != nil {
+		status := 400
+		if errors.Is(err, downloader.ErrTargetNotFound) {
+			status = 404
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	delivery, err := downloader.GetManager().Submit(target, task)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "创建推送任务失败: " + err.Error()})
+		return
+	}
+	c.JSON(202, delivery)
+}
+
+// preferredDownloadLink 优先选择磁力链接，其次ed2k，否则使用第一个链接
+func preferredDownloadLink(links []model.Link) model.Link {
+	for _, linkType := range []string{"magnet", "ed2k"} {
+		for _, link := range links {
+			if link.Type == linkType {
+				return link
+			}
+		}
+	}
+	return links[0]
+}
+
+// DownloadStatusHandler 查询推送记录
+func DownloadStatusHandler(c *gin.Context) {
+	delivery, ok := downloader.GetManager().Get(downloadUser(c), c.Param("id"))
+	if !ok {
+		c.JSON(404, gin.H{"error": "推送记录不存在"})
+		return
+	}
+	c.JSON(200, delivery)
+}
+
+// ListDownloadsHandler 最近的推送记录
+func ListDownloadsHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"downloads": downloader.GetManager().List(downloadUser(c), queryLimit(c, 50, 1000))})
+}
+
+// ListDownloadTargetsHandler 可用的下载目标（不含密码）
+func ListDownloadTargetsHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"targets": downloader.GetTargetStore().List(downloadUser(c))})
+}
+
+// SaveDownloadTargetHandler 新增或更新当前用户的下载目标
+func SaveDownloadTargetHandler(c *gin.Context) {
+	var target downloader.Target
+	if err := c.ShouldBindJSON(&target); err != nil {
+		c.JSON(400, gin.H{"error": "无效的请求参数: " + err.Error()})
+		return
+	}
+	target.Name = c.Param("name")
+
+	info, err := downloader.GetTargetStore().Save(downloadUser(c), target)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, info)
+}
+
+// DeleteDownloadTargetHandler 删除当前用户的下载目标
+func DeleteDownloadTargetHandler(c *gin.Context) {
+	if err := downloader.GetTargetStore().Delete(downloadUser(c), c.Param("name")); err != nil {
+		if errors.Is(err, downloader.ErrTargetNotFound) {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(500, gin.H{"error": "删除下载目标失败: " + err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": "下载目标已删除"})
+}
@@ -8,9 +8,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"pansou/config"
 	"pansou/model"
+	"pansou/plugin/peer"
 	"pansou/service"
 	jsonutil "pansou/util/json"
 	"pansou/util"
+	"pansou/util/cache"
 	"strings"
 )
 
@@ -198,10 +200,57 @@ func SearchHandler(c *gin.Context) {
 		}
 	}
 	
+	// 按API Key的限定范围（插件/仅缓存）调整请求
+	if req.Ext == nil {
+		req.Ext = make(map[string]interface{})
+	}
+	// 内部参数（如仅缓存、联邦请求标记）不允许由客户端传入
+	for key := range req.Ext {
+		if strings.HasPrefix(key, "_") {
+			delete(req.Ext, key)
+		}
+	}
+	var allowed bool
+	req.Plugins, req.SourceType, allowed = applyAPIKeyScope(c, req.Plugins, req.SourceType, req.Ext)
+	if !allowed {
+		c.JSON(http.StatusForbidden, model.NewErrorResponse(403, "API Key无权搜索指定的来源"))
+		return
+	}
+	if req.SourceType == "plugin" {
+		req.Channels = nil
+	}
+	if cacheOnly, _ := req.Ext[service.CacheOnlyExtKey].(bool); cacheOnly {
+		req.ForceRefresh = false
+	}
+	// 来自其他PanSou实例的联邦请求只搜索本地来源
+	if c.GetHeader(peer.ViaHeader) != "" {
+		req.Ext[service.FederatedExtKey] = true
+	}
+
 	// 可选：启用调试输出（生产环境建议注释掉）
 	// fmt.Printf("🔧 [调试] 搜索参数: keyword=%s, channels=%v, concurrency=%d, refresh=%v, resultType=%s, sourceType=%s, plugins=%v, cloudTypes=%v, ext=%v\n", 
 	//	req.Keyword, req.Channels, req.Concurrency, req.ForceRefresh, req.ResultType, req.SourceType, req.Plugins, req.CloudTypes, req.Ext)
 	
+	// 响应缓存：相同请求直接输出已序列化的响应，跳过合并、排序和序列化
+	responseCache := service.GetResponseCache()
+	var responseCacheKey string
+	var sourceVersion cache.SourceVersion
+	var responseStatus *service.ResponseStatus
+	if responseCache != nil {
+		responseCacheKey = service.ResponseCacheKey(req)
+		if !req.ForceRefresh {
+			if entry, hit := responseCache.Get(responseCacheKey); hit {
+				service.GetHistoryStore().Record(historyUser(c), req.Keyword, req.SourceType, req.Plugins, req.CloudTypes, entry.Total)
+				writeCachedResponse(c, entry)
+				return
+			}
+		}
+		// 在搜索前获取来源版本，搜索期间来源变更时不缓存本次响应
+		sourceVersion = responseCache.Version(req.Keyword)
+		// 记录本次请求的响应是否完整，有来源失败或缺失时不缓存本次响应
+		req.Ext, responseStatus = service.WithResponseStatus(req.Ext)
+	}
+	
 	// 执行搜索
 	result, err := searchService.Search(req.Keyword, req.Channels, req.Concurrency, req.ForceRefresh, req.ResultType, req.SourceType, req.Plugins, req.CloudTypes, req.Ext)
 	
@@ -217,8 +266,27 @@ func SearchHandler(c *gin.Context) {
 		result = applyResultFilter(result, req.Filter, req.ResultType)
 	}
 
+	// 记录搜索历史
+	service.GetHistoryStore().Record(historyUser(c), req.Keyword, req.SourceType, req.Plugins, req.CloudTypes, result.Total)
+
 	// 包装SearchResponse到标准响应格式中
 	response := model.NewSuccessResponse(result)
 	jsonData, _ := jsonutil.Marshal(response)
+	if responseCache != nil && responseStatus.Complete() {
+		responseCache.Set(responseCacheKey, req.Keyword, sourceVersion, jsonData, result.Total)
+	}
 	c.Data(http.StatusOK, "application/json", jsonData)
+}
+
+// writeCachedResponse 输出缓存的响应，客户端支持gzip时直接输出预先压缩的内容
+func writeCachedResponse(c *gin.Context, entry *service.ResponseCacheEntry) {
+	c.Header("X-Cache", "HIT")
+	if entry.Gzip != nil && config.AppConfig.EnableCompression && strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+		c.Set(util.PrecompressedKey, true)
+		c.Header("Content-Encoding", "gzip")
+		c.Header("Vary", "Accept-Encoding")
+		c.Data(http.StatusOK, "application/json", entry.Gzip)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", entry.JSON)
 } 
\ No newline at end of file
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pansou/config"
+	"pansou/service"
+	"pansou/util"
+)
+
+// historyUser 历史记录归属：启用认证时为当前用户，否则为全局（空字符串）
+func historyUser(c *gin.Context) string {
+	if !config.AppConfig.AuthEnabled {
+		return ""
+	}
+	return c.GetString("username")
+}
+
+// queryLimit 解析limit参数，限制在[1, max]之间
+func queryLimit(c *gin.Context, def, max int) int {
+	limit := util.StringToInt(c.Query("limit"))
+	if limit <= 0 {
+		return def
+	}
+	if limit > max {
+		return max
+	}
+	return limit
+}
+
+// parseWindow 解析时间窗口，支持Go时长格式（如24h、30m）及天数（如7d）
+func parseWindow(window string) (time.Duration, bool) {
+	if window == "" {
+		return 24 * time.Hour, true
+	}
+	if strings.HasSuffix(window, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(window, "d"))
+		if err != nil || days <= 0 {
+			return 0, false
+		}
+		return time.Duration(days) * 24 * time.Hour, true
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// HistoryHandler 获取当前调用方的搜索历史
+func HistoryHandler(c *gin.Context) {
+	history := service.GetHistoryStore().List(historyUser(c), queryLimit(c, 50, 500))
+	c.JSON(200, gin.H{
+		"enabled": config.AppConfig.HistoryEnabled && !config.AppConfig.HistoryPrivacyMode,
+		"history": history,
+	})
+}
+
+// DeleteHistoryHandler 删除当前调用方的搜索历史，指定keyword时只删除该关键词
+func DeleteHistoryHandler(c *gin.Context) {
+	removed := service.GetHistoryStore().Delete(historyUser(c), c.Query("keyword"))
+	c.JSON(200, gin.H{"deleted": removed})
+}
+
+// TrendingHandler 获取热搜关键词
+func TrendingHandler(c *gin.Context) {
+	window, ok := parseWindow(c.Query("window"))
+	if !ok {
+		c.JSON(400, gin.H{"error": "无效的window参数，示例：24h、7d"})
+		return
+	}
+	c.JSON(200, gin.H{
+		"window":   window.String(),
+		"trending": service.GetHistoryStore().Trending(window, queryLimit(c, 20, 100)),
+	})
+}
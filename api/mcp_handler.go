@@ -0,0 +1,165 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pansou/config"
+	"pansou/mcp"
+)
+
+const (
+	// MCP会话ID请求头
+	mcpSessionHeader = "Mcp-Session-Id"
+	// MCP会话空闲多久后失效
+	mcpSessionIdleTimeout = time.Hour
+)
+
+// mcpSession MCP会话，绑定创建会话的认证身份
+type mcpSession struct {
+	principal string // 认证用户名或 apikey:<名称>，未启用认证时为空
+	lastSeen  time.Time
+}
+
+var (
+	mcpServer       *mcp.Server
+	mcpSessions     = make(map[string]*mcpSession) // 会话ID -> 会话
+	mcpSessionsLock sync.Mutex
+)
+
+// MCPHandler MCP streamable HTTP传输：POST发送JSON-RPC消息，DELETE结束会话
+// 服务端不主动推送消息，GET返回405
+func MCPHandler(c *gin.Context) {
+	// 校验浏览器来源，防止DNS重绑定攻击本机部署的服务
+	if !mcpOriginAllowed(c.GetHeader("Origin")) {
+		c.JSON(403, gin.H{"error": "不允许的请求来源"})
+		return
+	}
+
+	switch c.Request.Method {
+	case "POST":
+		handleMCPPost(c)
+	case "DELETE":
+		sessionID := c.GetHeader(mcpSessionHeader)
+		principal := c.GetString("username")
+		mcpSessionsLock.Lock()
+		session, ok := mcpSessions[sessionID]
+		ok = ok && session.principal == principal
+		if ok {
+			delete(mcpSessions, sessionID)
+		}
+		mcpSessionsLock.Unlock()
+		if !ok {
+			c.JSON(404, gin.H{"error": "会话不存在"})
+			return
+		}
+		c.Status(204)
+	default:
+		c.Header("Allow", "POST, DELETE")
+		c.JSON(405, gin.H{"error": "不支持的请求方法"})
+	}
+}
+
+// handleMCPPost 处理客户端发送的JSON-RPC消息
+func handleMCPPost(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "读取请求数据失败: " + err.Error()})
+		return
+	}
+
+	// initialize请求创建新会话，其余请求携带会话ID时校验会话
+	principal := c.GetString("username")
+	if mcp.IsInitializeRequest(data) {
+		sessionID, err := newMCPSession(principal)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "创建会话失败: " + err.Error()})
+			return
+		}
+		c.Header(mcpSessionHeader, sessionID)
+	} else if sessionID := c.GetHeader(mcpSessionHeader); sessionID != "" && !touchMCPSession(sessionID, principal) {
+		c.JSON(404, gin.H{"error": "会话不存在或已过期"})
+		return
+	}
+
+	// 按API Key的限定范围（插件/仅缓存）调整搜索
+	ctx := mcp.WithSearchScope(c.Request.Context(), func(plugins []string, sourceType string, ext map[string]interface{}) ([]string, string, bool) {
+		return applyAPIKeyScope(c, plugins, sourceType, ext)
+	})
+
+	resp := mcpServer.HandleMessage(ctx, data)
+	if resp == nil {
+		// 只包含通知或响应
+		c.Status(202)
+		return
+	}
+	c.Data(200, "application/json", resp)
+}
+
+// mcpOriginAllowed 请求来源是否允许访问：没有Origin（非浏览器客户端）、本机来源或配置的来源
+func mcpOriginAllowed(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	origin = strings.ToLower(strings.TrimRight(origin, "/"))
+	for _, allowed := range config.AppConfig.MCPAllowedOrigins {
+		if origin == allowed {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// newMCPSession 为认证身份创建会话，同时清理空闲过久的会话
+func newMCPSession(principal string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	sessionID := hex.EncodeToString(buf)
+
+	now := time.Now()
+	mcpSessionsLock.Lock()
+	defer mcpSessionsLock.Unlock()
+	for id, session := range mcpSessions {
+		if now.Sub(session.lastSeen) > mcpSessionIdleTimeout {
+			delete(mcpSessions, id)
+		}
+	}
+	mcpSessions[sessionID] = &mcpSession{principal: principal, lastSeen: now}
+	return sessionID, nil
+}
+
+// touchMCPSession 更新会话的最后活动时间
+// 会话不存在、已过期或不属于当前认证身份时返回false
+func touchMCPSession(sessionID, principal string) bool {
+	now := time.Now()
+	mcpSessionsLock.Lock()
+	defer mcpSessionsLock.Unlock()
+
+	session, ok := mcpSessions[sessionID]
+	if !ok || session.principal != principal {
+		return false
+	}
+	if now.Sub(session.lastSeen) > mcpSessionIdleTimeout {
+		delete(mcpSessions, sessionID)
+		return false
+	}
+	session.lastSeen = now
+	return true
+}
@@ -15,8 +15,9 @@ import (
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, Mcp-Session-Id, Mcp-Protocol-Version")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Mcp-Session-Id")
 		
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -74,8 +75,10 @@ func LoggerMiddleware() gin.HandlerFunc {
 	}
 }
 
-// AuthMiddleware JWT认证中间件
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware 认证中间件
+// 全局注册时（不带参数）负责认证：支持JWT令牌和API Key（X-API-Key 或 Bearer）
+// 在路由组上注册时传入最低角色，只校验已认证请求的角色权限
+func AuthMiddleware(requiredRole ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 如果未启用认证，直接放行
 		if !config.AppConfig.AuthEnabled {
@@ -83,11 +86,35 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// 路由组上的角色校验（全局中间件已完成认证）
+		if len(requiredRole) > 0 {
+			if _, authenticated := c.Get("role"); !authenticated {
+				// 公开接口未经过认证，在此补充认证
+				if !authenticate(c) {
+					return
+				}
+			}
+			role := c.GetString("role")
+			if !util.RoleAllows(role, requiredRole[0]) {
+				c.JSON(403, gin.H{
+					"error": "禁止访问：权限不足",
+					"code":  "AUTH_FORBIDDEN",
+				})
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
 		// 定义公开接口（不需要认证）
 		publicPaths := []string{
 			"/api/auth/login",
+			"/api/auth/refresh",
 			"/api/auth/logout",
-			"/api/health", // 健康检查接口可选择是否需要认证
+			"/api/health",  // 健康检查接口可选择是否需要认证
+			"/api/torznab", // Torznab接口使用apikey参数认证
+			"/api/onebot",  // OneBot反向WebSocket使用access_token认证
 		}
 
 		// 检查当前路径是否是公开接口
@@ -99,43 +126,80 @@ func AuthMiddleware() gin.HandlerFunc {
 			}
 		}
 
-		// 获取Authorization头
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(401, gin.H{
-				"error": "未授权：缺少认证令牌",
-				"code":  "AUTH_TOKEN_MISSING",
-			})
-			c.Abort()
-			return
+		if authenticate(c) {
+			c.Next()
 		}
+	}
+}
 
-		// 解析Bearer token
-		const bearerPrefix = "Bearer "
-		if !strings.HasPrefix(authHeader, bearerPrefix) {
-			c.JSON(401, gin.H{
-				"error": "未授权：令牌格式错误",
-				"code":  "AUTH_TOKEN_INVALID_FORMAT",
-			})
-			c.Abort()
-			return
-		}
+// authenticate 校验请求凭证并将用户信息存入上下文，失败时写入401响应并中止
+func authenticate(c *gin.Context) bool {
+	// 优先使用 X-API-Key 头
+	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+		return authenticateAPIKey(c, apiKey)
+	}
 
-		tokenString := strings.TrimPrefix(authHeader, bearerPrefix)
+	// 获取Authorization头
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(401, gin.H{
+			"error": "未授权：缺少认证令牌",
+			"code":  "AUTH_TOKEN_MISSING",
+		})
+		c.Abort()
+		return false
+	}
 
-		// 验证token
-		claims, err := util.ValidateToken(tokenString, config.AppConfig.AuthJWTSecret)
-		if err != nil {
-			c.JSON(401, gin.H{
-				"error": "未授权：令牌无效或已过期",
-				"code":  "AUTH_TOKEN_INVALID",
-			})
-			c.Abort()
-			return
-		}
+	// 解析Bearer token
+	const bearerPrefix = "Bearer "
+	if !strings.HasPrefix(authHeader, bearerPrefix) {
+		c.JSON(401, gin.H{
+			"error": "未授权：令牌格式错误",
+			"code":  "AUTH_TOKEN_INVALID_FORMAT",
+		})
+		c.Abort()
+		return false
+	}
 
-		// 将用户信息存入上下文，供后续处理使用
-		c.Set("username", claims.Username)
-		c.Next()
+	tokenString := strings.TrimPrefix(authHeader, bearerPrefix)
+
+	// Bearer 中携带的 API Key
+	if strings.HasPrefix(tokenString, util.APIKeyPrefix) {
+		return authenticateAPIKey(c, tokenString)
+	}
+
+	// 验证token
+	claims, err := util.ValidateAccessToken(tokenString, config.AppConfig.AuthJWTSecret)
+	if err != nil {
+		c.JSON(401, gin.H{
+			"error": "未授权：令牌无效或已过期",
+			"code":  "AUTH_TOKEN_INVALID",
+		})
+		c.Abort()
+		return false
 	}
-} 
\ No newline at end of file
+
+	// 将用户信息存入上下文，供后续处理使用
+	// 角色按当前配置确定，修改配置后无需等待旧令牌过期
+	c.Set("username", claims.Username)
+	c.Set("role", util.UserRole(claims.Username))
+	return true
+}
+
+// authenticateAPIKey 校验API Key并将Key信息存入上下文
+func authenticateAPIKey(c *gin.Context, plainKey string) bool {
+	key, ok := util.GetAPIKeyStore().Validate(plainKey)
+	if !ok {
+		c.JSON(401, gin.H{
+			"error": "未授权：API Key无效或已吊销",
+			"code":  "AUTH_API_KEY_INVALID",
+		})
+		c.Abort()
+		return false
+	}
+
+	c.Set("username", "apikey:"+key.Name)
+	c.Set("role", key.Role)
+	c.Set("api_key", key)
+	return true
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"pansou/plugin"
+)
+
+// MirrorOverridesRequest 设置插件基础地址请求
+type MirrorOverridesRequest struct {
+	URLs []string `json:"urls"`
+}
+
+// ListMirrorsHandler 返回各插件的基础地址、镜像状态与检测到的重定向
+func ListMirrorsHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"plugins": plugin.MirrorStatuses()})
+}
+
+// SetMirrorsHandler 设置插件的基础地址列表（按优先顺序，持久化保存，为空时恢复原配置）
+func SetMirrorsHandler(c *gin.Context) {
+	var req MirrorOverridesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "参数错误：urls必须是字符串数组"})
+		return
+	}
+	status, err := plugin.SetMirrorOverrides(c.Param("plugin"), req.URLs)
+	if err != nil {
+		respondMirrorError(c, err)
+		return
+	}
+	c.JSON(200, status)
+}
+
+// ResetMirrorsHandler 清除插件的管理员设置与检测到的重定向
+func ResetMirrorsHandler(c *gin.Context) {
+	status, err := plugin.ResetMirrors(c.Param("plugin"))
+	if err != nil {
+		respondMirrorError(c, err)
+		return
+	}
+	c.JSON(200, status)
+}
+
+// respondMirrorError 插件不存在返回404，其余为参数错误
+func respondMirrorError(c *gin.Context, err error) {
+	if errors.Is(err, plugin.ErrMirrorPluginNotFound) {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(400, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"pansou/config"
+	"pansou/util"
+)
+
+// oneBotServer 处理OneBot反向WebSocket连接的机器人
+var oneBotServer http.Handler
+
+// SetOneBot 设置处理反向WebSocket连接的OneBot机器人
+func SetOneBot(handler http.Handler) {
+	oneBotServer = handler
+}
+
+// OneBotHandler 接受OneBot实现的反向WebSocket连接
+func OneBotHandler(c *gin.Context) {
+	if oneBotServer == nil {
+		c.JSON(503, gin.H{"error": "OneBot机器人未启用"})
+		return
+	}
+	if !authenticateOneBot(c) {
+		c.JSON(401, gin.H{"error": "access_token 无效"})
+		return
+	}
+	oneBotServer.ServeHTTP(c.Writer, c.Request)
+}
+
+// authenticateOneBot 校验OneBot实现发送的access_token（Authorization头或access_token参数）
+// 配置了ONEBOT_ACCESS_TOKEN时使用该值；否则开启认证时需要具有搜索权限的API Key
+func authenticateOneBot(c *gin.Context) bool {
+	token := c.Query("access_token")
+	if auth := c.GetHeader("Authorization"); auth != "" {
+		if _, value, found := strings.Cut(auth, " "); found {
+			token = strings.TrimSpace(value)
+		}
+	}
+
+	staticToken := config.AppConfig.OneBotAccessToken
+	if staticToken != "" {
+		return subtle.ConstantTimeCompare([]byte(token), []byte(staticToken)) == 1
+	}
+	if !config.AppConfig.AuthEnabled {
+		return true
+	}
+
+	key, ok := util.GetAPIKeyStore().Validate(token)
+	return ok && util.RoleAllows(key.Role, util.RoleSearch)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"pansou/config"
+	"pansou/mcp"
 	"pansou/plugin"
 	"pansou/service"
 	"pansou/util"
@@ -32,52 +33,114 @@ func SetupRouter(searchService *service.SearchService, frontendFS fs.FS) *gin.En
 		auth := api.Group("/auth")
 		{
 			auth.POST("/login", LoginHandler)
-			auth.POST("/verify", VerifyHandler)
+			auth.POST("/refresh", RefreshHandler)
+			auth.POST("/verify", AuthMiddleware(util.RoleReadonly), VerifyHandler)
 			auth.POST("/logout", LogoutHandler)
 		}
 
-		api.POST("/search", SearchHandler)
-		api.GET("/search", SearchHandler)
+		search := api.Group("", AuthMiddleware(util.RoleSearch))
+		{
+			search.POST("/search", SearchHandler)
+			search.GET("/search", SearchHandler)
+		}
 
-		api.GET("/health", func(c *gin.Context) {
-			pluginCount := 0
-			pluginNames := []string{}
-			pluginsEnabled := config.AppConfig.AsyncPluginEnabled
-
-			if pluginsEnabled && searchService != nil && searchService.GetPluginManager() != nil {
-				plugins := searchService.GetPluginManager().GetPlugins()
-				pluginCount = len(plugins)
-				for _, p := range plugins {
-					pluginNames = append(pluginNames, p.Name())
-				}
-			}
+		history := api.Group("", AuthMiddleware(util.RoleReadonly))
+		{
+			history.GET("/history", HistoryHandler)
+			history.DELETE("/history", DeleteHistoryHandler)
+			history.GET("/trending", TrendingHandler)
+		}
 
-			channels := config.AppConfig.DefaultChannels
-			channelsCount := len(channels)
+		stats := api.Group("/stats", AuthMiddleware(util.RoleReadonly))
+		{
+			stats.GET("/outbound", OutboundStatsHandler)
+			stats.GET("/proxy", ProxyStatsHandler)
+			stats.GET("/antibot", AntiBotStatsHandler)
+		}
 
-			response := gin.H{
-				"status":          "ok",
-				"auth_enabled":    config.AppConfig.AuthEnabled,
-				"plugins_enabled": pluginsEnabled,
-				"channels":        channels,
-				"channels_count":  channelsCount,
+		// 推送链接到下载工具
+		if config.AppConfig.DownloadEnabled {
+			download := api.Group("", AuthMiddleware(util.RoleSearch))
+			{
+				download.POST("/download", DownloadHandler)
+				download.GET("/download/targets", ListDownloadTargetsHandler)
+				download.GET("/download/:id", DownloadStatusHandler)
+				download.GET("/downloads", ListDownloadsHandler)
+				// 下载目标归属当前用户；用户保存的目标只能指向公网地址，推送时也只连接公网地址
+				download.PUT("/download/targets/:name", SaveDownloadTargetHandler)
+				download.DELETE("/download/targets/:name", DeleteDownloadTargetHandler)
 			}
+		}
 
-			if pluginsEnabled {
-				response["plugin_count"] = pluginCount
-				response["plugins"] = pluginNames
-			}
+		// 登录型插件的账号管理（账号ID即管理地址，与插件管理页面一致）
+		account := api.Group("/accounts", AuthMiddleware(util.RoleSearch))
+		{
+			account.GET("", ListAccountPluginsHandler)
+			account.POST("/:plugin/resolve", ResolveAccountHandler)
+			account.GET("/:plugin/:id", GetAccountHandler)
+			account.POST("/:plugin/:id/:action", AccountActionHandler)
+		}
 
-			c.JSON(200, response)
+		admin := api.Group("/admin", AuthMiddleware(util.RoleAdmin))
+		{
+			admin.POST("/keys", CreateAPIKeyHandler)
+			admin.GET("/keys", ListAPIKeysHandler)
+			admin.DELETE("/keys/:id", RevokeAPIKeyHandler)
+			admin.DELETE("/sessions/:username", RevokeUserSessionsHandler)
+
+			admin.GET("/cache/stats", CacheStatsHandler)
+			admin.GET("/cache/entries", ListCacheEntriesHandler)
+			admin.DELETE("/cache/entries", InvalidateCacheHandler)
+			admin.POST("/cache/purge", PurgeExpiredCacheHandler)
+			admin.GET("/cache/plugin-entries", ListPluginCacheHandler)
+			admin.DELETE("/cache/plugin-entries", InvalidatePluginCacheHandler)
+			admin.GET("/cache/warm", CacheWarmStatusHandler)
+			admin.PUT("/cache/warm", SetCacheWarmKeywordsHandler)
+			admin.POST("/cache/warm/run", RunCacheWarmHandler)
+
+			admin.GET("/mirrors", ListMirrorsHandler)
+			admin.PUT("/mirrors/:plugin", SetMirrorsHandler)
+			admin.DELETE("/mirrors/:plugin", ResetMirrorsHandler)
+
+			admin.GET("/accounts", ListAccountsHandler)
+			admin.POST("/accounts/:plugin/keepalive", KeepAliveAccountsHandler)
+			admin.DELETE("/accounts/:plugin/:id", DeleteAccountHandler)
+		}
+
+		api.GET("/health", func(c *gin.Context) {
+			c.JSON(200, service.HealthStatus(searchService))
 		})
+
+		// Torznab索引器接口，由处理器按apikey参数认证
+		if config.AppConfig.TorznabEnabled {
+			api.GET("/torznab", TorznabHandler)
+			api.GET("/torznab/api", TorznabHandler)
+		}
+
+		// OneBot反向WebSocket，由处理器按access_token认证
+		if config.AppConfig.OneBotReverseEnabled {
+			api.GET("/onebot/ws", OneBotHandler)
+		}
+	}
+
+	// MCP（Model Context Protocol）服务，使用搜索权限
+	if config.AppConfig.MCPEnabled {
+		mcpServer = mcp.NewServer(searchService)
+		mcpGroup := r.Group("/mcp", AuthMiddleware(util.RoleSearch))
+		{
+			mcpGroup.POST("", MCPHandler)
+			mcpGroup.GET("", MCPHandler)
+			mcpGroup.DELETE("", MCPHandler)
+		}
 	}
 
-	// 注册插件 Web 路由
+	// 注册插件 Web 路由（账号管理页面，需要搜索权限）
 	if config.AppConfig.AsyncPluginEnabled && searchService != nil && searchService.GetPluginManager() != nil {
 		enabledPlugins := searchService.GetPluginManager().GetPlugins()
+		pluginGroup := r.Group("", AuthMiddleware(util.RoleSearch))
 		for _, p := range enabledPlugins {
 			if webPlugin, ok := p.(plugin.PluginWithWebHandler); ok {
-				webPlugin.RegisterWebRoutes(r.Group(""))
+				webPlugin.RegisterWebRoutes(pluginGroup)
 			}
 		}
 	}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"pansou/util"
+)
+
+// OutboundStatsHandler 返回出站请求限流统计
+func OutboundStatsHandler(c *gin.Context) {
+	c.JSON(200, util.GetOutboundStats())
+}
+
+// ProxyStatsHandler 返回代理池健康状态与路由规则
+func ProxyStatsHandler(c *gin.Context) {
+	c.JSON(200, util.GetProxyStats())
+}
+
+// AntiBotStatsHandler 返回各主机的反爬会话状态
+func AntiBotStatsHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"sessions": util.GetAntiBotStats()})
+}
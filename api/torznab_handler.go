@@ -0,0 +1,165 @@
+package api
+
+import (
+	"crypto/subtle"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"pansou/config"
+	"pansou/service"
+	"pansou/torznab"
+	"pansou/util"
+)
+
+// TorznabHandler Torznab索引器接口，t=caps|search|tvsearch|movie
+// 通过apikey参数认证，错误以Torznab的<error>返回
+func TorznabHandler(c *gin.Context) {
+	if !authenticateTorznab(c) {
+		writeTorznab(c, torznab.NewError(torznab.ErrorIncorrectCredentials, "Incorrect user credentials"))
+		return
+	}
+
+	switch t := c.Query("t"); t {
+	case "caps":
+		writeTorznab(c, torznab.NewCaps())
+	case "search", "tvsearch", "movie":
+		torznabSearch(c, t)
+	case "":
+		writeTorznab(c, torznab.NewError(torznab.ErrorMissingParameter, "Missing parameter (t)"))
+	default:
+		writeTorznab(c, torznab.NewError(torznab.ErrorNoSuchFunction, "No such function ("+t+")"))
+	}
+}
+
+// authenticateTorznab 校验apikey参数（也接受X-API-Key头）
+// 配置了TORZNAB_API_KEY时可直接使用该值；否则开启认证时需要具有搜索权限的API Key
+func authenticateTorznab(c *gin.Context) bool {
+	apiKey := c.Query("apikey")
+	if apiKey == "" {
+		apiKey = c.GetHeader("X-API-Key")
+	}
+
+	staticKey := config.AppConfig.TorznabAPIKey
+	if staticKey != "" && subtle.ConstantTimeCompare([]byte(apiKey), []byte(staticKey)) == 1 {
+		return true
+	}
+	if staticKey == "" && !config.AppConfig.AuthEnabled {
+		return true
+	}
+	if apiKey == "" {
+		return false
+	}
+
+	key, ok := util.GetAPIKeyStore().Validate(apiKey)
+	if !ok || !util.RoleAllows(key.Role, util.RoleSearch) {
+		return false
+	}
+	c.Set("username", "apikey:"+key.Name)
+	c.Set("role", key.Role)
+	c.Set("api_key", key)
+	return true
+}
+
+// torznabSearch 搜索并返回磁力和ed2k链接
+func torznabSearch(c *gin.Context, searchType string) {
+	query := torznab.Query{}
+	var err error
+	if query.Season, err = torznabIntParam(c, "season"); err != nil {
+		writeTorznab(c, torznab.NewError(torznab.ErrorIncorrectParameter, "Incorrect parameter (season)"))
+		return
+	}
+	if query.Episode, err = torznabIntParam(c, "ep"); err != nil {
+		writeTorznab(c, torznab.NewError(torznab.ErrorIncorrectParameter, "Incorrect parameter (ep)"))
+		return
+	}
+	if searchType != "tvsearch" {
+		query.Season, query.Episode = 0, 0
+	}
+	for _, cat := range strings.Split(c.Query("cat"), ",") {
+		if cat = strings.TrimSpace(cat); cat == "" {
+			continue
+		}
+		id, err := strconv.Atoi(cat)
+		if err != nil {
+			writeTorznab(c, torznab.NewError(torznab.ErrorIncorrectParameter, "Incorrect parameter (cat)"))
+			return
+		}
+		query.Categories = append(query.Categories, id)
+	}
+	offset, err := torznabIntParam(c, "offset")
+	if err != nil {
+		writeTorznab(c, torznab.NewError(torznab.ErrorIncorrectParameter, "Incorrect parameter (offset)"))
+		return
+	}
+	limit, err := torznabIntParam(c, "limit")
+	if err != nil {
+		writeTorznab(c, torznab.NewError(torznab.ErrorIncorrectParameter, "Incorrect parameter (limit)"))
+		return
+	}
+	if limit <= 0 {
+		limit = torznab.DefaultLimit
+	} else if limit > torznab.MaxLimit {
+		limit = torznab.MaxLimit
+	}
+
+	// 没有关键词（如按imdbid搜索或索引器连通性测试）时返回空结果
+	keyword := strings.TrimSpace(c.Query("q"))
+	if keyword == "" {
+		writeTorznab(c, torznab.NewFeed(nil, offset, 0))
+		return
+	}
+
+	ext := make(map[string]interface{})
+	plugins, sourceType, allowed := applyAPIKeyScope(c, nil, "all", ext)
+	if !allowed {
+		writeTorznab(c, torznab.NewError(torznab.ErrorIncorrectCredentials, "Insufficient privileges"))
+		return
+	}
+	var channels []string
+	if sourceType != "plugin" {
+		channels = config.AppConfig.DefaultChannels
+	}
+
+	result, err := searchService.Search(keyword, channels, 0, false, "results", sourceType, plugins, nil, ext)
+	if err != nil {
+		writeTorznab(c, torznab.NewError(torznab.ErrorUnknown, "Search failed: "+err.Error()))
+		return
+	}
+
+	items := torznab.BuildItems(result.Results, query, service.ResultSource)
+	total := len(items)
+	if offset >= total {
+		items = nil
+	} else {
+		end := offset + limit
+		if end > total {
+			end = total
+		}
+		items = items[offset:end]
+	}
+	writeTorznab(c, torznab.NewFeed(items, offset, total))
+}
+
+// torznabIntParam 读取非负整数参数，未传入时为0
+func torznabIntParam(c *gin.Context, name string) (int, error) {
+	value := strings.TrimSpace(c.Query(name))
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, strconv.ErrSyntax
+	}
+	return n, nil
+}
+
+// writeTorznab 输出XML响应（Torznab客户端按内容判断错误，状态码始终为200）
+func writeTorznab(c *gin.Context, v interface{}) {
+	data, err := torznab.Marshal(v)
+	if err != nil {
+		c.String(500, "序列化响应失败: "+err.Error())
+		return
+	}
+	c.Data(200, "application/xml; charset=utf-8", data)
+}
@@ -0,0 +1,145 @@
+// Package bot 聊天机器人前端的公共部分：调用搜索服务、整理按网盘类型分组的结果以及按会话限流
+// 具体的聊天平台适配见子包（如 bot/telegram）
+package bot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/service"
+)
+
+// Entry 展开后的一条链接
+type Entry struct {
+	CloudType string
+	Link      model.MergedLink
+}
+
+// Search 按关键词搜索全部来源，返回按网盘类型分组的结果；cloudTypes为空表示不限类型
+func Search(searchService *service.SearchService, keyword string, cloudTypes []string) (model.MergedLinks, error) {
+	result, err := searchService.Search(keyword, config.AppConfig.DefaultChannels, 0, false, "merged_by_type", "all", nil, cloudTypes, make(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	return result.MergedByType, nil
+}
+
+// SearchWithTimeout 带超时的 Search，超时或ctx取消时直接返回错误（搜索仍在后台完成并写入缓存）
+func SearchWithTimeout(ctx context.Context, searchService *service.SearchService, keyword string, cloudTypes []string, timeout time.Duration) (model.MergedLinks, error) {
+	type searchResult struct {
+		merged model.MergedLinks
+		err    error
+	}
+	done := make(chan searchResult, 1)
+	go func() {
+		merged, err := Search(searchService, keyword, cloudTypes)
+		done <- searchResult{merged, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.merged, r.err
+	case <-timer.C:
+		return nil, errors.New("搜索超时，请稍后重试")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Flatten 按网盘类型的展示顺序展开链接，cloudType不为空时只保留该类型
+func Flatten(merged model.MergedLinks, cloudType string) []Entry {
+	entries := make([]Entry, 0)
+	for _, t := range OrderedTypes(merged) {
+		if cloudType != "" && t != cloudType {
+			continue
+		}
+		for _, link := range merged[t] {
+			entries = append(entries, Entry{CloudType: t, Link: link})
+		}
+	}
+	return entries
+}
+
+// OrderedTypes 结果中包含的网盘类型，按 model.CloudTypes 的顺序排列，未知类型排在最后
+func OrderedTypes(merged model.MergedLinks) []string {
+	types := make([]string, 0, len(merged))
+	known := make(map[string]bool, len(model.CloudTypes))
+	for _, t := range model.CloudTypes {
+		known[t.Type] = true
+		if len(merged[t.Type]) > 0 {
+			types = append(types, t.Type)
+		}
+	}
+	for t, links := range merged {
+		if !known[t] && len(links) > 0 {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// Truncate 按字符数截断文本，超出时以省略号结尾
+func Truncate(text string, maxRunes int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= maxRunes {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:maxRunes-1]) + "…"
+}
+
+// Limiter 按会话（聊天、群等）限制一段时间内的请求次数
+// limit为1时相当于冷却时间
+type Limiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	hits   map[string][]time.Time
+}
+
+// NewLimiter 创建限流器，limit<=0表示不限制
+func NewLimiter(limit int, window time.Duration) *Limiter {
+	return &Limiter{limit: limit, window: window, hits: make(map[string][]time.Time)}
+}
+
+// Allow 记录一次请求，超出限制时返回false及需要等待的时间
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil || l.limit <= 0 {
+		return true, 0
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// 键较多时顺带清理已过期的记录
+	if len(l.hits) > 1000 {
+		for k, hits := range l.hits {
+			if len(hits) == 0 || now.Sub(hits[len(hits)-1]) >= l.window {
+				delete(l.hits, k)
+			}
+		}
+	}
+
+	hits := l.hits[key]
+	valid := hits[:0]
+	for _, t := range hits {
+		if now.Sub(t) < l.window {
+			valid = append(valid, t)
+		}
+	}
+	if len(valid) >= l.limit {
+		l.hits[key] = valid
+		return false, l.window - now.Sub(valid[0])
+	}
+	l.hits[key] = append(valid, now)
+	return true, 0
+}
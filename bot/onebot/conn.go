@@ -0,0 +1,154 @@
+package onebot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	jsonutil "pansou/util/json"
+)
+
+const (
+	// 调用API等待响应的超时时间
+	callTimeout = 15 * time.Second
+	// 未收到心跳事件时的读超时（心跳间隔的倍数）
+	heartbeatTimeoutFactor = 3
+)
+
+// errConnClosed 连接已关闭
+var errConnClosed = errors.New("OneBot连接已关闭")
+
+// apiResponse API调用响应
+type apiResponse struct {
+	Status  string          `json:"status"`
+	RetCode int             `json:"retcode"`
+	Data    json.RawMessage `json:"data"`
+	Message string          `json:"message,omitempty"`
+	Wording string          `json:"wording,omitempty"`
+	Echo    string          `json:"echo"`
+}
+
+// conn 一个OneBot连接（Universal角色，事件和API共用）
+type conn struct {
+	ws      *websocket.Conn
+	selfID  int64 // 连接时声明的机器人QQ号（正向连接为0）
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	pending map[string]chan apiResponse
+	seq     uint64
+	closed  chan struct{}
+}
+
+func newConn(ws *websocket.Conn, selfID int64) *conn {
+	return &conn{ws: ws, selfID: selfID, pending: make(map[string]chan apiResponse), closed: make(chan struct{})}
+}
+
+// call 调用OneBot API并等待响应
+func (c *conn) call(ctx context.Context, action string, params interface{}) (json.RawMessage, error) {
+	echo := strconv.FormatUint(atomic.AddUint64(&c.seq, 1), 10)
+	data, err := jsonutil.Marshal(map[string]interface{}{"action": action, "params": params, "echo": echo})
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan apiResponse, 1)
+	c.mu.Lock()
+	c.pending[echo] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, echo)
+		c.mu.Unlock()
+	}()
+
+	c.writeMu.Lock()
+	c.ws.SetWriteDeadline(time.Now().Add(callTimeout))
+	err = websocket.Message.Send(c.ws, string(data))
+	c.writeMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(callTimeout)
+	defer timer.Stop()
+	select {
+	case resp := <-ch:
+		if resp.Status == "failed" || resp.RetCode != 0 {
+			msg := resp.Wording
+			if msg == "" {
+				msg = resp.Message
+			}
+			return nil, fmt.Errorf("OneBot API %s 调用失败(%d): %s", action, resp.RetCode, msg)
+		}
+		return resp.Data, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("OneBot API %s 调用超时", action)
+	case <-c.closed:
+		return nil, errConnClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// serve 读取消息直到连接断开：API响应交给等待中的调用，事件交给handle异步处理
+func (c *conn) serve(handle func(*conn, *Event)) error {
+	defer close(c.closed)
+	c.ws.SetReadDeadline(time.Time{})
+
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(c.ws, &data); err != nil {
+			return err
+		}
+
+		var probe struct {
+			PostType string `json:"post_type"`
+		}
+		if err := jsonutil.Unmarshal(data, &probe); err != nil {
+			continue
+		}
+
+		if probe.PostType == "" {
+			var resp apiResponse
+			if err := jsonutil.Unmarshal(data, &resp); err != nil {
+				continue
+			}
+			// 取出等待中的调用后再发送，重复或过期的响应直接丢弃，不阻塞读取
+			c.mu.Lock()
+			ch, ok := c.pending[resp.Echo]
+			delete(c.pending, resp.Echo)
+			c.mu.Unlock()
+			if ok {
+				select {
+				case ch <- resp:
+				default:
+				}
+			}
+			continue
+		}
+
+		var event Event
+		if err := jsonutil.Unmarshal(data, &event); err != nil {
+			continue
+		}
+		// 根据心跳间隔设置读超时，实现端失联时及时重连
+		if event.MetaEventType == "heartbeat" && event.Interval > 0 {
+			c.ws.SetReadDeadline(time.Now().Add(time.Duration(event.Interval*heartbeatTimeoutFactor) * time.Millisecond))
+		}
+		if event.PostType == "message" {
+			go handle(c, &event)
+		}
+	}
+}
+
+// close 关闭连接
+func (c *conn) close() {
+	c.ws.Close()
+}
@@ -0,0 +1,108 @@
+package onebot
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	jsonutil "pansou/util/json"
+)
+
+// Event OneBot v11 上报事件（只解析用到的字段）
+type Event struct {
+	PostType      string          `json:"post_type"`
+	MetaEventType string          `json:"meta_event_type,omitempty"`
+	MessageType   string          `json:"message_type,omitempty"` // private、group
+	SelfID        int64           `json:"self_id"`
+	UserID        int64           `json:"user_id,omitempty"`
+	GroupID       int64           `json:"group_id,omitempty"`
+	MessageID     int64           `json:"message_id,omitempty"`
+	Message       json.RawMessage `json:"message,omitempty"` // 字符串（CQ码）或消息段数组
+	RawMessage    string          `json:"raw_message,omitempty"`
+	Sender        Sender          `json:"sender"`
+	Interval      int64           `json:"interval,omitempty"` // 心跳间隔（毫秒）
+}
+
+// Sender 消息发送者
+type Sender struct {
+	UserID   int64  `json:"user_id"`
+	Nickname string `json:"nickname,omitempty"`
+	Card     string `json:"card,omitempty"`
+	Role     string `json:"role,omitempty"` // 群消息中为 owner、admin、member
+}
+
+// Segment 消息段
+type Segment struct {
+	Type string                 `json:"type"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// textSegment 文本消息段
+func textSegment(text string) Segment {
+	return Segment{Type: "text", Data: map[string]interface{}{"text": text}}
+}
+
+// replySegment 回复消息段
+func replySegment(messageID int64) Segment {
+	return Segment{Type: "reply", Data: map[string]interface{}{"id": strconv.FormatInt(messageID, 10)}}
+}
+
+// cqPattern 字符串格式消息中的CQ码
+var cqPattern = regexp.MustCompile(`\[CQ:([a-zA-Z_]+)((?:,[^\]]*)?)\]`)
+
+// cqUnescaper CQ码文本转义
+var cqUnescaper = strings.NewReplacer("&#91;", "[", "&#93;", "]", "&#44;", ",", "&amp;", "&")
+
+// PlainText 提取消息中的纯文本，并返回是否@了机器人
+func (e *Event) PlainText() (string, bool) {
+	raw := strings.TrimSpace(string(e.Message))
+	if strings.HasPrefix(raw, "[") {
+		var segments []Segment
+		if err := jsonutil.Unmarshal(e.Message, &segments); err == nil {
+			return segmentsText(segments, e.SelfID)
+		}
+	}
+
+	message := e.RawMessage
+	if strings.HasPrefix(raw, `"`) {
+		jsonutil.Unmarshal(e.Message, &message)
+	}
+	return cqText(message, e.SelfID)
+}
+
+// segmentsText 拼接数组格式消息中的文本段
+func segmentsText(segments []Segment, selfID int64) (string, bool) {
+	var b strings.Builder
+	mentioned := false
+	for _, seg := range segments {
+		switch seg.Type {
+		case "text":
+			b.WriteString(fmt.Sprint(seg.Data["text"]))
+		case "at":
+			if fmt.Sprint(seg.Data["qq"]) == strconv.FormatInt(selfID, 10) {
+				mentioned = true
+			}
+		}
+	}
+	return strings.TrimSpace(b.String()), mentioned
+}
+
+// cqText 去掉字符串格式消息中的CQ码并反转义
+func cqText(message string, selfID int64) (string, bool) {
+	mentioned := false
+	self := "qq=" + strconv.FormatInt(selfID, 10)
+	text := cqPattern.ReplaceAllStringFunc(message, func(code string) string {
+		match := cqPattern.FindStringSubmatch(code)
+		if match[1] == "at" {
+			for _, param := range strings.Split(strings.TrimPrefix(match[2], ","), ",") {
+				if param == self {
+					mentioned = true
+				}
+			}
+		}
+		return ""
+	})
+	return strings.TrimSpace(cqUnescaper.Replace(text)), mentioned
+}
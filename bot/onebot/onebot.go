@@ -0,0 +1,456 @@
+// Package onebot OneBot v11（QQ）机器人：通过正向或反向WebSocket连接OneBot实现（如 NapCat、LLOneBot、go-cqhttp），
+// 在群聊和私聊中响应搜索命令，按链接类型分组回复精简的文本结果
+package onebot
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/net/websocket"
+
+	"pansou/bot"
+	"pansou/model"
+	"pansou/service"
+	"pansou/util"
+)
+
+const (
+	// 单次搜索的超时时间
+	searchTimeout = 60 * time.Second
+	// 正向连接断开后的重连间隔（逐步增加到最大值）
+	reconnectDelay    = 3 * time.Second
+	maxReconnectDelay = time.Minute
+	// 连接超时时间
+	dialTimeout = 10 * time.Second
+	// 标题最多显示的字符数
+	maxTitleRunes = 40
+	// 默认最多回复的链接数
+	defaultMaxResults = 10
+)
+
+// Config 机器人配置
+type Config struct {
+	WSURL         string        // 正向WebSocket地址，为空时只接受反向连接
+	AccessToken   string        // 正向连接时发送的access_token
+	AllowedGroups []int64       // 允许使用的群，为空表示不限制
+	AllowPrivate  bool          // 是否响应私聊
+	Admins        []int64       // 可以修改群设置的QQ号（群主和群管理员总是可以）
+	Cooldown      time.Duration // 每个群（私聊为每个用户）两次搜索的最小间隔
+	MaxResults    int           // 每次最多回复的链接数
+	CloudTypes    []string      // 群未设置时默认返回的网盘类型，为空表示全部
+	DataPath      string        // 群设置保存目录
+}
+
+// Bot OneBot机器人
+type Bot struct {
+	cfg           Config
+	searchService *service.SearchService
+	allowedGroups map[int64]bool
+	admins        map[int64]bool
+	limiter       *bot.Limiter
+	prefs         *prefsStore
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	conns  map[*conn]bool
+}
+
+// New 创建机器人
+func New(searchService *service.SearchService, cfg Config) *Bot {
+	if cfg.MaxResults <= 0 {
+		cfg.MaxResults = defaultMaxResults
+	}
+	limit := 0
+	if cfg.Cooldown > 0 {
+		limit = 1
+	}
+
+	b := &Bot{
+		cfg:           cfg,
+		searchService: searchService,
+		allowedGroups: int64Set(cfg.AllowedGroups),
+		admins:        int64Set(cfg.Admins),
+		limiter:       bot.NewLimiter(limit, cfg.Cooldown),
+		prefs:         newPrefsStore(cfg.DataPath),
+		conns:         make(map[*conn]bool),
+	}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	return b
+}
+
+func int64Set(ids []int64) map[int64]bool {
+	if len(ids) == 0 {
+		return nil
+	}
+	set := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// Run 维持正向连接（如已配置），直到ctx取消后关闭所有连接
+func (b *Bot) Run(ctx context.Context) error {
+	defer b.closeAll()
+
+	if b.cfg.WSURL == "" {
+		<-ctx.Done()
+		return nil
+	}
+
+	delay := reconnectDelay
+	for ctx.Err() == nil {
+		ws, err := b.dial()
+		if err != nil {
+			fmt.Printf("[OneBot] 连接 %s 失败: %v，%v 后重试\n", b.cfg.WSURL, err, delay)
+		} else {
+			fmt.Printf("[OneBot] 已连接 %s\n", b.cfg.WSURL)
+			delay = reconnectDelay
+			stop := context.AfterFunc(ctx, func() { ws.Close() })
+			err = b.serveConn(newConn(ws, 0))
+			stop()
+			if ctx.Err() != nil {
+				break
+			}
+			fmt.Printf("[OneBot] 连接断开: %v，%v 后重连\n", err, delay)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+	return nil
+}
+
+// dial 建立正向WebSocket连接
+func (b *Bot) dial() (*websocket.Conn, error) {
+	wsURL := b.cfg.WSURL
+	if strings.HasPrefix(wsURL, "http://") || strings.HasPrefix(wsURL, "https://") {
+		wsURL = "ws" + strings.TrimPrefix(wsURL, "http")
+	}
+	config, err := websocket.NewConfig(wsURL, "http://localhost/")
+	if err != nil {
+		return nil, err
+	}
+	if b.cfg.AccessToken != "" {
+		config.Header.Set("Authorization", "Bearer "+b.cfg.AccessToken)
+	}
+	config.Dialer = &net.Dialer{Timeout: dialTimeout}
+	return websocket.DialConfig(config)
+}
+
+// ServeHTTP 接受反向WebSocket连接（Universal角色），鉴权由调用方完成
+func (b *Bot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if role := r.Header.Get("X-Client-Role"); role != "" && !strings.EqualFold(role, "Universal") {
+		http.Error(w, "只支持Universal角色的连接", http.StatusBadRequest)
+		return
+	}
+	selfID, _ := strconv.ParseInt(r.Header.Get("X-Self-ID"), 10, 64)
+
+	server := websocket.Server{
+		// OneBot实现不发送Origin，不做校验
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			// 清除HTTP服务器设置的读写超时
+			ws.SetDeadline(time.Time{})
+			fmt.Printf("[OneBot] 反向连接已建立: %d (%s)\n", selfID, r.RemoteAddr)
+			err := b.serveConn(newConn(ws, selfID))
+			fmt.Printf("[OneBot] 反向连接断开: %d: %v\n", selfID, err)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// serveConn 处理一个连接直到断开
+func (b *Bot) serveConn(c *conn) error {
+	b.mu.Lock()
+	if b.ctx.Err() != nil {
+		b.mu.Unlock()
+		c.close()
+		return b.ctx.Err()
+	}
+	b.conns[c] = true
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.conns, c)
+		b.mu.Unlock()
+		c.close()
+	}()
+	return c.serve(b.handleEvent)
+}
+
+// closeAll 停止机器人并关闭所有连接
+func (b *Bot) closeAll() {
+	b.cancel()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for c := range b.conns {
+		c.close()
+	}
+}
+
+// 命令
+const (
+	commandNone = iota
+	commandSearch
+	commandTypes
+	commandHelp
+)
+
+// commands 命令名称，requirePrefix 表示必须以 / 或 # 开头
+var commands = []struct {
+	name          string
+	command       int
+	requirePrefix bool
+}{
+	{"搜索", commandSearch, false},
+	{"搜", commandSearch, false},
+	{"search", commandSearch, true},
+	{"s", commandSearch, true},
+	{"网盘", commandTypes, true},
+	{"types", commandTypes, true},
+	{"帮助", commandHelp, true},
+	{"help", commandHelp, true},
+}
+
+// parseCommand 解析命令和参数，不是命令时返回 commandNone
+func parseCommand(text string) (int, string) {
+	prefixed := strings.HasPrefix(text, "/") || strings.HasPrefix(text, "#")
+	if prefixed {
+		text = text[1:]
+	}
+	word, args := text, ""
+	if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+		word, args = text[:i], strings.TrimSpace(text[i:])
+	}
+	word = strings.ToLower(word)
+	for _, c := range commands {
+		if word == c.name && (prefixed || !c.requirePrefix) {
+			return c.command, args
+		}
+	}
+	return commandNone, ""
+}
+
+// handleEvent 处理消息事件
+func (b *Bot) handleEvent(c *conn, e *Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("[OneBot] 处理消息时发生panic: %v\n", r)
+		}
+	}()
+
+	isGroup := e.MessageType == "group"
+	switch {
+	case isGroup && b.allowedGroups != nil && !b.allowedGroups[e.GroupID]:
+		return
+	case !isGroup && (e.MessageType != "private" || !b.cfg.AllowPrivate):
+		return
+	}
+
+	text, mentioned := e.PlainText()
+	if text == "" {
+		return
+	}
+	command, args := parseCommand(text)
+	switch command {
+	case commandHelp:
+		b.reply(c, e, helpText())
+		return
+	case commandTypes:
+		b.handleTypes(c, e, args)
+		return
+	case commandNone:
+		// 群聊中只响应命令或@机器人的消息，私聊中直接搜索
+		if isGroup && !mentioned {
+			return
+		}
+		args = text
+	}
+
+	keyword := strings.TrimSpace(args)
+	if keyword == "" {
+		b.reply(c, e, "用法：搜索 关键词")
+		return
+	}
+	if ok, wait := b.limiter.Allow(b.sessionKey(e)); !ok {
+		b.reply(c, e, fmt.Sprintf("搜索冷却中，请 %d 秒后再试", int(wait.Seconds())+1))
+		return
+	}
+
+	cloudTypes := b.cloudTypes(e)
+	merged, err := bot.SearchWithTimeout(b.ctx, b.searchService, keyword, cloudTypes, searchTimeout)
+	if err != nil {
+		b.reply(c, e, "搜索失败: "+err.Error())
+		return
+	}
+	b.reply(c, e, renderResults(keyword, merged, cloudTypes, b.cfg.MaxResults))
+}
+
+// sessionKey 冷却时间的计算单位：群聊按群，私聊按用户
+func (b *Bot) sessionKey(e *Event) string {
+	if e.MessageType == "group" {
+		return "group:" + strconv.FormatInt(e.GroupID, 10)
+	}
+	return "private:" + strconv.FormatInt(e.UserID, 10)
+}
+
+// cloudTypes 当前会话的网盘类型：群设置优先，其次为默认设置
+func (b *Bot) cloudTypes(e *Event) []string {
+	if e.MessageType == "group" {
+		if types := b.prefs.get(e.GroupID); len(types) > 0 {
+			return types
+		}
+	}
+	return b.cfg.CloudTypes
+}
+
+// handleTypes 查看或设置群的网盘类型
+func (b *Bot) handleTypes(c *conn, e *Event, args string) {
+	if e.MessageType != "group" {
+		b.reply(c, e, "请在群聊中设置网盘类型")
+		return
+	}
+	if args == "" {
+		if types := b.prefs.get(e.GroupID); len(types) > 0 {
+			b.reply(c, e, "本群只搜索："+cloudTypeNames(types))
+		} else if len(b.cfg.CloudTypes) > 0 {
+			b.reply(c, e, "本群未设置网盘类型，使用默认设置："+cloudTypeNames(b.cfg.CloudTypes))
+		} else {
+			b.reply(c, e, "本群未设置网盘类型，搜索全部类型")
+		}
+		return
+	}
+
+	if e.Sender.Role != "owner" && e.Sender.Role != "admin" && !b.admins[e.UserID] {
+		b.reply(c, e, "只有群主和管理员可以设置网盘类型")
+		return
+	}
+
+	var types []string
+	switch strings.ToLower(args) {
+	case "全部", "all", "重置", "reset":
+	default:
+		var unknown []string
+		types, unknown = parseCloudTypes(args)
+		if len(unknown) > 0 {
+			b.reply(c, e, "无法识别："+strings.Join(unknown, "、")+"\n可选："+cloudTypeNames(allCloudTypes()))
+			return
+		}
+	}
+	if err := b.prefs.set(e.GroupID, types); err != nil {
+		b.reply(c, e, "保存设置失败: "+err.Error())
+		return
+	}
+	if len(types) == 0 {
+		b.reply(c, e, "已恢复默认设置")
+		return
+	}
+	b.reply(c, e, "本群只搜索："+cloudTypeNames(types))
+}
+
+// reply 回复消息（引用原消息）
+func (b *Bot) reply(c *conn, e *Event, text string) {
+	params := map[string]interface{}{
+		"message_type": e.MessageType,
+		"message":      []Segment{replySegment(e.MessageID), textSegment(text)},
+	}
+	if e.MessageType == "group" {
+		params["group_id"] = e.GroupID
+	} else {
+		params["user_id"] = e.UserID
+	}
+	if _, err := c.call(b.ctx, "send_msg", params); err != nil {
+		fmt.Printf("[OneBot] 发送消息失败: %v\n", err)
+	}
+}
+
+// renderResults 按链接类型分组输出精简的结果，最多maxResults条，尽量让每种类型都有结果
+func renderResults(keyword string, merged model.MergedLinks, cloudTypes []string, maxResults int) string {
+	allowed := make(map[string]bool, len(cloudTypes))
+	for _, t := range cloudTypes {
+		allowed[t] = true
+	}
+
+	// 按链接本身重新判断类型，修正来源标注不准确的类型
+	grouped := make(model.MergedLinks)
+	total := 0
+	for _, entry := range bot.Flatten(merged, "") {
+		linkType := util.GetLinkType(entry.Link.URL)
+		if linkType == "others" {
+			linkType = entry.CloudType
+		}
+		if len(allowed) > 0 && !allowed[linkType] {
+			continue
+		}
+		grouped[linkType] = append(grouped[linkType], entry.Link)
+		total++
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔍 %s 共 %d 条", keyword, total)
+	if total == 0 {
+		b.WriteString("\n没有找到相关资源")
+		return b.String()
+	}
+
+	types := bot.OrderedTypes(grouped)
+	perType := (maxResults + len(types) - 1) / len(types)
+	shown := 0
+	for _, t := range types {
+		if shown >= maxResults {
+			break
+		}
+		fmt.Fprintf(&b, "\n【%s】", model.CloudTypeName(t))
+		for i, link := range grouped[t] {
+			if i >= perType || shown >= maxResults {
+				break
+			}
+			shown++
+			title := bot.Truncate(link.Note, maxTitleRunes)
+			if title == "" {
+				title = "未命名资源"
+			}
+			fmt.Fprintf(&b, "\n%d. %s\n%s", shown, title, link.URL)
+			if link.Password != "" {
+				fmt.Fprintf(&b, " 提取码: %s", link.Password)
+			}
+		}
+	}
+	if shown < total {
+		fmt.Fprintf(&b, "\n\n仅显示 %d 条", shown)
+	}
+	return b.String()
+}
+
+// allCloudTypes 全部网盘类型
+func allCloudTypes() []string {
+	types := make([]string, 0, len(model.CloudTypes))
+	for _, t := range model.CloudTypes {
+		types = append(types, t.Type)
+	}
+	return types
+}
+
+// helpText 帮助信息
+func helpText() string {
+	return "PanSou 网盘搜索\n" +
+		"搜索 关键词 — 搜索网盘资源（私聊中可直接发送关键词，群聊中也可以@机器人）\n" +
+		"/网盘 — 查看本群的网盘类型\n" +
+		"/网盘 夸克 百度 — 本群只搜索指定类型（群主、管理员）\n" +
+		"/网盘 全部 — 恢复默认设置"
+}
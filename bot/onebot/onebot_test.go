@@ -0,0 +1,155 @@
+package onebot_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"pansou/bot/onebot"
+	"pansou/config"
+	"pansou/model"
+	"pansou/plugin"
+	"pansou/service"
+)
+
+// fakePlugin 返回固定结果的搜索插件
+type fakePlugin struct{}
+
+func (fakePlugin) Name() string             { return "fake" }
+func (fakePlugin) Priority() int            { return 1 }
+func (fakePlugin) SetMainCacheKey(string)   {}
+func (fakePlugin) SetCurrentKeyword(string) {}
+func (fakePlugin) SkipServiceFilter() bool  { return true }
+func (p fakePlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	return []model.SearchResult{{
+		UniqueID: "fake-1",
+		Title:    keyword + " 4K",
+		Datetime: time.Now(),
+		Links:    []model.Link{{Type: "quark", URL: "https://pan.quark.cn/s/abc123"}},
+	}}, nil
+}
+func (p fakePlugin) AsyncSearch(keyword string, _ func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error), _ string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	return p.Search(keyword, ext)
+}
+
+// newTestBot 创建只使用fakePlugin搜索的机器人，并以反向WebSocket方式提供服务
+func newTestBot(t *testing.T) *httptest.Server {
+	t.Helper()
+	config.AppConfig = &config.Config{
+		DefaultConcurrency: 2,
+		PluginTimeout:      5 * time.Second,
+		AsyncPluginEnabled: true,
+	}
+	manager := plugin.NewPluginManager()
+	manager.RegisterPlugin(fakePlugin{})
+	b := onebot.New(service.NewSearchService(manager), onebot.Config{AllowPrivate: true})
+
+	srv := httptest.NewServer(b)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// dialBot 以OneBot实现的身份建立反向连接
+func dialBot(t *testing.T, srv *httptest.Server) *websocket.Conn {
+	t.Helper()
+	cfg, err := websocket.NewConfig("ws"+strings.TrimPrefix(srv.URL, "http"), "http://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Header.Set("X-Client-Role", "Universal")
+	cfg.Header.Set("X-Self-ID", "10000")
+	ws, err := websocket.DialConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+// apiCall 机器人发起的API调用
+type apiCall struct {
+	Action string `json:"action"`
+	Echo   string `json:"echo"`
+	Params struct {
+		MessageType string           `json:"message_type"`
+		UserID      int64            `json:"user_id"`
+		Message     []onebot.Segment `json:"message"`
+	} `json:"params"`
+}
+
+func sendJSON(t *testing.T, ws *websocket.Conn, v interface{}) {
+	t.Helper()
+	if err := websocket.JSON.Send(ws, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func receiveCall(t *testing.T, ws *websocket.Conn) apiCall {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(10 * time.Second))
+	var call apiCall
+	if err := websocket.JSON.Receive(ws, &call); err != nil {
+		t.Fatal(err)
+	}
+	return call
+}
+
+func privateMessage(id int64, text string) map[string]interface{} {
+	return map[string]interface{}{
+		"post_type":    "message",
+		"message_type": "private",
+		"self_id":      10000,
+		"user_id":      42,
+		"message_id":   id,
+		"message":      text,
+		"sender":       map[string]interface{}{"user_id": 42},
+	}
+}
+
+// replyText 回复消息中的文本
+func replyText(call apiCall) string {
+	var text strings.Builder
+	for _, seg := range call.Params.Message {
+		if seg.Type == "text" {
+			text.WriteString(seg.Data["text"].(string))
+		}
+	}
+	return text.String()
+}
+
+func TestPrivateMessageSearchReply(t *testing.T) {
+	ws := dialBot(t, newTestBot(t))
+
+	sendJSON(t, ws, privateMessage(1, "流浪地球"))
+	call := receiveCall(t, ws)
+	if call.Action != "send_msg" || call.Params.MessageType != "private" || call.Params.UserID != 42 {
+		t.Fatalf("call = %+v", call)
+	}
+	if text := replyText(call); !strings.Contains(text, "https://pan.quark.cn/s/abc123") || !strings.Contains(text, "流浪地球") {
+		t.Fatalf("reply = %q", text)
+	}
+	sendJSON(t, ws, map[string]interface{}{"status": "ok", "retcode": 0, "data": json.RawMessage(`{"message_id":2}`), "echo": call.Echo})
+}
+
+func TestDuplicateResponseDoesNotBlockConnection(t *testing.T) {
+	ws := dialBot(t, newTestBot(t))
+
+	sendJSON(t, ws, privateMessage(1, "/help"))
+	call := receiveCall(t, ws)
+	resp := map[string]interface{}{"status": "ok", "retcode": 0, "echo": call.Echo}
+	// 重复和未知echo的响应都应被丢弃，连接继续读取后续事件
+	sendJSON(t, ws, resp)
+	sendJSON(t, ws, resp)
+	sendJSON(t, ws, resp)
+	sendJSON(t, ws, map[string]interface{}{"status": "ok", "retcode": 0, "echo": "unknown"})
+
+	sendJSON(t, ws, privateMessage(2, "/help"))
+	if call := receiveCall(t, ws); call.Action != "send_msg" || !strings.Contains(replyText(call), "PanSou") {
+		t.Fatalf("second call = %+v", call)
+	}
+}
@@ -0,0 +1,135 @@
+package onebot
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"pansou/model"
+	"pansou/util/json"
+)
+
+// 群设置文件名
+const prefsFileName = "onebot_groups.json"
+
+// prefsStore 每个群的网盘类型偏好，保存在缓存目录
+type prefsStore struct {
+	mu     sync.RWMutex
+	path   string
+	groups map[int64][]string
+}
+
+func newPrefsStore(dir string) *prefsStore {
+	s := &prefsStore{path: filepath.Join(dir, prefsFileName), groups: make(map[int64][]string)}
+	s.load()
+	return s
+}
+
+// load 从文件加载群设置
+func (s *prefsStore) load() {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return
+	}
+	var stored map[string][]string
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return
+	}
+	for key, types := range stored {
+		if groupID, err := strconv.ParseInt(key, 10, 64); err == nil && len(types) > 0 {
+			s.groups[groupID] = types
+		}
+	}
+}
+
+// get 群的网盘类型偏好，未设置时返回nil
+func (s *prefsStore) get(groupID int64) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.groups[groupID]
+}
+
+// set 设置群的网盘类型偏好，types为空表示清除
+func (s *prefsStore) set(groupID int64, types []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(types) == 0 {
+		delete(s.groups, groupID)
+	} else {
+		s.groups[groupID] = types
+	}
+
+	stored := make(map[string][]string, len(s.groups))
+	for id, t := range s.groups {
+		stored[strconv.FormatInt(id, 10)] = t
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+// parseCloudTypes 解析用户输入的网盘类型，支持类型名（quark）和中文名（夸克网盘、夸克），返回无法识别的项
+func parseCloudTypes(input string) ([]string, []string) {
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == '，' || r == '、' || r == ' ' || r == '\t'
+	})
+
+	seen := make(map[string]bool)
+	types := make([]string, 0, len(fields))
+	unknown := make([]string, 0)
+	for _, field := range fields {
+		cloudType := lookupCloudType(field)
+		if cloudType == "" {
+			unknown = append(unknown, field)
+			continue
+		}
+		if !seen[cloudType] {
+			seen[cloudType] = true
+			types = append(types, cloudType)
+		}
+	}
+	// 按展示顺序排列
+	order := make(map[string]int, len(model.CloudTypes))
+	for i, t := range model.CloudTypes {
+		order[t.Type] = i
+	}
+	sort.SliceStable(types, func(i, j int) bool { return order[types[i]] < order[types[j]] })
+	return types, unknown
+}
+
+// lookupCloudType 按类型名或中文名查找网盘类型
+func lookupCloudType(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, t := range model.CloudTypes {
+		if name == t.Type || name == strings.ToLower(t.Name) {
+			return t.Type
+		}
+		for _, suffix := range []string{"网盘", "云盘", "链接"} {
+			if short := strings.TrimSuffix(strings.ToLower(t.Name), suffix); short != strings.ToLower(t.Name) && name == short {
+				return t.Type
+			}
+		}
+	}
+	return ""
+}
+
+// cloudTypeNames 网盘类型的中文名列表
+func cloudTypeNames(types []string) string {
+	names := make([]string, 0, len(types))
+	for _, t := range types {
+		names = append(names, model.CloudTypeName(t))
+	}
+	return strings.Join(names, "、")
+}
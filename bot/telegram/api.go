@@ -0,0 +1,236 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	jsonutil "pansou/util/json"
+)
+
+// DefaultAPIURL Telegram Bot API默认地址
+const DefaultAPIURL = "https://api.telegram.org"
+
+// Update 更新（只处理消息、内联查询和按钮回调）
+type Update struct {
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message,omitempty"`
+	InlineQuery   *InlineQuery   `json:"inline_query,omitempty"`
+	CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
+}
+
+// User 用户
+type User struct {
+	ID       int64  `json:"id"`
+	IsBot    bool   `json:"is_bot,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// Chat 聊天
+type Chat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"` // private、group、supergroup、channel
+}
+
+// Message 消息
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	From      *User  `json:"from,omitempty"`
+	Chat      Chat   `json:"chat"`
+	Text      string `json:"text,omitempty"`
+}
+
+// InlineQuery 内联查询
+type InlineQuery struct {
+	ID     string `json:"id"`
+	From   User   `json:"from"`
+	Query  string `json:"query"`
+	Offset string `json:"offset"`
+}
+
+// CallbackQuery 按钮回调
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	From    User     `json:"from"`
+	Message *Message `json:"message,omitempty"`
+	Data    string   `json:"data,omitempty"`
+}
+
+// InlineKeyboardButton 内联键盘按钮
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data,omitempty"`
+	URL          string `json:"url,omitempty"`
+}
+
+// InlineKeyboardMarkup 内联键盘
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+// InlineQueryResultArticle 内联查询结果（文章类型）
+type InlineQueryResultArticle struct {
+	Type                string              `json:"type"`
+	ID                  string              `json:"id"`
+	Title               string              `json:"title"`
+	Description         string              `json:"description,omitempty"`
+	InputMessageContent inputMessageContent `json:"input_message_content"`
+}
+
+type inputMessageContent struct {
+	MessageText    string `json:"message_text"`
+	ParseMode      string `json:"parse_mode,omitempty"`
+	DisablePreview bool   `json:"disable_web_page_preview,omitempty"`
+}
+
+// APIError Bot API返回的错误
+type APIError struct {
+	Code        int
+	Description string
+	RetryAfter  int // 被限流时需要等待的秒数
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("Telegram API错误(%d): %s", e.Code, e.Description)
+}
+
+// API Bot API客户端
+type API struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewAPI 创建Bot API客户端，baseURL为空时使用官方地址
+func NewAPI(baseURL, token string, httpClient *http.Client) *API {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &API{baseURL: strings.TrimRight(baseURL, "/"), token: token, http: httpClient}
+}
+
+// call 调用Bot API方法，结果写入result
+func (a *API) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	body, err := jsonutil.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", a.baseURL+"/bot"+a.token+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.http.Do(req)
+	if err != nil {
+		// 错误信息中的地址包含token，不直接返回
+		return fmt.Errorf("请求Telegram API失败: %s", strings.ReplaceAll(err.Error(), a.token, "***"))
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return err
+	}
+
+	var apiResp struct {
+		OK          bool            `json:"ok"`
+		Result      json.RawMessage `json:"result"`
+		ErrorCode   int             `json:"error_code"`
+		Description string          `json:"description"`
+		Parameters  struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	if err := jsonutil.Unmarshal(data, &apiResp); err != nil {
+		return fmt.Errorf("解析Telegram API响应失败(状态码%d): %w", resp.StatusCode, err)
+	}
+	if !apiResp.OK {
+		return &APIError{Code: apiResp.ErrorCode, Description: apiResp.Description, RetryAfter: apiResp.Parameters.RetryAfter}
+	}
+	if result != nil && len(apiResp.Result) > 0 {
+		return jsonutil.Unmarshal(apiResp.Result, result)
+	}
+	return nil
+}
+
+// GetMe 获取机器人自身信息
+func (a *API) GetMe(ctx context.Context) (User, error) {
+	var user User
+	err := a.call(ctx, "getMe", struct{}{}, &user)
+	return user, err
+}
+
+// GetUpdates 长轮询获取更新
+func (a *API) GetUpdates(ctx context.Context, offset int64, timeoutSeconds int) ([]Update, error) {
+	var updates []Update
+	err := a.call(ctx, "getUpdates", map[string]interface{}{
+		"offset":          offset,
+		"timeout":         timeoutSeconds,
+		"allowed_updates": []string{"message", "inline_query", "callback_query"},
+	}, &updates)
+	return updates, err
+}
+
+// SendMessage 发送HTML消息，返回发送的消息
+func (a *API) SendMessage(ctx context.Context, chatID int64, text string, markup *InlineKeyboardMarkup, replyTo int64) (Message, error) {
+	params := map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}
+	if markup != nil {
+		params["reply_markup"] = markup
+	}
+	if replyTo != 0 {
+		params["reply_to_message_id"] = replyTo
+		params["allow_sending_without_reply"] = true
+	}
+	var msg Message
+	err := a.call(ctx, "sendMessage", params, &msg)
+	return msg, err
+}
+
+// EditMessageText 修改消息内容和键盘
+func (a *API) EditMessageText(ctx context.Context, chatID, messageID int64, text string, markup *InlineKeyboardMarkup) error {
+	params := map[string]interface{}{
+		"chat_id":                  chatID,
+		"message_id":               messageID,
+		"text":                     text,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}
+	if markup != nil {
+		params["reply_markup"] = markup
+	}
+	err := a.call(ctx, "editMessageText", params, nil)
+	// 内容没有变化（如重复点击同一按钮）不视为错误
+	if apiErr, ok := err.(*APIError); ok && strings.Contains(apiErr.Description, "message is not modified") {
+		return nil
+	}
+	return err
+}
+
+// AnswerCallbackQuery 响应按钮回调，text不为空时显示提示
+func (a *API) AnswerCallbackQuery(ctx context.Context, callbackQueryID, text string) error {
+	params := map[string]interface{}{"callback_query_id": callbackQueryID}
+	if text != "" {
+		params["text"] = text
+	}
+	return a.call(ctx, "answerCallbackQuery", params, nil)
+}
+
+// AnswerInlineQuery 响应内联查询，nextOffset不为空时客户端滚动到底部会继续请求
+func (a *API) AnswerInlineQuery(ctx context.Context, inlineQueryID string, results []InlineQueryResultArticle, nextOffset string, cacheTime int) error {
+	return a.call(ctx, "answerInlineQuery", map[string]interface{}{
+		"inline_query_id": inlineQueryID,
+		"results":         results,
+		"next_offset":     nextOffset,
+		"cache_time":      cacheTime,
+		"is_personal":     false,
+	}, nil)
+}
@@ -0,0 +1,295 @@
+// Package telegram Telegram机器人：通过长轮询接收消息，支持 /search 命令、内联查询以及按钮翻页和网盘类型筛选
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pansou/bot"
+	"pansou/service"
+)
+
+const (
+	// 长轮询等待时间（秒）
+	pollTimeout = 30
+	// 获取更新失败后的重试间隔
+	pollRetryDelay = 5 * time.Second
+	// 单次搜索的超时时间
+	searchTimeout = 60 * time.Second
+	// 内联查询每页结果数（Telegram上限为50）
+	inlinePageSize = 20
+	// 内联查询结果的缓存时间（秒）
+	inlineCacheTime = 300
+	// 默认每页显示的链接数
+	defaultPageSize = 8
+)
+
+// Config 机器人配置
+type Config struct {
+	Token        string
+	APIURL       string  // Bot API地址，可指向自建的Bot API服务
+	AllowedChats []int64 // 允许使用的聊天ID（内联查询按用户ID判断），为空表示不限制
+	RateLimit    int     // 每个聊天每分钟最多搜索次数，<=0表示不限制
+	PageSize     int     // 每页显示的链接数
+}
+
+// Bot Telegram机器人
+type Bot struct {
+	api           *API
+	searchService *service.SearchService
+	allowed       map[int64]bool
+	limiter       *bot.Limiter
+	sessions      *sessionStore
+	pageSize      int
+	username      string
+}
+
+// New 创建机器人
+func New(searchService *service.SearchService, cfg Config, httpClient *http.Client) *Bot {
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	// 长轮询请求需要比轮询时间更长的超时
+	if httpClient.Timeout != 0 && httpClient.Timeout < (pollTimeout+15)*time.Second {
+		client := *httpClient
+		client.Timeout = (pollTimeout + 15) * time.Second
+		httpClient = &client
+	}
+
+	b := &Bot{
+		api:           NewAPI(cfg.APIURL, cfg.Token, httpClient),
+		searchService: searchService,
+		limiter:       bot.NewLimiter(cfg.RateLimit, time.Minute),
+		sessions:      newSessionStore(),
+		pageSize:      cfg.PageSize,
+	}
+	if b.pageSize <= 0 {
+		b.pageSize = defaultPageSize
+	}
+	if len(cfg.AllowedChats) > 0 {
+		b.allowed = make(map[int64]bool, len(cfg.AllowedChats))
+		for _, id := range cfg.AllowedChats {
+			b.allowed[id] = true
+		}
+	}
+	return b
+}
+
+// Run 长轮询处理更新，直到ctx取消
+func (b *Bot) Run(ctx context.Context) error {
+	me, err := b.api.GetMe(ctx)
+	if err != nil {
+		return fmt.Errorf("获取机器人信息失败: %w", err)
+	}
+	b.username = me.Username
+
+	var offset int64
+	for ctx.Err() == nil {
+		updates, err := b.api.GetUpdates(ctx, offset, pollTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			delay := pollRetryDelay
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+				delay = time.Duration(apiErr.RetryAfter) * time.Second
+			}
+			fmt.Printf("[TelegramBot] 获取更新失败: %v\n", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(delay):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			go b.handleUpdate(ctx, update)
+		}
+	}
+	return nil
+}
+
+// handleUpdate 按更新类型分发
+func (b *Bot) handleUpdate(ctx context.Context, update Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("[TelegramBot] 处理更新 %d 时发生panic: %v\n", update.UpdateID, r)
+		}
+	}()
+
+	switch {
+	case update.Message != nil:
+		b.handleMessage(ctx, update.Message)
+	case update.CallbackQuery != nil:
+		b.handleCallback(ctx, update.CallbackQuery)
+	case update.InlineQuery != nil:
+		b.handleInlineQuery(ctx, update.InlineQuery)
+	}
+}
+
+// isAllowed 聊天（或用户）是否在允许列表中
+func (b *Bot) isAllowed(id int64) bool {
+	return b.allowed == nil || b.allowed[id]
+}
+
+// handleMessage 处理 /search、/start、/help 命令；私聊中的普通文本视为搜索关键词
+func (b *Bot) handleMessage(ctx context.Context, msg *Message) {
+	text := strings.TrimSpace(msg.Text)
+	if text == "" || !b.isAllowed(msg.Chat.ID) {
+		return
+	}
+
+	command, args := b.parseCommand(text)
+	switch command {
+	case "start", "help":
+		b.api.SendMessage(ctx, msg.Chat.ID, helpText(b.username), nil, 0)
+		return
+	case "search", "s":
+	case "":
+		// 群聊中只响应命令
+		if msg.Chat.Type != "private" {
+			return
+		}
+		args = text
+	default:
+		return
+	}
+
+	keyword := strings.TrimSpace(args)
+	if keyword == "" {
+		b.api.SendMessage(ctx, msg.Chat.ID, "用法：/search 关键词", nil, msg.MessageID)
+		return
+	}
+	if ok, wait := b.limiter.Allow(strconv.FormatInt(msg.Chat.ID, 10)); !ok {
+		b.api.SendMessage(ctx, msg.Chat.ID, fmt.Sprintf("搜索太频繁，请 %d 秒后再试", int(wait.Seconds())+1), nil, msg.MessageID)
+		return
+	}
+
+	pending, err := b.api.SendMessage(ctx, msg.Chat.ID, "🔍 正在搜索 <b>"+html.EscapeString(keyword)+"</b> …", nil, msg.MessageID)
+	if err != nil {
+		fmt.Printf("[TelegramBot] 发送消息失败: %v\n", err)
+		return
+	}
+
+	merged, err := bot.SearchWithTimeout(ctx, b.searchService, keyword, nil, searchTimeout)
+	if err != nil {
+		b.api.EditMessageText(ctx, msg.Chat.ID, pending.MessageID, "搜索失败: "+html.EscapeString(err.Error()), nil)
+		return
+	}
+	sess := b.sessions.create(keyword, merged)
+	text, markup := renderPage(sess, "", 0, b.pageSize)
+	if err := b.api.EditMessageText(ctx, msg.Chat.ID, pending.MessageID, text, markup); err != nil {
+		fmt.Printf("[TelegramBot] 更新消息失败: %v\n", err)
+	}
+}
+
+// parseCommand 解析命令及参数，群聊中的 /search@机器人名 只响应发给自己的命令
+func (b *Bot) parseCommand(text string) (string, string) {
+	if !strings.HasPrefix(text, "/") {
+		return "", text
+	}
+	command, args, _ := strings.Cut(text[1:], " ")
+	if name, target, found := strings.Cut(command, "@"); found {
+		if !strings.EqualFold(target, b.username) {
+			return "-", ""
+		}
+		command = name
+	}
+	return strings.ToLower(command), args
+}
+
+// handleCallback 处理翻页和筛选按钮
+func (b *Bot) handleCallback(ctx context.Context, query *CallbackQuery) {
+	if query.Message == nil || !b.isAllowed(query.Message.Chat.ID) {
+		b.api.AnswerCallbackQuery(ctx, query.ID, "")
+		return
+	}
+
+	action, sessionID, arg, ok := parseCallback(query.Data)
+	if !ok {
+		b.api.AnswerCallbackQuery(ctx, query.ID, "")
+		return
+	}
+	sess, ok := b.sessions.get(sessionID)
+	if !ok {
+		b.api.AnswerCallbackQuery(ctx, query.ID, "结果已过期，请重新搜索")
+		return
+	}
+
+	page := 0
+	switch action {
+	case callbackPage:
+		page, _ = strconv.Atoi(arg)
+	case callbackFilter:
+		b.sessions.setFilter(sess, arg)
+	default:
+		b.api.AnswerCallbackQuery(ctx, query.ID, "")
+		return
+	}
+
+	b.sessions.mu.Lock()
+	filter := sess.filter
+	b.sessions.mu.Unlock()
+	text, markup := renderPage(sess, filter, page, b.pageSize)
+	if err := b.api.EditMessageText(ctx, query.Message.Chat.ID, query.Message.MessageID, text, markup); err != nil {
+		fmt.Printf("[TelegramBot] 更新消息失败: %v\n", err)
+	}
+	b.api.AnswerCallbackQuery(ctx, query.ID, "")
+}
+
+// handleInlineQuery 处理内联查询，按offset分页返回
+func (b *Bot) handleInlineQuery(ctx context.Context, query *InlineQuery) {
+	keyword := strings.TrimSpace(query.Query)
+	if keyword == "" || !b.isAllowed(query.From.ID) {
+		b.api.AnswerInlineQuery(ctx, query.ID, nil, "", inlineCacheTime)
+		return
+	}
+
+	offset, _ := strconv.Atoi(query.Offset)
+	// 翻页请求不计入限流
+	if offset == 0 {
+		if ok, _ := b.limiter.Allow("inline:" + strconv.FormatInt(query.From.ID, 10)); !ok {
+			b.api.AnswerInlineQuery(ctx, query.ID, nil, "", 5)
+			return
+		}
+	}
+
+	merged, err := bot.SearchWithTimeout(ctx, b.searchService, keyword, nil, searchTimeout)
+	if err != nil {
+		b.api.AnswerInlineQuery(ctx, query.ID, nil, "", 5)
+		return
+	}
+	entries := bot.Flatten(merged, "")
+	if offset > len(entries) {
+		offset = len(entries)
+	}
+	end := offset + inlinePageSize
+	nextOffset := strconv.Itoa(end)
+	if end >= len(entries) {
+		end = len(entries)
+		nextOffset = ""
+	}
+	if err := b.api.AnswerInlineQuery(ctx, query.ID, inlineResults(entries[offset:end], offset), nextOffset, inlineCacheTime); err != nil {
+		fmt.Printf("[TelegramBot] 响应内联查询失败: %v\n", err)
+	}
+}
+
+// helpText 帮助信息
+func helpText(username string) string {
+	var b strings.Builder
+	b.WriteString("<b>PanSou 网盘搜索</b>\n\n")
+	b.WriteString("/search 关键词 — 搜索网盘资源（私聊中可直接发送关键词）\n")
+	if username != "" {
+		fmt.Fprintf(&b, "在任意聊天中输入 <code>@%s 关键词</code> 使用内联搜索\n", html.EscapeString(username))
+	}
+	b.WriteString("\n结果下方的按钮可以翻页和按网盘类型筛选")
+	return b.String()
+}
@@ -0,0 +1,249 @@
+package telegram
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pansou/bot"
+	"pansou/model"
+)
+
+const (
+	// 搜索会话（用于翻页和筛选）的有效期
+	sessionTTL = 30 * time.Minute
+	// 最多保留的搜索会话数
+	maxSessions = 1000
+	// 每行的筛选按钮数
+	filterButtonsPerRow = 3
+	// 链接标题最多显示的字符数
+	maxNoteRunes = 60
+)
+
+// session 一次搜索的结果，翻页和筛选时直接使用，不重新搜索
+type session struct {
+	id        string
+	keyword   string
+	merged    model.MergedLinks
+	filter    string // 当前筛选的网盘类型，空表示全部
+	createdAt time.Time
+}
+
+// sessionStore 搜索会话存储
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+	order    []string
+}
+
+func newSessionStore() *sessionStore {
+	return &sessionStore{sessions: make(map[string]*session)}
+}
+
+// create 保存搜索结果，超出上限时删除最早的会话
+func (s *sessionStore) create(keyword string, merged model.MergedLinks) *session {
+	idBytes := make([]byte, 4)
+	rand.Read(idBytes)
+	sess := &session{id: hex.EncodeToString(idBytes), keyword: keyword, merged: merged, createdAt: time.Now()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[sess.id] = sess
+	s.order = append(s.order, sess.id)
+	for len(s.order) > maxSessions {
+		delete(s.sessions, s.order[0])
+		s.order = s.order[1:]
+	}
+	return sess
+}
+
+// get 获取未过期的会话
+func (s *sessionStore) get(id string) (*session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if !ok || time.Since(sess.createdAt) > sessionTTL {
+		return nil, false
+	}
+	return sess, true
+}
+
+// setFilter 修改会话的筛选类型
+func (s *sessionStore) setFilter(sess *session, filter string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess.filter = filter
+}
+
+// 按钮回调数据：p:会话ID:页码 翻页，f:会话ID:类型 筛选（类型为空表示全部）
+const (
+	callbackPage   = "p"
+	callbackFilter = "f"
+	callbackNoop   = "noop"
+)
+
+// parseCallback 解析按钮回调数据
+func parseCallback(data string) (action, sessionID, arg string, ok bool) {
+	parts := strings.SplitN(data, ":", 3)
+	if len(parts) != 3 {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], parts[2], true
+}
+
+// renderPage 渲染某一页结果及翻页、筛选按钮，page超出范围时取最近的有效页
+func renderPage(sess *session, filter string, page, pageSize int) (string, *InlineKeyboardMarkup) {
+	entries := bot.Flatten(sess.merged, filter)
+	pages := (len(entries) + pageSize - 1) / pageSize
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔍 <b>%s</b>  共 %d 条", html.EscapeString(sess.keyword), len(entries))
+	if filter != "" {
+		fmt.Fprintf(&b, " · %s", html.EscapeString(model.CloudTypeName(filter)))
+	}
+	b.WriteString("\n")
+	if len(entries) == 0 {
+		b.WriteString("\n没有找到相关资源")
+		return b.String(), filterKeyboard(sess, filter, nil)
+	}
+
+	start := page * pageSize
+	end := start + pageSize
+	if end > len(entries) {
+		end = len(entries)
+	}
+	lastType := ""
+	for i, entry := range entries[start:end] {
+		if entry.CloudType != lastType {
+			fmt.Fprintf(&b, "\n<b>%s</b>\n", html.EscapeString(model.CloudTypeName(entry.CloudType)))
+			lastType = entry.CloudType
+		}
+		writeEntry(&b, start+i+1, entry)
+	}
+
+	var nav []InlineKeyboardButton
+	if pages > 1 {
+		prev, next := callbackNoop, callbackNoop
+		if page > 0 {
+			prev = pageCallback(sess.id, page-1)
+		}
+		if page < pages-1 {
+			next = pageCallback(sess.id, page+1)
+		}
+		nav = []InlineKeyboardButton{
+			{Text: "« 上一页", CallbackData: prev},
+			{Text: fmt.Sprintf("%d/%d", page+1, pages), CallbackData: callbackNoop},
+			{Text: "下一页 »", CallbackData: next},
+		}
+	}
+	return b.String(), filterKeyboard(sess, filter, nav)
+}
+
+// writeEntry 输出一条链接：序号（为0时不输出）、标题、链接和提取码
+func writeEntry(b *strings.Builder, index int, entry bot.Entry) {
+	note := bot.Truncate(entry.Link.Note, maxNoteRunes)
+	if note == "" {
+		note = "未命名资源"
+	}
+	if index > 0 {
+		fmt.Fprintf(b, "%d. ", index)
+	}
+	fmt.Fprintf(b, "%s\n", html.EscapeString(note))
+	// 磁力和ed2k链接较长且不能点击，用code方便复制
+	if entry.CloudType == "magnet" || entry.CloudType == "ed2k" {
+		fmt.Fprintf(b, "<code>%s</code>", html.EscapeString(entry.Link.URL))
+	} else {
+		b.WriteString(html.EscapeString(entry.Link.URL))
+	}
+	if entry.Link.Password != "" {
+		fmt.Fprintf(b, "  提取码: <code>%s</code>", html.EscapeString(entry.Link.Password))
+	}
+	b.WriteString("\n")
+}
+
+// filterKeyboard 网盘类型筛选按钮（附带数量）和翻页按钮
+func filterKeyboard(sess *session, filter string, nav []InlineKeyboardButton) *InlineKeyboardMarkup {
+	types := bot.OrderedTypes(sess.merged)
+	rows := make([][]InlineKeyboardButton, 0)
+	if len(types) > 1 {
+		row := make([]InlineKeyboardButton, 0, filterButtonsPerRow)
+		buttons := []InlineKeyboardButton{{Text: markSelected("全部", filter == ""), CallbackData: filterCallback(sess.id, "")}}
+		for _, t := range types {
+			label := fmt.Sprintf("%s %d", model.CloudTypeName(t), len(sess.merged[t]))
+			buttons = append(buttons, InlineKeyboardButton{Text: markSelected(label, filter == t), CallbackData: filterCallback(sess.id, t)})
+		}
+		for _, button := range buttons {
+			row = append(row, button)
+			if len(row) == filterButtonsPerRow {
+				rows = append(rows, row)
+				row = make([]InlineKeyboardButton, 0, filterButtonsPerRow)
+			}
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return &InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// markSelected 标记当前选中的按钮
+func markSelected(label string, selected bool) string {
+	if selected {
+		return "✅ " + label
+	}
+	return label
+}
+
+func pageCallback(sessionID string, page int) string {
+	return callbackPage + ":" + sessionID + ":" + strconv.Itoa(page)
+}
+
+func filterCallback(sessionID, cloudType string) string {
+	return callbackFilter + ":" + sessionID + ":" + cloudType
+}
+
+// inlineResults 内联查询的一页结果，每条链接一项
+func inlineResults(entries []bot.Entry, offset int) []InlineQueryResultArticle {
+	results := make([]InlineQueryResultArticle, 0, len(entries))
+	for i, entry := range entries {
+		var b strings.Builder
+		writeEntry(&b, 0, entry)
+		title := bot.Truncate(entry.Link.Note, maxNoteRunes)
+		if title == "" {
+			title = "未命名资源"
+		}
+		description := entry.Link.URL
+		if entry.Link.Password != "" {
+			description += "  提取码: " + entry.Link.Password
+		}
+		results = append(results, InlineQueryResultArticle{
+			Type:        "article",
+			ID:          strconv.Itoa(offset + i),
+			Title:       "[" + model.CloudTypeName(entry.CloudType) + "] " + title,
+			Description: description,
+			InputMessageContent: inputMessageContent{
+				MessageText:    b.String(),
+				ParseMode:      "HTML",
+				DisablePreview: true,
+			},
+		})
+	}
+	return results
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"pansou/config"
+	"pansou/mcp"
+	"pansou/util"
+	"pansou/util/cache"
+)
+
+// runCommand 执行命令行子命令，返回false表示不是子命令（继续启动服务）
+func runCommand(args []string) bool {
+	switch args[0] {
+	case "hash-password":
+		os.Exit(hashPasswordCommand(args[1:]))
+	case "migrate-cache":
+		os.Exit(migrateCacheCommand(args[1:]))
+	case "mcp":
+		os.Exit(mcpCommand(args[1:]))
+	}
+	return false
+}
+
+// hashPasswordCommand 生成 AUTH_USERS 使用的密码哈希
+// 用法：pansou hash-password [-algo bcrypt|argon2id] [密码]，未提供密码时从标准输入读取
+func hashPasswordCommand(args []string) int {
+	fs := flag.NewFlagSet("hash-password", flag.ContinueOnError)
+	algo := fs.String("algo", util.PasswordAlgoBcrypt, "哈希算法：bcrypt 或 argon2id")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	password := fs.Arg(0)
+	if password == "" {
+		fmt.Fprint(os.Stderr, "请输入密码: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintf(os.Stderr, "读取密码失败: %v\n", err)
+			return 1
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	hash, err := util.HashPassword(password, *algo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "生成哈希失败: %v\n", err)
+		return 1
+	}
+	fmt.Println(hash)
+	return 0
+}
+
+// migrateCacheCommand 将每个键一个文件的磁盘缓存导入日志存储引擎
+// 用法：pansou migrate-cache [-path 缓存目录] [-remove]，迁移后设置 CACHE_DISK_ENGINE=log 启用
+func migrateCacheCommand(args []string) int {
+	config.Init()
+
+	fs := flag.NewFlagSet("migrate-cache", flag.ContinueOnError)
+	path := fs.String("path", config.AppConfig.CachePath, "缓存目录")
+	remove := fs.Bool("remove", false, "导入成功后删除旧的缓存文件")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	start := time.Now()
+	result, err := cache.MigrateFileCacheToLog(*path, config.AppConfig.CacheMaxSizeMB, *remove)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "迁移失败: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("迁移完成: 导入 %d 项 (%.1f MB)，跳过过期 %d 项，失败 %d 项，耗时 %v\n",
+		result.Imported, float64(result.Bytes)/1024/1024, result.Expired, result.Failed, time.Since(start).Round(time.Millisecond))
+	fmt.Println("请设置 CACHE_DISK_ENGINE=log 后重启服务")
+	return 0
+}
+
+// mcpCommand 通过stdio提供MCP服务，供MCP客户端以子进程方式启动
+// 用法：pansou mcp，标准输出只用于协议消息，日志输出到标准错误；本地进程不经过认证
+func mcpCommand(args []string) int {
+	fs := flag.NewFlagSet("mcp", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	// 插件日志写入标准输出，重定向到标准错误以免混入协议消息
+	out := os.Stdout
+	os.Stdout = os.Stderr
+	log.SetOutput(io.Discard)
+
+	initApp()
+	searchService, pluginCount := newSearchService()
+	fmt.Fprintf(os.Stderr, "PanSou MCP 服务已启动（stdio），插件数: %d，频道数: %d\n",
+		pluginCount, len(config.AppConfig.DefaultChannels))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- mcp.NewServer(searchService).ServeStdio(ctx, os.Stdin, out)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+	}
+	flushCaches()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取标准输入失败: %v\n", err)
+		return 1
+	}
+	return 0
+}
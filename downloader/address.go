@@ -0,0 +1,87 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress 用户保存的下载目标不能指向本机或内网地址
+var ErrPrivateAddress = errors.New("下载目标不能指向本机或内网地址，请在配置文件中定义内网下载工具")
+
+// 解析下载目标主机名的超时时间
+const resolveTimeout = 5 * time.Second
+
+// isPublicIP 是否为公网地址（排除回环、私有、链路本地、组播和未指定地址）
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// checkPublicHost 解析主机名，任一地址不是公网地址时返回ErrPrivateAddress
+func checkPublicHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !isPublicIP(ip) {
+			return ErrPrivateAddress
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("解析下载目标地址失败: %w", err)
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// publicOnlyControl 连接前校验解析后的地址，防止域名在保存后重新解析到内网地址
+func publicOnlyControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+var (
+	publicOnlyClient     *http.Client
+	publicOnlyClientOnce sync.Once
+)
+
+// getPublicOnlyHTTPClient 只能连接公网地址的HTTP客户端（不使用代理，否则无法校验最终地址）
+func getPublicOnlyHTTPClient() *http.Client {
+	publicOnlyClientOnce.Do(func() {
+		dialer := &net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   publicOnlyControl,
+		}
+		publicOnlyClient = &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				MaxIdleConnsPerHost: 2,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+		}
+	})
+	return publicOnlyClient
+}
@@ -0,0 +1,88 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"pansou/util/json"
+)
+
+// aria2请求ID
+var aria2RequestID int64
+
+// aria2Client aria2 JSON-RPC客户端（地址如 http://127.0.0.1:6800/jsonrpc）
+type aria2Client struct {
+	target Target
+	http   *http.Client
+}
+
+// Add 通过 aria2.addUri 添加任务，返回GID
+func (c *aria2Client) Add(ctx context.Context, task Task) (string, error) {
+	switch task.LinkType() {
+	case "magnet", "http":
+	default:
+		return "", ErrUnsupportedLink
+	}
+
+	options := map[string]string{}
+	if dir := c.target.option("dir"); dir != "" {
+		options["dir"] = dir
+	}
+	if c.target.optionBool("paused") {
+		options["pause"] = "true"
+	}
+	params := []interface{}{[]string{task.URL}, options}
+	if c.target.Password != "" {
+		params = append([]interface{}{"token:" + c.target.Password}, params...)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      strconv.FormatInt(atomic.AddInt64(&aria2RequestID, 1), 10),
+		"method":  "aria2.addUri",
+		"params":  params,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.target.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	data, err := readBody(resp)
+	if err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return "", statusError(resp)
+		}
+		return "", fmt.Errorf("解析aria2响应失败: %w", err)
+	}
+	if result.Error != nil {
+		return "", fmt.Errorf("aria2错误(%d): %s", result.Error.Code, result.Error.Message)
+	}
+	if result.Result == "" {
+		return "", fmt.Errorf("aria2未返回任务GID")
+	}
+	return result.Result, nil
+}
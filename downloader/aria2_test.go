@@ -0,0 +1,84 @@
+package downloader_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pansou/downloader"
+)
+
+// fakeAria2 aria2 JSON-RPC服务，记录收到的请求参数
+func fakeAria2(t *testing.T, params *[]interface{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     string        `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "aria2.addUri" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		*params = req.Params
+		if req.Params[0] != "token:secret" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"jsonrpc": "2.0", "id": req.ID,
+				"error": map[string]interface{}{"code": 1, "message": "Unauthorized"},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": "2089b05ecca3d829"})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAria2Add(t *testing.T) {
+	var params []interface{}
+	srv := fakeAria2(t, &params)
+	client, err := downloader.NewClient(downloader.Target{
+		Name:     "aria",
+		Type:     downloader.TypeAria2,
+		URL:      srv.URL + "/jsonrpc",
+		Password: "secret",
+		Options:  map[string]string{"dir": "/downloads", "paused": "true"},
+	}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gid, err := client.Add(context.Background(), downloader.Task{URL: "magnet:?xt=urn:btih:ABCDEF"})
+	if err != nil || gid != "2089b05ecca3d829" {
+		t.Fatalf("Add = %q, %v", gid, err)
+	}
+	uris, _ := params[1].([]interface{})
+	options, _ := params[2].(map[string]interface{})
+	if len(uris) != 1 || uris[0] != "magnet:?xt=urn:btih:ABCDEF" {
+		t.Fatalf("uris = %v", params[1])
+	}
+	if options["dir"] != "/downloads" || options["pause"] != "true" {
+		t.Fatalf("options = %v", options)
+	}
+}
+
+func TestAria2Errors(t *testing.T) {
+	var params []interface{}
+	srv := fakeAria2(t, &params)
+	client, _ := downloader.NewClient(downloader.Target{
+		Name:     "aria",
+		Type:     downloader.TypeAria2,
+		URL:      srv.URL + "/jsonrpc",
+		Password: "wrong",
+	}, srv.Client())
+
+	if _, err := client.Add(context.Background(), downloader.Task{URL: "magnet:?xt=urn:btih:ABCDEF"}); err == nil {
+		t.Fatal("expected RPC error for wrong token")
+	}
+	if _, err := client.Add(context.Background(), downloader.Task{URL: "ed2k://|file|a|1|b|/"}); err != downloader.ErrUnsupportedLink {
+		t.Fatalf("err = %v, want ErrUnsupportedLink", err)
+	}
+}
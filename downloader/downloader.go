@@ -0,0 +1,187 @@
+// Package downloader 将磁力、ed2k等链接推送到下载工具（aria2、qBittorrent、Transmission或自定义webhook）
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 支持的下载工具类型
+const (
+	TypeAria2        = "aria2"
+	TypeQBittorrent  = "qbittorrent"
+	TypeTransmission = "transmission"
+	TypeWebhook      = "webhook"
+)
+
+// 发送请求的超时时间
+const requestTimeout = 15 * time.Second
+
+var (
+	// ErrUnsupportedLink 下载工具不支持该类型的链接
+	ErrUnsupportedLink = errors.New("下载工具不支持该类型的链接")
+	// ErrTargetNotFound 下载目标不存在
+	ErrTargetNotFound = errors.New("下载目标不存在")
+
+	targetNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+)
+
+// Target 下载目标
+// Options 支持 dir（保存目录）、category（qBittorrent分类或Transmission标签）、paused（添加后暂停）
+type Target struct {
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	URL      string            `json:"url"`
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"` // aria2为RPC密钥，webhook为Bearer令牌
+	Options  map[string]string `json:"options,omitempty"`
+	Global   bool              `json:"-"` // 由配置文件定义，所有用户可用
+}
+
+// TargetInfo 对外展示的下载目标（不含密码）
+type TargetInfo struct {
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`
+	URL         string            `json:"url"`
+	Username    string            `json:"username,omitempty"`
+	HasPassword bool              `json:"has_password"`
+	Options     map[string]string `json:"options,omitempty"`
+	Global      bool              `json:"global"`
+}
+
+// Info 去掉密码的下载目标信息
+func (t *Target) Info() TargetInfo {
+	return TargetInfo{
+		Name:        t.Name,
+		Type:        t.Type,
+		URL:         t.URL,
+		Username:    t.Username,
+		HasPassword: t.Password != "",
+		Options:     t.Options,
+		Global:      t.Global,
+	}
+}
+
+// Validate 校验下载目标，地址中的用户名和密码会移到对应字段
+func (t *Target) Validate() error {
+	if !targetNameRegex.MatchString(t.Name) {
+		return fmt.Errorf("名称只能包含字母、数字、下划线和连字符，长度1-32")
+	}
+	t.Type = strings.ToLower(strings.TrimSpace(t.Type))
+	switch t.Type {
+	case TypeAria2, TypeQBittorrent, TypeTransmission, TypeWebhook:
+	default:
+		return fmt.Errorf("不支持的下载工具类型: %s", t.Type)
+	}
+
+	u, err := url.Parse(strings.TrimSpace(t.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("无效的地址: %s", t.URL)
+	}
+	if u.User != nil {
+		if t.Username == "" {
+			t.Username = u.User.Username()
+		}
+		if password, ok := u.User.Password(); ok && t.Password == "" {
+			t.Password = password
+		}
+		u.User = nil
+	}
+	t.URL = u.String()
+	return nil
+}
+
+// Task 推送的下载任务
+type Task struct {
+	URL      string `json:"url"`
+	Password string `json:"password,omitempty"`
+	Title    string `json:"title,omitempty"`
+	User     string `json:"user,omitempty"`
+}
+
+// LinkType 任务链接的类型：magnet、ed2k、http或other
+func (t Task) LinkType() string {
+	lower := strings.ToLower(t.URL)
+	switch {
+	case strings.HasPrefix(lower, "magnet:"):
+		return "magnet"
+	case strings.HasPrefix(lower, "ed2k://"):
+		return "ed2k"
+	case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"):
+		return "http"
+	default:
+		return "other"
+	}
+}
+
+// Client 下载工具客户端
+type Client interface {
+	// Add 添加下载任务，返回下载工具中的任务标识（如aria2的GID、种子的info hash）
+	Add(ctx context.Context, task Task) (string, error)
+}
+
+// NewClient 按下载目标类型创建客户端
+// httpClient为nil时使用默认客户端：配置文件中的全局目标可以连接任意地址，用户保存的目标只能连接公网地址
+func NewClient(target Target, httpClient *http.Client) (Client, error) {
+	if httpClient == nil {
+		if target.Global {
+			httpClient = &http.Client{Timeout: requestTimeout}
+		} else {
+			httpClient = getPublicOnlyHTTPClient()
+		}
+	}
+	switch target.Type {
+	case TypeAria2:
+		return &aria2Client{target: target, http: httpClient}, nil
+	case TypeQBittorrent:
+		return &qbittorrentClient{target: target, http: httpClient}, nil
+	case TypeTransmission:
+		return &transmissionClient{target: target, http: httpClient}, nil
+	case TypeWebhook:
+		return &webhookClient{target: target, http: httpClient}, nil
+	default:
+		return nil, fmt.Errorf("不支持的下载工具类型: %s", target.Type)
+	}
+}
+
+// option 读取下载目标选项
+func (t *Target) option(name string) string {
+	return strings.TrimSpace(t.Options[name])
+}
+
+// optionBool 读取布尔类型的下载目标选项
+func (t *Target) optionBool(name string) bool {
+	value := strings.ToLower(t.option(name))
+	return value == "true" || value == "1" || value == "yes"
+}
+
+// infoHash 磁力链接中的info hash（小写），不是磁力链接时返回空
+func infoHash(link string) string {
+	lower := strings.ToLower(link)
+	idx := strings.Index(lower, "urn:btih:")
+	if !strings.HasPrefix(lower, "magnet:") || idx < 0 {
+		return ""
+	}
+	hash := lower[idx+len("urn:btih:"):]
+	if end := strings.IndexAny(hash, "&"); end >= 0 {
+		hash = hash[:end]
+	}
+	return hash
+}
+
+// readBody 读取响应内容（最多1MB）
+func readBody(resp *http.Response) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// statusError 非预期的HTTP状态码
+func statusError(resp *http.Response) error {
+	return fmt.Errorf("下载工具返回状态码 %d", resp.StatusCode)
+}
@@ -0,0 +1,65 @@
+package downloader_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pansou/downloader"
+)
+
+func TestUserTargetCannotReachPrivateAddress(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer srv.Close()
+
+	// 用户保存的目标使用只能连接公网地址的默认客户端
+	client, err := downloader.NewClient(downloader.Target{
+		Name: "hook",
+		Type: downloader.TypeWebhook,
+		URL:  srv.URL,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Add(context.Background(), downloader.Task{URL: "magnet:?xt=urn:btih:ABCDEF"}); !errors.Is(err, downloader.ErrPrivateAddress) {
+		t.Fatalf("err = %v, want ErrPrivateAddress", err)
+	}
+	if hits != 0 {
+		t.Fatal("request reached the loopback server")
+	}
+
+	// 配置文件中的全局目标可以连接内网地址
+	client, _ = downloader.NewClient(downloader.Target{
+		Name:   "hook",
+		Type:   downloader.TypeWebhook,
+		URL:    srv.URL,
+		Global: true,
+	}, nil)
+	if _, err := client.Add(context.Background(), downloader.Task{URL: "magnet:?xt=urn:btih:ABCDEF"}); err != nil {
+		t.Fatalf("global target: %v", err)
+	}
+	if hits != 1 {
+		t.Fatalf("hits = %d, want 1", hits)
+	}
+}
+
+func TestSaveRejectsPrivateTarget(t *testing.T) {
+	store := downloader.GetTargetStore()
+	for _, url := range []string{
+		"http://127.0.0.1:6800/jsonrpc",
+		"http://10.0.0.2:8080",
+		"http://169.254.169.254/latest",
+		"http://[::1]:9091/transmission/rpc",
+		"http://localhost:8080",
+	} {
+		_, err := store.Save("tester", downloader.Target{Name: "t", Type: downloader.TypeWebhook, URL: url})
+		if !errors.Is(err, downloader.ErrPrivateAddress) {
+			t.Errorf("Save(%s) err = %v, want ErrPrivateAddress", url, err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package downloader
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 推送状态
+const (
+	StatusPending = "pending" // 等待发送
+	StatusSending = "sending" // 发送中（含重试等待）
+	StatusSuccess = "success" // 下载工具已接受任务
+	StatusFailed  = "failed"  // 重试后仍失败
+)
+
+const (
+	// 最多保留的推送记录数，超出时删除最早的已结束记录
+	maxDeliveries = 1000
+	// 单个任务最多发送次数
+	maxDeliveryAttempts = 3
+	// 重试间隔（按次数递增）
+	deliveryRetryDelay = 2 * time.Second
+)
+
+// Delivery 推送记录
+type Delivery struct {
+	ID         string    `json:"id"`
+	User       string    `json:"user,omitempty"`
+	Target     string    `json:"target"`
+	TargetType string    `json:"target_type"`
+	URL        string    `json:"url"`
+	Title      string    `json:"title,omitempty"`
+	Status     string    `json:"status"`
+	RemoteID   string    `json:"remote_id,omitempty"` // 下载工具中的任务标识
+	Error      string    `json:"error,omitempty"`
+	Attempts   int       `json:"attempts"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Finished 是否已结束（成功或失败）
+func (d *Delivery) Finished() bool {
+	return d.Status == StatusSuccess || d.Status == StatusFailed
+}
+
+// Manager 推送管理：异步发送任务并记录状态（只保存在内存中）
+type Manager struct {
+	mu         sync.Mutex
+	deliveries map[string]*Delivery
+	order      []string // 按创建顺序排列的ID
+	httpClient *http.Client
+	retryDelay time.Duration
+}
+
+var (
+	globalManager     *Manager
+	globalManagerOnce sync.Once
+)
+
+// GetManager 获取全局推送管理
+func GetManager() *Manager {
+	globalManagerOnce.Do(func() {
+		globalManager = NewManager(nil)
+	})
+	return globalManager
+}
+
+// NewManager 创建推送管理，httpClient为nil时使用默认客户端
+func NewManager(httpClient *http.Client) *Manager {
+	return &Manager{
+		deliveries: make(map[string]*Delivery),
+		httpClient: httpClient,
+		retryDelay: deliveryRetryDelay,
+	}
+}
+
+// Submit 创建推送记录并在后台发送，返回记录副本
+func (m *Manager) Submit(target Target, task Task) (Delivery, error) {
+	client, err := NewClient(target, m.httpClient)
+	if err != nil {
+		return Delivery{}, err
+	}
+
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return Delivery{}, err
+	}
+	now := time.Now()
+	d := &Delivery{
+		ID:         hex.EncodeToString(idBytes),
+		User:       task.User,
+		Target:     target.Name,
+		TargetType: target.Type,
+		URL:        task.URL,
+		Title:      task.Title,
+		Status:     StatusPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	m.mu.Lock()
+	m.deliveries[d.ID] = d
+	m.order = append(m.order, d.ID)
+	m.pruneLocked()
+	snapshot := *d
+	m.mu.Unlock()
+
+	go m.deliver(d, client, task)
+	return snapshot, nil
+}
+
+// deliver 发送任务，失败时重试；下载工具不支持的链接不重试
+func (m *Manager) deliver(d *Delivery, client Client, task Task) {
+	for attempt := 1; attempt <= maxDeliveryAttempts; attempt++ {
+		m.update(d, func(d *Delivery) {
+			d.Status = StatusSending
+			d.Attempts = attempt
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		remoteID, err := client.Add(ctx, task)
+		cancel()
+
+		if err == nil {
+			m.update(d, func(d *Delivery) {
+				d.Status = StatusSuccess
+				d.RemoteID = remoteID
+				d.Error = ""
+			})
+			return
+		}
+
+		m.update(d, func(d *Delivery) { d.Error = err.Error() })
+		if errors.Is(err, ErrUnsupportedLink) || attempt == maxDeliveryAttempts {
+			m.update(d, func(d *Delivery) { d.Status = StatusFailed })
+			fmt.Printf("[Download] 推送到 %s 失败: %v\n", d.Target, err)
+			return
+		}
+		time.Sleep(m.retryDelay * time.Duration(attempt))
+	}
+}
+
+// update 修改推送记录
+func (m *Manager) update(d *Delivery, f func(d *Delivery)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f(d)
+	d.UpdatedAt = time.Now()
+}
+
+// pruneLocked 记录超过上限时删除最早的已结束记录（调用方需持有锁）
+func (m *Manager) pruneLocked() {
+	for i := 0; len(m.deliveries) > maxDeliveries && i < len(m.order); {
+		id := m.order[i]
+		if d, ok := m.deliveries[id]; ok && !d.Finished() {
+			i++
+			continue
+		}
+		delete(m.deliveries, id)
+		m.order = append(m.order[:i], m.order[i+1:]...)
+	}
+}
+
+// Get 获取用户的推送记录
+func (m *Manager) Get(user, id string) (Delivery, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.deliveries[id]
+	if !ok || d.User != user {
+		return Delivery{}, false
+	}
+	return *d, true
+}
+
+// List 用户最近的推送记录（新的在前）
+func (m *Manager) List(user string, limit int) []Delivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]Delivery, 0)
+	for i := len(m.order) - 1; i >= 0 && len(list) < limit; i-- {
+		if d := m.deliveries[m.order[i]]; d != nil && d.User == user {
+			list = append(list, *d)
+		}
+	}
+	return list
+}
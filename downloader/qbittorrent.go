@@ -0,0 +1,106 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// qbittorrentClient qBittorrent WebUI API客户端（地址为WebUI地址，如 http://127.0.0.1:8080）
+type qbittorrentClient struct {
+	target Target
+	http   *http.Client
+}
+
+// Add 登录后通过 /api/v2/torrents/add 添加任务，磁力链接返回info hash
+func (c *qbittorrentClient) Add(ctx context.Context, task Task) (string, error) {
+	switch task.LinkType() {
+	case "magnet", "http":
+	default:
+		return "", ErrUnsupportedLink
+	}
+
+	base := strings.TrimRight(c.target.URL, "/")
+	cookie, err := c.login(ctx, base)
+	if err != nil {
+		return "", err
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("urls", task.URL)
+	if dir := c.target.option("dir"); dir != "" {
+		form.WriteField("savepath", dir)
+	}
+	if category := c.target.option("category"); category != "" {
+		form.WriteField("category", category)
+	}
+	if c.target.optionBool("paused") {
+		// qBittorrent 5.0起改名为stopped
+		form.WriteField("paused", "true")
+		form.WriteField("stopped", "true")
+	}
+	form.Close()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", base+"/api/v2/torrents/add", &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Referer", base)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, _ := readBody(resp)
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError(resp)
+	}
+	if strings.TrimSpace(string(data)) == "Fails." {
+		return "", fmt.Errorf("qBittorrent拒绝添加任务")
+	}
+	return infoHash(task.URL), nil
+}
+
+// login 登录WebUI获取SID；未配置用户名时跳过（WebUI对本机或白名单免认证）
+func (c *qbittorrentClient) login(ctx context.Context, base string) (*http.Cookie, error) {
+	if c.target.Username == "" {
+		return nil, nil
+	}
+
+	form := url.Values{"username": {c.target.Username}, "password": {c.target.Password}}
+	req, err := http.NewRequestWithContext(ctx, "POST", base+"/api/v2/auth/login", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Referer", base)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, _ := readBody(resp)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("qBittorrent登录失败: 状态码 %d", resp.StatusCode)
+	}
+	if strings.TrimSpace(string(data)) != "Ok." {
+		return nil, fmt.Errorf("qBittorrent登录失败: 用户名或密码错误")
+	}
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "SID" {
+			return cookie, nil
+		}
+	}
+	return nil, fmt.Errorf("qBittorrent登录失败: 未返回SID")
+}
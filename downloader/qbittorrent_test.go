@@ -0,0 +1,84 @@
+package downloader_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pansou/downloader"
+)
+
+// fakeQBittorrent qBittorrent WebUI，登录后才能添加任务
+func fakeQBittorrent(t *testing.T, added *http.Request) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("username") != "admin" || r.PostForm.Get("password") != "pass" {
+			w.Write([]byte("Fails."))
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "SID", Value: "session"})
+		w.Write([]byte("Ok."))
+	})
+	mux.HandleFunc("/api/v2/torrents/add", func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("SID"); err != nil || cookie.Value != "session" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		*added = *r
+		w.Write([]byte("Ok."))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestQBittorrentAdd(t *testing.T) {
+	var added http.Request
+	srv := fakeQBittorrent(t, &added)
+	client, err := downloader.NewClient(downloader.Target{
+		Name:     "nas",
+		Type:     downloader.TypeQBittorrent,
+		URL:      srv.URL,
+		Username: "admin",
+		Password: "pass",
+		Options:  map[string]string{"dir": "/downloads", "category": "pansou"},
+	}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := client.Add(context.Background(), downloader.Task{URL: "magnet:?xt=urn:btih:ABCDEF&dn=test"})
+	if err != nil || hash != "abcdef" {
+		t.Fatalf("Add = %q, %v", hash, err)
+	}
+	form := added.MultipartForm.Value
+	if form["urls"][0] != "magnet:?xt=urn:btih:ABCDEF&dn=test" || form["savepath"][0] != "/downloads" || form["category"][0] != "pansou" {
+		t.Fatalf("form = %v", form)
+	}
+}
+
+func TestQBittorrentLoginFailure(t *testing.T) {
+	var added http.Request
+	srv := fakeQBittorrent(t, &added)
+	client, _ := downloader.NewClient(downloader.Target{
+		Name:     "nas",
+		Type:     downloader.TypeQBittorrent,
+		URL:      srv.URL,
+		Username: "admin",
+		Password: "wrong",
+	}, srv.Client())
+
+	if _, err := client.Add(context.Background(), downloader.Task{URL: "magnet:?xt=urn:btih:ABCDEF"}); err == nil {
+		t.Fatal("expected login error")
+	}
+	if added.MultipartForm != nil {
+		t.Fatal("task was added without a session")
+	}
+}
@@ -0,0 +1,218 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"pansou/config"
+	"pansou/util/json"
+)
+
+const (
+	// 用户下载目标存储文件名（位于缓存目录下）
+	targetFileName = "download_targets.json"
+	// 每个用户最多保存的下载目标数
+	maxTargetsPerUser = 20
+)
+
+// ErrTargetRequired 有多个可用目标时必须指定目标名称
+var ErrTargetRequired = errors.New("请指定下载目标")
+
+// TargetStore 下载目标存储：配置文件中的全局目标，以及各用户自己保存的目标
+// 未启用认证时用户为空字符串
+type TargetStore struct {
+	mu     sync.RWMutex
+	path   string
+	global map[string]*Target
+	byUser map[string]map[string]*Target // 用户 -> 名称 -> 目标
+}
+
+var (
+	globalTargetStore     *TargetStore
+	globalTargetStoreOnce sync.Once
+)
+
+// GetTargetStore 获取全局下载目标存储（首次调用时加载配置和缓存目录中的用户目标）
+func GetTargetStore() *TargetStore {
+	globalTargetStoreOnce.Do(func() {
+		dir := "./cache"
+		if config.AppConfig != nil && config.AppConfig.CachePath != "" {
+			dir = config.AppConfig.CachePath
+		}
+		globalTargetStore = &TargetStore{
+			path:   filepath.Join(dir, targetFileName),
+			global: make(map[string]*Target),
+			byUser: make(map[string]map[string]*Target),
+		}
+		if config.AppConfig != nil {
+			for _, c := range config.AppConfig.DownloadTargets {
+				target := &Target{Name: c.Name, Type: c.Type, URL: c.URL, Options: c.Options, Global: true}
+				if err := target.Validate(); err != nil {
+					fmt.Printf("[Download] 忽略下载目标 %s: %v\n", c.Name, err)
+					continue
+				}
+				globalTargetStore.global[target.Name] = target
+			}
+		}
+		globalTargetStore.load()
+	})
+	return globalTargetStore
+}
+
+// load 从文件加载用户下载目标
+func (s *TargetStore) load() {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return
+	}
+	var stored map[string][]*Target
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return
+	}
+	for user, targets := range stored {
+		s.byUser[user] = make(map[string]*Target, len(targets))
+		for _, t := range targets {
+			s.byUser[user][t.Name] = t
+		}
+	}
+}
+
+// saveLocked 将用户下载目标写入文件（调用方需持有写锁）
+func (s *TargetStore) saveLocked() error {
+	stored := make(map[string][]*Target, len(s.byUser))
+	for user, targets := range s.byUser {
+		if len(targets) == 0 {
+			continue
+		}
+		stored[user] = sortedTargets(targets)
+	}
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+// List 用户可用的下载目标（用户目标在前，按名称排序）
+func (s *TargetStore) List(user string) []TargetInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	infos := make([]TargetInfo, 0)
+	for _, t := range sortedTargets(s.byUser[user]) {
+		infos = append(infos, t.Info())
+	}
+	for _, t := range sortedTargets(s.global) {
+		infos = append(infos, t.Info())
+	}
+	return infos
+}
+
+// Get 获取用户可用的下载目标，名称为空且只有一个可用目标时使用该目标
+func (s *TargetStore) Get(user, name string) (Target, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if name == "" {
+		if len(s.byUser[user])+len(s.global) != 1 {
+			return Target{}, ErrTargetRequired
+		}
+		for _, t := range s.byUser[user] {
+			return *t, nil
+		}
+		for _, t := range s.global {
+			return *t, nil
+		}
+	}
+	if t, ok := s.byUser[user][name]; ok {
+		return *t, nil
+	}
+	if t, ok := s.global[name]; ok {
+		return *t, nil
+	}
+	return Target{}, ErrTargetNotFound
+}
+
+// Save 保存用户下载目标；更新已有目标时未传入密码则保留原密码
+// 用户保存的目标只能指向公网地址，内网下载工具需在配置文件中定义
+func (s *TargetStore) Save(user string, target Target) (TargetInfo, error) {
+	target.Global = false
+	if err := target.Validate(); err != nil {
+		return TargetInfo{}, err
+	}
+	u, _ := url.Parse(target.URL)
+	if err := checkPublicHost(context.Background(), u.Hostname()); err != nil {
+		return TargetInfo{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.global[target.Name]; ok {
+		return TargetInfo{}, fmt.Errorf("名称 %s 已被全局下载目标使用", target.Name)
+	}
+	targets := s.byUser[user]
+	if targets == nil {
+		targets = make(map[string]*Target)
+		s.byUser[user] = targets
+	}
+	existing, exists := targets[target.Name]
+	if !exists && len(targets) >= maxTargetsPerUser {
+		return TargetInfo{}, fmt.Errorf("每个用户最多保存 %d 个下载目标", maxTargetsPerUser)
+	}
+	if exists && target.Password == "" {
+		target.Password = existing.Password
+	}
+
+	targets[target.Name] = &target
+	if err := s.saveLocked(); err != nil {
+		if exists {
+			targets[target.Name] = existing
+		} else {
+			delete(targets, target.Name)
+		}
+		return TargetInfo{}, err
+	}
+	return target.Info(), nil
+}
+
+// Delete 删除用户下载目标（全局目标不能删除）
+func (s *TargetStore) Delete(user, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.byUser[user][name]
+	if !ok {
+		return ErrTargetNotFound
+	}
+	delete(s.byUser[user], name)
+	if err := s.saveLocked(); err != nil {
+		s.byUser[user][name] = existing
+		return err
+	}
+	return nil
+}
+
+// sortedTargets 按名称排序
+func sortedTargets(targets map[string]*Target) []*Target {
+	list := make([]*Target, 0, len(targets))
+	for _, t := range targets {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
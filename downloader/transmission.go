@@ -0,0 +1,110 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"pansou/util/json"
+)
+
+// Transmission用于防CSRF的会话ID请求头
+const transmissionSessionHeader = "X-Transmission-Session-Id"
+
+// transmissionClient Transmission RPC客户端（地址如 http://127.0.0.1:9091/transmission/rpc）
+type transmissionClient struct {
+	target Target
+	http   *http.Client
+}
+
+// Add 通过 torrent-add 添加任务，返回info hash
+func (c *transmissionClient) Add(ctx context.Context, task Task) (string, error) {
+	switch task.LinkType() {
+	case "magnet", "http":
+	default:
+		return "", ErrUnsupportedLink
+	}
+
+	arguments := map[string]interface{}{"filename": task.URL}
+	if dir := c.target.option("dir"); dir != "" {
+		arguments["download-dir"] = dir
+	}
+	if c.target.optionBool("paused") {
+		arguments["paused"] = true
+	}
+	if category := c.target.option("category"); category != "" {
+		arguments["labels"] = []string{category}
+	}
+	body, err := json.Marshal(map[string]interface{}{"method": "torrent-add", "arguments": arguments})
+	if err != nil {
+		return "", err
+	}
+
+	// 首次请求返回409及会话ID，带上会话ID重试
+	sessionID := ""
+	for attempt := 0; attempt < 2; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.target.URL, bytes.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if sessionID != "" {
+			req.Header.Set(transmissionSessionHeader, sessionID)
+		}
+		if c.target.Username != "" {
+			req.SetBasicAuth(c.target.Username, c.target.Password)
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return "", err
+		}
+		data, _ := readBody(resp)
+		resp.Body.Close()
+
+		switch resp.StatusCode {
+		case http.StatusConflict:
+			sessionID = resp.Header.Get(transmissionSessionHeader)
+			if sessionID == "" {
+				return "", fmt.Errorf("Transmission未返回会话ID")
+			}
+			continue
+		case http.StatusUnauthorized:
+			return "", fmt.Errorf("Transmission认证失败: 用户名或密码错误")
+		case http.StatusOK:
+			return parseTransmissionResult(data)
+		default:
+			return "", statusError(resp)
+		}
+	}
+	return "", fmt.Errorf("Transmission会话ID无效")
+}
+
+// parseTransmissionResult 解析torrent-add的结果，已存在的种子同样视为成功
+func parseTransmissionResult(data []byte) (string, error) {
+	type torrent struct {
+		ID         int    `json:"id"`
+		HashString string `json:"hashString"`
+	}
+	var result struct {
+		Result    string `json:"result"`
+		Arguments struct {
+			Added     *torrent `json:"torrent-added"`
+			Duplicate *torrent `json:"torrent-duplicate"`
+		} `json:"arguments"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("解析Transmission响应失败: %w", err)
+	}
+	if result.Result != "success" {
+		return "", fmt.Errorf("Transmission错误: %s", result.Result)
+	}
+	for _, t := range []*torrent{result.Arguments.Added, result.Arguments.Duplicate} {
+		if t != nil {
+			return strings.ToLower(t.HashString), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,84 @@
+package downloader_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pansou/downloader"
+)
+
+// fakeTransmission Transmission RPC，没有会话ID的请求返回409
+func fakeTransmission(t *testing.T, arguments *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("X-Transmission-Session-Id") != "sid-1" {
+			w.Header().Set("X-Transmission-Session-Id", "sid-1")
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		var req struct {
+			Method    string                 `json:"method"`
+			Arguments map[string]interface{} `json:"arguments"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "torrent-add" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		*arguments = req.Arguments
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": "success",
+			"arguments": map[string]interface{}{
+				"torrent-duplicate": map[string]interface{}{"id": 1, "hashString": "ABCDEF"},
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTransmissionAdd(t *testing.T) {
+	var arguments map[string]interface{}
+	srv := fakeTransmission(t, &arguments)
+	client, err := downloader.NewClient(downloader.Target{
+		Name:     "tr",
+		Type:     downloader.TypeTransmission,
+		URL:      srv.URL + "/transmission/rpc",
+		Username: "admin",
+		Password: "pass",
+		Options:  map[string]string{"dir": "/downloads", "paused": "yes"},
+	}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := client.Add(context.Background(), downloader.Task{URL: "magnet:?xt=urn:btih:ABCDEF"})
+	if err != nil || hash != "abcdef" {
+		t.Fatalf("Add = %q, %v", hash, err)
+	}
+	if arguments["filename"] != "magnet:?xt=urn:btih:ABCDEF" || arguments["download-dir"] != "/downloads" || arguments["paused"] != true {
+		t.Fatalf("arguments = %v", arguments)
+	}
+}
+
+func TestTransmissionUnauthorized(t *testing.T) {
+	var arguments map[string]interface{}
+	srv := fakeTransmission(t, &arguments)
+	client, _ := downloader.NewClient(downloader.Target{
+		Name:     "tr",
+		Type:     downloader.TypeTransmission,
+		URL:      srv.URL + "/transmission/rpc",
+		Username: "admin",
+		Password: "wrong",
+	}, srv.Client())
+
+	if _, err := client.Add(context.Background(), downloader.Task{URL: "magnet:?xt=urn:btih:ABCDEF"}); err == nil {
+		t.Fatal("expected authentication error")
+	}
+}
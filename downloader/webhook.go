@@ -0,0 +1,61 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"time"
+
+	"pansou/util/json"
+)
+
+// webhookClient 将任务以JSON POST到自定义地址，支持任意类型的链接
+type webhookClient struct {
+	target Target
+	http   *http.Client
+}
+
+// webhookPayload webhook请求内容
+type webhookPayload struct {
+	Task
+	Type    string            `json:"type"`
+	Target  string            `json:"target"`
+	Options map[string]string `json:"options,omitempty"`
+	Time    time.Time         `json:"time"`
+}
+
+// Add 发送任务，响应状态码为2xx时视为成功
+func (c *webhookClient) Add(ctx context.Context, task Task) (string, error) {
+	body, err := json.Marshal(webhookPayload{
+		Task:    task,
+		Type:    task.LinkType(),
+		Target:  c.target.Name,
+		Options: c.target.Options,
+		Time:    time.Now(),
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.target.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "PanSou-Downloader")
+	if c.target.Username != "" {
+		req.SetBasicAuth(c.target.Username, c.target.Password)
+	} else if c.target.Password != "" {
+		req.Header.Set("Authorization", "Bearer "+c.target.Password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	readBody(resp)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", statusError(resp)
+	}
+	return "", nil
+}
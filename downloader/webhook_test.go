@@ -0,0 +1,116 @@
+package downloader_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pansou/downloader"
+)
+
+// webhookRequest fake webhook收到的请求
+type webhookRequest struct {
+	Authorization string
+	ContentType   string
+	Payload       map[string]interface{}
+}
+
+// fakeWebhook 记录收到的请求，按status返回状态码
+func fakeWebhook(t *testing.T, status int, received *webhookRequest) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		received.Authorization = r.Header.Get("Authorization")
+		received.ContentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&received.Payload); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWebhookAdd(t *testing.T) {
+	var received webhookRequest
+	srv := fakeWebhook(t, http.StatusNoContent, &received)
+	client, err := downloader.NewClient(downloader.Target{
+		Name:     "hook",
+		Type:     downloader.TypeWebhook,
+		URL:      srv.URL + "/push",
+		Password: "token-1",
+		Options:  map[string]string{"category": "movies"},
+	}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := client.Add(context.Background(), downloader.Task{
+		URL:      "ed2k://|file|a.mkv|1|ABC|/",
+		Password: "1234",
+		Title:    "标题",
+	})
+	if err != nil || id != "" {
+		t.Fatalf("Add = %q, %v", id, err)
+	}
+	if received.Authorization != "Bearer token-1" || received.ContentType != "application/json" {
+		t.Fatalf("headers = %q, %q", received.Authorization, received.ContentType)
+	}
+	payload := received.Payload
+	if payload["url"] != "ed2k://|file|a.mkv|1|ABC|/" || payload["password"] != "1234" || payload["title"] != "标题" {
+		t.Fatalf("payload = %v", payload)
+	}
+	if payload["type"] != "ed2k" || payload["target"] != "hook" {
+		t.Fatalf("payload = %v", payload)
+	}
+	if options, _ := payload["options"].(map[string]interface{}); options["category"] != "movies" {
+		t.Fatalf("options = %v", payload["options"])
+	}
+}
+
+func TestWebhookBasicAuth(t *testing.T) {
+	var received webhookRequest
+	srv := fakeWebhook(t, http.StatusOK, &received)
+	client, _ := downloader.NewClient(downloader.Target{
+		Name:     "hook",
+		Type:     downloader.TypeWebhook,
+		URL:      srv.URL,
+		Username: "admin",
+		Password: "pass",
+	}, srv.Client())
+
+	if _, err := client.Add(context.Background(), downloader.Task{URL: "magnet:?xt=urn:btih:ABCDEF"}); err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("admin", "pass")
+	if received.Authorization != req.Header.Get("Authorization") {
+		t.Fatalf("Authorization = %q", received.Authorization)
+	}
+	if received.Payload["type"] != "magnet" {
+		t.Fatalf("payload = %v", received.Payload)
+	}
+}
+
+func TestWebhookErrorStatus(t *testing.T) {
+	var received webhookRequest
+	srv := fakeWebhook(t, http.StatusInternalServerError, &received)
+	client, _ := downloader.NewClient(downloader.Target{
+		Name: "hook",
+		Type: downloader.TypeWebhook,
+		URL:  srv.URL,
+	}, srv.Client())
+
+	if _, err := client.Add(context.Background(), downloader.Task{URL: "https://example.com/a.torrent"}); err == nil {
+		t.Fatal("expected error for non-2xx status")
+	}
+	if received.Authorization != "" {
+		t.Fatalf("Authorization = %q", received.Authorization)
+	}
+}
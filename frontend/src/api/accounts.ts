@@ -0,0 +1,91 @@
+import axios from 'axios'
+import { handleUnauthorized } from './index'
+import type {
+  AccountInfo,
+  AccountPluginSummary,
+  ResolveAccountResponse,
+  AccountActionResponse
+} from '@/types/accounts'
+
+// 创建统一账号API实例（gying、qqpd、weibo等登录型插件共用）
+const accountsApi = axios.create({
+  baseURL: '/api/accounts',
+  timeout: 15000
+})
+
+// 请求拦截器 - 自动添加token（支持安全认证）
+accountsApi.interceptors.request.use(
+  (config) => {
+    const token = localStorage.getItem('auth_token');
+    if (token) {
+      config.headers.Authorization = `Bearer ${token}`;
+    }
+    return config;
+  },
+  (error) => {
+    return Promise.reject(error);
+  }
+)
+
+// 响应拦截器 - 处理401认证失败
+accountsApi.interceptors.response.use(
+  (response) => response,
+  (error) => handleUnauthorized(accountsApi, error)
+)
+
+// ============================================================
+// 统一账号 API 调用函数
+// ============================================================
+
+/**
+ * 获取已启用的登录型插件及其支持的账号操作
+ */
+export const listAccountPlugins = async (): Promise<AccountPluginSummary[]> => {
+  const response = await accountsApi.get<{ plugins: AccountPluginSummary[] }>('')
+  return response.data.plugins
+}
+
+/**
+ * 由登录标识（用户名、QQ号等）计算账号ID
+ * @param plugin 插件名称
+ * @param identifier 登录标识
+ */
+export const resolveAccount = async (plugin: string, identifier: string): Promise<ResolveAccountResponse> => {
+  const response = await accountsApi.post<ResolveAccountResponse>(`/${plugin}/resolve`, { identifier })
+  return response.data
+}
+
+/**
+ * 获取账号状态与健康信息
+ * @param plugin 插件名称
+ * @param id 账号ID（64位hash）
+ */
+export const getAccount = async (plugin: string, id: string): Promise<AccountInfo> => {
+  const response = await accountsApi.get<AccountInfo>(`/${plugin}/${id}`)
+  return response.data
+}
+
+/**
+ * 执行插件的账号操作（get_status、login、check_login、test_search等）
+ * @param plugin 插件名称
+ * @param id 账号ID
+ * @param action 操作名称
+ * @param params 操作参数
+ */
+export const runAccountAction = async (
+  plugin: string,
+  id: string,
+  action: string,
+  params: Record<string, any> = {}
+): Promise<AccountActionResponse> => {
+  const response = await accountsApi.post<AccountActionResponse>(`/${plugin}/${id}/${action}`, params)
+  return response.data
+}
+
+// 导出所有API函数
+export default {
+  listAccountPlugins,
+  resolveAccount,
+  getAccount,
+  runAccountAction
+}
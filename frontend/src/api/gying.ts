@@ -1,4 +1,5 @@
 import axios from 'axios'
+import { handleUnauthorized } from './index'
 import type {
   GyingStatusResponse,
   GyingLoginResponse,
@@ -29,17 +30,7 @@ gyingApi.interceptors.request.use(
 // 响应拦截器 - 处理401认证失败
 gyingApi.interceptors.response.use(
   (response) => response,
-  (error) => {
-    if (error.response?.status === 401) {
-      // 清除token
-      localStorage.removeItem('auth_token');
-      localStorage.removeItem('auth_username');
-      
-      // 触发显示登录窗口的事件
-      window.dispatchEvent(new CustomEvent('auth:required'));
-    }
-    return Promise.reject(error);
-  }
+  (error) => handleUnauthorized(gyingApi, error)
 )
 
 // ============================================================
@@ -1,4 +1,5 @@
 import axios from 'axios';
+import type { AxiosInstance } from 'axios';
 import type { SearchResponse } from '@/types';
 
 const api = axios.create({
@@ -20,20 +21,66 @@ api.interceptors.request.use(
   }
 );
 
+// 保存登录结果（访问令牌 + 刷新令牌）
+export const saveAuth = (data: LoginResponse) => {
+  localStorage.setItem('auth_token', data.token);
+  localStorage.setItem('auth_refresh_token', data.refresh_token);
+  localStorage.setItem('auth_username', data.username);
+};
+
+// 清除登录信息
+export const clearAuth = () => {
+  localStorage.removeItem('auth_token');
+  localStorage.removeItem('auth_refresh_token');
+  localStorage.removeItem('auth_username');
+};
+
+// 正在进行的刷新请求（并发的401共享同一次刷新）
+let refreshPromise: Promise<boolean> | null = null;
+
+// 使用刷新令牌换取新的访问令牌
+export const refreshAccessToken = (): Promise<boolean> => {
+  const refreshToken = localStorage.getItem('auth_refresh_token');
+  if (!refreshToken) {
+    return Promise.resolve(false);
+  }
+  if (!refreshPromise) {
+    refreshPromise = axios.post<LoginResponse>('/api/auth/refresh', { refresh_token: refreshToken })
+      .then((response) => {
+        saveAuth(response.data);
+        return true;
+      })
+      .catch(() => false)
+      .finally(() => {
+        refreshPromise = null;
+      });
+  }
+  return refreshPromise;
+};
+
+// 处理401：先尝试刷新令牌并重试一次，失败则清除token并要求重新登录
+export const handleUnauthorized = async (instance: AxiosInstance, error: any) => {
+  const config = error.config;
+  if (error.response?.status === 401) {
+    const isAuthRequest = /\/auth\/(login|refresh|logout)/.test(config?.url || '');
+    if (config && !config._retried && !isAuthRequest && await refreshAccessToken()) {
+      config._retried = true;
+      config.headers.Authorization = `Bearer ${localStorage.getItem('auth_token')}`;
+      return instance(config);
+    }
+
+    clearAuth();
+
+    // 触发显示登录窗口的事件
+    window.dispatchEvent(new CustomEvent('auth:required'));
+  }
+  return Promise.reject(error);
+};
+
 // 响应拦截器 - 处理401
 api.interceptors.response.use(
   (response) => response,
-  (error) => {
-    if (error.response?.status === 401) {
-      // 清除token
-      localStorage.removeItem('auth_token');
-      localStorage.removeItem('auth_username');
-      
-      // 触发显示登录窗口的事件
-      window.dispatchEvent(new CustomEvent('auth:required'));
-    }
-    return Promise.reject(error);
-  }
+  (error) => handleUnauthorized(api, error)
 );
 
 // 搜索参数接口
@@ -75,7 +122,10 @@ export interface LoginParams {
 export interface LoginResponse {
   token: string;
   expires_at: number;
+  refresh_token: string;
+  refresh_expires_at: number;
   username: string;
+  role: string;
 }
 
 // 认证状态
@@ -137,10 +187,11 @@ export const verifyToken = async (): Promise<boolean> => {
 // 退出登录
 export const logout = async (): Promise<void> => {
   try {
-    await api.post('/auth/logout');
+    await api.post('/auth/logout', {
+      refresh_token: localStorage.getItem('auth_refresh_token') || ''
+    });
   } finally {
-    localStorage.removeItem('auth_token');
-    localStorage.removeItem('auth_username');
+    clearAuth();
   }
 };
 
@@ -1,4 +1,5 @@
 import axios from 'axios'
+import { handleUnauthorized } from './index'
 import type {
   QQPDStatusResponse,
   QQPDQRCodeResponse,
@@ -31,17 +32,7 @@ qqpdApi.interceptors.request.use(
 // 响应拦截器 - 处理401认证失败
 qqpdApi.interceptors.response.use(
   (response) => response,
-  (error) => {
-    if (error.response?.status === 401) {
-      // 清除token
-      localStorage.removeItem('auth_token');
-      localStorage.removeItem('auth_username');
-      
-      // 触发显示登录窗口的事件
-      window.dispatchEvent(new CustomEvent('auth:required'));
-    }
-    return Promise.reject(error);
-  }
+  (error) => handleUnauthorized(qqpdApi, error)
 )
 
 // ============================================================
@@ -1,4 +1,5 @@
 import axios from 'axios'
+import { handleUnauthorized } from './index'
 import type {
   WeiboStatusResponse,
   WeiboQRCodeResponse,
@@ -28,14 +29,7 @@ weiboApi.interceptors.request.use(
 
 weiboApi.interceptors.response.use(
   (response) => response,
-  (error) => {
-    if (error.response?.status === 401) {
-      localStorage.removeItem('auth_token');
-      localStorage.removeItem('auth_username');
-      window.dispatchEvent(new CustomEvent('auth:required'));
-    }
-    return Promise.reject(error);
-  }
+  (error) => handleUnauthorized(weiboApi, error)
 )
 
 export const getStatus = async (hash: string): Promise<WeiboStatusResponse> => {
@@ -0,0 +1,54 @@
+// ============================================================
+// 登录型插件统一账号接口类型定义
+// ============================================================
+
+// 账号状态
+export type AccountStatus = 'pending' | 'active' | 'expired'
+
+// 健康状态（由保活和搜索结果上报）
+export type AccountHealth = 'unknown' | 'healthy' | 'degraded' | 'failing'
+
+// 账号信息（不含Cookie、密码等敏感数据）
+export interface AccountInfo {
+  plugin: string
+  id: string
+  status: AccountStatus
+  health: AccountHealth
+  failures: number
+  last_error?: string
+  created_at: string
+  login_at: string
+  expire_at: string
+  last_access_at: string
+  last_check_at: string
+  last_used_at: string
+  recent_uses: number
+  details?: Record<string, any>
+}
+
+// 插件的账号统计
+export interface AccountPluginSummary {
+  plugin: string
+  total: number
+  active: number
+  pending: number
+  expired: number
+  failing: number
+  rate_limit: number
+  actions: string[]
+  accounts?: AccountInfo[]
+}
+
+// 按登录标识查找账号ID的响应
+export interface ResolveAccountResponse {
+  plugin: string
+  id: string
+  exists: boolean
+}
+
+// 插件账号操作的响应（与插件管理页面接口一致）
+export interface AccountActionResponse {
+  success: boolean
+  message: string
+  data?: any
+}
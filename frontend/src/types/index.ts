@@ -58,7 +58,10 @@ export interface LoginParams {
 export interface LoginResponse {
   token: string;
   expires_at: number;
+  refresh_token: string;
+  refresh_expires_at: number;
   username: string;
+  role: string;
 }
 
 // 认证状态
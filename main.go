@@ -15,8 +15,13 @@ import (
 	"golang.org/x/net/netutil"
 
 	"pansou/api"
+	"pansou/bot/onebot"
+	"pansou/bot/telegram"
 	"pansou/config"
 	"pansou/plugin"
+	"pansou/plugin/external"
+	"pansou/plugin/peer"
+	"pansou/plugin/script"
 	"pansou/service"
 	"pansou/util"
 	"pansou/util/cache"
@@ -108,6 +113,11 @@ func print(format string, a ...any) {
 }
 
 func main() {
+	// 命令行子命令（如 hash-password）执行完直接退出
+	if len(os.Args) > 1 && runCommand(os.Args[1:]) {
+		return
+	}
+
 	// 屏蔽 log 包输出
 	log.SetOutput(io.Discard)
 
@@ -150,11 +160,18 @@ func initApp() {
 	plugin.InitAsyncPluginSystem()
 }
 
-func startServer() {
+// newSearchService 注册启用的插件并创建搜索服务，返回搜索服务和插件数
+func newSearchService() (*service.SearchService, int) {
 	pluginManager := plugin.NewPluginManager()
 
 	if config.AppConfig.AsyncPluginEnabled {
 		pluginManager.RegisterGlobalPluginsWithFilter(config.AppConfig.EnabledPlugins)
+		// 联邦搜索的远程实例
+		peer.Register(pluginManager)
+		// 通过JSON-RPC接入的外部插件
+		external.Register(pluginManager)
+		// 插件目录中的JavaScript脚本
+		script.Register(pluginManager)
 	}
 
 	pluginCount := 0
@@ -163,9 +180,67 @@ func startServer() {
 	}
 	config.UpdateDefaultConcurrency(pluginCount)
 
-	searchService := service.NewSearchService(pluginManager)
+	return service.NewSearchService(pluginManager), pluginCount
+}
+
+// flushCaches 退出前将待写入的缓存写入磁盘
+func flushCaches() {
+	if globalCacheWriteManager != nil {
+		globalCacheWriteManager.Shutdown(10 * time.Second)
+	}
+
+	if mainCache := service.GetEnhancedTwoLevelCache(); mainCache != nil {
+		mainCache.FlushMemoryToDisk()
+	}
+}
+
+// startBots 启动已配置的聊天机器人，返回用于停止机器人的函数
+func startBots(searchService *service.SearchService) context.CancelFunc {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if config.AppConfig.TelegramBotToken != "" {
+		tgBot := telegram.New(searchService, telegram.Config{
+			Token:        config.AppConfig.TelegramBotToken,
+			APIURL:       config.AppConfig.TelegramBotAPIURL,
+			AllowedChats: config.AppConfig.TelegramBotAllowedChats,
+			RateLimit:    config.AppConfig.TelegramBotRateLimit,
+			PageSize:     config.AppConfig.TelegramBotPageSize,
+		}, util.GetHTTPClient())
+		go func() {
+			if err := tgBot.Run(ctx); err != nil {
+				print("Telegram机器人启动失败: %v\n", err)
+			}
+		}()
+		print("Telegram机器人: 已启用\n")
+	}
+
+	if config.AppConfig.OneBotWSURL != "" || config.AppConfig.OneBotReverseEnabled {
+		qqBot := onebot.New(searchService, onebot.Config{
+			WSURL:         config.AppConfig.OneBotWSURL,
+			AccessToken:   config.AppConfig.OneBotAccessToken,
+			AllowedGroups: config.AppConfig.OneBotAllowedGroups,
+			AllowPrivate:  config.AppConfig.OneBotAllowPrivate,
+			Admins:        config.AppConfig.OneBotAdmins,
+			Cooldown:      config.AppConfig.OneBotCooldown,
+			MaxResults:    config.AppConfig.OneBotMaxResults,
+			CloudTypes:    config.AppConfig.OneBotCloudTypes,
+			DataPath:      config.AppConfig.CachePath,
+		})
+		if config.AppConfig.OneBotReverseEnabled {
+			api.SetOneBot(qqBot)
+		}
+		go qqBot.Run(ctx)
+		print("OneBot机器人: 已启用\n")
+	}
+
+	return cancel
+}
+
+func startServer() {
+	searchService, pluginCount := newSearchService()
 
 	router := api.SetupRouter(searchService, frontendFS)
+	stopBots := startBots(searchService)
 
 	port := config.AppConfig.Port
 
@@ -178,6 +253,20 @@ func startServer() {
 	print("========================================\n")
 	print("并发数: %d (频道数%d + 插件数%d + 10)\n",
 		config.AppConfig.DefaultConcurrency, channelCount, pluginCount)
+	if config.AppConfig.AuthEnabled {
+		hasAdmin := false
+		for username, password := range config.AppConfig.AuthUsers {
+			if !util.IsPasswordHashed(password) {
+				print("警告: 用户 %s 使用明文密码，建议使用 pansou hash-password 生成哈希\n", username)
+			}
+			if util.UserRole(username) == util.RoleAdmin {
+				hasAdmin = true
+			}
+		}
+		if !hasAdmin {
+			print("警告: 未通过 AUTH_USER_ROLES 指定管理员（如 admin=admin），所有用户只有搜索权限\n")
+		}
+	}
 
 	srv := &http.Server{
 		Addr:         ":" + port,
@@ -213,13 +302,9 @@ func startServer() {
 	<-quit
 	print("正在关闭服务器...\n")
 
-	if globalCacheWriteManager != nil {
-		globalCacheWriteManager.Shutdown(10 * time.Second)
-	}
-
-	if mainCache := service.GetEnhancedTwoLevelCache(); mainCache != nil {
-		mainCache.FlushMemoryToDisk()
-	}
+	stopBots()
+	external.Shutdown()
+	flushCaches()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -0,0 +1,235 @@
+// Package mcp 实现Model Context Protocol服务端，将搜索能力以工具和资源的形式提供给MCP客户端
+// 传输层（streamable HTTP、stdio）只负责收发JSON-RPC消息，由Server统一处理
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"pansou/service"
+	jsonutil "pansou/util/json"
+)
+
+// LatestProtocolVersion 支持的最新协议版本
+const LatestProtocolVersion = "2025-06-18"
+
+// supportedProtocolVersions 支持的协议版本，客户端请求的版本不在其中时使用最新版本
+var supportedProtocolVersions = []string{LatestProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// ServerName 服务端名称
+const ServerName = "pansou"
+
+// JSON-RPC错误码
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Request JSON-RPC请求或通知（没有ID）
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// IsNotification 是否为通知（不需要响应）
+func (r *Request) IsNotification() bool {
+	return len(r.ID) == 0
+}
+
+// Response JSON-RPC响应
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error JSON-RPC错误
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// SearchScope 按调用方权限调整搜索范围（插件、来源类型、ext），返回false表示无权搜索
+type SearchScope func(plugins []string, sourceType string, ext map[string]interface{}) ([]string, string, bool)
+
+type contextKey int
+
+const searchScopeKey contextKey = iota
+
+// WithSearchScope 在上下文中设置调用方的搜索范围
+func WithSearchScope(ctx context.Context, scope SearchScope) context.Context {
+	return context.WithValue(ctx, searchScopeKey, scope)
+}
+
+// searchScopeFrom 获取上下文中的搜索范围
+func searchScopeFrom(ctx context.Context) SearchScope {
+	scope, _ := ctx.Value(searchScopeKey).(SearchScope)
+	return scope
+}
+
+// Server MCP服务端，直接调用搜索服务
+type Server struct {
+	searchService *service.SearchService
+}
+
+// NewServer 创建MCP服务端
+func NewServer(searchService *service.SearchService) *Server {
+	return &Server{searchService: searchService}
+}
+
+// IsInitializeRequest 判断消息是否为initialize请求（传输层据此创建会话）
+func IsInitializeRequest(data []byte) bool {
+	var req Request
+	if err := jsonutil.Unmarshal(bytes.TrimSpace(data), &req); err != nil {
+		return false
+	}
+	return req.Method == "initialize" && !req.IsNotification()
+}
+
+// HandleMessage 处理一条JSON-RPC消息或批量消息，返回需要发送的响应
+// 消息全部为通知时返回nil
+func (s *Server) HandleMessage(ctx context.Context, data []byte) []byte {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		return s.handleBatch(ctx, data)
+	}
+
+	var req Request
+	if err := jsonutil.Unmarshal(data, &req); err != nil {
+		return marshalResponse(errorResponse(nil, CodeParseError, "无法解析JSON-RPC消息: "+err.Error()))
+	}
+	if resp := s.handleRequest(ctx, &req); resp != nil {
+		return marshalResponse(resp)
+	}
+	return nil
+}
+
+// handleBatch 处理批量消息
+func (s *Server) handleBatch(ctx context.Context, data []byte) []byte {
+	var batch []json.RawMessage
+	if err := jsonutil.Unmarshal(data, &batch); err != nil {
+		return marshalResponse(errorResponse(nil, CodeParseError, "无法解析JSON-RPC消息: "+err.Error()))
+	}
+	if len(batch) == 0 {
+		return marshalResponse(errorResponse(nil, CodeInvalidRequest, "批量消息不能为空"))
+	}
+
+	responses := make([]*Response, 0, len(batch))
+	for _, item := range batch {
+		var req Request
+		if err := jsonutil.Unmarshal(item, &req); err != nil {
+			responses = append(responses, errorResponse(nil, CodeInvalidRequest, "无效的JSON-RPC消息"))
+			continue
+		}
+		if resp := s.handleRequest(ctx, &req); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+	if len(responses) == 0 {
+		return nil
+	}
+	data, _ = jsonutil.Marshal(responses)
+	return data
+}
+
+// handleRequest 按方法分发请求，通知返回nil
+func (s *Server) handleRequest(ctx context.Context, req *Request) *Response {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if req.IsNotification() {
+			return nil
+		}
+		return errorResponse(req.ID, CodeInvalidRequest, "无效的JSON-RPC请求")
+	}
+
+	var result interface{}
+	var rpcErr *Error
+	switch req.Method {
+	case "initialize":
+		result, rpcErr = s.initialize(req.Params)
+	case "ping":
+		result = struct{}{}
+	case "tools/list":
+		result = map[string]interface{}{"tools": toolDefinitions()}
+	case "tools/call":
+		result, rpcErr = s.callTool(ctx, req.Params)
+	case "resources/list":
+		result = map[string]interface{}{"resources": resourceDefinitions()}
+	case "resources/templates/list":
+		result = map[string]interface{}{"resourceTemplates": []interface{}{}}
+	case "resources/read":
+		result, rpcErr = s.readResource(req.Params)
+	default:
+		// 客户端通知（如notifications/initialized、notifications/cancelled）无需处理
+		if req.IsNotification() {
+			return nil
+		}
+		rpcErr = &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("不支持的方法: %s", req.Method)}
+	}
+
+	if req.IsNotification() {
+		return nil
+	}
+	if rpcErr != nil {
+		return &Response{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	}
+	return &Response{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+// initialize 协商协议版本并声明服务端能力
+func (s *Server) initialize(params json.RawMessage) (interface{}, *Error) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := jsonutil.Unmarshal(params, &p); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: "无效的参数: " + err.Error()}
+		}
+	}
+
+	version := LatestProtocolVersion
+	for _, v := range supportedProtocolVersions {
+		if v == p.ProtocolVersion {
+			version = v
+			break
+		}
+	}
+
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"tools":     map[string]interface{}{"listChanged": false},
+			"resources": map[string]interface{}{"subscribe": false, "listChanged": false},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    ServerName,
+			"version": "1.0.0",
+		},
+		"instructions": "使用 search_netdisk 搜索网盘资源，check_service_health 查看可用的频道和插件；pansou://plugins、pansou://channels、pansou://cloud-types 资源提供可用的插件、频道和网盘类型。",
+	}, nil
+}
+
+// errorResponse 创建错误响应（无法确定请求ID时ID为null）
+func errorResponse(id json.RawMessage, code int, message string) *Response {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &Response{JSONRPC: "2.0", ID: id, Error: &Error{Code: code, Message: message}}
+}
+
+// marshalResponse 序列化响应
+func marshalResponse(resp *Response) []byte {
+	data, err := jsonutil.Marshal(resp)
+	if err != nil {
+		data, _ = jsonutil.Marshal(errorResponse(resp.ID, CodeInternalError, "序列化响应失败: "+err.Error()))
+	}
+	return data
+}
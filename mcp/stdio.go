@@ -0,0 +1,41 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sync"
+)
+
+// 单条stdio消息的最大长度
+const maxStdioMessageSize = 16 * 1024 * 1024
+
+// ServeStdio 通过stdio传输处理消息：每行一条JSON-RPC消息，响应同样按行写出
+// 请求并发处理，响应顺序可能与请求顺序不同（由ID对应）；输入结束后等待处理中的请求完成
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxStdioMessageSize)
+
+	var writeMu sync.Mutex
+	var wg sync.WaitGroup
+	for scanner.Scan() {
+		line := append([]byte(nil), scanner.Bytes()...)
+		if len(line) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := s.HandleMessage(ctx, line)
+			if resp == nil {
+				return
+			}
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			out.Write(append(resp, '\n'))
+		}()
+	}
+	wg.Wait()
+	return scanner.Err()
+}
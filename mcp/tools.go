@@ -0,0 +1,302 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/service"
+	jsonutil "pansou/util/json"
+)
+
+const (
+	// 每种结果默认返回的最大条数
+	defaultMaxResults = 50
+	// 每种结果允许返回的最大条数
+	maxResultsLimit = 500
+)
+
+// 资源URI
+const (
+	ResourcePlugins    = "pansou://plugins"
+	ResourceChannels   = "pansou://channels"
+	ResourceCloudTypes = "pansou://cloud-types"
+)
+
+// toolDefinitions 工具列表
+func toolDefinitions() []map[string]interface{} {
+	cloudTypeNames := make([]string, 0, len(model.CloudTypes))
+	for _, t := range model.CloudTypes {
+		cloudTypeNames = append(cloudTypeNames, t.Type)
+	}
+
+	return []map[string]interface{}{
+		{
+			"name":        "search_netdisk",
+			"title":       "搜索网盘资源",
+			"description": "按关键词搜索网盘资源，可指定来源（Telegram频道、插件或全部）和网盘类型，返回按网盘类型分组的链接或原始搜索结果。",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"keyword": map[string]interface{}{
+						"type":        "string",
+						"description": "搜索关键词",
+					},
+					"source_type": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"all", "tg", "plugin"},
+						"description": "数据来源：all（默认）、tg（仅Telegram频道）、plugin（仅插件）",
+					},
+					"cloud_types": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string", "enum": cloudTypeNames},
+						"description": "只返回指定类型的网盘链接，不指定则返回全部类型",
+					},
+					"ext": map[string]interface{}{
+						"type":        "object",
+						"description": "传递给插件的扩展参数，如 {\"title_en\": \"Avatar\"}",
+					},
+					"result_type": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"merged_by_type", "results", "all"},
+						"description": "结果格式：merged_by_type（默认，按网盘类型分组）、results（原始结果）、all（两者）",
+					},
+					"concurrency": map[string]interface{}{
+						"type":        "integer",
+						"minimum":     1,
+						"description": "并发搜索数量，不指定则使用服务端默认值",
+					},
+					"force_refresh": map[string]interface{}{
+						"type":        "boolean",
+						"description": "是否忽略缓存重新搜索",
+					},
+					"max_results": map[string]interface{}{
+						"type":        "integer",
+						"minimum":     1,
+						"maximum":     maxResultsLimit,
+						"description": fmt.Sprintf("每种网盘类型（或原始结果）最多返回的条数，默认%d", defaultMaxResults),
+					},
+				},
+				"required": []string{"keyword"},
+			},
+		},
+		{
+			"name":        "check_service_health",
+			"title":       "检查服务状态",
+			"description": "检查PanSou服务状态，返回可用的Telegram频道和插件列表。",
+			"inputSchema": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+	}
+}
+
+// resourceDefinitions 资源列表
+func resourceDefinitions() []map[string]interface{} {
+	return []map[string]interface{}{
+		{"uri": ResourcePlugins, "name": "plugins", "title": "可用插件", "description": "已启用的搜索插件及其优先级", "mimeType": "application/json"},
+		{"uri": ResourceChannels, "name": "channels", "title": "可用频道", "description": "默认搜索的Telegram频道", "mimeType": "application/json"},
+		{"uri": ResourceCloudTypes, "name": "cloud-types", "title": "网盘类型", "description": "支持的网盘类型，可用于 search_netdisk 的 cloud_types 参数", "mimeType": "application/json"},
+	}
+}
+
+// searchArgs search_netdisk的参数
+type searchArgs struct {
+	Keyword      string                 `json:"keyword"`
+	SourceType   string                 `json:"source_type"`
+	CloudTypes   []string               `json:"cloud_types"`
+	Ext          map[string]interface{} `json:"ext"`
+	ResultType   string                 `json:"result_type"`
+	Concurrency  int                    `json:"concurrency"`
+	ForceRefresh bool                   `json:"force_refresh"`
+	MaxResults   int                    `json:"max_results"`
+}
+
+// callTool 调用工具；工具执行失败以isError结果返回，参数无法解析时返回协议错误
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (interface{}, *Error) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := jsonutil.Unmarshal(params, &p); err != nil {
+		return nil, &Error{Code: CodeInvalidParams, Message: "无效的参数: " + err.Error()}
+	}
+
+	switch p.Name {
+	case "search_netdisk":
+		var args searchArgs
+		if len(p.Arguments) > 0 {
+			if err := jsonutil.Unmarshal(p.Arguments, &args); err != nil {
+				return nil, &Error{Code: CodeInvalidParams, Message: "无效的工具参数: " + err.Error()}
+			}
+		}
+		return s.searchNetdisk(ctx, args), nil
+	case "check_service_health":
+		return jsonResult(service.HealthStatus(s.searchService)), nil
+	default:
+		return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("未知的工具: %s", p.Name)}
+	}
+}
+
+// searchNetdisk 执行搜索，参数默认值与搜索接口一致
+func (s *Server) searchNetdisk(ctx context.Context, args searchArgs) map[string]interface{} {
+	keyword := strings.TrimSpace(args.Keyword)
+	if keyword == "" {
+		return errorResult("keyword 不能为空")
+	}
+	if s.searchService == nil {
+		return errorResult("搜索服务未初始化")
+	}
+
+	sourceType := args.SourceType
+	if sourceType == "" {
+		sourceType = "all"
+	}
+	if sourceType != "all" && sourceType != "tg" && sourceType != "plugin" {
+		return errorResult("source_type 只能是 all、tg 或 plugin")
+	}
+	resultType := args.ResultType
+	if resultType == "" || resultType == "merge" {
+		resultType = "merged_by_type"
+	}
+	ext := args.Ext
+	if ext == nil {
+		ext = make(map[string]interface{})
+	}
+	// 内部参数不允许由客户端传入
+	for key := range ext {
+		if strings.HasPrefix(key, "_") {
+			delete(ext, key)
+		}
+	}
+
+	var channels, plugins []string
+	if sourceType != "plugin" {
+		channels = config.AppConfig.DefaultChannels
+	}
+	if scope := searchScopeFrom(ctx); scope != nil {
+		var allowed bool
+		plugins, sourceType, allowed = scope(plugins, sourceType, ext)
+		if !allowed {
+			return errorResult("无权搜索指定的来源")
+		}
+		if sourceType == "plugin" {
+			channels = nil
+		}
+	}
+	forceRefresh := args.ForceRefresh
+	if cacheOnly, _ := ext[service.CacheOnlyExtKey].(bool); cacheOnly {
+		forceRefresh = false
+	}
+
+	result, err := s.searchService.Search(keyword, channels, args.Concurrency, forceRefresh, resultType, sourceType, plugins, args.CloudTypes, ext)
+	if err != nil {
+		return errorResult("搜索失败: " + err.Error())
+	}
+
+	maxResults := args.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultMaxResults
+	} else if maxResults > maxResultsLimit {
+		maxResults = maxResultsLimit
+	}
+	return jsonResult(truncateResponse(result, maxResults))
+}
+
+// truncateResponse 限制原始结果和每种网盘类型的条数，total保持为实际总数
+func truncateResponse(response model.SearchResponse, maxResults int) model.SearchResponse {
+	if len(response.Results) > maxResults {
+		response.Results = response.Results[:maxResults]
+	}
+	if len(response.MergedByType) > 0 {
+		merged := make(model.MergedLinks, len(response.MergedByType))
+		for cloudType, links := range response.MergedByType {
+			if len(links) > maxResults {
+				links = links[:maxResults]
+			}
+			merged[cloudType] = links
+		}
+		response.MergedByType = merged
+	}
+	return response
+}
+
+// readResource 读取资源
+func (s *Server) readResource(params json.RawMessage) (interface{}, *Error) {
+	var p struct {
+		URI string `json:"uri"`
+	}
+	if err := jsonutil.Unmarshal(params, &p); err != nil {
+		return nil, &Error{Code: CodeInvalidParams, Message: "无效的参数: " + err.Error()}
+	}
+
+	var content interface{}
+	switch p.URI {
+	case ResourcePlugins:
+		content = s.pluginList()
+	case ResourceChannels:
+		content = map[string]interface{}{"channels": config.AppConfig.DefaultChannels}
+	case ResourceCloudTypes:
+		content = map[string]interface{}{"cloud_types": model.CloudTypes}
+	default:
+		return nil, &Error{Code: -32002, Message: "资源不存在", Data: map[string]string{"uri": p.URI}}
+	}
+
+	text, err := jsonutil.MarshalString(content)
+	if err != nil {
+		return nil, &Error{Code: CodeInternalError, Message: "序列化资源失败: " + err.Error()}
+	}
+	return map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{"uri": p.URI, "mimeType": "application/json", "text": text},
+		},
+	}, nil
+}
+
+// pluginList 已启用的插件，按优先级排序
+func (s *Server) pluginList() map[string]interface{} {
+	type pluginInfo struct {
+		Name     string `json:"name"`
+		Priority int    `json:"priority"`
+	}
+	plugins := make([]pluginInfo, 0)
+	if config.AppConfig.AsyncPluginEnabled && s.searchService != nil && s.searchService.GetPluginManager() != nil {
+		for _, p := range s.searchService.GetPluginManager().GetPlugins() {
+			plugins = append(plugins, pluginInfo{Name: p.Name(), Priority: p.Priority()})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		if plugins[i].Priority != plugins[j].Priority {
+			return plugins[i].Priority < plugins[j].Priority
+		}
+		return plugins[i].Name < plugins[j].Name
+	})
+	return map[string]interface{}{
+		"enabled": config.AppConfig.AsyncPluginEnabled,
+		"plugins": plugins,
+	}
+}
+
+// jsonResult 以JSON文本返回工具结果
+func jsonResult(v interface{}) map[string]interface{} {
+	text, err := jsonutil.MarshalString(v)
+	if err != nil {
+		return errorResult("序列化结果失败: " + err.Error())
+	}
+	return map[string]interface{}{
+		"content": []map[string]interface{}{{"type": "text", "text": text}},
+	}
+}
+
+// errorResult 工具执行失败的结果
+func errorResult(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []map[string]interface{}{{"type": "text", "text": message}},
+		"isError": true,
+	}
+}
@@ -0,0 +1,34 @@
+package model
+
+// CloudType 网盘类型及显示名称
+type CloudType struct {
+	Type string `json:"type" sonic:"type"`
+	Name string `json:"name" sonic:"name"`
+}
+
+// CloudTypes 支持的网盘类型（按展示顺序）
+var CloudTypes = []CloudType{
+	{"baidu", "百度网盘"},
+	{"aliyun", "阿里云盘"},
+	{"quark", "夸克网盘"},
+	{"tianyi", "天翼云盘"},
+	{"uc", "UC网盘"},
+	{"mobile", "移动云盘"},
+	{"115", "115网盘"},
+	{"pikpak", "PikPak"},
+	{"xunlei", "迅雷网盘"},
+	{"123", "123网盘"},
+	{"magnet", "磁力链接"},
+	{"ed2k", "电驴链接"},
+	{"others", "其他"},
+}
+
+// CloudTypeName 网盘类型的显示名称，未知类型返回类型本身
+func CloudTypeName(cloudType string) string {
+	for _, t := range CloudTypes {
+		if t.Type == cloudType {
+			return t.Name
+		}
+	}
+	return cloudType
+}
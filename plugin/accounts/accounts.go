@@ -0,0 +1,594 @@
+// Package accounts 登录型插件的账号管理
+//
+// 需要用户登录才能搜索的插件（如 gying、qqpd、weibo）共用这里的账号存储与调度：
+// 账号数据加密保存在 <缓存目录>/accounts/<插件名>/ 下（启用共享存储时在实例间同步），
+// 管理器负责会话保活、健康状态、过期清理，以及搜索时按最近最少使用的顺序选择账号并限制每个账号的调用频率。
+// 插件的账号结构体嵌入 Account，插件特有的操作（登录、扫码、设置频道等）注册为 Actions，
+// 由插件自己的管理页面和统一的 /api/accounts 接口共同使用。
+package accounts
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"pansou/config"
+)
+
+// 账号状态
+const (
+	StatusPending = "pending" // 已创建，尚未登录
+	StatusActive  = "active"  // 已登录，可用于搜索
+	StatusExpired = "expired" // 登录已过期或长期未使用
+)
+
+// 健康状态（由保活和搜索结果上报）
+const (
+	HealthUnknown  = "unknown"
+	HealthHealthy  = "healthy"
+	HealthDegraded = "degraded" // 最近有失败，但未达到阈值
+	HealthFailing  = "failing"  // 连续失败达到阈值，选择账号时排在最后
+)
+
+const (
+	// 连续失败达到该次数后标记为failing
+	failingThreshold = 3
+	// 过期账号超过该时间未访问则删除
+	expiredRetention = 30 * 24 * time.Hour
+	// 超过该时间未访问的账号标记为过期
+	inactiveAfter = 90 * 24 * time.Hour
+	// 清理任务的执行间隔
+	cleanupInterval = 24 * time.Hour
+	// 同时执行保活的账号数
+	keepAliveConcurrency = 4
+	// 频率限制的统计窗口
+	rateWindow = time.Minute
+)
+
+// Account 账号的公共字段，插件的账号结构体通过嵌入该类型获得
+type Account struct {
+	Hash         string    `json:"hash"` // 账号ID（由登录标识计算，也是管理页面地址）
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+	LoginAt      time.Time `json:"login_at"`
+	ExpireAt     time.Time `json:"expire_at"`
+	LastAccessAt time.Time `json:"last_access_at"` // 最近一次访问管理页面或修改配置的时间
+
+	// 以下字段由管理器维护（读写时持有管理器的锁）
+	Health      string    `json:"health,omitempty"`
+	Failures    int       `json:"failures,omitempty"` // 连续失败次数
+	LastError   string    `json:"last_error,omitempty"`
+	LastCheckAt time.Time `json:"last_check_at"` // 最近一次上报健康状态的时间
+	LastUsedAt  time.Time `json:"last_used_at"`  // 最近一次被选中执行搜索的时间
+}
+
+// Base 返回账号的公共字段，嵌入Account的结构体由此实现Record接口
+func (a *Account) Base() *Account {
+	return a
+}
+
+// Record 插件的账号记录
+type Record interface {
+	Base() *Account
+}
+
+// ActionFunc 账号操作的处理函数，params为请求体中的全部参数
+type ActionFunc func(c *gin.Context, id string, params map[string]interface{})
+
+// Options 账号管理器配置
+type Options struct {
+	Plugin string        // 插件名称
+	New    func() Record // 创建空记录，用于解码存储的数据
+
+	// LegacyDir 旧版账号目录（相对缓存目录，如 gying_users），启动时导入后删除其中的明文文件
+	LegacyDir string
+	// HashID 由登录标识（用户名、QQ号等）计算账号ID，为空时不支持按标识查找账号
+	HashID func(identifier string) string
+	// Details 账号信息中展示的插件字段（如脱敏用户名、频道数），不能包含Cookie、密码等敏感数据
+	Details func(r Record) map[string]interface{}
+	// OnExpire 账号过期时清理会话数据（如清空Cookie）
+	OnExpire func(r Record)
+
+	// KeepAlive 对单个已登录账号执行保活，返回的错误计入健康状态
+	KeepAlive         func(r Record) error
+	KeepAliveInterval time.Duration
+	KeepAliveDelay    time.Duration // 启动后首次保活前的等待时间（避免启动时请求过多）
+
+	// Actions 插件支持的账号操作（get_status、login、logout等）
+	Actions map[string]ActionFunc
+}
+
+// Manager 单个插件的账号管理器
+type Manager struct {
+	opts Options
+
+	mu      sync.RWMutex
+	records map[string]Record
+	usage   map[string][]time.Time // 每个账号在频率限制窗口内被选中的时间
+	dir     string
+	started bool
+
+	startOnce sync.Once
+	startErr  error
+}
+
+var (
+	managers   = make(map[string]*Manager)
+	managersMu sync.RWMutex
+)
+
+// NewManager 创建并登记插件的账号管理器（可在插件init中调用，存储在Start时才加载）
+func NewManager(opts Options) *Manager {
+	m := &Manager{
+		opts:    opts,
+		records: make(map[string]Record),
+		usage:   make(map[string][]time.Time),
+	}
+	managersMu.Lock()
+	managers[opts.Plugin] = m
+	managersMu.Unlock()
+	return m
+}
+
+// GetManager 获取已启动的插件账号管理器
+func GetManager(pluginName string) (*Manager, bool) {
+	managersMu.RLock()
+	m, ok := managers[pluginName]
+	managersMu.RUnlock()
+	if !ok || !m.isStarted() {
+		return nil, false
+	}
+	return m, true
+}
+
+// Managers 获取所有已启动的账号管理器（按插件名排序）
+func Managers() []*Manager {
+	managersMu.RLock()
+	list := make([]*Manager, 0, len(managers))
+	for _, m := range managers {
+		if m.isStarted() {
+			list = append(list, m)
+		}
+	}
+	managersMu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].opts.Plugin < list[j].opts.Plugin })
+	return list
+}
+
+// Plugin 管理器所属的插件名称
+func (m *Manager) Plugin() string {
+	return m.opts.Plugin
+}
+
+func (m *Manager) isStarted() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.started
+}
+
+// Start 加载账号并启动保活和清理任务（在插件Initialize中调用，重复调用无效）
+func (m *Manager) Start() error {
+	m.startOnce.Do(func() {
+		m.dir = accountsDir(m.opts.Plugin)
+		if err := ensureDir(m.dir); err != nil {
+			m.startErr = fmt.Errorf("创建账号目录失败: %w", err)
+			return
+		}
+		m.importLegacy()
+		m.load()
+
+		go m.cleanupLoop()
+		if m.opts.KeepAlive != nil && m.opts.KeepAliveInterval > 0 {
+			go m.keepAliveLoop()
+		}
+
+		m.mu.Lock()
+		m.started = true
+		m.mu.Unlock()
+	})
+	return m.startErr
+}
+
+// Get 获取账号，本地不存在时从共享存储读取（其他实例新增的账号）
+func (m *Manager) Get(id string) (Record, bool) {
+	m.mu.RLock()
+	r, ok := m.records[id]
+	m.mu.RUnlock()
+	if ok {
+		return r, true
+	}
+
+	r, ok = m.loadShared(id)
+	if !ok {
+		return nil, false
+	}
+	m.mu.Lock()
+	if existing, exists := m.records[id]; exists {
+		r = existing
+	} else {
+		m.records[id] = r
+	}
+	m.mu.Unlock()
+	return r, true
+}
+
+// Save 保存账号（内存+加密文件+共享存储）
+func (m *Manager) Save(r Record) error {
+	a := r.Base()
+	if !ValidID(a.Hash) {
+		return fmt.Errorf("账号ID无效: %q", a.Hash)
+	}
+	m.mu.Lock()
+	if a.Health == "" {
+		a.Health = HealthUnknown
+	}
+	m.records[a.Hash] = r
+	m.mu.Unlock()
+	return m.persist(r)
+}
+
+// Delete 删除账号
+func (m *Manager) Delete(id string) error {
+	m.mu.Lock()
+	delete(m.records, id)
+	delete(m.usage, id)
+	m.mu.Unlock()
+	return m.remove(id)
+}
+
+// All 获取所有账号
+func (m *Manager) All() []Record {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	list := make([]Record, 0, len(m.records))
+	for _, r := range m.records {
+		list = append(list, r)
+	}
+	return list
+}
+
+// Acquire 选择最多n个可用账号（n<=0表示不限数量）用于搜索
+// 只选择已登录且未过期、满足filter、未超过频率限制的账号；健康的账号优先，同等健康状态下最久未使用的优先。
+// 选中的账号记录使用时间，计入频率限制
+func (m *Manager) Acquire(n int, filter func(r Record) bool) []Record {
+	now := time.Now()
+	limit := 0
+	if config.AppConfig != nil {
+		limit = config.AppConfig.AccountRateLimit
+	}
+
+	var candidates, expired []Record
+	m.mu.Lock()
+	for id, r := range m.records {
+		a := r.Base()
+		if a.Status != StatusActive {
+			continue
+		}
+		if !a.ExpireAt.IsZero() && now.After(a.ExpireAt) {
+			expired = append(expired, r)
+			continue
+		}
+		if filter != nil && !filter(r) {
+			continue
+		}
+		if limit > 0 && len(m.recentUsageLocked(id, now)) >= limit {
+			continue
+		}
+		candidates = append(candidates, r)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i].Base(), candidates[j].Base()
+		if ra, rb := healthRank(a.Health), healthRank(b.Health); ra != rb {
+			return ra < rb
+		}
+		return a.LastUsedAt.Before(b.LastUsedAt)
+	})
+	if n > 0 && len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	for _, r := range candidates {
+		a := r.Base()
+		a.LastUsedAt = now
+		m.usage[a.Hash] = append(m.recentUsageLocked(a.Hash, now), now)
+	}
+	m.mu.Unlock()
+
+	for _, r := range expired {
+		m.Expire(r)
+	}
+	return candidates
+}
+
+// recentUsageLocked 账号在频率限制窗口内的使用记录（调用方需持有锁）
+func (m *Manager) recentUsageLocked(id string, now time.Time) []time.Time {
+	uses := m.usage[id]
+	i := 0
+	for i < len(uses) && now.Sub(uses[i]) >= rateWindow {
+		i++
+	}
+	if i > 0 {
+		uses = uses[i:]
+		m.usage[id] = uses
+	}
+	return uses
+}
+
+// healthRank 选择账号时的健康状态排序
+func healthRank(health string) int {
+	switch health {
+	case HealthHealthy, HealthUnknown, "":
+		return 0
+	case HealthDegraded:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Report 上报账号的一次请求结果（err为nil表示成功），更新健康状态
+func (m *Manager) Report(id string, err error) {
+	m.mu.Lock()
+	r, ok := m.records[id]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	a := r.Base()
+	previous := a.Health
+	a.LastCheckAt = time.Now()
+	if err == nil {
+		a.Failures = 0
+		a.LastError = ""
+		a.Health = HealthHealthy
+	} else {
+		a.Failures++
+		a.LastError = err.Error()
+		if a.Failures >= failingThreshold {
+			a.Health = HealthFailing
+		} else {
+			a.Health = HealthDegraded
+		}
+	}
+	changed := previous != a.Health
+	health, failures, lastError := a.Health, a.Failures, a.LastError
+	m.mu.Unlock()
+
+	// 只在状态变化时写入，避免每次搜索都写文件
+	if changed {
+		if health == HealthFailing {
+			fmt.Printf("[%s] 账号 %s 连续失败 %d 次: %s\n", m.opts.Plugin, shortID(id), failures, lastError)
+		}
+		m.persist(r)
+	}
+}
+
+// Expire 将账号标记为过期并清理会话数据
+func (m *Manager) Expire(r Record) error {
+	m.mu.Lock()
+	r.Base().Status = StatusExpired
+	if m.opts.OnExpire != nil {
+		m.opts.OnExpire(r)
+	}
+	m.mu.Unlock()
+	return m.Save(r)
+}
+
+// snapshot 在锁内复制账号的公共字段
+func (m *Manager) snapshot(r Record) Account {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return *r.Base()
+}
+
+// HashID 由登录标识计算账号ID
+func (m *Manager) HashID(identifier string) (string, bool) {
+	if m.opts.HashID == nil || identifier == "" {
+		return "", false
+	}
+	return m.opts.HashID(identifier), true
+}
+
+// HandleAction 执行插件注册的账号操作，操作不存在时返回false
+func (m *Manager) HandleAction(c *gin.Context, id, action string, params map[string]interface{}) bool {
+	handler, ok := m.opts.Actions[action]
+	if !ok {
+		return false
+	}
+	handler(c, id, params)
+	return true
+}
+
+// ActionNames 插件支持的账号操作名称
+func (m *Manager) ActionNames() []string {
+	names := make([]string, 0, len(m.opts.Actions))
+	for name := range m.opts.Actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ============ 保活与清理 ============
+
+// keepAliveLoop 定期为已登录账号执行保活
+func (m *Manager) keepAliveLoop() {
+	time.Sleep(m.opts.KeepAliveDelay)
+	m.KeepAliveAll()
+
+	ticker := time.NewTicker(m.opts.KeepAliveInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.KeepAliveAll()
+	}
+}
+
+// KeepAliveAll 立即为所有已登录且未过期的账号执行一次保活，返回执行的账号数
+func (m *Manager) KeepAliveAll() int {
+	if m.opts.KeepAlive == nil {
+		return 0
+	}
+	now := time.Now()
+	var targets []Record
+	for _, r := range m.All() {
+		a := m.snapshot(r)
+		if a.Status == StatusActive && (a.ExpireAt.IsZero() || now.Before(a.ExpireAt)) {
+			targets = append(targets, r)
+		}
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, keepAliveConcurrency)
+	for _, r := range targets {
+		wg.Add(1)
+		go func(r Record) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			m.Report(r.Base().Hash, m.opts.KeepAlive(r))
+		}(r)
+	}
+	wg.Wait()
+	return len(targets)
+}
+
+// cleanupLoop 每天清理过期账号、标记长期未使用的账号
+func (m *Manager) cleanupLoop() {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		deleted, marked := m.Cleanup()
+		if deleted > 0 || marked > 0 {
+			fmt.Printf("[%s] 清理任务完成: 删除 %d 个过期用户, 标记 %d 个不活跃用户\n", m.opts.Plugin, deleted, marked)
+		}
+	}
+}
+
+// Cleanup 删除过期且超过30天未访问的账号，将超过90天未访问的账号标记为过期
+func (m *Manager) Cleanup() (deleted, marked int) {
+	now := time.Now()
+	for _, r := range m.All() {
+		a := m.snapshot(r)
+		if a.Status == StatusExpired {
+			if now.Sub(a.LastAccessAt) > expiredRetention && m.Delete(a.Hash) == nil {
+				deleted++
+			}
+			continue
+		}
+		if now.Sub(a.LastAccessAt) > inactiveAfter && m.Expire(r) == nil {
+			marked++
+		}
+	}
+	return deleted, marked
+}
+
+// ============ 账号信息 ============
+
+// Info 账号信息（不含Cookie、密码等敏感数据）
+type Info struct {
+	Plugin       string                 `json:"plugin"`
+	ID           string                 `json:"id"`
+	Status       string                 `json:"status"`
+	Health       string                 `json:"health"`
+	Failures     int                    `json:"failures"`
+	LastError    string                 `json:"last_error,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+	LoginAt      time.Time              `json:"login_at"`
+	ExpireAt     time.Time              `json:"expire_at"`
+	LastAccessAt time.Time              `json:"last_access_at"`
+	LastCheckAt  time.Time              `json:"last_check_at"`
+	LastUsedAt   time.Time              `json:"last_used_at"`
+	RecentUses   int                    `json:"recent_uses"` // 最近一分钟被选中的次数
+	Details      map[string]interface{} `json:"details,omitempty"`
+}
+
+// Summary 插件的账号统计
+type Summary struct {
+	Plugin    string   `json:"plugin"`
+	Total     int      `json:"total"`
+	Active    int      `json:"active"`
+	Pending   int      `json:"pending"`
+	Expired   int      `json:"expired"`
+	Failing   int      `json:"failing"`
+	RateLimit int      `json:"rate_limit"` // 每个账号每分钟最多被选中的次数，0表示不限制
+	Actions   []string `json:"actions"`
+	Accounts  []Info   `json:"accounts,omitempty"`
+}
+
+// Info 获取账号信息
+func (m *Manager) Info(r Record) Info {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a := r.Base()
+	recent := len(m.recentUsageLocked(a.Hash, time.Now()))
+
+	info := Info{
+		Plugin:       m.opts.Plugin,
+		ID:           a.Hash,
+		Status:       a.Status,
+		Health:       a.Health,
+		Failures:     a.Failures,
+		LastError:    a.LastError,
+		CreatedAt:    a.CreatedAt,
+		LoginAt:      a.LoginAt,
+		ExpireAt:     a.ExpireAt,
+		LastAccessAt: a.LastAccessAt,
+		LastCheckAt:  a.LastCheckAt,
+		LastUsedAt:   a.LastUsedAt,
+		RecentUses:   recent,
+	}
+	if info.Health == "" {
+		info.Health = HealthUnknown
+	}
+	if m.opts.Details != nil {
+		info.Details = m.opts.Details(r)
+	}
+	return info
+}
+
+// Summary 获取插件的账号统计，withAccounts为true时包含每个账号的信息（按创建时间排序）
+func (m *Manager) Summary(withAccounts bool) Summary {
+	summary := Summary{Plugin: m.opts.Plugin, Actions: m.ActionNames()}
+	if config.AppConfig != nil {
+		summary.RateLimit = config.AppConfig.AccountRateLimit
+	}
+
+	records := m.All()
+	snapshots := make(map[Record]Account, len(records))
+	for _, r := range records {
+		snapshots[r] = m.snapshot(r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return snapshots[records[i]].CreatedAt.Before(snapshots[records[j]].CreatedAt)
+	})
+	for _, r := range records {
+		a := snapshots[r]
+		summary.Total++
+		switch a.Status {
+		case StatusActive:
+			summary.Active++
+		case StatusExpired:
+			summary.Expired++
+		default:
+			summary.Pending++
+		}
+		if a.Health == HealthFailing {
+			summary.Failing++
+		}
+		if withAccounts {
+			summary.Accounts = append(summary.Accounts, m.Info(r))
+		}
+	}
+	return summary
+}
+
+// shortID 日志中显示的账号ID前缀
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8] + "..."
+	}
+	return id
+}
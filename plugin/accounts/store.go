@@ -0,0 +1,353 @@
+package accounts
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"pansou/config"
+	"pansou/util/json"
+	"pansou/util/sharedstore"
+)
+
+const (
+	// 账号数据目录（相对缓存目录）
+	accountsDirName = "accounts"
+	// 未配置 ACCOUNTS_SECRET_KEY 时自动生成的密钥文件
+	secretKeyFileName = "secret.key"
+)
+
+// sealedRecord 加密后的账号文件
+type sealedRecord struct {
+	Version int    `json:"v"`
+	Data    string `json:"data"` // base64(nonce + AES-GCM密文)
+}
+
+var (
+	secretOnce sync.Once
+	secretKey  []byte
+	secretErr  error
+)
+
+// cacheDir 缓存目录
+func cacheDir() string {
+	if config.AppConfig != nil && config.AppConfig.CachePath != "" {
+		return config.AppConfig.CachePath
+	}
+	return "./cache"
+}
+
+// accountsDir 插件的账号目录
+func accountsDir(pluginName string) string {
+	return filepath.Join(cacheDir(), accountsDirName, pluginName)
+}
+
+// ensureDir 创建只有当前用户可访问的目录
+func ensureDir(dir string) error {
+	return os.MkdirAll(dir, 0700)
+}
+
+// getSecretKey 获取账号数据的加密密钥
+// 优先使用 ACCOUNTS_SECRET_KEY（多实例共享账号时必须配置相同的值），否则使用缓存目录中自动生成的随机密钥
+func getSecretKey() ([]byte, error) {
+	secretOnce.Do(func() {
+		if config.AppConfig != nil && config.AppConfig.AccountsSecretKey != "" {
+			sum := sha256.Sum256([]byte(config.AppConfig.AccountsSecretKey))
+			secretKey = sum[:]
+			return
+		}
+
+		path := filepath.Join(cacheDir(), accountsDirName, secretKeyFileName)
+		if data, err := os.ReadFile(path); err == nil {
+			key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+			if err == nil && len(key) == 32 {
+				secretKey = key
+				return
+			}
+			secretErr = fmt.Errorf("密钥文件 %s 格式无效", path)
+			return
+		}
+
+		key := make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			secretErr = err
+			return
+		}
+		if err := ensureDir(filepath.Dir(path)); err != nil {
+			secretErr = err
+			return
+		}
+		if err := os.WriteFile(path, []byte(hex.EncodeToString(key)), 0600); err != nil {
+			secretErr = err
+			return
+		}
+		secretKey = key
+	})
+	return secretKey, secretErr
+}
+
+// newGCM 使用账号密钥创建AES-GCM
+func newGCM() (cipher.AEAD, error) {
+	key, err := getSecretKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal 加密序列化后的账号记录
+func seal(plain []byte) ([]byte, error) {
+	gcm, err := newGCM()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	sealed := gcm.Seal(nonce, nonce, plain, nil)
+	return json.Marshal(sealedRecord{Version: 1, Data: base64.StdEncoding.EncodeToString(sealed)})
+}
+
+// decode 解码账号文件，兼容旧版未加密的账号文件
+func (m *Manager) decode(data []byte) (Record, error) {
+	var sealed sealedRecord
+	if err := json.Unmarshal(data, &sealed); err == nil && sealed.Data != "" {
+		ciphertext, err := base64.StdEncoding.DecodeString(sealed.Data)
+		if err != nil {
+			return nil, err
+		}
+		gcm, err := newGCM()
+		if err != nil {
+			return nil, err
+		}
+		if len(ciphertext) < gcm.NonceSize() {
+			return nil, fmt.Errorf("ciphertext too short")
+		}
+		nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+		if data, err = gcm.Open(nil, nonce, ciphertext, nil); err != nil {
+			return nil, fmt.Errorf("解密失败（密钥是否一致？）: %w", err)
+		}
+	}
+
+	r := m.opts.New()
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	a := r.Base()
+	if !ValidID(a.Hash) {
+		return nil, fmt.Errorf("账号ID无效")
+	}
+	if a.Health == "" {
+		a.Health = HealthUnknown
+	}
+	return r, nil
+}
+
+// ValidID 判断账号ID是否有效（同时用作文件名，只允许字母、数字、下划线和短横线）
+func ValidID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// importLegacy 导入旧版账号目录（<缓存目录>/<插件名>_users）中的未加密账号，导入后覆盖并删除旧的明文文件
+// 无法解析或删除的文件保留在旧目录中；加密写入失败时保留全部旧文件，下次启动重试
+func (m *Manager) importLegacy() {
+	if m.opts.LegacyDir == "" {
+		return
+	}
+	legacyDir := filepath.Join(cacheDir(), m.opts.LegacyDir)
+	entries, err := os.ReadDir(legacyDir)
+	if err != nil {
+		return
+	}
+
+	var imported []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		// 已有加密文件的账号无需重复导入，只删除旧文件
+		if _, err := os.Stat(filepath.Join(m.dir, entry.Name())); err == nil {
+			imported = append(imported, entry.Name())
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(legacyDir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		r, err := m.decode(data)
+		if err != nil {
+			fmt.Printf("[%s] 跳过无法解析的旧账号文件 %s: %v\n", m.opts.Plugin, entry.Name(), err)
+			continue
+		}
+		id, plain, err := m.marshal(r)
+		if err == nil {
+			_, err = m.writeSealed(id, plain)
+		}
+		if err != nil {
+			fmt.Printf("[%s] 导入旧账号文件 %s 失败: %v\n", m.opts.Plugin, entry.Name(), err)
+			return
+		}
+		imported = append(imported, entry.Name())
+	}
+
+	removed := 0
+	for _, name := range imported {
+		if err := removePlaintext(filepath.Join(legacyDir, name)); err != nil {
+			fmt.Printf("[%s] 删除旧账号文件 %s 失败: %v\n", m.opts.Plugin, name, err)
+			continue
+		}
+		removed++
+	}
+	kept := len(entries) - removed
+	if kept == 0 {
+		os.Remove(legacyDir)
+	}
+	fmt.Printf("[%s] 已从 %s 导入 %d 个账号，删除 %d 个旧的明文账号文件（旧目录剩余 %d 个文件）\n",
+		m.opts.Plugin, m.opts.LegacyDir, len(imported), removed, kept)
+}
+
+// removePlaintext 用零覆盖旧的明文账号文件后删除
+func removePlaintext(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err == nil {
+		_, err = f.Write(make([]byte, info.Size()))
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// load 与共享存储同步后加载账号目录中的所有账号
+func (m *Manager) load() {
+	// 多实例部署时先与共享存储同步账号文件
+	sharedstore.SyncAccountFiles(m.opts.Plugin, m.dir)
+
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return
+	}
+
+	loaded, active := 0, 0
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(m.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		r, err := m.decode(data)
+		if err != nil {
+			fmt.Printf("[%s] 跳过无法解析的账号文件 %s: %v\n", m.opts.Plugin, entry.Name(), err)
+			continue
+		}
+		m.records[r.Base().Hash] = r
+		loaded++
+		if r.Base().Status == StatusActive {
+			active++
+		}
+	}
+	fmt.Printf("[%s] 已加载 %d 个账号（已登录 %d 个）\n", m.opts.Plugin, loaded, active)
+}
+
+// loadShared 从共享存储读取账号
+func (m *Manager) loadShared(id string) (Record, bool) {
+	data, found := sharedstore.LoadAccount(m.opts.Plugin, id)
+	if !found {
+		return nil, false
+	}
+	r, err := m.decode(data)
+	if err != nil || r.Base().Hash != id {
+		return nil, false
+	}
+	return r, true
+}
+
+// marshal 在管理器锁内序列化账号，得到与并发修改隔离的副本
+func (m *Manager) marshal(r Record) (string, []byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	plain, err := json.Marshal(r)
+	return r.Base().Hash, plain, err
+}
+
+// writeSealed 加密写入账号文件，返回写入的内容
+func (m *Manager) writeSealed(id string, plain []byte) ([]byte, error) {
+	data, err := seal(plain)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(m.dir, id+".json")
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// persist 持久化账号到文件并同步到共享存储
+func (m *Manager) persist(r Record) error {
+	id, plain, err := m.marshal(r)
+	var data []byte
+	if err == nil {
+		data, err = m.writeSealed(id, plain)
+	}
+	if err != nil {
+		fmt.Printf("[%s] 保存账号失败: %v\n", m.opts.Plugin, err)
+		return err
+	}
+
+	// 同步到共享存储（未配置时忽略，失败不影响本地保存）
+	if err := sharedstore.SaveAccount(m.opts.Plugin, id, data); err != nil {
+		fmt.Printf("[%s] 同步账号到共享存储失败: %v\n", m.opts.Plugin, err)
+	}
+	return nil
+}
+
+// remove 删除账号文件和共享存储中的数据
+func (m *Manager) remove(id string) error {
+	sharedstore.DeleteAccount(m.opts.Plugin, id)
+	err := os.Remove(filepath.Join(m.dir, id+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
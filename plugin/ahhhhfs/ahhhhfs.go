@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"regexp"
 	"strings"
 	"sync"
@@ -112,7 +113,7 @@ func createOptimizedHTTPClient() *http.Client {
 	}
 
 	return &http.Client{
-		Transport: transport,
+		Transport: util.NewPluginTransport(pluginName, transport),
 		Timeout:   DefaultTimeout,
 	}
 }
@@ -150,7 +151,7 @@ func (p *AhhhhfsAsyncPlugin) searchImpl(client *http.Client, keyword string, ext
 
 	// 使用优化的客户端
 	if p.optimizedClient != nil {
-		client = p.optimizedClient
+		client = util.BudgetedClient(p.optimizedClient, ext)
 	}
 
 	// 1. 构建搜索URL
@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"regexp"
 	"strings"
 	"sync"
@@ -87,7 +88,7 @@ func createOptimizedHTTPClient() *http.Client {
 	}
 
 	return &http.Client{
-		Transport: transport,
+		Transport: util.NewPluginTransport("aikanzy", transport),
 		Timeout:   defaultTimeout * time.Second,
 	}
 }
@@ -128,7 +129,7 @@ func (p *AikanzyAsyncPlugin) SearchWithResult(keyword string, ext map[string]int
 func (p *AikanzyAsyncPlugin) doSearch(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	// 使用优化的客户端
 	if p.optimizedClient != nil {
-		client = p.optimizedClient
+		client = util.BudgetedClient(p.optimizedClient, ext)
 	}
 	
 	// 对关键词进行URL编码
@@ -14,6 +14,7 @@ import (
 
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 var (
@@ -107,14 +108,14 @@ func newHTTPClient() *http.Client {
 		ForceAttemptHTTP2:     true,
 	}
 	return &http.Client{
-		Transport: transport,
+		Transport: util.NewPluginTransport(pluginName, transport),
 		Timeout:   searchTimeout,
 	}
 }
 
 func (p *AlupanPlugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	if p.client != nil {
-		client = p.client
+		client = util.BudgetedClient(p.client, ext)
 	}
 
 	searchURL := fmt.Sprintf("https://www.aliupan.com/?s=%s", url.QueryEscape(keyword))
@@ -0,0 +1,375 @@
+package plugin
+
+import (
+	"container/list"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"pansou/config"
+	"pansou/model"
+)
+
+const (
+	// 插件内存缓存分片数
+	apiCacheShardCount = 16
+	// 淘汰时从最久未使用的一端取样的缓存项数，淘汰其中访问频率最低的一项
+	apiCacheEvictionSamples = 5
+	// 访问计数最多记录的键数，超过时所有计数减半（近似TinyLFU的重置）
+	maxAccessCounterKeys = 50000
+	// 内存压力检查间隔
+	memoryPressureCheckInterval = 30 * time.Second
+)
+
+// 估算缓存项大小时使用的固定开销
+var (
+	searchResultOverhead = int64(unsafe.Sizeof(model.SearchResult{}))
+	linkOverhead         = int64(unsafe.Sizeof(model.Link{}))
+	apiCacheItemOverhead = int64(unsafe.Sizeof(apiCacheItem{}) + unsafe.Sizeof(list.Element{}))
+)
+
+// apiCacheItem 缓存项及其估算大小
+type apiCacheItem struct {
+	key   string
+	value cachedResponse
+	size  int64
+}
+
+// apiCacheShard 缓存分片，按最近使用顺序排列
+type apiCacheShard struct {
+	mu    sync.Mutex
+	items map[string]*list.Element
+	lru   *list.List
+	bytes int64
+}
+
+// apiCache 插件内存缓存：分片LRU，按结果大小计入字节预算
+// 超出预算时从最久未使用的几项中淘汰访问频率最低的一项，内存压力较大时主动缩减
+type apiCache struct {
+	shards            [apiCacheShardCount]*apiCacheShard
+	evictions         int64
+	pressureEvictions int64
+	rejected          int64
+}
+
+// newAPICache 创建插件内存缓存
+func newAPICache() *apiCache {
+	c := &apiCache{}
+	for i := range c.shards {
+		c.shards[i] = &apiCacheShard{
+			items: make(map[string]*list.Element),
+			lru:   list.New(),
+		}
+	}
+	return c
+}
+
+// apiCacheMaxBytes 插件内存缓存的字节预算
+func apiCacheMaxBytes() int64 {
+	if config.AppConfig != nil && config.AppConfig.PluginCacheMaxMB > 0 {
+		return int64(config.AppConfig.PluginCacheMaxMB) * 1024 * 1024
+	}
+	return 64 * 1024 * 1024
+}
+
+// shardBudget 单个分片的字节预算
+func shardBudget() int64 {
+	return apiCacheMaxBytes() / apiCacheShardCount
+}
+
+// shard 键所在的分片
+func (c *apiCache) shard(key string) *apiCacheShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return c.shards[h.Sum32()%apiCacheShardCount]
+}
+
+// estimateCachedResponseSize 估算缓存项占用的内存
+func estimateCachedResponseSize(key string, value cachedResponse) int64 {
+	size := apiCacheItemOverhead + int64(len(key))
+	for _, r := range value.Results {
+		size += searchResultOverhead + int64(len(r.MessageID)+len(r.UniqueID)+len(r.Channel)+len(r.Title)+len(r.Content))
+		for _, link := range r.Links {
+			size += linkOverhead + int64(len(link.Type)+len(link.URL)+len(link.Password)+len(link.WorkTitle))
+		}
+		for _, tag := range r.Tags {
+			size += 16 + int64(len(tag))
+		}
+		for _, image := range r.Images {
+			size += 16 + int64(len(image))
+		}
+	}
+	return size
+}
+
+// Load 读取缓存项
+func (c *apiCache) Load(key string) (cachedResponse, bool) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return cachedResponse{}, false
+	}
+	s.lru.MoveToFront(elem)
+	return elem.Value.(*apiCacheItem).value, true
+}
+
+// Store 写入缓存项，超出分片预算时淘汰其他缓存项；单项超过分片预算时不缓存
+func (c *apiCache) Store(key string, value cachedResponse) {
+	item := &apiCacheItem{key: key, value: value, size: estimateCachedResponseSize(key, value)}
+	budget := shardBudget()
+
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		s.remove(elem)
+	}
+	if item.size > budget {
+		atomic.AddInt64(&c.rejected, 1)
+		return
+	}
+	s.items[key] = s.lru.PushFront(item)
+	s.bytes += item.size
+
+	for s.bytes > budget && s.lru.Len() > 1 {
+		s.evictOne()
+		atomic.AddInt64(&c.evictions, 1)
+	}
+}
+
+// Touch 记录一次缓存项访问
+func (c *apiCache) Touch(key string) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		item := elem.Value.(*apiCacheItem)
+		item.value.LastAccess = time.Now()
+		item.value.AccessCount++
+		s.lru.MoveToFront(elem)
+	}
+}
+
+// Delete 删除缓存项
+func (c *apiCache) Delete(key string) {
+	c.LoadAndDelete(key)
+}
+
+// LoadAndDelete 删除缓存项并返回删除前的值
+func (c *apiCache) LoadAndDelete(key string) (cachedResponse, bool) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return cachedResponse{}, false
+	}
+	s.remove(elem)
+	return elem.Value.(*apiCacheItem).value, true
+}
+
+// Range 遍历所有缓存项（遍历的是各分片的快照，回调中可以修改缓存）
+func (c *apiCache) Range(f func(key string, value cachedResponse) bool) {
+	for _, s := range c.shards {
+		s.mu.Lock()
+		snapshot := make([]*apiCacheItem, 0, len(s.items))
+		for elem := s.lru.Front(); elem != nil; elem = elem.Next() {
+			snapshot = append(snapshot, elem.Value.(*apiCacheItem))
+		}
+		s.mu.Unlock()
+
+		for _, item := range snapshot {
+			if !f(item.key, item.value) {
+				return
+			}
+		}
+	}
+}
+
+// Size 缓存项数量和估算占用字节数
+func (c *apiCache) Size() (int, int64) {
+	items := 0
+	var bytes int64
+	for _, s := range c.shards {
+		s.mu.Lock()
+		items += len(s.items)
+		bytes += s.bytes
+		s.mu.Unlock()
+	}
+	return items, bytes
+}
+
+// Shrink 将每个分片缩减到当前占用的ratio倍，返回淘汰数量
+func (c *apiCache) Shrink(ratio float64) int {
+	evicted := 0
+	for _, s := range c.shards {
+		s.mu.Lock()
+		target := int64(float64(s.bytes) * ratio)
+		for s.bytes > target && s.lru.Len() > 0 {
+			s.evictOne()
+			evicted++
+		}
+		s.mu.Unlock()
+	}
+	atomic.AddInt64(&c.pressureEvictions, int64(evicted))
+	return evicted
+}
+
+// remove 删除缓存项（调用方需持有分片锁）
+func (s *apiCacheShard) remove(elem *list.Element) {
+	item := elem.Value.(*apiCacheItem)
+	s.lru.Remove(elem)
+	delete(s.items, item.key)
+	s.bytes -= item.size
+}
+
+// evictOne 从最久未使用的几项中淘汰访问频率最低的一项（调用方需持有分片锁）
+func (s *apiCacheShard) evictOne() {
+	var victim *list.Element
+	victimCount := math.MaxInt32
+	elem := s.lru.Back()
+	for i := 0; i < apiCacheEvictionSamples && elem != nil; i++ {
+		if count := cacheAccessCount.Get(elem.Value.(*apiCacheItem).key); count < victimCount {
+			victim = elem
+			victimCount = count
+		}
+		elem = elem.Prev()
+	}
+	if victim != nil {
+		s.remove(victim)
+	}
+}
+
+// accessCounter 有上限的访问计数
+// 记录的键数超过上限时所有计数减半并删除归零的项，近期访问多的键得以保留
+type accessCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// newAccessCounter 创建访问计数
+func newAccessCounter() *accessCounter {
+	return &accessCounter{counts: make(map[string]int)}
+}
+
+// Increment 访问计数加一
+func (a *accessCounter) Increment(key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.counts[key]; !ok {
+		for len(a.counts) >= maxAccessCounterKeys {
+			a.decayLocked()
+		}
+	}
+	a.counts[key]++
+}
+
+// Get 获取访问计数
+func (a *accessCounter) Get(key string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.counts[key]
+}
+
+// Delete 删除访问计数
+func (a *accessCounter) Delete(key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.counts, key)
+}
+
+// Decay 所有计数减半并删除归零的项
+func (a *accessCounter) Decay() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.decayLocked()
+}
+
+// decayLocked 所有计数减半（调用方需持有锁）
+func (a *accessCounter) decayLocked() {
+	for key, count := range a.counts {
+		if count/2 <= 0 {
+			delete(a.counts, key)
+		} else {
+			a.counts[key] = count / 2
+		}
+	}
+}
+
+// Range 遍历访问计数（遍历的是快照）
+func (a *accessCounter) Range(f func(key string, count int) bool) {
+	a.mu.Lock()
+	snapshot := make(map[string]int, len(a.counts))
+	for key, count := range a.counts {
+		snapshot[key] = count
+	}
+	a.mu.Unlock()
+
+	for key, count := range snapshot {
+		if !f(key, count) {
+			return
+		}
+	}
+}
+
+// Len 记录的键数
+func (a *accessCounter) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.counts)
+}
+
+// memoryPressureRatio 内存用量超过软内存限制的该比例时缩减插件内存缓存
+func memoryPressureRatio() float64 {
+	if config.AppConfig != nil && config.AppConfig.OptimizeMemory {
+		return 0.8
+	}
+	return 0.9
+}
+
+// startMemoryPressureMonitor 定期检查内存用量，接近软内存限制（MEMORY_LIMIT_MB或GOMEMLIMIT）时缩减插件内存缓存
+func startMemoryPressureMonitor() {
+	go func() {
+		ticker := time.NewTicker(memoryPressureCheckInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			relieveMemoryPressure()
+		}
+	}()
+}
+
+// relieveMemoryPressure 内存用量接近软内存限制时淘汰一半插件内存缓存，返回淘汰数量
+func relieveMemoryPressure() int {
+	limit := debug.SetMemoryLimit(-1)
+	if limit <= 0 || limit == math.MaxInt64 {
+		return 0
+	}
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	used := ms.Sys - ms.HeapReleased
+	if float64(used) < float64(limit)*memoryPressureRatio() {
+		return 0
+	}
+
+	evicted := apiResponseCache.Shrink(0.5)
+	if config.AppConfig != nil && config.AppConfig.OptimizeMemory {
+		debug.FreeOSMemory()
+	}
+	fmt.Printf("[Cache] 内存接近软限制（已用 %dMB / 限制 %dMB），插件内存缓存淘汰 %d 项\n",
+		used/1024/1024, limit/1024/1024, evicted)
+	return evicted
+}
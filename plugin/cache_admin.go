@@ -0,0 +1,173 @@
+package plugin
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"pansou/config"
+)
+
+// APICacheEntry 插件内存缓存（apiResponseCache）项概要
+type APICacheEntry struct {
+	Key         string    `json:"key"`
+	Plugin      string    `json:"plugin"`
+	Keyword     string    `json:"keyword"`
+	Results     int       `json:"results"`
+	Complete    bool      `json:"complete"`
+	CachedAt    time.Time `json:"cached_at"`
+	Expiry      time.Time `json:"expiry"`
+	Expired     bool      `json:"expired"`
+	LastAccess  time.Time `json:"last_access"`
+	AccessCount int       `json:"access_count"`
+}
+
+// APICacheStats 插件内存缓存统计
+type APICacheStats struct {
+	Items            int     `json:"items"`
+	Expired          int     `json:"expired"`
+	Results          int     `json:"results"`
+	Hits             int64   `json:"hits"`
+	Misses           int64   `json:"misses"`
+	HitRate          float64 `json:"hit_rate"`
+	AsyncCompletions int64   `json:"async_completions"`
+
+	Bytes             int64 `json:"bytes"`              // 估算占用字节数
+	MaxBytes          int64 `json:"max_bytes"`          // 字节预算
+	Evictions         int64 `json:"evictions"`          // 超出预算淘汰的缓存项数
+	PressureEvictions int64 `json:"pressure_evictions"` // 内存压力下淘汰的缓存项数
+	Rejected          int64 `json:"rejected"`           // 单项超过分片预算未缓存的次数
+	TrackedKeys       int   `json:"tracked_keys"`       // 访问计数记录的键数
+}
+
+// apiCacheTTL 插件内存缓存有效期
+func apiCacheTTL() time.Duration {
+	if config.AppConfig != nil && config.AppConfig.AsyncCacheTTLHours > 0 {
+		return time.Duration(config.AppConfig.AsyncCacheTTLHours) * time.Hour
+	}
+	return defaultCacheTTL
+}
+
+// splitAPICacheKey 拆分缓存键（格式为 插件名:关键词）
+func splitAPICacheKey(key string) (string, string) {
+	if idx := strings.Index(key, ":"); idx >= 0 {
+		return key[:idx], key[idx+1:]
+	}
+	return "", key
+}
+
+// matchAPICacheEntry 判断缓存项是否匹配插件名和关键词（为空表示不限）
+func matchAPICacheEntry(entry APICacheEntry, pluginName, keyword string) bool {
+	if pluginName != "" && !strings.EqualFold(entry.Plugin, pluginName) {
+		return false
+	}
+	if keyword != "" && strings.ToLower(strings.TrimSpace(entry.Keyword)) != strings.ToLower(strings.TrimSpace(keyword)) {
+		return false
+	}
+	return true
+}
+
+// ListAPICache 列出插件内存缓存项，按缓存时间倒序
+func ListAPICache(pluginName, keyword string) []APICacheEntry {
+	now := time.Now()
+	ttl := apiCacheTTL()
+	entries := make([]APICacheEntry, 0)
+
+	apiResponseCache.Range(func(key string, cached cachedResponse) bool {
+		name, kw := splitAPICacheKey(key)
+		entry := APICacheEntry{
+			Key:         key,
+			Plugin:      name,
+			Keyword:     kw,
+			Results:     len(cached.Results),
+			Complete:    cached.Complete,
+			CachedAt:    cached.Timestamp,
+			Expiry:      cached.Timestamp.Add(ttl),
+			Expired:     now.Sub(cached.Timestamp) >= ttl,
+			LastAccess:  cached.LastAccess,
+			AccessCount: cached.AccessCount,
+		}
+		if matchAPICacheEntry(entry, pluginName, keyword) {
+			entries = append(entries, entry)
+		}
+		return true
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CachedAt.After(entries[j].CachedAt)
+	})
+	return entries
+}
+
+// InvalidateAPICache 按键、插件名或关键词删除插件内存缓存项，返回删除数量
+// key不为空时只按键删除；插件名和关键词全部为空时不删除任何项
+func InvalidateAPICache(key, pluginName, keyword string) int {
+	if key != "" {
+		if _, ok := apiResponseCache.LoadAndDelete(key); ok {
+			cacheAccessCount.Delete(key)
+			return 1
+		}
+		return 0
+	}
+	if pluginName == "" && keyword == "" {
+		return 0
+	}
+
+	removed := 0
+	for _, entry := range ListAPICache(pluginName, keyword) {
+		apiResponseCache.Delete(entry.Key)
+		cacheAccessCount.Delete(entry.Key)
+		removed++
+	}
+	return removed
+}
+
+// PurgeExpiredAPICache 立即清理已过期的插件内存缓存项，返回清理数量
+func PurgeExpiredAPICache() int {
+	now := time.Now()
+	ttl := apiCacheTTL()
+	purged := 0
+
+	apiResponseCache.Range(func(key string, cached cachedResponse) bool {
+		if now.Sub(cached.Timestamp) >= ttl {
+			apiResponseCache.Delete(key)
+			cacheAccessCount.Delete(key)
+			purged++
+		}
+		return true
+	})
+	return purged
+}
+
+// GetAPICacheStats 获取插件内存缓存统计
+func GetAPICacheStats() APICacheStats {
+	now := time.Now()
+	ttl := apiCacheTTL()
+	stats := APICacheStats{
+		Hits:             atomic.LoadInt64(&cacheHits),
+		Misses:           atomic.LoadInt64(&cacheMisses),
+		AsyncCompletions: atomic.LoadInt64(&asyncCompletions),
+
+		MaxBytes:          apiCacheMaxBytes(),
+		Evictions:         atomic.LoadInt64(&apiResponseCache.evictions),
+		PressureEvictions: atomic.LoadInt64(&apiResponseCache.pressureEvictions),
+		Rejected:          atomic.LoadInt64(&apiResponseCache.rejected),
+		TrackedKeys:       cacheAccessCount.Len(),
+	}
+
+	apiResponseCache.Range(func(key string, cached cachedResponse) bool {
+		stats.Items++
+		stats.Results += len(cached.Results)
+		if now.Sub(cached.Timestamp) >= ttl {
+			stats.Expired++
+		}
+		return true
+	})
+	_, stats.Bytes = apiResponseCache.Size()
+
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
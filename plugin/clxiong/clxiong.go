@@ -14,6 +14,7 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 const (
@@ -106,7 +107,8 @@ func (p *ClxiongPlugin) getSearchID(keyword string) (string, error) {
 	}
 
 	client := &http.Client{
-		Timeout: 30 * time.Second,
+		Transport: util.NewPluginTransport("clxiong", nil),
+		Timeout:   30 * time.Second,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			// 不自动跟随重定向，我们需要手动处理
 			return http.ErrUseLastResponse
@@ -196,7 +198,7 @@ func (p *ClxiongPlugin) getSearchResults(searchID, keyword string) ([]model.Sear
 	// 构建结果页URL
 	resultURL := fmt.Sprintf("%s/e/search/result/?searchid=%s", BaseURL, searchID)
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := &http.Client{Transport: util.NewPluginTransport("clxiong", nil), Timeout: 30 * time.Second}
 
 	req, err := http.NewRequest("GET", resultURL, nil)
 	if err != nil {
@@ -462,7 +464,7 @@ func (p *ClxiongPlugin) fetchDetailPageInfo(detailURL string, movieTitle string)
 		log.Printf("[CLXIONG] 正在获取详情页信息: %s", detailURL)
 	}
 
-	client := &http.Client{Timeout: 20 * time.Second}
+	client := &http.Client{Transport: util.NewPluginTransport("clxiong", nil), Timeout: 20 * time.Second}
 
 	req, err := http.NewRequest("GET", detailURL, nil)
 	if err != nil {
@@ -15,6 +15,7 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 const (
@@ -71,7 +72,8 @@ func (p *DdysPlugin) Description() string {
 
 // Search 搜索接口
 func (p *DdysPlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
-	return p.searchImpl(&http.Client{Timeout: 30 * time.Second}, keyword, ext)
+	client := &http.Client{Transport: util.NewPluginTransport(PluginName, nil), Timeout: 30 * time.Second}
+	return p.searchImpl(util.BudgetedClient(client, ext), keyword, ext)
 }
 
 // searchImpl 搜索实现
@@ -11,8 +11,6 @@ import (
 	"regexp"
 	"strings"
 	"time"
-
-	cloudscraper "github.com/Advik-B/cloudscraper/lib"
 )
 
 // 预编译的正则表达式 - 用于从blurb中提取网盘链接
@@ -48,7 +46,6 @@ const (
 // DiscourseAsyncPlugin 是 Discourse 论坛的异步搜索插件实现
 type DiscourseAsyncPlugin struct {
 	*plugin.BaseAsyncPlugin
-	scraper *cloudscraper.Scraper
 }
 
 // SearchResponse 搜索API响应结构
@@ -128,20 +125,14 @@ func init() {
 
 // NewDiscourseAsyncPlugin 创建一个新的 Discourse 异步插件实例
 func NewDiscourseAsyncPlugin() *DiscourseAsyncPlugin {
-	// 创建 cloudscraper 实例
-	scraper, err := cloudscraper.New()
-	if err != nil {
-		// 如果创建失败，记录错误但不阻止插件注册
-		fmt.Printf("[%s] Failed to create cloudscraper: %v\n", pluginName, err)
-		return &DiscourseAsyncPlugin{
-			BaseAsyncPlugin: plugin.NewBaseAsyncPlugin(pluginName, defaultPriority),
-		}
-	}
-
-	return &DiscourseAsyncPlugin{
+	p := &DiscourseAsyncPlugin{
 		BaseAsyncPlugin: plugin.NewBaseAsyncPlugin(pluginName, defaultPriority),
-		scraper:         scraper,
 	}
+	// linux.do 受 Cloudflare 保护，使用共享的反爬会话
+	p.EnableAntiBot()
+	// 请求按 linux.do 构造，站点迁移或配置镜像后由插件客户端改写
+	p.SetBaseURLs("https://linux.do")
+	return p
 }
 
 // Search 执行搜索并返回结果（兼容性方法）
@@ -161,11 +152,6 @@ func (p *DiscourseAsyncPlugin) SearchWithResult(keyword string, ext map[string]i
 
 // searchImpl 实现具体的搜索逻辑
 func (p *DiscourseAsyncPlugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
-	// 检查 cloudscraper 是否初始化成功
-	if p.scraper == nil {
-		return nil, fmt.Errorf("cloudscraper not initialized")
-	}
-
 	// 提取 max_pages 参数（最多获取多少页）
 	maxPages := defaultMaxPages
 	if maxPagesVal, ok := ext["max_pages"]; ok {
@@ -211,7 +197,7 @@ func (p *DiscourseAsyncPlugin) searchImpl(client *http.Client, keyword string, e
 		searchURL := fmt.Sprintf(searchURLTemplate, encodedKeyword, currentPage)
 		
 		// 发送搜索请求
-		resp, err := p.scraper.Get(searchURL)
+		resp, err := client.Get(searchURL)
 		if err != nil {
 			// 如果已经获取到一些结果，返回已有结果而不是报错
 			if len(allResults) > 0 {
@@ -463,16 +449,11 @@ func (p *DiscourseAsyncPlugin) cleanContent(content string) string {
 
 // GetTopicDetail 获取主题详情（可选实现，用于获取完整链接）
 func (p *DiscourseAsyncPlugin) GetTopicDetail(topicID int) ([]model.Link, error) {
-	// 检查 cloudscraper 是否初始化成功
-	if p.scraper == nil {
-		return nil, fmt.Errorf("cloudscraper not initialized")
-	}
-
 	// 构建详情URL
 	detailURL := fmt.Sprintf(detailURLTemplate, topicID)
 
 	// 发送详情请求
-	resp, err := p.scraper.Get(detailURL)
+	resp, err := p.GetClient().Get(detailURL)
 	if err != nil {
 		return nil, fmt.Errorf("detail request failed: %w", err)
 	}
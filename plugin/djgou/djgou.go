@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"regexp"
 	"strings"
 	"sync"
@@ -83,7 +84,7 @@ func createOptimizedHTTPClient() *http.Client {
 		IdleConnTimeout:     IdleConnTimeout,
 		DisableKeepAlives:   false,
 	}
-	return &http.Client{Transport: transport, Timeout: DefaultTimeout}
+	return &http.Client{Transport: util.NewPluginTransport("djgou", transport), Timeout: DefaultTimeout}
 }
 
 // NewDjgouPlugin 创建新的短剧狗插件
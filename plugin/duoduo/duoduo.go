@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"regexp"
 	"strings"
 	"sync"
@@ -109,7 +110,7 @@ func createOptimizedHTTPClient() *http.Client {
 	}
 
 	return &http.Client{
-		Transport: transport,
+		Transport: util.NewPluginTransport("duoduo", transport),
 		Timeout:   DefaultTimeout,
 	}
 }
@@ -148,7 +149,7 @@ func (p *DuoduoAsyncPlugin) searchImpl(client *http.Client, keyword string, ext
 
 	// 使用优化的客户端
 	if p.optimizedClient != nil {
-		client = p.optimizedClient
+		client = util.BudgetedClient(p.optimizedClient, ext)
 	}
 
 	// 1. 构建搜索URL
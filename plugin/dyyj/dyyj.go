@@ -16,6 +16,7 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 const (
@@ -120,7 +121,7 @@ func createOptimizedHTTPClient() *http.Client {
 	}
 
 	return &http.Client{
-		Transport: transport,
+		Transport: util.NewPluginTransport(PluginName, transport),
 		Timeout:   RequestTimeout,
 	}
 }
@@ -161,8 +162,9 @@ func (p *DyyjPlugin) searchImpl(client *http.Client, keyword string, ext map[str
 	}
 
 	// 第一步：执行搜索获取结果列表
-	// 使用优化的客户端（连接池）而不是传入的client
-	searchResults, err := p.executeSearch(p.optimizedClient, keyword)
+	// 使用优化的客户端（连接池）而不是传入的client，同样受本次搜索的请求预算约束
+	client = util.BudgetedClient(p.optimizedClient, ext)
+	searchResults, err := p.executeSearch(client, keyword)
 	if err != nil {
 		if p.debugMode {
 			log.Printf("[DYYJ] 执行搜索失败: %v", err)
@@ -182,7 +184,7 @@ func (p *DyyjPlugin) searchImpl(client *http.Client, keyword string, ext map[str
 
 	// 第三步：并发获取详情页链接（只对标题包含关键词的结果）
 	// 使用优化的客户端（连接池）而不是传入的client
-	finalResults := p.fetchDetailLinks(p.optimizedClient, titleFilteredResults, keyword)
+	finalResults := p.fetchDetailLinks(client, titleFilteredResults, keyword)
 
 	if p.debugMode {
 		log.Printf("[DYYJ] 最终获取到 %d 个有效结果", len(finalResults))
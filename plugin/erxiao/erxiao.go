@@ -14,6 +14,7 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 const (
@@ -94,7 +95,7 @@ func createOptimizedHTTPClient() *http.Client {
 	}
 
 	return &http.Client{
-		Transport: transport,
+		Transport: util.NewPluginTransport("erxiao", transport),
 		Timeout:   DefaultTimeout,
 	}
 }
@@ -132,7 +133,7 @@ func (p *ErxiaoAsyncPlugin) searchImpl(client *http.Client, keyword string, ext
 
 	// 使用优化的客户端
 	if p.optimizedClient != nil {
-		client = p.optimizedClient
+		client = util.BudgetedClient(p.optimizedClient, ext)
 	}
 
 	// 1. 构建搜索URL
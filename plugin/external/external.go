@@ -0,0 +1,461 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/plugin"
+	"pansou/util"
+)
+
+const (
+	// 握手超时时间
+	handshakeTimeout = 10 * time.Second
+	// 健康检查超时时间
+	pingTimeout = 5 * time.Second
+	// 重启间隔，连续失败时翻倍
+	minRestartDelay = time.Second
+	maxRestartDelay = time.Minute
+	// 未声明优先级时的默认值
+	defaultPriority = 3
+)
+
+// 插件状态
+const (
+	StateRunning = "running"
+	StateDown    = "down"
+)
+
+// namePattern 插件名称只能包含小写字母、数字和下划线（结果ID以 名称- 开头）
+var namePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+var (
+	registered   []*Plugin
+	registeredMu sync.Mutex
+)
+
+// Register 启动配置的外部插件并注册，返回注册的数量
+func Register(pm *plugin.PluginManager) int {
+	if config.AppConfig == nil {
+		return 0
+	}
+	count := 0
+	for _, cfg := range config.AppConfig.ExternalPlugins {
+		p, err := newPlugin(cfg)
+		if err != nil {
+			fmt.Printf("[external:%s] 加载失败: %v\n", label(cfg), err)
+			continue
+		}
+		if _, exists := plugin.GetPluginByName(p.Name()); exists {
+			fmt.Printf("[external:%s] 插件名称 %s 已被使用，跳过\n", label(cfg), p.Name())
+			p.shutdown()
+			continue
+		}
+		plugin.RegisterGlobalPlugin(p)
+		pm.RegisterPlugin(p)
+		go p.supervise()
+
+		registeredMu.Lock()
+		registered = append(registered, p)
+		registeredMu.Unlock()
+		count++
+	}
+	return count
+}
+
+// Shutdown 停止所有外部插件进程
+func Shutdown() {
+	registeredMu.Lock()
+	plugins := registered
+	registered = nil
+	registeredMu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, p := range plugins {
+		wg.Add(1)
+		go func(p *Plugin) {
+			defer wg.Done()
+			p.shutdown()
+		}(p)
+	}
+	wg.Wait()
+}
+
+// Status 外部插件运行状态
+type Status struct {
+	Name        string              `json:"name"`
+	Transport   string              `json:"transport"` // stdio 或 http
+	State       string              `json:"state"`
+	Restarts    int                 `json:"restarts"`
+	Active      int                 `json:"active"`
+	Concurrency int                 `json:"concurrency"`
+	ExtSchema   map[string]ExtField `json:"ext_schema,omitempty"`
+}
+
+// Statuses 获取所有外部插件的运行状态
+func Statuses() []Status {
+	registeredMu.Lock()
+	plugins := registered
+	registeredMu.Unlock()
+
+	statuses := make([]Status, 0, len(plugins))
+	for _, p := range plugins {
+		p.mu.RLock()
+		status := Status{
+			Name:        p.Name(),
+			Transport:   "stdio",
+			State:       p.state,
+			Restarts:    p.restarts,
+			Active:      len(p.sem),
+			Concurrency: cap(p.sem),
+			ExtSchema:   p.info.ExtSchema,
+		}
+		p.mu.RUnlock()
+		if p.cfg.URL != "" {
+			status.Transport = "http"
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Plugin 外部插件适配器：管理插件进程（或HTTP服务）的握手、健康检查和重启，限制并发搜索数
+type Plugin struct {
+	*plugin.BaseAsyncPlugin
+	cfg config.ExternalPluginConfig
+	sem chan struct{}
+
+	mu        sync.RWMutex
+	conn      transport
+	info      Info
+	state     string
+	lastError string
+	restarts  int
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// newPlugin 连接插件并握手，按插件声明的名称、优先级和过滤设置创建适配器
+// 握手失败时，配置了名称的插件仍然创建（使用默认优先级），由健康检查继续尝试启动
+func newPlugin(cfg config.ExternalPluginConfig) (*Plugin, error) {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	p := &Plugin{
+		cfg:   cfg,
+		sem:   make(chan struct{}, cfg.Concurrency),
+		state: StateDown,
+		stop:  make(chan struct{}),
+	}
+
+	info, err := p.connect()
+	name := cfg.Name
+	switch {
+	case err != nil && name == "":
+		return nil, err
+	case err != nil:
+		fmt.Printf("[external:%s] 启动失败，稍后重试: %v\n", label(cfg), err)
+		info = Info{Name: name, Priority: defaultPriority}
+		p.mu.Lock()
+		p.info = info
+		p.mu.Unlock()
+	case name == "":
+		name = info.Name
+	case name != info.Name:
+		fmt.Printf("[external:%s] 插件声明的名称为 %s，使用配置的名称\n", label(cfg), info.Name)
+	}
+
+	p.BaseAsyncPlugin = plugin.NewBaseAsyncPluginWithFilter(name, info.Priority, info.SkipServiceFilter)
+	return p, nil
+}
+
+// Priority 插件优先级，使用最近一次握手时插件声明的值（启动时握手失败的插件在之后握手成功时更新）
+func (p *Plugin) Priority() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.info.Priority
+}
+
+// SkipServiceFilter 是否跳过Service层的关键词过滤，使用最近一次握手时插件声明的值
+func (p *Plugin) SkipServiceFilter() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.info.SkipServiceFilter
+}
+
+// label 日志中显示的插件标识
+func label(cfg config.ExternalPluginConfig) string {
+	if cfg.Name != "" {
+		return cfg.Name
+	}
+	if cfg.URL != "" {
+		if u, err := url.Parse(cfg.URL); err == nil && u.Host != "" {
+			return u.Host
+		}
+		return cfg.URL
+	}
+	if len(cfg.Command) > 0 {
+		return filepath.Base(cfg.Command[len(cfg.Command)-1])
+	}
+	return "unknown"
+}
+
+// dial 启动插件进程或创建HTTP连接
+func (p *Plugin) dial() (transport, error) {
+	if p.cfg.URL != "" {
+		return newHTTPTransport(p.cfg.URL), nil
+	}
+	return startStdio(label(p.cfg), p.cfg.Command, p.cfg.Env)
+}
+
+// connect 建立连接并握手，成功后替换当前连接
+func (p *Plugin) connect() (Info, error) {
+	conn, err := p.dial()
+	if err != nil {
+		return Info{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+	defer cancel()
+	var info Info
+	params := initializeParams{ProtocolVersion: ProtocolVersion, Server: "pansou"}
+	if err := conn.call(ctx, MethodInitialize, params, &info); err != nil {
+		conn.close()
+		return Info{}, fmt.Errorf("握手失败: %w", err)
+	}
+
+	info.Name = strings.ToLower(strings.TrimSpace(info.Name))
+	if !namePattern.MatchString(info.Name) && p.cfg.Name == "" {
+		conn.close()
+		return Info{}, fmt.Errorf("插件名称 %q 无效，只能包含小写字母、数字和下划线", info.Name)
+	}
+	if info.Priority < 1 || info.Priority > 4 {
+		info.Priority = defaultPriority
+	}
+
+	p.mu.Lock()
+	old := p.conn
+	p.conn = conn
+	p.info = info
+	p.state = StateRunning
+	p.lastError = ""
+	p.mu.Unlock()
+	if old != nil {
+		old.close()
+	}
+	return info, nil
+}
+
+// current 获取当前连接，插件未运行时返回nil
+func (p *Plugin) current() transport {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.conn
+}
+
+// markDown 标记连接不可用并关闭
+func (p *Plugin) markDown(conn transport, reason string) {
+	p.mu.Lock()
+	if p.conn == conn {
+		p.conn = nil
+		p.state = StateDown
+		p.lastError = reason
+	}
+	p.mu.Unlock()
+	conn.close()
+}
+
+// supervise 健康检查和重启：进程退出或健康检查失败时按退避间隔重新启动
+func (p *Plugin) supervise() {
+	ticker := time.NewTicker(p.cfg.PingInterval)
+	defer ticker.Stop()
+
+	backoff := minRestartDelay
+	var restart <-chan time.Time
+	if p.current() == nil {
+		restart = time.After(backoff)
+	}
+
+	for {
+		conn := p.current()
+		var exited <-chan struct{}
+		if conn != nil {
+			exited = conn.done()
+		}
+
+		select {
+		case <-p.stop:
+			return
+		case <-exited:
+			p.markDown(conn, errProcessExited.Error())
+			fmt.Printf("[external:%s] 插件已停止，%v 后重启\n", p.Name(), backoff)
+			restart = time.After(backoff)
+			backoff = nextBackoff(backoff)
+		case <-restart:
+			restart = nil
+			if _, err := p.connect(); err != nil {
+				p.mu.Lock()
+				p.lastError = err.Error()
+				p.mu.Unlock()
+				fmt.Printf("[external:%s] 重启失败，%v 后重试: %v\n", p.Name(), backoff, err)
+				restart = time.After(backoff)
+				backoff = nextBackoff(backoff)
+				continue
+			}
+			p.mu.Lock()
+			p.restarts++
+			p.mu.Unlock()
+			fmt.Printf("[external:%s] 插件已启动\n", p.Name())
+		case <-ticker.C:
+			if conn == nil {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+			err := conn.call(ctx, MethodPing, nil, nil)
+			cancel()
+			if err != nil {
+				p.markDown(conn, "健康检查失败: "+err.Error())
+				fmt.Printf("[external:%s] 健康检查失败，%v 后重启: %v\n", p.Name(), backoff, err)
+				restart = time.After(backoff)
+				backoff = nextBackoff(backoff)
+				continue
+			}
+			// 运行正常，重置重启间隔
+			backoff = minRestartDelay
+		}
+	}
+}
+
+// nextBackoff 下一次重启间隔
+func nextBackoff(backoff time.Duration) time.Duration {
+	if backoff*2 > maxRestartDelay {
+		return maxRestartDelay
+	}
+	return backoff * 2
+}
+
+// shutdown 停止健康检查并关闭连接
+func (p *Plugin) shutdown() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		if conn := p.current(); conn != nil {
+			p.markDown(conn, "已停止")
+		}
+	})
+}
+
+// Search 执行搜索并返回结果（兼容性方法）
+func (p *Plugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	result, err := p.SearchWithResult(keyword, ext)
+	if err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// SearchWithResult 执行搜索并返回包含IsFinal标记的结果
+func (p *Plugin) SearchWithResult(keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
+// searchImpl 调用插件的search方法，截止时间取客户端的超时时间
+func (p *Plugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	timeout := client.Timeout
+	if timeout <= 0 {
+		timeout = config.AppConfig.PluginTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// 限制同时进行的搜索数
+	select {
+	case p.sem <- struct{}{}:
+		defer func() { <-p.sem }()
+	case <-ctx.Done():
+		return nil, fmt.Errorf("[%s] 等待空闲并发槽超时", p.Name())
+	}
+
+	p.mu.RLock()
+	conn, schema, lastError := p.conn, p.info.ExtSchema, p.lastError
+	p.mu.RUnlock()
+	if conn == nil {
+		return nil, fmt.Errorf("[%s] 插件未运行: %s", p.Name(), lastError)
+	}
+
+	deadline, _ := ctx.Deadline()
+	params := searchParams{
+		Keyword:   keyword,
+		Ext:       filterExt(ext, schema),
+		Deadline:  deadline.UnixMilli(),
+		TimeoutMs: time.Until(deadline).Milliseconds(),
+	}
+	var result searchResult
+	if err := conn.call(ctx, MethodSearch, params, &result); err != nil {
+		return nil, fmt.Errorf("[%s] 搜索失败: %w", p.Name(), err)
+	}
+	return p.normalizeResults(result.Results), nil
+}
+
+// filterExt 只转发插件声明且类型匹配的ext参数，插件未声明ext_schema时转发全部；内部参数（以_开头）不发送
+func filterExt(ext map[string]interface{}, schema map[string]ExtField) map[string]interface{} {
+	filtered := make(map[string]interface{})
+	for key, value := range ext {
+		if strings.HasPrefix(key, "_") {
+			continue
+		}
+		if schema != nil {
+			field, ok := schema[key]
+			if !ok || !matchType(field.Type, value) {
+				continue
+			}
+		}
+		filtered[key] = value
+	}
+	return filtered
+}
+
+// normalizeResults 规范化插件返回的结果：UniqueID以 插件名- 开头，补全链接类型，丢弃没有链接的结果
+func (p *Plugin) normalizeResults(results []model.SearchResult) []model.SearchResult {
+	prefix := p.Name() + "-"
+	normalized := make([]model.SearchResult, 0, len(results))
+	for _, r := range results {
+		links := make([]model.Link, 0, len(r.Links))
+		for _, link := range r.Links {
+			if link.URL == "" {
+				continue
+			}
+			if link.Type == "" {
+				link.Type = util.GetLinkType(link.URL)
+			}
+			links = append(links, link)
+		}
+		if len(links) == 0 {
+			continue
+		}
+		r.Links = links
+
+		if r.UniqueID == "" {
+			r.UniqueID = r.MessageID
+		}
+		if r.UniqueID == "" {
+			r.UniqueID = links[0].URL
+		}
+		if !strings.HasPrefix(r.UniqueID, prefix) {
+			r.UniqueID = prefix + r.UniqueID
+		}
+		// 有频道的结果会被当作TG结果
+		r.Channel = ""
+		normalized = append(normalized, r)
+	}
+	return normalized
+}
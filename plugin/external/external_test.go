@@ -0,0 +1,169 @@
+package external
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pansou/config"
+)
+
+// helperEnv 标记测试二进制作为插件进程运行
+const helperEnv = "PANSOU_EXTERNAL_TEST_HELPER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) == "1" {
+		runHelperPlugin()
+		return
+	}
+	config.AppConfig = &config.Config{PluginTimeout: 5 * time.Second}
+	os.Exit(m.Run())
+}
+
+// runHelperPlugin 最小的stdio插件：搜索结果的标题中带上收到的密钥和插件环境变量，用于检查进程环境
+// 关键词为dup时重复输出三次响应
+func runHelperPlugin() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     uint64          `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &req) != nil || req.ID == 0 {
+			continue
+		}
+		var result interface{}
+		switch req.Method {
+		case MethodInitialize:
+			result = Info{Name: "helper", Priority: 1, SkipServiceFilter: true}
+		case MethodSearch:
+			var params searchParams
+			json.Unmarshal(req.Params, &params)
+			title := fmt.Sprintf("%s secret=%q token=%q", params.Keyword, os.Getenv("AUTH_JWT_SECRET"), os.Getenv("PLUGIN_TOKEN"))
+			result = map[string]interface{}{"results": []map[string]interface{}{{
+				"unique_id": "1",
+				"title":     title,
+				"links":     []map[string]string{{"url": "https://pan.quark.cn/s/abc"}},
+			}}}
+		default:
+			result = map[string]bool{"ok": true}
+		}
+		data, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+		fmt.Println(string(data))
+		if strings.Contains(string(req.Params), `"dup"`) {
+			fmt.Println(string(data))
+			fmt.Println(string(data))
+		}
+	}
+}
+
+func TestStdioTransport(t *testing.T) {
+	t.Setenv("AUTH_JWT_SECRET", "do-not-leak")
+	p, err := newPlugin(config.ExternalPluginConfig{
+		Command:      []string{os.Args[0], "-test.run=^$"},
+		Env:          map[string]string{helperEnv: "1", "PLUGIN_TOKEN": "abc"},
+		Concurrency:  1,
+		PingInterval: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.shutdown()
+
+	if p.Name() != "helper" || p.Priority() != 1 || !p.SkipServiceFilter() {
+		t.Fatalf("name=%s priority=%d skip=%v", p.Name(), p.Priority(), p.SkipServiceFilter())
+	}
+	results, err := p.searchImpl(&http.Client{Timeout: 5 * time.Second}, "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].UniqueID != "helper-1" || results[0].Links[0].Type != "quark" {
+		t.Fatalf("results = %+v", results)
+	}
+	// 插件进程只收到为它配置的环境变量
+	if title := results[0].Title; !strings.Contains(title, `secret=""`) || !strings.Contains(title, `token="abc"`) {
+		t.Fatalf("plugin environment = %s", title)
+	}
+}
+
+func TestStdioDuplicateResponse(t *testing.T) {
+	p, err := newPlugin(config.ExternalPluginConfig{
+		Command:      []string{os.Args[0], "-test.run=^$"},
+		Env:          map[string]string{helperEnv: "1"},
+		Concurrency:  1,
+		PingInterval: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.shutdown()
+
+	// 重复的响应不能阻塞读取循环，之后的请求照常返回
+	for _, keyword := range []string{"dup", "dup", "dup", "test"} {
+		if _, err := p.searchImpl(&http.Client{Timeout: 2 * time.Second}, keyword, nil); err != nil {
+			t.Fatalf("search %s: %v", keyword, err)
+		}
+	}
+}
+
+func TestHTTPTransportLateHandshake(t *testing.T) {
+	var ready int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&ready) == 0 {
+			http.Error(w, "starting", http.StatusServiceUnavailable)
+			return
+		}
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var result interface{} = map[string]bool{"ok": true}
+		switch req.Method {
+		case MethodInitialize:
+			result = Info{Name: "remote", Priority: 1, SkipServiceFilter: true}
+		case MethodSearch:
+			result = map[string]interface{}{"results": []map[string]interface{}{{
+				"unique_id": "x",
+				"title":     "result",
+				"links":     []map[string]string{{"url": "magnet:?xt=urn:btih:abc"}},
+			}}}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	defer srv.Close()
+
+	// 启动时握手失败，配置了名称的插件以默认优先级注册
+	p, err := newPlugin(config.ExternalPluginConfig{Name: "remote", URL: srv.URL, Concurrency: 1, PingInterval: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.shutdown()
+	if p.Priority() != defaultPriority || p.SkipServiceFilter() {
+		t.Fatalf("before handshake: priority=%d skip=%v", p.Priority(), p.SkipServiceFilter())
+	}
+	if _, err := p.searchImpl(&http.Client{Timeout: time.Second}, "test", nil); err == nil {
+		t.Fatal("search should fail before the handshake")
+	}
+
+	// 之后握手成功，使用插件声明的优先级和过滤设置
+	atomic.StoreInt32(&ready, 1)
+	if _, err := p.connect(); err != nil {
+		t.Fatal(err)
+	}
+	if p.Priority() != 1 || !p.SkipServiceFilter() {
+		t.Fatalf("after handshake: priority=%d skip=%v", p.Priority(), p.SkipServiceFilter())
+	}
+	results, err := p.searchImpl(&http.Client{Timeout: 5 * time.Second}, "test", nil)
+	if err != nil || len(results) != 1 || results[0].UniqueID != "remote-x" || results[0].Links[0].Type != "magnet" {
+		t.Fatalf("results = %+v, %v", results, err)
+	}
+}
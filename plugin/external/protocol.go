@@ -0,0 +1,123 @@
+// Package external 外部插件：通过JSON-RPC 2.0接入独立进程实现的搜索插件（如Python爬虫）
+//
+// 传输方式：
+//   - stdio：PanSou启动插件进程，通过标准输入输出通信，每行一条JSON消息，标准错误输出写入日志
+//   - HTTP：插件作为HTTP服务运行，每次POST一条JSON-RPC消息，响应体为对应的JSON-RPC响应
+//
+// 方法：
+//   - initialize：握手，参数 {protocol_version, server}，返回 {name, priority, skip_service_filter, ext_schema}
+//   - search：搜索，参数 {keyword, ext, deadline, timeout_ms}，返回 {results: [SearchResult]}
+//   - ping：健康检查，返回任意结果
+//   - cancel：通知（没有ID），参数 {id}，请求已超时，插件可以停止处理
+package external
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"pansou/model"
+)
+
+// ProtocolVersion 外部插件协议版本
+const ProtocolVersion = "1"
+
+// 协议方法
+const (
+	MethodInitialize = "initialize"
+	MethodSearch     = "search"
+	MethodPing       = "ping"
+	MethodCancel     = "cancel"
+)
+
+// rpcRequest JSON-RPC请求，ID为0时为通知
+type rpcRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      uint64      `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// rpcResponse JSON-RPC响应
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *RPCError       `json:"error"`
+}
+
+// RPCError 插件返回的JSON-RPC错误
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("插件返回错误(%d): %s", e.Code, e.Message)
+}
+
+// initializeParams 握手参数
+type initializeParams struct {
+	ProtocolVersion string `json:"protocol_version"`
+	Server          string `json:"server"`
+}
+
+// ExtField 插件支持的ext参数
+type ExtField struct {
+	Type        string `json:"type"` // string、number、boolean、array、object，为空时不检查类型
+	Description string `json:"description,omitempty"`
+}
+
+// Info 插件握手时声明的信息
+type Info struct {
+	Name              string              `json:"name"`
+	Priority          int                 `json:"priority"`
+	SkipServiceFilter bool                `json:"skip_service_filter"`
+	ExtSchema         map[string]ExtField `json:"ext_schema,omitempty"`
+	ProtocolVersion   string              `json:"protocol_version,omitempty"`
+}
+
+// searchParams 搜索参数
+type searchParams struct {
+	Keyword   string                 `json:"keyword"`
+	Ext       map[string]interface{} `json:"ext,omitempty"`
+	Deadline  int64                  `json:"deadline"`   // 截止时间（Unix毫秒）
+	TimeoutMs int64                  `json:"timeout_ms"` // 剩余时间（毫秒）
+}
+
+// searchResult 搜索结果
+type searchResult struct {
+	Results []model.SearchResult `json:"results"`
+}
+
+// cancelParams 取消通知参数
+type cancelParams struct {
+	ID uint64 `json:"id"`
+}
+
+// matchType ext参数值是否符合声明的类型（值为JSON解码后的类型）
+func matchType(fieldType string, value interface{}) bool {
+	switch fieldType {
+	case "":
+		return true
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		switch value.(type) {
+		case float64, float32, int, int64, int32, uint, uint64, json.Number:
+			return true
+		}
+		return false
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return false
+}
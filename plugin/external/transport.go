@@ -0,0 +1,321 @@
+package external
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	jsonutil "pansou/util/json"
+)
+
+const (
+	// 单条消息大小上限
+	maxMessageSize = 32 << 20
+	// 关闭stdin后等待进程退出的时间，超时则强制结束
+	exitGracePeriod = 2 * time.Second
+)
+
+// errProcessExited 插件进程已退出
+var errProcessExited = errors.New("插件进程已退出")
+
+// transport 与外部插件的连接
+type transport interface {
+	// call 调用方法并把结果解码到result（为nil时忽略结果）
+	call(ctx context.Context, method string, params interface{}, result interface{}) error
+	// done 连接断开（进程退出）时关闭
+	done() <-chan struct{}
+	// close 关闭连接，stdio传输会结束插件进程
+	close()
+}
+
+// decodeResult 解码响应结果
+func decodeResult(resp rpcResponse, result interface{}) error {
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil || len(resp.Result) == 0 || string(resp.Result) == "null" {
+		return nil
+	}
+	if err := jsonutil.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("解析结果失败: %w", err)
+	}
+	return nil
+}
+
+// parseID 解析响应ID（数字或数字字符串）
+func parseID(raw json.RawMessage) (uint64, bool) {
+	id, err := strconv.ParseUint(strings.Trim(string(raw), `"`), 10, 64)
+	return id, err == nil
+}
+
+// stdioTransport 通过标准输入输出与插件进程通信，每行一条消息
+type stdioTransport struct {
+	label   string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	pending map[uint64]chan rpcResponse
+	seq     uint64
+	exited  chan struct{}
+	once    sync.Once
+}
+
+// inheritedEnv 插件进程从PanSou继承的环境变量，其余变量（如密钥、代理凭据）不传给插件
+var inheritedEnv = []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TZ", "TMPDIR", "SYSTEMROOT"}
+
+// childEnv 插件进程的环境变量：最小的系统环境加上该插件配置的变量
+func childEnv(extra map[string]string) []string {
+	env := make([]string, 0, len(inheritedEnv)+len(extra))
+	for _, name := range inheritedEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, name+"="+extra[name])
+	}
+	return env
+}
+
+// startStdio 启动插件进程，env为该插件配置的环境变量
+func startStdio(label string, command []string, env map[string]string) (*stdioTransport, error) {
+	if len(command) == 0 {
+		return nil, errors.New("未配置启动命令")
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Env = childEnv(env)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("启动进程失败: %w", err)
+	}
+
+	t := &stdioTransport{
+		label:   label,
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[uint64]chan rpcResponse),
+		exited:  make(chan struct{}),
+	}
+
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		t.readLoop(stdout)
+	}()
+	go func() {
+		defer readers.Done()
+		t.logStderr(stderr)
+	}()
+	// 输出读取完毕后回收进程
+	go func() {
+		readers.Wait()
+		if err := cmd.Wait(); err != nil {
+			fmt.Printf("[external:%s] 进程退出: %v\n", t.label, err)
+		}
+		close(t.exited)
+	}()
+	return t, nil
+}
+
+// readLoop 读取插件输出的响应，交给等待中的调用
+func (t *stdioTransport) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var resp rpcResponse
+		if err := jsonutil.Unmarshal(line, &resp); err != nil {
+			fmt.Printf("[external:%s] 忽略无法解析的输出: %s\n", t.label, truncate(string(line), 200))
+			continue
+		}
+		id, ok := parseID(resp.ID)
+		if !ok {
+			continue
+		}
+		// 取出后即删除，重复或迟到的响应找不到等待方；发送不阻塞，避免卡住读取循环
+		t.mu.Lock()
+		ch, ok := t.pending[id]
+		delete(t.pending, id)
+		t.mu.Unlock()
+		if ok {
+			select {
+			case ch <- resp:
+			default:
+			}
+		}
+	}
+	// 输出异常（如单行过长）时结束进程，由适配器重启
+	if err := scanner.Err(); err != nil {
+		fmt.Printf("[external:%s] 读取输出失败: %v\n", t.label, err)
+		t.cmd.Process.Kill()
+	}
+}
+
+// logStderr 把插件的标准错误输出写入日志
+func (t *stdioTransport) logStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		fmt.Printf("[external:%s] %s\n", t.label, scanner.Text())
+	}
+}
+
+// send 写入一条消息
+func (t *stdioTransport) send(req rpcRequest) error {
+	data, err := jsonutil.Marshal(req)
+	if err != nil {
+		return err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err = t.stdin.Write(append(data, '\n'))
+	return err
+}
+
+func (t *stdioTransport) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	id := atomic.AddUint64(&t.seq, 1)
+	ch := make(chan rpcResponse, 1)
+	t.mu.Lock()
+	t.pending[id] = ch
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, id)
+		t.mu.Unlock()
+	}()
+
+	if err := t.send(rpcRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params}); err != nil {
+		select {
+		case <-t.exited:
+			return errProcessExited
+		default:
+			return fmt.Errorf("发送请求失败: %w", err)
+		}
+	}
+
+	select {
+	case resp := <-ch:
+		return decodeResult(resp, result)
+	case <-t.exited:
+		return errProcessExited
+	case <-ctx.Done():
+		// 通知插件停止处理超时的请求
+		t.send(rpcRequest{JSONRPC: "2.0", Method: MethodCancel, Params: cancelParams{ID: id}})
+		return ctx.Err()
+	}
+}
+
+func (t *stdioTransport) done() <-chan struct{} {
+	return t.exited
+}
+
+// close 关闭stdin通知插件退出，超时未退出则强制结束
+func (t *stdioTransport) close() {
+	t.once.Do(func() {
+		t.stdin.Close()
+		select {
+		case <-t.exited:
+		case <-time.After(exitGracePeriod):
+			t.cmd.Process.Kill()
+			<-t.exited
+		}
+	})
+}
+
+// httpTransport 通过HTTP POST与插件服务通信
+type httpTransport struct {
+	url    string
+	client *http.Client
+	seq    uint64
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newHTTPTransport(url string) *httpTransport {
+	// 不设置客户端超时，由调用的上下文控制
+	return &httpTransport{url: url, client: &http.Client{}, closed: make(chan struct{})}
+}
+
+func (t *httpTransport) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	body, err := jsonutil.Marshal(rpcRequest{JSONRPC: "2.0", ID: atomic.AddUint64(&t.seq, 1), Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMessageSize))
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求失败，状态码: %d", resp.StatusCode)
+	}
+
+	var rpcResp rpcResponse
+	if err := jsonutil.Unmarshal(data, &rpcResp); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return decodeResult(rpcResp, result)
+}
+
+func (t *httpTransport) done() <-chan struct{} {
+	return t.closed
+}
+
+func (t *httpTransport) close() {
+	t.once.Do(func() {
+		close(t.closed)
+		t.client.CloseIdleConnections()
+	})
+}
+
+// truncate 截断过长的日志内容
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
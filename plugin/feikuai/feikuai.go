@@ -12,6 +12,7 @@ import (
 
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"pansou/util/json"
 )
 
@@ -58,7 +59,7 @@ func createOptimizedHTTPClient() *http.Client {
 	}
 
 	return &http.Client{
-		Transport: transport,
+		Transport: util.NewPluginTransport("feikuai", transport),
 		Timeout:   DefaultTimeout,
 	}
 }
@@ -93,7 +94,7 @@ func (p *FeikuaiPlugin) SearchWithResult(keyword string, ext map[string]interfac
 func (p *FeikuaiPlugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	// 使用优化的客户端
 	if p.optimizedClient != nil {
-		client = p.optimizedClient
+		client = util.BudgetedClient(p.optimizedClient, ext)
 	}
 
 	// 构建API搜索URL
@@ -20,6 +20,7 @@ import (
 	"golang.org/x/net/proxy"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 // 常量定义
@@ -196,7 +197,7 @@ func createOptimizedHTTPClient() *http.Client {
 	}
 	
 	return &http.Client{
-		Transport: transport,
+		Transport: util.NewPluginTransport("fox4k", transport),
 		Timeout:   DefaultTimeout,
 	}
 }
@@ -273,7 +274,7 @@ func (p *Fox4kPlugin) searchImpl(client *http.Client, keyword string, ext map[st
 	
 	// 使用优化的客户端
 	if p.optimizedClient != nil {
-		client = p.optimizedClient
+		client = util.BudgetedClient(p.optimizedClient, ext)
 	}
 	
 	encodedKeyword := url.QueryEscape(keyword)
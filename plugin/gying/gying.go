@@ -11,24 +11,21 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/cookiejar"
 	"net/url"
 	"os"
-	"path/filepath"
-	"reflect"
 	"regexp"
-	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
-	"unsafe"
 
 	"github.com/gin-gonic/gin"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/plugin/accounts"
+	"pansou/util"
 	"pansou/util/json"
-	
-	cloudscraper "github.com/Advik-B/cloudscraper/lib"
 )
 
 // 插件配置参数
@@ -48,11 +45,6 @@ var DefaultAccounts = []struct {
 	// POST /gying/add_user?username=xxx&password=xxx
 }
 
-// 存储目录
-var StorageDir string
-
-// 初始化存储目录
-
 // HTML模板
 const HTMLTemplate = `<!DOCTYPE html>
 <html lang="zh-CN">
@@ -368,8 +360,8 @@ const HTMLTemplate = `<!DOCTYPE html>
 // GyingPlugin 插件结构
 type GyingPlugin struct {
 	*plugin.BaseAsyncPlugin
-	users       sync.Map // 内存缓存：hash -> *User
-	scrapers    sync.Map // cloudscraper实例缓存：hash -> *cloudscraper.Scraper
+	accounts    *accounts.Manager // 账号管理：hash -> *User
+	clients     sync.Map          // HTTP客户端缓存：hash -> *http.Client
 	mu          sync.RWMutex
 	searchCache sync.Map // 插件级缓存：关键词->model.PluginSearchResult
 	initialized bool     // 初始化状态标记
@@ -377,16 +369,11 @@ type GyingPlugin struct {
 
 // User 用户数据结构
 type User struct {
-	Hash              string    `json:"hash"`
-	Username          string    `json:"username"`           // 原始用户名（存储）
-	UsernameMasked    string    `json:"username_masked"`    // 脱敏用户名（显示）
-	EncryptedPassword string    `json:"encrypted_password"` // 加密后的密码（用于重启恢复）
-	Cookie            string    `json:"cookie"`             // 登录Cookie字符串（仅供参考）
-	Status            string    `json:"status"`             // pending/active/expired
-	CreatedAt         time.Time `json:"created_at"`
-	LoginAt           time.Time `json:"login_at"`
-	ExpireAt          time.Time `json:"expire_at"`
-	LastAccessAt      time.Time `json:"last_access_at"`
+	accounts.Account         // 账号ID、状态（pending/active/expired）、登录和过期时间、健康状态
+	Username          string `json:"username"`           // 原始用户名（存储）
+	UsernameMasked    string `json:"username_masked"`    // 脱敏用户名（显示）
+	EncryptedPassword string `json:"encrypted_password"` // 加密后的密码（用于重启恢复）
+	Cookie            string `json:"cookie"`             // 登录Cookie字符串（仅供参考）
 }
 
 // SearchData 搜索页面JSON数据结构
@@ -425,6 +412,30 @@ func init() {
 	p := &GyingPlugin{
 		BaseAsyncPlugin: plugin.NewBaseAsyncPlugin("gying", 3),
 	}
+	p.accounts = accounts.NewManager(accounts.Options{
+		Plugin:    "gying",
+		New:       func() accounts.Record { return &User{} },
+		LegacyDir: "gying_users",
+		HashID:    p.generateHash,
+		Details: func(r accounts.Record) map[string]interface{} {
+			return map[string]interface{}{"username_masked": r.(*User).UsernameMasked}
+		},
+		OnExpire: func(r accounts.Record) {
+			user := r.(*User)
+			user.Cookie = ""
+			p.clients.Delete(user.Hash)
+		},
+		// 定期访问首页，防止session超时（首次启动后延迟3分钟，避免启动时过多请求）
+		KeepAlive:         p.keepSessionAlive,
+		KeepAliveInterval: 3 * time.Minute,
+		KeepAliveDelay:    3 * time.Minute,
+		Actions: map[string]accounts.ActionFunc{
+			"get_status":  p.handleGetStatus,
+			"login":       p.handleLogin,
+			"logout":      p.handleLogout,
+			"test_search": p.handleTestSearch,
+		},
+	})
 
 	plugin.RegisterGlobalPlugin(p)
 }
@@ -435,21 +446,11 @@ func (p *GyingPlugin) Initialize() error {
 		return nil
 	}
 
-	// 初始化存储目录路径
-	cachePath := os.Getenv("CACHE_PATH")
-	if cachePath == "" {
-		cachePath = "./cache"
-	}
-	StorageDir = filepath.Join(cachePath, "gying_users")
-
-	// 初始化存储目录
-	if err := os.MkdirAll(StorageDir, 0755); err != nil {
-		return fmt.Errorf("创建存储目录失败: %v", err)
+	// 加载所有用户，启动session保活和定期清理任务
+	if err := p.accounts.Start(); err != nil {
+		return err
 	}
 
-	// 加载所有用户到内存
-	p.loadAllUsers()
-
 	// 异步初始化默认账户（不阻塞启动）
 	go func() {
 		// 延迟1秒，等待主程序完全启动
@@ -457,12 +458,6 @@ func (p *GyingPlugin) Initialize() error {
 		p.initDefaultAccounts()
 	}()
 
-	// 启动定期清理任务
-	go p.startCleanupTask()
-	
-	// 启动session保活任务（防止session超时）
-	go p.startSessionKeepAlive()
-
 	p.initialized = true
 	return nil
 }
@@ -489,7 +484,7 @@ func (p *GyingPlugin) Search(keyword string, ext map[string]interface{}) ([]mode
 
 // SearchWithResult 执行搜索并返回包含IsFinal标记的结果
 // 注意：gying插件不使用AsyncSearchWithResult的缓存机制，因为：
-// 1. 使用自己的cloudscraper实例而不是传入的http.Client
+// 1. 使用每个用户自己的HTTP客户端（独立的登录Cookie）而不是传入的http.Client
 // 2. 有自己的用户会话管理
 // 3. Service层已经有缓存，无需插件层再次缓存
 func (p *GyingPlugin) SearchWithResult(keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
@@ -519,9 +514,9 @@ func (p *GyingPlugin) SearchWithResult(keyword string, ext map[string]interface{
 
     // 原有真实抓取逻辑
     if DebugLog {
-        fmt.Printf("[Gying] searchWithScraper REAL 执行: %s\n", keyword)
+        fmt.Printf("[Gying] searchWithClient REAL 执行: %s\n", keyword)
     }
-    users := p.getActiveUsers()
+    users := p.acquireUsers()
     if DebugLog {
         fmt.Printf("[Gying] 找到 %d 个有效用户\n", len(users))
     }
@@ -531,13 +526,7 @@ func (p *GyingPlugin) SearchWithResult(keyword string, ext map[string]interface{
         }
         return model.PluginSearchResult{Results: []model.SearchResult{}, IsFinal: true}, nil
     }
-    if len(users) > MaxConcurrentUsers {
-        sort.Slice(users, func(i, j int) bool {
-            return users[i].LastAccessAt.After(users[j].LastAccessAt)
-        })
-        users = users[:MaxConcurrentUsers]
-    }
-    results := p.executeSearchTasks(users, keyword)
+    results := p.executeSearchTasks(users, keyword, util.RequestBudgetFromExt(ext))
     if DebugLog {
         fmt.Printf("[Gying] 搜索完成，获得 %d 条结果\n", len(results))
     }
@@ -554,63 +543,6 @@ func (p *GyingPlugin) SearchWithResult(keyword string, ext map[string]interface{
 
 // ============ 用户管理 ============
 
-// loadAllUsers 加载所有用户到内存（包括用户名、加密密码等）
-// 注意：只加载用户数据，scraper实例将在initDefaultAccounts中使用密码重新登录获取
-func (p *GyingPlugin) loadAllUsers() {
-	files, err := ioutil.ReadDir(StorageDir)
-	if err != nil {
-		return
-	}
-
-	totalFiles := 0
-	loadedCount := 0
-	skippedInactive := 0
-	
-	for _, file := range files {
-		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
-			continue
-		}
-		
-		totalFiles++
-
-		filePath := filepath.Join(StorageDir, file.Name())
-		data, err := ioutil.ReadFile(filePath)
-		if err != nil {
-			continue
-		}
-
-		var user User
-		if err := json.Unmarshal(data, &user); err != nil {
-			continue
-		}
-
-		// 过滤条件：status必须是active
-		if user.Status != "active" {
-			if DebugLog {
-				fmt.Printf("[Gying] ⏭️  跳过用户 %s: status=%s (非active)\n", user.UsernameMasked, user.Status)
-			}
-			skippedInactive++
-			continue
-		}
-
-		// 只存储用户数据（包括用户名和加密密码）
-		// scraper实例将在initDefaultAccounts中通过重新登录获取
-		p.users.Store(user.Hash, &user)
-		loadedCount++
-		
-		if DebugLog {
-			hasPassword := "无"
-			if user.EncryptedPassword != "" {
-				hasPassword = "有"
-			}
-			fmt.Printf("[Gying] ✅ 已加载用户 %s (密码:%s, 将在初始化时登录)\n", user.UsernameMasked, hasPassword)
-		}
-	}
-
-	fmt.Printf("[Gying] 用户加载完成: 总文件=%d, 已加载=%d, 跳过(非active)=%d\n", 
-		totalFiles, loadedCount, skippedInactive)
-}
-
 // initDefaultAccounts 初始化所有账户（异步执行，不阻塞启动）
 // 包括：1. DefaultAccounts（代码配置）  2. 从文件加载的用户（使用加密密码重新登录）
 func (p *GyingPlugin) initDefaultAccounts() {
@@ -625,17 +557,16 @@ func (p *GyingPlugin) initDefaultAccounts() {
 		p.initOrRestoreUser(account.Username, account.Password, "default")
 	}
 	
-	// 步骤2：遍历所有已加载的用户，恢复没有scraper的用户
+	// 步骤2：遍历所有已登录的用户，恢复没有HTTP客户端的用户
 	var usersToRestore []*User
-	p.users.Range(func(key, value interface{}) bool {
-		user := value.(*User)
-		// 检查scraper是否存在
-		_, scraperExists := p.scrapers.Load(user.Hash)
-		if !scraperExists && user.EncryptedPassword != "" {
+	for _, r := range p.accounts.All() {
+		user := r.(*User)
+		// 检查HTTP客户端是否存在
+		_, clientExists := p.clients.Load(user.Hash)
+		if user.Status == accounts.StatusActive && !clientExists && user.EncryptedPassword != "" {
 			usersToRestore = append(usersToRestore, user)
 		}
-		return true
-	})
+	}
 	
 	if len(usersToRestore) > 0 {
 		fmt.Printf("[Gying] 发现 %d 个需要恢复的用户（使用加密密码重新登录）\n", len(usersToRestore))
@@ -662,11 +593,11 @@ func (p *GyingPlugin) initDefaultAccounts() {
 func (p *GyingPlugin) initOrRestoreUser(username, password, source string) {
 	hash := p.generateHash(username)
 	
-	// 检查scraper是否已存在
-	_, scraperExists := p.scrapers.Load(hash)
-	if scraperExists {
+	// 检查HTTP客户端是否已存在
+	_, clientExists := p.clients.Load(hash)
+	if clientExists {
 		if DebugLog {
-			fmt.Printf("[Gying] 用户 %s scraper已存在，跳过\n", p.maskUsername(username))
+			fmt.Printf("[Gying] 用户 %s HTTP客户端已存在，跳过\n", p.maskUsername(username))
 		}
 		return
 	}
@@ -675,14 +606,14 @@ func (p *GyingPlugin) initOrRestoreUser(username, password, source string) {
 	if DebugLog {
 		fmt.Printf("[Gying] 开始登录账户: %s\n", username)
 	}
-	scraper, cookie, err := p.doLogin(username, password)
+	client, cookie, err := p.doLogin(username, password)
 	if err != nil {
 		fmt.Printf("[Gying] ❌ 账户 %s 登录失败: %v\n", username, err)
 		return
 	}
 
 	if DebugLog {
-		fmt.Printf("[Gying] 登录成功，已获取cloudscraper实例\n")
+		fmt.Printf("[Gying] 登录成功，已获取HTTP客户端\n")
 	}
 
 	// 加密密码
@@ -694,20 +625,22 @@ func (p *GyingPlugin) initOrRestoreUser(username, password, source string) {
 	
 	// 保存用户
 	user := &User{
-		Hash:              hash,
+		Account: accounts.Account{
+			Hash:         hash,
+			Status:       accounts.StatusActive,
+			CreatedAt:    time.Now(),
+			LoginAt:      time.Now(),
+			ExpireAt:     time.Now().AddDate(0, 4, 0), // 121天有效期
+			LastAccessAt: time.Now(),
+		},
 		Username:          username,
 		UsernameMasked:    p.maskUsername(username),
 		EncryptedPassword: encryptedPassword,
 		Cookie:            cookie,
-		Status:            "active",
-		CreatedAt:         time.Now(),
-		LoginAt:           time.Now(),
-		ExpireAt:          time.Now().AddDate(0, 4, 0), // 121天有效期
-		LastAccessAt:      time.Now(),
 	}
 	
-	// 保存scraper实例到内存
-	p.scrapers.Store(hash, scraper)
+	// 保存HTTP客户端到内存
+	p.clients.Store(hash, client)
 	
 	if err := p.saveUser(user); err != nil {
 		fmt.Printf("[Gying] ❌ 保存账户失败: %v\n", err)
@@ -719,48 +652,27 @@ func (p *GyingPlugin) initOrRestoreUser(username, password, source string) {
 
 // getUserByHash 获取用户
 func (p *GyingPlugin) getUserByHash(hash string) (*User, bool) {
-	value, ok := p.users.Load(hash)
+	r, ok := p.accounts.Get(hash)
 	if !ok {
 		return nil, false
 	}
-	return value.(*User), true
+	return r.(*User), true
 }
 
 // saveUser 保存用户
 func (p *GyingPlugin) saveUser(user *User) error {
-	p.users.Store(user.Hash, user)
-	return p.persistUser(user)
-}
-
-// persistUser 持久化用户到文件
-func (p *GyingPlugin) persistUser(user *User) error {
-	filePath := filepath.Join(StorageDir, user.Hash+".json")
-	data, err := json.MarshalIndent(user, "", "  ")
-	if err != nil {
-		return err
-	}
-	return ioutil.WriteFile(filePath, data, 0644)
-}
-
-// deleteUser 删除用户
-func (p *GyingPlugin) deleteUser(hash string) error {
-	p.users.Delete(hash)
-	filePath := filepath.Join(StorageDir, hash+".json")
-	return os.Remove(filePath)
+	return p.accounts.Save(user)
 }
 
-// getActiveUsers 获取有效用户
-func (p *GyingPlugin) getActiveUsers() []*User {
-	var users []*User
-	
-	p.users.Range(func(key, value interface{}) bool {
-		user := value.(*User)
-		if user.Status == "active" && user.Cookie != "" {
-			users = append(users, user)
-		}
-		return true
+// acquireUsers 按最近最少使用的顺序选择有效用户（跳过超过频率限制的用户）
+func (p *GyingPlugin) acquireUsers() []*User {
+	records := p.accounts.Acquire(MaxConcurrentUsers, func(r accounts.Record) bool {
+		return r.(*User).Cookie != ""
 	})
-	
+	users := make([]*User, 0, len(records))
+	for _, r := range records {
+		users = append(users, r.(*User))
+	}
 	return users
 }
 
@@ -796,39 +708,35 @@ func (p *GyingPlugin) handleManagePagePOST(c *gin.Context) {
 		return
 	}
 
-	switch action {
-	case "get_status":
-		p.handleGetStatus(c, hash)
-	case "login":
-		p.handleLogin(c, hash, reqData)
-	case "logout":
-		p.handleLogout(c, hash)
-	case "test_search":
-		p.handleTestSearch(c, hash, reqData)
-	default:
+	if !p.accounts.HandleAction(c, hash, action, reqData) {
 		respondError(c, "未知的操作类型: "+action)
 	}
 }
 
 // handleGetStatus 获取状态
-func (p *GyingPlugin) handleGetStatus(c *gin.Context, hash string) {
+func (p *GyingPlugin) handleGetStatus(c *gin.Context, hash string, _ map[string]interface{}) {
 	user, exists := p.getUserByHash(hash)
 
 	if !exists {
 		user = &User{
-			Hash:         hash,
-			Status:       "pending",
-			CreatedAt:    time.Now(),
-			LastAccessAt: time.Now(),
+			Account: accounts.Account{
+				Hash:         hash,
+				Status:       accounts.StatusPending,
+				CreatedAt:    time.Now(),
+				LastAccessAt: time.Now(),
+			},
+		}
+		if err := p.saveUser(user); err != nil {
+			respondError(c, "创建用户失败: "+err.Error())
+			return
 		}
-		p.saveUser(user)
 	} else {
 		user.LastAccessAt = time.Now()
 		p.saveUser(user)
 	}
 
 	loggedIn := false
-	if user.Status == "active" && user.Cookie != "" {
+	if user.Status == accounts.StatusActive && user.Cookie != "" {
 		loggedIn = true
 	}
 
@@ -862,14 +770,14 @@ func (p *GyingPlugin) handleLogin(c *gin.Context, hash string, reqData map[strin
 	}
 
 	// 执行登录
-	scraper, cookie, err := p.doLogin(username, password)
+	client, cookie, err := p.doLogin(username, password)
 	if err != nil {
 		respondError(c, "登录失败: "+err.Error())
 		return
 	}
 
-	// 保存scraper实例到内存
-	p.scrapers.Store(hash, scraper)
+	// 保存HTTP客户端到内存
+	p.clients.Store(hash, client)
 
 	// 加密密码
 	encryptedPassword, err := p.encryptPassword(password)
@@ -880,18 +788,22 @@ func (p *GyingPlugin) handleLogin(c *gin.Context, hash string, reqData map[strin
 	
 	// 保存用户
 	user := &User{
-		Hash:              hash,
+		Account: accounts.Account{
+			Hash:         hash,
+			Status:       accounts.StatusActive,
+			LoginAt:      time.Now(),
+			ExpireAt:     time.Now().AddDate(0, 4, 0), // 121天
+			LastAccessAt: time.Now(),
+		},
 		Username:          username,
 		UsernameMasked:    p.maskUsername(username),
 		EncryptedPassword: encryptedPassword,
 		Cookie:            cookie,
-		Status:            "active",
-		LoginAt:           time.Now(),
-		ExpireAt:          time.Now().AddDate(0, 4, 0), // 121天
-		LastAccessAt:      time.Now(),
 	}
 	
-	if _, exists := p.getUserByHash(hash); !exists {
+	if existing, exists := p.getUserByHash(hash); exists {
+		user.CreatedAt = existing.CreatedAt
+	} else {
 		user.CreatedAt = time.Now()
 	}
 
@@ -907,7 +819,7 @@ func (p *GyingPlugin) handleLogin(c *gin.Context, hash string, reqData map[strin
 }
 
 // handleLogout 退出登录
-func (p *GyingPlugin) handleLogout(c *gin.Context, hash string) {
+func (p *GyingPlugin) handleLogout(c *gin.Context, hash string, _ map[string]interface{}) {
 	user, exists := p.getUserByHash(hash)
 	if !exists {
 		respondError(c, "用户不存在")
@@ -915,7 +827,7 @@ func (p *GyingPlugin) handleLogout(c *gin.Context, hash string) {
 	}
 
 	user.Cookie = ""
-	user.Status = "pending"
+	user.Status = accounts.StatusPending
 
 	if err := p.saveUser(user); err != nil {
 		respondError(c, "退出失败")
@@ -941,21 +853,21 @@ func (p *GyingPlugin) handleTestSearch(c *gin.Context, hash string, reqData map[
 		return
 	}
 
-	// 获取scraper实例
-	scraperVal, exists := p.scrapers.Load(hash)
+	// 获取HTTP客户端
+	clientVal, exists := p.clients.Load(hash)
 	if !exists {
-		respondError(c, "用户scraper实例不存在，请重新登录")
+		respondError(c, "用户HTTP客户端不存在，请重新登录")
 		return
 	}
 	
-	scraper, ok := scraperVal.(*cloudscraper.Scraper)
-	if !ok || scraper == nil {
-		respondError(c, "scraper实例无效，请重新登录")
+	client, ok := clientVal.(*http.Client)
+	if !ok || client == nil {
+		respondError(c, "HTTP客户端无效，请重新登录")
 		return
 	}
 	
 	// 执行搜索（带403自动重新登录）
-	results, err := p.searchWithScraperWithRetry(keyword, scraper, user)
+	results, err := p.searchWithClientWithRetry(keyword, client, user, nil)
 	if err != nil {
 		respondError(c, "搜索失败: "+err.Error())
 		return
@@ -1060,87 +972,42 @@ func (p *GyingPlugin) decryptPassword(encrypted string) (string, error) {
 
 // ============ Cookie管理 ============
 
-// createScraperWithCookies 创建一个带有指定cookies的cloudscraper实例
-// 使用反射访问内部的http.Client并设置cookies到cookiejar
-// 关键：禁用session refresh以防止cookies被清空
-func (p *GyingPlugin) createScraperWithCookies(cookieStr string) (*cloudscraper.Scraper, error) {
-	// 创建cloudscraper实例，配置以保护cookies不被刷新
-	scraper, err := cloudscraper.New(
-		cloudscraper.WithSessionConfig(
-			false,              // refreshOn403 = false，禁用403时自动刷新
-			365*24*time.Hour,   // interval = 1年，基本不刷新
-			0,                  // maxRetries = 0
-		),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("创建cloudscraper失败: %w", err)
+// newUserClient 创建用户独立的HTTP客户端
+// 登录Cookie保存在用户自己的cookiejar中，Cloudflare会话（cf_clearance、User-Agent、TLS指纹）
+// 与代理路由由共享的反爬传输管理，遇到质询时自动求解
+func newUserClient() *http.Client {
+	jar, _ := cookiejar.New(nil)
+	return &http.Client{
+		Jar:       jar,
+		Transport: util.NewAntiBotPluginTransport("gying"),
+		Timeout:   30 * time.Second,
 	}
-	
-	// 如果有保存的cookies，使用反射设置到scraper的内部http.Client
-	if cookieStr != "" {
-		cookies := parseCookieString(cookieStr)
-		
-		if DebugLog {
-			fmt.Printf("[Gying] 正在恢复 %d 个cookie到scraper实例\n", len(cookies))
-		}
-		
-		// 使用反射访问scraper的unexported client字段
-		scraperValue := reflect.ValueOf(scraper).Elem()
-		clientField := scraperValue.FieldByName("client")
-		
-		if clientField.IsValid() && !clientField.IsNil() {
-			// 使用反射访问client (需要使用Elem()因为是指针)
-			clientValue := reflect.NewAt(clientField.Type(), unsafe.Pointer(clientField.UnsafeAddr())).Elem()
-			client, ok := clientValue.Interface().(*http.Client)
-			
-			if ok && client != nil && client.Jar != nil {
-				// 将cookies设置到cookiejar
-				// 注意：必须使用正确的URL和cookie属性
-				gyingURL, _ := url.Parse("https://www.gying.net")
-				var httpCookies []*http.Cookie
-				
-				for name, value := range cookies {
-					cookie := &http.Cookie{
-						Name:   name,
-						Value:  value,
-						// 不设置Domain和Path，让cookiejar根据URL自动推导
-						// cookiejar.SetCookies会根据提供的URL自动设置正确的Domain和Path
-					}
-					httpCookies = append(httpCookies, cookie)
-					
-					if DebugLog {
-						fmt.Printf("[Gying]   准备恢复Cookie: %s=%s\n", 
-							cookie.Name, cookie.Value[:min(10, len(cookie.Value))])
-					}
-				}
-				
-				client.Jar.SetCookies(gyingURL, httpCookies)
-				
-				// 验证cookies是否被正确设置
-				if DebugLog {
-					storedCookies := client.Jar.Cookies(gyingURL)
-					fmt.Printf("[Gying] ✅ 成功恢复 %d 个cookie到scraper的cookiejar\n", len(cookies))
-					fmt.Printf("[Gying] 验证: cookiejar中现有 %d 个cookie\n", len(storedCookies))
-					
-					// 详细打印每个cookie以便调试  
-					for i, c := range storedCookies {
-						fmt.Printf("[Gying]   设置后Cookie[%d]: %s=%s (Domain:%s, Path:%s)\n", 
-							i, c.Name, c.Value[:min(10, len(c.Value))], c.Domain, c.Path)
-					}
-				}
-			} else {
-				if DebugLog {
-					fmt.Printf("[Gying] ⚠️  无法获取http.Client或其Jar\n")
-				}
-			}
-		} else {
-			if DebugLog {
-				fmt.Printf("[Gying] ⚠️  无法通过反射访问client字段\n")
-			}
-		}
+}
+
+// createClientWithCookies 创建一个带有指定cookies的HTTP客户端
+func (p *GyingPlugin) createClientWithCookies(cookieStr string) (*http.Client, error) {
+	client := newUserClient()
+	if cookieStr == "" {
+		return client, nil
 	}
-	
-	return scraper, nil
+
+	cookies := parseCookieString(cookieStr)
+	if DebugLog {
+		fmt.Printf("[Gying] 正在恢复 %d 个cookie到cookiejar\n", len(cookies))
+	}
+
+	// 不设置Domain和Path，由cookiejar根据URL推导
+	gyingURL, _ := url.Parse("https://www.gying.net")
+	httpCookies := make([]*http.Cookie, 0, len(cookies))
+	for name, value := range cookies {
+		httpCookies = append(httpCookies, &http.Cookie{Name: name, Value: value})
+	}
+	client.Jar.SetCookies(gyingURL, httpCookies)
+
+	if DebugLog {
+		fmt.Printf("[Gying] ✅ 成功恢复 %d 个cookie，cookiejar中现有 %d 个cookie\n", len(cookies), len(client.Jar.Cookies(gyingURL)))
+	}
+	return client, nil
 }
 
 // parseCookieString 解析cookie字符串为map
@@ -1162,40 +1029,23 @@ func parseCookieString(cookieStr string) map[string]string {
 
 // ============ 登录逻辑 ============
 
-// doLogin 执行登录，返回scraper实例和cookie字符串
+// doLogin 执行登录，返回HTTP客户端和cookie字符串
 // 
 // 登录流程（3步）：
 //   1. GET登录页 (https://www.gying.net/user/login/) → 获取PHPSESSID
 //   2. POST登录  (https://www.gying.net/user/login)  → 获取BT_auth、BT_cookietime等认证cookies
 //   3. GET详情页 (https://www.gying.net/mv/wkMn)     → 触发防爬cookies (vrg_sc、vrg_go等)
 //
-// 返回: (*cloudscraper.Scraper, cookie字符串, error)
-func (p *GyingPlugin) doLogin(username, password string) (*cloudscraper.Scraper, string, error) {
+// 返回: (*http.Client, cookie字符串, error)
+func (p *GyingPlugin) doLogin(username, password string) (*http.Client, string, error) {
 	if DebugLog {
 		fmt.Printf("[Gying] ========== 开始登录 ==========\n")
 		fmt.Printf("[Gying] 用户名: %s\n", username)
 		fmt.Printf("[Gying] 密码长度: %d\n", len(password))
 	}
 
-	// 创建cloudscraper实例（每个用户独立的实例）
-	// 关键配置：禁用403自动刷新,防止cookie被清空
-	scraper, err := cloudscraper.New(
-		cloudscraper.WithSessionConfig(
-			false,              // refreshOn403 = false，禁用403时自动刷新（重要！）
-			365*24*time.Hour,   // interval = 1年，基本不刷新
-			0,                  // maxRetries = 0
-		),
-	)
-	if err != nil {
-		if DebugLog {
-			fmt.Printf("[Gying] 创建cloudscraper失败: %v\n", err)
-		}
-		return nil, "", fmt.Errorf("创建cloudscraper失败: %w", err)
-	}
-
-	if DebugLog {
-		fmt.Printf("[Gying] cloudscraper创建成功（已禁用403自动刷新）\n")
-	}
+	// 创建HTTP客户端（每个用户独立的cookiejar）
+	client := newUserClient()
 
 	// 创建cookieMap用于收集所有cookies
 	cookieMap := make(map[string]string)
@@ -1206,7 +1056,7 @@ func (p *GyingPlugin) doLogin(username, password string) (*cloudscraper.Scraper,
 		fmt.Printf("[Gying] 步骤1: 访问登录页面: %s\n", loginPageURL)
 	}
 
-	getResp, err := scraper.Get(loginPageURL)
+	getResp, err := client.Get(loginPageURL)
 	if err != nil {
 		if DebugLog {
 			fmt.Printf("[Gying] 访问登录页面失败: %v\n", err)
@@ -1252,7 +1102,7 @@ func (p *GyingPlugin) doLogin(username, password string) (*cloudscraper.Scraper,
 		fmt.Printf("[Gying] POST数据: %s\n", postData)
 	}
 
-	resp, err := scraper.Post(loginURL, "application/x-www-form-urlencoded", strings.NewReader(postData))
+	resp, err := client.Post(loginURL, "application/x-www-form-urlencoded", strings.NewReader(postData))
 	if err != nil {
 		if DebugLog {
 			fmt.Printf("[Gying] 登录POST请求失败: %v\n", err)
@@ -1351,7 +1201,7 @@ func (p *GyingPlugin) doLogin(username, password string) (*cloudscraper.Scraper,
 		fmt.Printf("[Gying] 步骤3: GET详情页收集完整Cookie\n")
 	}
 	
-	detailResp, err := scraper.Get("https://www.gying.net/mv/wkMn")
+	detailResp, err := client.Get("https://www.gying.net/mv/wkMn")
 	if err == nil {
 		defer detailResp.Body.Close()
 		ioutil.ReadAll(detailResp.Body)
@@ -1401,8 +1251,8 @@ func (p *GyingPlugin) doLogin(username, password string) (*cloudscraper.Scraper,
 		fmt.Printf("[Gying] ========== 登录完成 ==========\n")
 	}
 
-	// 返回scraper实例和实际的cookie字符串
-	return scraper, cookieStr, nil
+	// 返回HTTP客户端和实际的cookie字符串
+	return client, cookieStr, nil
 }
 
 // min 辅助函数
@@ -1431,7 +1281,7 @@ func (p *GyingPlugin) reloginUser(user *User) error {
 	}
 	
 	// 执行登录
-	scraper, cookie, err := p.doLogin(user.Username, password)
+	client, cookie, err := p.doLogin(user.Username, password)
 	if err != nil {
 		if DebugLog {
 			fmt.Printf("[Gying] ❌ 重新登录失败: %v\n", err)
@@ -1439,14 +1289,14 @@ func (p *GyingPlugin) reloginUser(user *User) error {
 		return fmt.Errorf("重新登录失败: %w", err)
 	}
 	
-	// 更新scraper实例
-	p.scrapers.Store(user.Hash, scraper)
+	// 更新HTTP客户端
+	p.clients.Store(user.Hash, client)
 	
 	// 更新用户信息
 	user.Cookie = cookie
 	user.LoginAt = time.Now()
 	user.ExpireAt = time.Now().AddDate(0, 4, 0)
-	user.Status = "active"
+	user.Status = accounts.StatusActive
 	
 	if err := p.saveUser(user); err != nil {
 		if DebugLog {
@@ -1463,8 +1313,8 @@ func (p *GyingPlugin) reloginUser(user *User) error {
 
 // ============ 搜索逻辑 ============
 
-// executeSearchTasks 并发执行搜索任务
-func (p *GyingPlugin) executeSearchTasks(users []*User, keyword string) []model.SearchResult {
+// executeSearchTasks 并发执行搜索任务，各用户的客户端共享本次搜索的请求预算
+func (p *GyingPlugin) executeSearchTasks(users []*User, keyword string, budget *util.RequestBudget) []model.SearchResult {
 	var allResults []model.SearchResult
 	var mu sync.Mutex
 	var wg sync.WaitGroup
@@ -1474,43 +1324,44 @@ func (p *GyingPlugin) executeSearchTasks(users []*User, keyword string) []model.
 		go func(u *User) {
 			defer wg.Done()
 
-			// 获取用户的scraper实例
-			scraperVal, exists := p.scrapers.Load(u.Hash)
-			var scraper *cloudscraper.Scraper
+			// 获取用户的HTTP客户端
+			clientVal, exists := p.clients.Load(u.Hash)
+			var client *http.Client
 			
 			if !exists {
 				if DebugLog {
-					fmt.Printf("[Gying] 用户 %s 没有scraper实例，尝试使用已保存的cookie创建\n", u.UsernameMasked)
+					fmt.Printf("[Gying] 用户 %s 没有HTTP客户端，尝试使用已保存的cookie创建\n", u.UsernameMasked)
 				}
 				
-				// 使用已保存的cookie创建scraper实例（关键！）
-				newScraper, err := p.createScraperWithCookies(u.Cookie)
+				// 使用已保存的cookie创建HTTP客户端（关键！）
+				newClient, err := p.createClientWithCookies(u.Cookie)
 				if err != nil {
 					if DebugLog {
-						fmt.Printf("[Gying] 为用户 %s 创建scraper失败: %v\n", u.UsernameMasked, err)
+						fmt.Printf("[Gying] 为用户 %s 创建HTTP客户端失败: %v\n", u.UsernameMasked, err)
 					}
 					return
 				}
 				
-				// 存储新创建的scraper实例
-				p.scrapers.Store(u.Hash, newScraper)
-				scraper = newScraper
+				// 存储新创建的HTTP客户端
+				p.clients.Store(u.Hash, newClient)
+				client = newClient
 				
 				if DebugLog {
-					fmt.Printf("[Gying] 已为用户 %s 恢复scraper实例（含cookie）\n", u.UsernameMasked)
+					fmt.Printf("[Gying] 已为用户 %s 恢复HTTP客户端（含cookie）\n", u.UsernameMasked)
 				}
 			} else {
 				var ok bool
-				scraper, ok = scraperVal.(*cloudscraper.Scraper)
-				if !ok || scraper == nil {
+				client, ok = clientVal.(*http.Client)
+				if !ok || client == nil {
 					if DebugLog {
-						fmt.Printf("[Gying] 用户 %s scraper实例无效，跳过\n", u.UsernameMasked)
+						fmt.Printf("[Gying] 用户 %s HTTP客户端无效，跳过\n", u.UsernameMasked)
 					}
 					return
 				}
 			}
 
-			results, err := p.searchWithScraperWithRetry(keyword, scraper, u)
+			results, err := p.searchWithClientWithRetry(keyword, util.WithRequestBudget(client, budget), u, budget)
+			p.accounts.Report(u.Hash, err)
 			if err != nil {
 				if DebugLog {
 					fmt.Printf("[Gying] 用户 %s 搜索失败（已重试）: %v\n", u.UsernameMasked, err)
@@ -1530,9 +1381,9 @@ func (p *GyingPlugin) executeSearchTasks(users []*User, keyword string) []model.
 	return p.deduplicateResults(allResults)
 }
 
-// searchWithScraperWithRetry 使用scraper搜索（带403自动重新登录重试）
-func (p *GyingPlugin) searchWithScraperWithRetry(keyword string, scraper *cloudscraper.Scraper, user *User) ([]model.SearchResult, error) {
-	results, err := p.searchWithScraper(keyword, scraper)
+// searchWithClientWithRetry 使用用户客户端搜索（带403自动重新登录重试），重新登录后的客户端同样受budget约束
+func (p *GyingPlugin) searchWithClientWithRetry(keyword string, client *http.Client, user *User, budget *util.RequestBudget) ([]model.SearchResult, error) {
+	results, err := p.searchWithClient(keyword, client)
 	
 	// 检测是否为403错误
 	if err != nil && strings.Contains(err.Error(), "403") {
@@ -1548,22 +1399,22 @@ func (p *GyingPlugin) searchWithScraperWithRetry(keyword string, scraper *clouds
 			return nil, fmt.Errorf("403错误且重新登录失败: %w", reloginErr)
 		}
 		
-		// 获取新的scraper实例
-		scraperVal, exists := p.scrapers.Load(user.Hash)
+		// 获取新的HTTP客户端
+		clientVal, exists := p.clients.Load(user.Hash)
 		if !exists {
-			return nil, fmt.Errorf("重新登录后未找到scraper实例")
+			return nil, fmt.Errorf("重新登录后未找到HTTP客户端")
 		}
 		
-		newScraper, ok := scraperVal.(*cloudscraper.Scraper)
-		if !ok || newScraper == nil {
-			return nil, fmt.Errorf("重新登录后scraper实例无效")
+		newClient, ok := clientVal.(*http.Client)
+		if !ok || newClient == nil {
+			return nil, fmt.Errorf("重新登录后HTTP客户端无效")
 		}
 		
-		// 使用新scraper重试搜索
+		// 使用新客户端重试搜索
 		if DebugLog {
 			fmt.Printf("[Gying] 🔄 使用新登录状态重试搜索\n")
 		}
-		results, err = p.searchWithScraper(keyword, newScraper)
+		results, err = p.searchWithClient(keyword, util.WithRequestBudget(newClient, budget))
 		if err != nil {
 			return nil, fmt.Errorf("重新登录后搜索仍然失败: %w", err)
 		}
@@ -1572,22 +1423,22 @@ func (p *GyingPlugin) searchWithScraperWithRetry(keyword string, scraper *clouds
 	return results, err
 }
 
-// searchWithScraper 使用scraper搜索
-func (p *GyingPlugin) searchWithScraper(keyword string, scraper *cloudscraper.Scraper) ([]model.SearchResult, error) {
+// searchWithClient 使用用户客户端搜索
+func (p *GyingPlugin) searchWithClient(keyword string, client *http.Client) ([]model.SearchResult, error) {
 	if DebugLog {
-		fmt.Printf("[Gying] ---------- searchWithScraper 开始 ----------\n")
+		fmt.Printf("[Gying] ---------- searchWithClient 开始 ----------\n")
 		fmt.Printf("[Gying] 关键词: %s\n", keyword)
 	}
 
-	// 1. 使用cloudscraper请求搜索页面
+	// 1. 请求搜索页面
 	searchURL := fmt.Sprintf("https://www.gying.net/s/2-0--1/%s", url.QueryEscape(keyword))
 	
 	if DebugLog {
 		fmt.Printf("[Gying] 搜索URL: %s\n", searchURL)
-		fmt.Printf("[Gying] 使用cloudscraper发送请求\n")
+		fmt.Printf("[Gying] 使用用户客户端发送请求\n")
 	}
 
-	resp, err := scraper.Get(searchURL)
+	resp, err := client.Get(searchURL)
 	if err != nil {
 		if DebugLog {
 			fmt.Printf("[Gying] 搜索请求失败: %v\n", err)
@@ -1690,7 +1541,7 @@ func (p *GyingPlugin) searchWithScraper(keyword string, scraper *cloudscraper.Sc
 	if DebugLog {
 		fmt.Printf("[Gying] 刷新防爬cookies...\n")
 	}
-	refreshResp, err := scraper.Get("https://www.gying.net/mv/wkMn")
+	refreshResp, err := client.Get("https://www.gying.net/mv/wkMn")
 	if err == nil && refreshResp != nil {
 		refreshResp.Body.Close()
 		if DebugLog {
@@ -1699,25 +1550,25 @@ func (p *GyingPlugin) searchWithScraper(keyword string, scraper *cloudscraper.Sc
 	}
 	
 	// 4. 并发请求详情接口
-	results, err := p.fetchAllDetails(&searchData, scraper, keyword)
+	results, err := p.fetchAllDetails(&searchData, client, keyword)
 	if err != nil {
 		if DebugLog {
 			fmt.Printf("[Gying] fetchAllDetails 失败: %v\n", err)
-			fmt.Printf("[Gying] ---------- searchWithScraper 结束 ----------\n")
+			fmt.Printf("[Gying] ---------- searchWithClient 结束 ----------\n")
 		}
 		return nil, err
 	}
 	
 	if DebugLog {
 		fmt.Printf("[Gying] fetchAllDetails 返回 %d 条结果\n", len(results))
-		fmt.Printf("[Gying] ---------- searchWithScraper 结束 ----------\n")
+		fmt.Printf("[Gying] ---------- searchWithClient 结束 ----------\n")
 	}
 
 	return results, nil
 }
 
 // fetchAllDetails 并发获取所有详情
-func (p *GyingPlugin) fetchAllDetails(searchData *SearchData, scraper *cloudscraper.Scraper, keyword string) ([]model.SearchResult, error) {
+func (p *GyingPlugin) fetchAllDetails(searchData *SearchData, client *http.Client, keyword string) ([]model.SearchResult, error) {
 	if DebugLog {
 		fmt.Printf("[Gying] >>> fetchAllDetails 开始\n")
 		fmt.Printf("[Gying] 需要获取 %d 个详情，关键词: %s\n", len(searchData.L.I), keyword)
@@ -1777,7 +1628,7 @@ func (p *GyingPlugin) fetchAllDetails(searchData *SearchData, scraper *cloudscra
 					index+1, len(searchData.L.I), searchData.L.I[index], searchData.L.D[index], title)
 			}
 
-			detail, err := p.fetchDetail(searchData.L.I[index], searchData.L.D[index], scraper)
+			detail, err := p.fetchDetail(searchData.L.I[index], searchData.L.D[index], client)
 			if err != nil {
 				if DebugLog {
 					fmt.Printf("[Gying]   [%d/%d] ❌ 获取详情失败: %v\n", index+1, len(searchData.L.I), err)
@@ -1842,15 +1693,15 @@ func (p *GyingPlugin) fetchAllDetails(searchData *SearchData, scraper *cloudscra
 }
 
 // fetchDetail 获取详情
-func (p *GyingPlugin) fetchDetail(resourceID, resourceType string, scraper *cloudscraper.Scraper) (*DetailData, error) {
+func (p *GyingPlugin) fetchDetail(resourceID, resourceType string, client *http.Client) (*DetailData, error) {
 	detailURL := fmt.Sprintf("https://www.gying.net/res/downurl/%s/%s", resourceType, resourceID)
 	
 	if DebugLog {
 		fmt.Printf("[Gying]     fetchDetail: %s\n", detailURL)
 	}
 
-	// 使用cloudscraper发送请求（自动管理Cookie和绕过反爬虫）
-	resp, err := scraper.Get(detailURL)
+	// 使用用户客户端发送请求（cookiejar管理登录Cookie，反爬传输处理Cloudflare质询）
+	resp, err := client.Get(detailURL)
 
 	if err != nil {
 		if DebugLog {
@@ -2265,179 +2116,30 @@ func respondError(c *gin.Context, message string) {
 	})
 }
 
-// ============ Cookie加密（可选） ============
-
-func getEncryptionKey() []byte {
-	key := os.Getenv("GYING_ENCRYPTION_KEY")
-	if key == "" {
-		key = "default-32-byte-key-change-me!"
-	}
-	return []byte(key)[:32]
-}
-
-func encryptCookie(plaintext string) (string, error) {
-	key := getEncryptionKey()
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
-	}
-
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
-}
-
-func decryptCookie(encrypted string) (string, error) {
-	key := getEncryptionKey()
-	ciphertext, err := base64.StdEncoding.DecodeString(encrypted)
-	if err != nil {
-		return "", err
-	}
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
+// ============ Session保活 ============
 
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
+// keepSessionAlive 访问首页保持用户session活跃（由账号管理器定期调用）
+func (p *GyingPlugin) keepSessionAlive(r accounts.Record) error {
+	user := r.(*User)
+	clientVal, exists := p.clients.Load(user.Hash)
+	if !exists {
+		return nil
 	}
-
-	nonceSize := gcm.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
+	client, ok := clientVal.(*http.Client)
+	if !ok || client == nil {
+		return nil
 	}
 
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	resp, err := client.Get("https://www.gying.net/")
 	if err != nil {
-		return "", err
-	}
-
-	return string(plaintext), nil
-}
-
-// ============ Session保活 ============
-
-// startSessionKeepAlive 启动session保活任务
-func (p *GyingPlugin) startSessionKeepAlive() {
-	// 首次启动后延迟3分钟再开始（避免启动时过多请求）
-	time.Sleep(3 * time.Minute)
-	
-	// 立即执行一次保活
-	p.keepAllSessionsAlive()
-	
-	// 每3分钟执行一次保活
-	ticker := time.NewTicker(3 * time.Minute)
-	for range ticker.C {
-		p.keepAllSessionsAlive()
+		return err
 	}
-}
-
-// keepAllSessionsAlive 保持所有用户的session活跃
-func (p *GyingPlugin) keepAllSessionsAlive() {
-	count := 0
-	
-	p.users.Range(func(key, value interface{}) bool {
-		user := value.(*User)
-		
-		// 只为active状态的用户保活
-		if user.Status != "active" {
-			return true
-		}
-		
-		// 获取scraper实例
-		scraperVal, exists := p.scrapers.Load(user.Hash)
-		if !exists {
-			return true
-		}
-		
-		scraper, ok := scraperVal.(*cloudscraper.Scraper)
-		if !ok || scraper == nil {
-			return true
-		}
-		
-		// 访问首页保持session活跃
-		go func(s *cloudscraper.Scraper, username string) {
-			resp, err := s.Get("https://www.gying.net/")
-			if err == nil && resp != nil {
-				resp.Body.Close()
-				if DebugLog {
-					fmt.Printf("[Gying] 💓 Session保活成功: %s (状态码: %d)\n", username, resp.StatusCode)
-				}
-			}
-		}(scraper, user.UsernameMasked)
-		
-		count++
-		return true
-	})
-	
-	if DebugLog && count > 0 {
-		fmt.Printf("[Gying] 💓 已为 %d 个用户执行session保活\n", count)
+	resp.Body.Close()
+	if DebugLog {
+		fmt.Printf("[Gying] 💓 Session保活: %s (状态码: %d)\n", user.UsernameMasked, resp.StatusCode)
 	}
-}
-
-// ============ 定期清理 ============
-
-func (p *GyingPlugin) startCleanupTask() {
-	ticker := time.NewTicker(24 * time.Hour)
-	for range ticker.C {
-		deleted := p.cleanupExpiredUsers()
-		marked := p.markInactiveUsers()
-
-		if deleted > 0 || marked > 0 {
-			fmt.Printf("[Gying] 清理任务完成: 删除 %d 个过期用户, 标记 %d 个不活跃用户\n", deleted, marked)
-		}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("保活请求返回状态码 %d", resp.StatusCode)
 	}
+	return nil
 }
-
-func (p *GyingPlugin) cleanupExpiredUsers() int {
-	deletedCount := 0
-	now := time.Now()
-	expireThreshold := now.AddDate(0, 0, -30)
-
-	p.users.Range(func(key, value interface{}) bool {
-		user := value.(*User)
-		if user.Status == "expired" && user.LastAccessAt.Before(expireThreshold) {
-			if err := p.deleteUser(user.Hash); err == nil {
-				deletedCount++
-			}
-		}
-		return true
-	})
-
-	return deletedCount
-}
-
-func (p *GyingPlugin) markInactiveUsers() int {
-	markedCount := 0
-	now := time.Now()
-	inactiveThreshold := now.AddDate(0, 0, -90)
-
-	p.users.Range(func(key, value interface{}) bool {
-		user := value.(*User)
-		if user.LastAccessAt.Before(inactiveThreshold) && user.Status != "expired" {
-			user.Status = "expired"
-			user.Cookie = ""
-
-			if err := p.saveUser(user); err == nil {
-				markedCount++
-			}
-		}
-		return true
-	})
-
-	return markedCount
-}
-
-
@@ -14,6 +14,7 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 const (
@@ -115,7 +116,7 @@ func createOptimizedHTTPClient() *http.Client {
 	}
 
 	return &http.Client{
-		Transport: transport,
+		Transport: util.NewPluginTransport("huban", transport),
 		Timeout:   DefaultTimeout,
 	}
 }
@@ -181,7 +182,7 @@ func (p *HubanAsyncPlugin) searchImpl(client *http.Client, keyword string, ext m
 
 	// 使用优化的客户端
 	if p.optimizedClient != nil {
-		client = p.optimizedClient
+		client = util.BudgetedClient(p.optimizedClient, ext)
 	}
 
 	// 1. 构建搜索URL
@@ -15,6 +15,7 @@ import (
 
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 const (
@@ -112,7 +113,7 @@ func (p *JsNoteClubPlugin) SearchWithResult(keyword string, ext map[string]inter
 
 func (p *JsNoteClubPlugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	if p.client != nil {
-		client = p.client
+		client = util.BudgetedClient(p.client, ext)
 	}
 
 	searchKeyword := strings.TrimSpace(keyword)
@@ -515,13 +516,13 @@ func (p *JsNoteClubPlugin) doRequestWithRetry(req *http.Request, client *http.Cl
 func newHTTPClient() *http.Client {
 	return &http.Client{
 		Timeout: requestTimeout,
-		Transport: &http.Transport{
+		Transport: util.NewPluginTransport(pluginName, &http.Transport{
 			MaxIdleConns:        httpMaxIdleConns,
 			MaxIdleConnsPerHost: httpMaxIdlePerHost,
 			MaxConnsPerHost:     httpMaxConnsPerHost,
 			IdleConnTimeout:     90 * time.Second,
 			TLSHandshakeTimeout: 10 * time.Second,
-		},
+		}),
 	}
 }
 
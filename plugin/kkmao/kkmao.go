@@ -14,6 +14,7 @@ import (
 
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 var (
@@ -98,14 +99,14 @@ func newHTTPClient() *http.Client {
 		ForceAttemptHTTP2:     true,
 	}
 	return &http.Client{
-		Transport: transport,
+		Transport: util.NewPluginTransport(pluginName, transport),
 		Timeout:   searchTimeout,
 	}
 }
 
 func (p *KkMaoPlugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	if p.client != nil {
-		client = p.client
+		client = util.BudgetedClient(p.client, ext)
 	}
 
 	searchURL := fmt.Sprintf("https://www.kuakemao.com/?s=%s", url.QueryEscape(keyword))
@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"regexp"
 	"strings"
 	"sync"
@@ -90,7 +91,7 @@ func createOptimizedHTTPClient() *http.Client {
 		IdleConnTimeout:     IdleConnTimeout,
 		DisableKeepAlives:   false,
 	}
-	return &http.Client{Transport: transport, Timeout: DefaultTimeout}
+	return &http.Client{Transport: util.NewPluginTransport("labi", transport), Timeout: DefaultTimeout}
 }
 
 // NewLabiPlugin 创建新的Labi异步插件
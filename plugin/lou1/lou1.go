@@ -14,6 +14,7 @@ import (
 
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 const (
@@ -94,7 +95,7 @@ func (p *Lou1Plugin) SearchWithResult(keyword string, ext map[string]interface{}
 
 func (p *Lou1Plugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	if p.client != nil {
-		client = p.client
+		client = util.BudgetedClient(p.client, ext)
 	}
 
 	searchKeyword := strings.TrimSpace(keyword)
@@ -529,13 +530,13 @@ func filterQuarkLinks(links []model.Link) []model.Link {
 func newHTTPClient() *http.Client {
 	return &http.Client{
 		Timeout: requestTimeout,
-		Transport: &http.Transport{
+		Transport: util.NewPluginTransport(pluginName, &http.Transport{
 			MaxIdleConns:        httpMaxIdleConns,
 			MaxIdleConnsPerHost: httpMaxIdlePerHost,
 			MaxConnsPerHost:     httpMaxConnsPerHost,
 			IdleConnTimeout:     90 * time.Second,
 			TLSHandshakeTimeout: 10 * time.Second,
-		},
+		}),
 	}
 }
 
@@ -11,6 +11,7 @@ import (
 
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"pansou/util/json"
 )
 
@@ -94,7 +95,7 @@ func createOptimizedHTTPClient() *http.Client {
 	}
 
 	return &http.Client{
-		Transport: transport,
+		Transport: util.NewPluginTransport(PluginName, transport),
 		Timeout:   RequestTimeout,
 	}
 }
@@ -165,7 +166,7 @@ func (p *MeitizyPlugin) searchImpl(client *http.Client, keyword string, ext map[
 	req.Header.Set("Referer", BaseURL+"/")
 
 	// 使用优化的客户端发送请求（带重试）
-	resp, err := p.doRequestWithRetry(req, p.optimizedClient)
+	resp, err := p.doRequestWithRetry(req, util.BudgetedClient(p.optimizedClient, ext))
 	if err != nil {
 		return nil, fmt.Errorf("[%s] 搜索请求失败: %w", p.Name(), err)
 	}
@@ -0,0 +1,721 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+
+	"pansou/config"
+	"pansou/util/json"
+)
+
+const (
+	// 镜像状态存储文件名（位于缓存目录下）
+	mirrorStateFileName = "plugin_mirrors.json"
+	// 重定向链的最大跟随次数（防止配置成环）
+	maxMirrorRedirectHops = 5
+	// 重定向到其他域名时，需要连续观察到的次数才会确认迁移
+	mirrorRedirectConfirmations = 3
+	// 计入确认次数的两次观察之间的最小间隔（同一次搜索中的并发请求只算一次）
+	mirrorRedirectConfirmInterval = time.Minute
+)
+
+// mirrorState 持久化的镜像状态：管理员设置的地址、确认的永久重定向与待确认的重定向
+type mirrorState struct {
+	Overrides  []string                    `json:"overrides,omitempty"`
+	Redirects  map[string]string           `json:"redirects,omitempty"`  // 旧基础地址 -> 新基础地址
+	Candidates map[string]*mirrorCandidate `json:"candidates,omitempty"` // 旧基础地址 -> 待确认的新地址
+}
+
+// mirrorCandidate 重定向到其他域名、尚未确认的新地址
+type mirrorCandidate struct {
+	URL       string    `json:"url"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// mirrorEndpointStats 单个基础地址的请求统计
+type mirrorEndpointStats struct {
+	successes   int64
+	failures    int64
+	lastError   string
+	lastFailure time.Time
+	lastSuccess time.Time
+}
+
+// MirrorSet 插件的基础地址列表
+// 插件按声明的第一个默认地址构造请求，传输层将请求改写到当前可用的地址，
+// 连接失败或返回5xx时按顺序切换到下一个地址，整站永久重定向到新地址时自动改用新地址
+// 重定向到地址列表之外的其他域名时先作为候选地址，只在其他地址都失败时尝试，
+// 间隔一段时间连续观察到相同的重定向后才确认迁移，避免一个被控制的镜像立即接管插件的全部流量
+type MirrorSet struct {
+	plugin   string
+	defaults []string
+
+	mu     sync.Mutex
+	active string // 最近一次请求成功的地址
+	stats  map[string]*mirrorEndpointStats
+}
+
+// mirrorRegistry 所有插件的镜像列表与持久化状态
+type mirrorRegistry struct {
+	path     string
+	loadOnce sync.Once
+
+	mu    sync.Mutex
+	sets  map[string]*MirrorSet
+	state map[string]*mirrorState
+}
+
+// globalMirrorRegistry 插件在init中声明基础地址，此时配置尚未加载，持久化状态延迟到首次使用时读取
+var globalMirrorRegistry = &mirrorRegistry{
+	sets:  make(map[string]*MirrorSet),
+	state: make(map[string]*mirrorState),
+}
+
+// getMirrorRegistry 获取全局镜像注册表（首次调用时从缓存目录加载状态）
+func getMirrorRegistry() *mirrorRegistry {
+	r := globalMirrorRegistry
+	r.loadOnce.Do(func() {
+		dir := "./cache"
+		if config.AppConfig != nil && config.AppConfig.CachePath != "" {
+			dir = config.AppConfig.CachePath
+		}
+		r.path = filepath.Join(dir, mirrorStateFileName)
+		r.load()
+	})
+	return r
+}
+
+// load 从文件恢复镜像状态
+func (r *mirrorRegistry) load() {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return
+	}
+	var stored map[string]*mirrorState
+	if err := json.Unmarshal(data, &stored); err != nil {
+		fmt.Printf("[镜像] 解析镜像状态文件失败: %v\n", err)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, state := range stored {
+		if state != nil {
+			r.state[name] = state
+		}
+	}
+}
+
+// saveLocked 写入文件（调用方需持有锁）
+func (r *mirrorRegistry) saveLocked() {
+	data, err := json.Marshal(r.state)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return
+	}
+	tmpPath := r.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return
+	}
+	os.Rename(tmpPath, r.path)
+}
+
+// stateLocked 获取插件的持久化状态，不存在时创建（调用方需持有锁）
+func (r *mirrorRegistry) stateLocked(name string) *mirrorState {
+	state, ok := r.state[name]
+	if !ok {
+		state = &mirrorState{}
+		r.state[name] = state
+	}
+	return state
+}
+
+// register 登记插件声明的默认地址
+func (r *mirrorRegistry) register(name string, defaults []string) *MirrorSet {
+	normalized := make([]string, 0, len(defaults))
+	for _, raw := range defaults {
+		base, err := normalizeBaseURL(raw)
+		if err != nil {
+			fmt.Printf("[镜像] 插件 %s 的默认地址 %s 无效: %v\n", name, raw, err)
+			continue
+		}
+		normalized = appendUnique(normalized, base)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	set := &MirrorSet{
+		plugin:   name,
+		defaults: normalized,
+		stats:    make(map[string]*mirrorEndpointStats),
+	}
+	r.sets[name] = set
+	return set
+}
+
+// normalizeBaseURL 校验并规范化基础地址（协议+主机+可选路径前缀，不含末尾斜杠）
+func normalizeBaseURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("不支持的协议: %s", u.Scheme)
+	}
+	if u.Host == "" {
+		return "", errors.New("缺少主机名")
+	}
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + strings.TrimRight(u.EscapedPath(), "/"), nil
+}
+
+// appendUnique 追加不重复的地址
+func appendUnique(list []string, base string) []string {
+	if containsBase(list, base) {
+		return list
+	}
+	return append(list, base)
+}
+
+// configured 生效的地址列表来源：管理员设置 > 环境变量 > 插件默认
+func (s *MirrorSet) configured(state *mirrorState) ([]string, string) {
+	if state != nil && len(state.Overrides) > 0 {
+		return state.Overrides, "admin"
+	}
+	if config.AppConfig != nil {
+		if urls := config.AppConfig.PluginMirrors[s.plugin]; len(urls) > 0 {
+			normalized := make([]string, 0, len(urls))
+			for _, raw := range urls {
+				if base, err := normalizeBaseURL(raw); err == nil {
+					normalized = appendUnique(normalized, base)
+				}
+			}
+			if len(normalized) > 0 {
+				return normalized, "config"
+			}
+		}
+	}
+	return s.defaults, "default"
+}
+
+// containsBase 列表中是否包含该地址
+func containsBase(list []string, base string) bool {
+	for _, existing := range list {
+		if existing == base {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedLocked 插件默认地址与生效的配置地址，重定向到这些地址时直接确认（调用方需持有注册表的锁）
+func (s *MirrorSet) allowedLocked(state *mirrorState) []string {
+	configured, _ := s.configured(state)
+	return append(append([]string{}, s.defaults...), configured...)
+}
+
+// sameSite 两个地址是否属于同一个可注册域名（如 www.example.com 与 example.com）
+func sameSite(a, b string) bool {
+	ua, errA := url.Parse(a)
+	ub, errB := url.Parse(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	siteA, errA := publicsuffix.EffectiveTLDPlusOne(ua.Hostname())
+	siteB, errB := publicsuffix.EffectiveTLDPlusOne(ub.Hostname())
+	return errA == nil && errB == nil && siteA == siteB
+}
+
+// resolveRedirect 沿确认的永久重定向找到最终地址
+func resolveRedirect(state *mirrorState, base string) string {
+	if state == nil {
+		return base
+	}
+	for i := 0; i < maxMirrorRedirectHops; i++ {
+		next, ok := state.Redirects[base]
+		if !ok || next == base {
+			break
+		}
+		base = next
+	}
+	return base
+}
+
+// listsLocked 生效的地址（已按确认的重定向替换）与待确认的候选地址（调用方需持有注册表的锁）
+func (s *MirrorSet) listsLocked(state *mirrorState) ([]string, []string) {
+	configured, _ := s.configured(state)
+	list := make([]string, 0, len(configured))
+	for _, base := range configured {
+		list = appendUnique(list, resolveRedirect(state, base))
+	}
+	var pending []string
+	if state != nil {
+		for _, base := range list {
+			if candidate, ok := state.Candidates[base]; ok && !containsBase(list, candidate.URL) {
+				pending = appendUnique(pending, candidate.URL)
+			}
+		}
+	}
+	return list, pending
+}
+
+// candidates 按尝试顺序返回地址：最近成功的地址优先，其余按配置顺序，待确认的候选地址最后尝试
+func (s *MirrorSet) candidates() []string {
+	r := getMirrorRegistry()
+	r.mu.Lock()
+	list, pending := s.listsLocked(r.state[s.plugin])
+	r.mu.Unlock()
+
+	s.mu.Lock()
+	active := s.active
+	s.mu.Unlock()
+	for i, base := range list {
+		if base == active && i > 0 {
+			reordered := append([]string{base}, list[:i]...)
+			list = append(reordered, list[i+1:]...)
+			break
+		}
+	}
+	return append(list, pending...)
+}
+
+// BaseURL 当前首选的基础地址（用于拼接结果中的页面链接）
+func (s *MirrorSet) BaseURL() string {
+	if list := s.candidates(); len(list) > 0 {
+		return list[0]
+	}
+	return ""
+}
+
+// knownBases 所有可能出现在请求中的地址：默认、配置、管理员设置以及确认的重定向两端的地址
+// 待确认的候选地址不在其中，客户端跟随重定向访问候选地址时不会被改写回旧地址
+func (s *MirrorSet) knownBases() []string {
+	r := getMirrorRegistry()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state := r.state[s.plugin]
+	bases := s.allowedLocked(state)
+	if state != nil {
+		for from, to := range state.Redirects {
+			bases = append(bases, from, to)
+		}
+	}
+	return bases
+}
+
+// match 判断请求是否指向插件的某个基础地址，返回该地址与剩余的路径（已转义）
+func (s *MirrorSet) match(u *url.URL) (string, string, bool) {
+	prefix := strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host)
+	path := u.EscapedPath()
+	best, rest := "", ""
+	for _, base := range s.knownBases() {
+		if !strings.HasPrefix(base, prefix) {
+			continue
+		}
+		basePath := base[len(prefix):]
+		if basePath != "" && basePath[0] != '/' {
+			continue // 主机名只是前缀相同（如 a.com 与 a.com.cn）
+		}
+		if path != basePath && !strings.HasPrefix(path, basePath+"/") {
+			continue
+		}
+		if len(base) > len(best) {
+			best, rest = base, path[len(basePath):]
+		}
+	}
+	return best, rest, best != ""
+}
+
+// rewrite 将请求改写到指定的基础地址，指向插件任一基础地址的Referer和Origin一并改写
+func rewrite(req *http.Request, known []string, to, rest string) *http.Request {
+	out := req.Clone(req.Context())
+	target, err := url.Parse(to + rest)
+	if err != nil {
+		return out
+	}
+	u := *req.URL
+	u.Scheme, u.Host = target.Scheme, target.Host
+	u.Path, u.RawPath = target.Path, target.RawPath
+	out.URL = &u
+	out.Host = ""
+	for _, header := range []string{"Referer", "Origin"} {
+		value := out.Header.Get(header)
+		for _, base := range known {
+			if value == base || strings.HasPrefix(value, base+"/") {
+				out.Header.Set(header, to+value[len(base):])
+				break
+			}
+		}
+	}
+	return out
+}
+
+// rewindBody 为重试准备新的请求体，请求体无法重放时返回false
+func rewindBody(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, true
+}
+
+// endpointStatsLocked 获取地址的统计信息（调用方需持有锁）
+func (s *MirrorSet) endpointStatsLocked(base string) *mirrorEndpointStats {
+	stats, ok := s.stats[base]
+	if !ok {
+		stats = &mirrorEndpointStats{}
+		s.stats[base] = stats
+	}
+	return stats
+}
+
+// reportSuccess 记录请求成功并设为首选地址（待确认的候选地址不会排到生效地址之前）
+func (s *MirrorSet) reportSuccess(base string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.endpointStatsLocked(base)
+	stats.successes++
+	stats.lastSuccess = time.Now()
+	if s.active != base {
+		if s.active != "" {
+			fmt.Printf("[镜像] 插件 %s 切换到 %s\n", s.plugin, base)
+		}
+		s.active = base
+	}
+}
+
+// reportFailure 记录请求失败
+func (s *MirrorSet) reportFailure(base, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.endpointStatsLocked(base)
+	stats.failures++
+	stats.lastError = reason
+	stats.lastFailure = time.Now()
+	if s.active == base {
+		s.active = ""
+	}
+}
+
+// recordRedirect 检测整站永久重定向（路径和参数不变、只换域名）
+// 新地址在插件的地址列表中或与旧地址属于同一个可注册域名时直接确认；
+// 其他域名先作为候选地址，间隔mirrorRedirectConfirmInterval以上连续观察到mirrorRedirectConfirmations次后确认
+func (s *MirrorSet) recordRedirect(base string, req *http.Request, resp *http.Response) {
+	if resp.StatusCode != http.StatusMovedPermanently && resp.StatusCode != http.StatusPermanentRedirect {
+		return
+	}
+	location, err := resp.Location()
+	if err != nil || (strings.EqualFold(location.Host, req.URL.Host) && location.Scheme == req.URL.Scheme) {
+		return
+	}
+	if strings.TrimRight(location.EscapedPath(), "/") != strings.TrimRight(req.URL.EscapedPath(), "/") || location.RawQuery != req.URL.RawQuery {
+		return
+	}
+	basePath := strings.TrimPrefix(base, strings.ToLower(req.URL.Scheme)+"://"+strings.ToLower(req.URL.Host))
+	newBase, err := normalizeBaseURL(location.Scheme + "://" + location.Host + basePath)
+	if err != nil || newBase == base {
+		return
+	}
+
+	r := getMirrorRegistry()
+	r.mu.Lock()
+	state := r.stateLocked(s.plugin)
+	if state.Redirects[base] == newBase {
+		r.mu.Unlock()
+		return
+	}
+	if !containsBase(s.allowedLocked(state), newBase) && !sameSite(base, newBase) {
+		confirmed := s.observeCandidateLocked(state, base, newBase)
+		if !confirmed {
+			r.saveLocked()
+			r.mu.Unlock()
+			return
+		}
+	}
+	if state.Redirects == nil {
+		state.Redirects = make(map[string]string)
+	}
+	// 站点迁回旧地址时删除反方向的旧记录，避免形成环
+	if resolveRedirect(state, newBase) == base {
+		delete(state.Redirects, newBase)
+	}
+	state.Redirects[base] = newBase
+	delete(state.Candidates, base)
+	r.saveLocked()
+	r.mu.Unlock()
+
+	// 客户端随后跟随重定向访问新地址，直接作为首选地址
+	s.mu.Lock()
+	s.active = newBase
+	s.mu.Unlock()
+	fmt.Printf("[镜像] 插件 %s 的地址 %s 已永久迁移到 %s\n", s.plugin, base, newBase)
+}
+
+// observeCandidateLocked 记录一次重定向到其他域名的观察，达到确认次数时返回true（调用方需持有注册表的锁）
+func (s *MirrorSet) observeCandidateLocked(state *mirrorState, base, newBase string) bool {
+	now := time.Now()
+	if state.Candidates == nil {
+		state.Candidates = make(map[string]*mirrorCandidate)
+	}
+	candidate, ok := state.Candidates[base]
+	if !ok || candidate.URL != newBase {
+		state.Candidates[base] = &mirrorCandidate{URL: newBase, Count: 1, FirstSeen: now, LastSeen: now}
+		fmt.Printf("[镜像] 插件 %s 的地址 %s 重定向到其他域名 %s，作为候选地址等待确认\n", s.plugin, base, newBase)
+		return false
+	}
+	if now.Sub(candidate.LastSeen) < mirrorRedirectConfirmInterval {
+		return false
+	}
+	candidate.Count++
+	candidate.LastSeen = now
+	return candidate.Count >= mirrorRedirectConfirmations
+}
+
+// mirrorTransport 按镜像列表改写请求并在失败时切换地址
+type mirrorTransport struct {
+	set  *MirrorSet
+	base http.RoundTripper
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *mirrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	_, rest, ok := t.set.match(req.URL)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	candidates := t.set.candidates()
+	if len(candidates) == 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	known := t.set.knownBases()
+	var lastResp *http.Response
+	var lastErr error
+	for i, candidate := range candidates {
+		attempt := req
+		if i > 0 {
+			if req.Context().Err() != nil {
+				break
+			}
+			rewound, ok := rewindBody(req)
+			if !ok {
+				break
+			}
+			attempt = rewound
+		}
+
+		if lastResp != nil {
+			lastResp.Body.Close()
+			lastResp = nil
+		}
+		out := rewrite(attempt, known, candidate, rest)
+		resp, err := t.base.RoundTrip(out)
+		if err == nil && resp.StatusCode < 500 {
+			t.set.reportSuccess(candidate)
+			t.set.recordRedirect(candidate, out, resp)
+			return resp, nil
+		}
+
+		if err != nil {
+			// 请求被调用方取消时不算地址故障
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return nil, err
+			}
+			t.set.reportFailure(candidate, err.Error())
+		} else {
+			t.set.reportFailure(candidate, fmt.Sprintf("状态码: %d", resp.StatusCode))
+		}
+		lastResp, lastErr = resp, err
+	}
+	if lastResp != nil {
+		return lastResp, nil
+	}
+	return nil, lastErr
+}
+
+// Client 返回使用镜像列表的客户端副本
+func (s *MirrorSet) Client(client *http.Client) *http.Client {
+	if client == nil {
+		return nil
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := *client
+	wrapped.Transport = &mirrorTransport{set: s, base: base}
+	return &wrapped
+}
+
+// MirrorEndpointStatus 单个基础地址的状态
+type MirrorEndpointStatus struct {
+	URL         string    `json:"url"`
+	Active      bool      `json:"active"`
+	Pending     bool      `json:"pending,omitempty"` // 待确认的重定向候选地址，其他地址都失败时才尝试
+	Successes   int64     `json:"successes"`
+	Failures    int64     `json:"failures"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastFailure time.Time `json:"last_failure,omitempty"`
+	LastSuccess time.Time `json:"last_success,omitempty"`
+}
+
+// MirrorStatus 插件的镜像状态
+type MirrorStatus struct {
+	Plugin    string            `json:"plugin"`
+	Source    string            `json:"source"` // 生效列表的来源：admin、config、default
+	Defaults  []string          `json:"defaults"`
+	Overrides []string          `json:"overrides,omitempty"`
+	Redirects map[string]string `json:"redirects,omitempty"`
+	// 重定向到其他域名、等待确认的候选地址（旧基础地址 -> 候选），确认前可由管理员加入地址列表
+	RedirectCandidates map[string]MirrorRedirectCandidate `json:"redirect_candidates,omitempty"`
+	Endpoints          []MirrorEndpointStatus             `json:"endpoints"`
+}
+
+// MirrorRedirectCandidate 待确认的重定向
+type MirrorRedirectCandidate struct {
+	URL       string    `json:"url"`
+	Count     int       `json:"count"` // 已观察到的次数，达到确认次数后自动迁移
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// status 生成插件的镜像状态
+func (s *MirrorSet) status() MirrorStatus {
+	r := getMirrorRegistry()
+	r.mu.Lock()
+	state := r.state[s.plugin]
+	_, source := s.configured(state)
+	status := MirrorStatus{Plugin: s.plugin, Source: source, Defaults: s.defaults}
+	if state != nil {
+		status.Overrides = append([]string{}, state.Overrides...)
+		if len(state.Redirects) > 0 {
+			status.Redirects = make(map[string]string, len(state.Redirects))
+			for from, to := range state.Redirects {
+				status.Redirects[from] = to
+			}
+		}
+		if len(state.Candidates) > 0 {
+			status.RedirectCandidates = make(map[string]MirrorRedirectCandidate, len(state.Candidates))
+			for from, candidate := range state.Candidates {
+				status.RedirectCandidates[from] = MirrorRedirectCandidate(*candidate)
+			}
+		}
+	}
+	_, pending := s.listsLocked(state)
+	r.mu.Unlock()
+
+	candidates := s.candidates()
+	s.mu.Lock()
+	for _, base := range candidates {
+		endpoint := MirrorEndpointStatus{URL: base, Active: base == s.active, Pending: containsBase(pending, base)}
+		if stats, ok := s.stats[base]; ok {
+			endpoint.Successes = stats.successes
+			endpoint.Failures = stats.failures
+			endpoint.LastError = stats.lastError
+			endpoint.LastFailure = stats.lastFailure
+			endpoint.LastSuccess = stats.lastSuccess
+		}
+		status.Endpoints = append(status.Endpoints, endpoint)
+	}
+	s.mu.Unlock()
+	return status
+}
+
+// MirrorStatuses 获取所有声明了基础地址的插件的镜像状态
+func MirrorStatuses() []MirrorStatus {
+	r := getMirrorRegistry()
+	r.mu.Lock()
+	sets := make([]*MirrorSet, 0, len(r.sets))
+	for _, set := range r.sets {
+		sets = append(sets, set)
+	}
+	r.mu.Unlock()
+
+	statuses := make([]MirrorStatus, 0, len(sets))
+	for _, set := range sets {
+		statuses = append(statuses, set.status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Plugin < statuses[j].Plugin })
+	return statuses
+}
+
+// ErrMirrorPluginNotFound 插件不存在或未声明基础地址
+var ErrMirrorPluginNotFound = errors.New("插件不存在或未声明基础地址")
+
+// lookupMirrorSet 按插件名查找镜像列表
+func lookupMirrorSet(name string) (*MirrorSet, error) {
+	r := getMirrorRegistry()
+	r.mu.Lock()
+	set, ok := r.sets[name]
+	r.mu.Unlock()
+	if !ok {
+		return nil, ErrMirrorPluginNotFound
+	}
+	return set, nil
+}
+
+// SetMirrorOverrides 设置插件的地址列表（按优先顺序，覆盖环境变量与默认地址），为空时恢复原配置
+func SetMirrorOverrides(name string, urls []string) (MirrorStatus, error) {
+	set, err := lookupMirrorSet(name)
+	if err != nil {
+		return MirrorStatus{}, err
+	}
+	overrides := make([]string, 0, len(urls))
+	for _, raw := range urls {
+		base, err := normalizeBaseURL(raw)
+		if err != nil {
+			return MirrorStatus{}, fmt.Errorf("地址 %s 无效: %w", raw, err)
+		}
+		overrides = appendUnique(overrides, base)
+	}
+
+	r := getMirrorRegistry()
+	r.mu.Lock()
+	r.stateLocked(name).Overrides = overrides
+	r.saveLocked()
+	r.mu.Unlock()
+
+	set.mu.Lock()
+	set.active = ""
+	set.mu.Unlock()
+	return set.status(), nil
+}
+
+// ResetMirrors 清除插件的管理员设置与检测到的重定向
+func ResetMirrors(name string) (MirrorStatus, error) {
+	set, err := lookupMirrorSet(name)
+	if err != nil {
+		return MirrorStatus{}, err
+	}
+	r := getMirrorRegistry()
+	r.mu.Lock()
+	delete(r.state, name)
+	r.saveLocked()
+	r.mu.Unlock()
+
+	set.mu.Lock()
+	set.active = ""
+	set.mu.Unlock()
+	return set.status(), nil
+}
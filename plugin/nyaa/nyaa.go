@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"regexp"
 	"strconv"
 	"strings"
@@ -34,7 +35,7 @@ const (
 	MaxConnsPerHost     = 30
 	IdleConnTimeout     = 90 * time.Second
 	
-	// 网站URL
+	// 网站URL（默认地址，可通过 PLUGIN_MIRRORS 配置镜像）
 	SiteURL = "https://nyaa.si"
 )
 
@@ -58,16 +59,19 @@ func createOptimizedHTTPClient() *http.Client {
 		IdleConnTimeout:     IdleConnTimeout,
 		DisableKeepAlives:   false,
 	}
-	return &http.Client{Transport: transport, Timeout: DefaultTimeout}
+	return &http.Client{Transport: util.NewPluginTransport("nyaa", transport), Timeout: DefaultTimeout}
 }
 
 // NewNyaaPlugin 创建新的Nyaa插件
 func NewNyaaPlugin() *NyaaPlugin {
-	return &NyaaPlugin{
+	p := &NyaaPlugin{
 		// 优先级3：普通质量数据源，跳过Service层过滤（磁力搜索插件）
 		BaseAsyncPlugin: plugin.NewBaseAsyncPluginWithFilter("nyaa", 3, true),
-		optimizedClient: createOptimizedHTTPClient(),
 	}
+	// 请求按 SiteURL 构造，由插件客户端改写到当前可用的镜像
+	p.SetBaseURLs(SiteURL)
+	p.optimizedClient = p.MirrorClient(createOptimizedHTTPClient())
+	return p
 }
 
 // Search 执行搜索并返回结果（兼容性方法）
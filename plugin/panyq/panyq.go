@@ -18,6 +18,7 @@ import (
 
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 // 常量定义
@@ -111,7 +112,7 @@ func NewPanyqPlugin() *PanyqPlugin {
 	
 	client := &http.Client{
 		Timeout:   DefaultTimeout,
-		Transport: transport,
+		Transport: util.NewPluginTransport("panyq", transport),
 		Jar:       jar, // 使用Cookie管理
 		// 自动处理重定向
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
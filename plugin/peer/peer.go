@@ -0,0 +1,164 @@
+// Package peer 联邦搜索：把其他PanSou实例的 /api/search 作为 peer:名称 插件接入
+// 结果的UniqueID加上 peer:名称- 前缀，来源显示为 peer:名称；
+// 请求远程实例时带上 X-PanSou-Via 头，收到该头的实例不再请求自己的远程实例，避免互相转发
+package peer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/plugin"
+	"pansou/util/json"
+)
+
+const (
+	// NamePrefix 远程实例插件名和结果UniqueID的前缀
+	NamePrefix = "peer:"
+	// ViaHeader 联邦搜索请求头，值为发起请求的实例标识
+	ViaHeader = "X-PanSou-Via"
+	// 响应体大小上限
+	maxResponseSize = 32 << 20
+)
+
+// IsPeer 插件名是否为远程实例
+func IsPeer(name string) bool {
+	return strings.HasPrefix(strings.ToLower(name), NamePrefix)
+}
+
+// Register 把配置的远程实例注册为插件，返回注册的数量
+func Register(pm *plugin.PluginManager) int {
+	if config.AppConfig == nil {
+		return 0
+	}
+	for _, cfg := range config.AppConfig.Peers {
+		p := NewPeerPlugin(cfg)
+		// 同时注册到全局注册表，Service层按名称查找优先级和过滤设置
+		plugin.RegisterGlobalPlugin(p)
+		pm.RegisterPlugin(p)
+	}
+	return len(config.AppConfig.Peers)
+}
+
+// PeerPlugin 远程PanSou实例插件
+type PeerPlugin struct {
+	*plugin.BaseAsyncPlugin
+	cfg config.PeerConfig
+}
+
+// NewPeerPlugin 创建远程实例插件
+func NewPeerPlugin(cfg config.PeerConfig) *PeerPlugin {
+	return &PeerPlugin{
+		// 远程实例已按关键词过滤，跳过Service层过滤
+		BaseAsyncPlugin: plugin.NewBaseAsyncPluginWithFilter(NamePrefix+cfg.Name, cfg.Priority, true),
+		cfg:             cfg,
+	}
+}
+
+// Search 执行搜索并返回结果（兼容性方法）
+func (p *PeerPlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	result, err := p.SearchWithResult(keyword, ext)
+	if err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// SearchWithResult 执行搜索并返回包含IsFinal标记的结果
+func (p *PeerPlugin) SearchWithResult(keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
+// searchRequest 远程实例的搜索请求
+type searchRequest struct {
+	Keyword string                 `json:"kw"`
+	Result  string                 `json:"res"`
+	Source  string                 `json:"src"`
+	Refresh bool                   `json:"refresh,omitempty"`
+	Ext     map[string]interface{} `json:"ext,omitempty"`
+}
+
+// searchImpl 请求远程实例的 /api/search（res=results）
+func (p *PeerPlugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	reqBody := searchRequest{Keyword: keyword, Result: "results", Source: p.cfg.Source}
+	// 只转发插件参数，内部参数（以_开头）不发送
+	for k, v := range ext {
+		switch {
+		case k == "refresh":
+			reqBody.Refresh, _ = v.(bool)
+		case !strings.HasPrefix(k, "_"):
+			if reqBody.Ext == nil {
+				reqBody.Ext = make(map[string]interface{})
+			}
+			reqBody.Ext[k] = v
+		}
+	}
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", p.cfg.URL+"/api/search", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("[%s] 创建请求失败: %w", p.Name(), err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(ViaHeader, config.AppConfig.PeerInstanceID)
+	if p.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.cfg.Token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] 请求失败: %w", p.Name(), err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("[%s] 读取响应失败: %w", p.Name(), err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("[%s] 请求失败，状态码: %d", p.Name(), resp.StatusCode)
+	}
+
+	var apiResp struct {
+		Code    int                  `json:"code"`
+		Message string               `json:"message"`
+		Data    model.SearchResponse `json:"data"`
+	}
+	if err := json.Unmarshal(data, &apiResp); err != nil {
+		return nil, fmt.Errorf("[%s] 解析响应失败: %w", p.Name(), err)
+	}
+	if apiResp.Code != 0 {
+		return nil, fmt.Errorf("[%s] 搜索失败: %s", p.Name(), apiResp.Message)
+	}
+	return p.tagResults(apiResp.Data.Results), nil
+}
+
+// tagResults 标记结果来源：UniqueID改为 peer:名称-原ID，频道信息移到标签中
+func (p *PeerPlugin) tagResults(results []model.SearchResult) []model.SearchResult {
+	tagged := make([]model.SearchResult, 0, len(results))
+	for _, r := range results {
+		if len(r.Links) == 0 {
+			continue
+		}
+		uniqueID := r.UniqueID
+		if uniqueID == "" {
+			uniqueID = r.Links[0].URL
+		}
+		r.UniqueID = p.Name() + "-" + uniqueID
+		if r.Channel != "" {
+			r.Tags = append(r.Tags, "tg:"+r.Channel)
+			r.Channel = ""
+		}
+		tagged = append(tagged, r)
+	}
+	return tagged
+}
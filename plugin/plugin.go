@@ -11,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"pansou/config"
 	"pansou/model"
+	"pansou/util"
 )
 
 // ============================================================
@@ -74,8 +75,8 @@ var (
 
 // 工作池和统计相关变量
 var (
-	// API响应缓存，键为关键词，值为缓存的响应（仅内存，不持久化）
-	apiResponseCache = sync.Map{}
+	// API响应缓存，键为 插件名:关键词，值为缓存的响应（仅内存，不持久化，按字节预算淘汰）
+	apiResponseCache = newAPICache()
 	
 	// 工作池相关变量
 	backgroundWorkerPool chan struct{}
@@ -97,8 +98,8 @@ var (
 	defaultMaxBackgroundWorkers = 20
 	defaultMaxBackgroundTasks = 100
 	
-	// 缓存访问频率记录
-	cacheAccessCount = sync.Map{}
+	// 缓存访问频率记录（有键数上限）
+	cacheAccessCount = newAccessCounter()
 	
 	// 缓存清理相关变量
 	lastCleanupTime = time.Now()
@@ -292,17 +293,14 @@ func cleanupExpiredApiCache() {
 	deletedKeys := make([]string, 0)
 	
 	// 清理已过期的缓存（基于实际TTL + 合理的宽限期）
-	apiResponseCache.Range(func(key, value interface{}) bool {
+	apiResponseCache.Range(func(key string, cached cachedResponse) bool {
 		totalCount++
-		if cached, ok := value.(cachedResponse); ok {
-			// 使用默认TTL + 30分钟宽限期，避免过于激进的清理
-			expireThreshold := defaultCacheTTL + 30*time.Minute
-			if now.Sub(cached.Timestamp) > expireThreshold {
-				keyStr := key.(string)
-				apiResponseCache.Delete(key)
-				deletedKeys = append(deletedKeys, keyStr)
-				cleanedCount++
-			}
+		// 使用默认TTL + 30分钟宽限期，避免过于激进的清理
+		expireThreshold := defaultCacheTTL + 30*time.Minute
+		if now.Sub(cached.Timestamp) > expireThreshold {
+			apiResponseCache.Delete(key)
+			deletedKeys = append(deletedKeys, key)
+			cleanedCount++
 		}
 		return true
 	})
@@ -339,6 +337,9 @@ func initAsyncPlugin() {
 	
 	// 异步插件本地缓存系统已移除，现在只依赖主缓存系统
 	
+	// 内存接近软限制时缩减插件内存缓存
+	startMemoryPressureMonitor()
+	
 	initialized = true
 }
 
@@ -394,19 +395,10 @@ func recordAsyncCompletion() {
 // recordCacheAccess 记录缓存访问次数，用于智能缓存策略（仅内存）
 func recordCacheAccess(key string) {
 	// 更新缓存项的访问时间和计数
-	if cached, ok := apiResponseCache.Load(key); ok {
-		cachedItem := cached.(cachedResponse)
-		cachedItem.LastAccess = time.Now()
-		cachedItem.AccessCount++
-		apiResponseCache.Store(key, cachedItem)
-	}
+	apiResponseCache.Touch(key)
 	
 	// 更新全局访问计数
-	if count, ok := cacheAccessCount.Load(key); ok {
-		cacheAccessCount.Store(key, count.(int) + 1)
-	} else {
-		cacheAccessCount.Store(key, 1)
-	}
+	cacheAccessCount.Increment(key)
 	
 	// 触发定期清理（异步执行，不阻塞当前操作）
 	go cleanupExpiredApiCache()
@@ -429,6 +421,7 @@ type BaseAsyncPlugin struct {
 	finalUpdateTracker map[string]bool // 追踪已更新的最终结果缓存
 	finalUpdateMutex   sync.RWMutex  // 保护finalUpdateTracker的并发访问
 	skipServiceFilter  bool          // 是否跳过Service层的关键词过滤
+	mirrors            *MirrorSet    // 插件声明的基础地址（可配置镜像与故障切换）
 }
 
 // NewBaseAsyncPlugin 创建基础异步插件
@@ -453,12 +446,8 @@ func NewBaseAsyncPlugin(name string, priority int) *BaseAsyncPlugin {
 	return &BaseAsyncPlugin{
 		name:     name,
 		priority: priority,
-		client: &http.Client{
-			Timeout: responseTimeout,
-		},
-		backgroundClient: &http.Client{
-			Timeout: processingTimeout,
-		},
+		client:           util.NewPluginClient(name, responseTimeout),
+		backgroundClient: util.NewPluginClient(name, processingTimeout),
 		cacheTTL:           cacheTTL,
 		finalUpdateTracker: make(map[string]bool), // 初始化缓存更新追踪器
 		skipServiceFilter:  false,                  // 默认不跳过Service层过滤
@@ -487,12 +476,8 @@ func NewBaseAsyncPluginWithFilter(name string, priority int, skipServiceFilter b
 	return &BaseAsyncPlugin{
 		name:     name,
 		priority: priority,
-		client: &http.Client{
-			Timeout: responseTimeout,
-		},
-		backgroundClient: &http.Client{
-			Timeout: processingTimeout,
-		},
+		client:           util.NewPluginClient(name, responseTimeout),
+		backgroundClient: util.NewPluginClient(name, processingTimeout),
 		cacheTTL:           cacheTTL,
 		finalUpdateTracker: make(map[string]bool), // 初始化缓存更新追踪器
 		skipServiceFilter:  skipServiceFilter,     // 使用传入的过滤设置
@@ -503,7 +488,7 @@ func NewBaseAsyncPluginWithFilter(name string, priority int, skipServiceFilter b
 // 第七部分：BaseAsyncPlugin 接口实现方法
 // ============================================================
 
-// SetMainCacheKey 设置主缓存键
+// SetMainCacheKey 设置主缓存键（并发搜索时以ext中的MainCacheKeyExtKey为准）
 func (p *BaseAsyncPlugin) SetMainCacheKey(key string) {
 	p.MainCacheKey = key
 }
@@ -538,17 +523,172 @@ func (p *BaseAsyncPlugin) GetClient() *http.Client {
 	return p.client
 }
 
+// EnableAntiBot 启用共享的反爬会话（用于Cloudflare保护的站点），在插件构造函数中调用
+// 启用后插件客户端按主机使用持久化的Cookie、固定的User-Agent和TLS指纹，遇到质询时自动求解并重试
+func (p *BaseAsyncPlugin) EnableAntiBot() {
+	transport := util.NewAntiBotPluginTransport(p.name)
+	p.client = &http.Client{Transport: transport, Timeout: p.client.Timeout}
+	p.backgroundClient = &http.Client{Transport: transport, Timeout: p.backgroundClient.Timeout}
+	if p.mirrors != nil {
+		p.client = p.mirrors.Client(p.client)
+		p.backgroundClient = p.mirrors.Client(p.backgroundClient)
+	}
+}
+
+// SetBaseURLs 声明插件站点的默认基础地址（按优先顺序），在插件构造函数中调用
+// 插件仍按第一个地址构造请求，插件客户端会改写到当前可用的地址：连接失败或返回5xx时切换到下一个地址，
+// 检测到整站永久重定向时记录新地址（跨域名的重定向需多次一致后才确认）。地址列表可通过 PLUGIN_MIRRORS 环境变量或管理接口覆盖
+func (p *BaseAsyncPlugin) SetBaseURLs(urls ...string) {
+	p.mirrors = globalMirrorRegistry.register(p.name, urls)
+	p.client = p.mirrors.Client(p.client)
+	p.backgroundClient = p.mirrors.Client(p.backgroundClient)
+}
+
+// BaseURL 返回当前首选的基础地址（用于拼接结果中的页面链接），未声明时返回空字符串
+func (p *BaseAsyncPlugin) BaseURL() string {
+	if p.mirrors == nil {
+		return ""
+	}
+	return p.mirrors.BaseURL()
+}
+
+// MirrorClient 为插件自行创建的客户端启用镜像改写与故障切换，未声明基础地址时原样返回
+func (p *BaseAsyncPlugin) MirrorClient(client *http.Client) *http.Client {
+	if p.mirrors == nil {
+		return client
+	}
+	return p.mirrors.Client(client)
+}
+
+// budgetedClients 返回受本次搜索出站请求预算约束的短超时与长超时客户端
+func (p *BaseAsyncPlugin) budgetedClients(ext map[string]interface{}) (*http.Client, *http.Client) {
+	return util.BudgetedClient(p.client, ext), util.BudgetedClient(p.backgroundClient, ext)
+}
+
 // ============================================================
 // 第八部分：异步搜索核心逻辑
 // ============================================================
 
+// asyncSearchFlight 合并相同插件、关键词和参数的并发搜索
+var asyncSearchFlight = util.NewFlightGroup()
+
+// SearchStatusExtKey 搜索状态在插件ext参数中的键名（以下划线开头，不参与请求合并键）
+const SearchStatusExtKey = "_search_status"
+
+// MainCacheKeyExtKey 本次搜索的来源缓存键在插件ext参数中的键名
+// 插件实例被并发的多个搜索共享，缓存键随每次调用传入，不能保存在插件实例上
+const MainCacheKeyExtKey = "_main_cache_key"
+
+// mainCacheKeyFrom 优先使用ext中本次搜索的来源缓存键
+func mainCacheKeyFrom(ext map[string]interface{}, fallback string) string {
+	if key, ok := ext[MainCacheKeyExtKey].(string); ok && key != "" {
+		return key
+	}
+	return fallback
+}
+
+// SearchStatus 记录一次插件搜索是否得到了最终结果
+// 插件响应超时、返回不完整或已过期的缓存结果时标记为未完成，插件在后台完成后自行更新缓存
+type SearchStatus struct {
+	partial int32
+}
+
+// Final 是否为插件完成搜索后的最终结果
+func (s *SearchStatus) Final() bool {
+	return atomic.LoadInt32(&s.partial) == 0
+}
+
+// markPartial 标记为非最终结果
+func (s *SearchStatus) markPartial() {
+	atomic.StoreInt32(&s.partial, 1)
+}
+
+// WithSearchStatus 返回带有新搜索状态的ext副本，用于在AsyncSearch返回后判断结果是否为最终结果
+func WithSearchStatus(ext map[string]interface{}) (map[string]interface{}, *SearchStatus) {
+	copied := make(map[string]interface{}, len(ext)+1)
+	for k, v := range ext {
+		copied[k] = v
+	}
+	status := &SearchStatus{}
+	copied[SearchStatusExtKey] = status
+	return copied, status
+}
+
+// markSearchPartial 将ext中的搜索状态标记为非最终结果
+func markSearchPartial(ext map[string]interface{}) {
+	if status, ok := ext[SearchStatusExtKey].(*SearchStatus); ok {
+		status.markPartial()
+	}
+}
+
+// asyncSearchOutcome 合并搜索的结果及其是否为最终结果
+type asyncSearchOutcome struct {
+	results []model.SearchResult
+	final   bool
+}
+
+// AsyncSearchFlightStats 获取插件搜索请求合并统计
+func AsyncSearchFlightStats() util.FlightStats {
+	return asyncSearchFlight.Stats()
+}
+
+// asyncResponseTimeout 异步插件响应超时时间
+func asyncResponseTimeout() time.Duration {
+	if config.AppConfig != nil {
+		return config.AppConfig.AsyncResponseTimeoutDur
+	}
+	return defaultAsyncResponseTimeout
+}
+
+// copyResults 复制结果切片，避免共享同一结果的多个调用方互相影响
+func copyResults(results []model.SearchResult) []model.SearchResult {
+	if results == nil {
+		return nil
+	}
+	copied := make([]model.SearchResult, len(results))
+	copy(copied, results)
+	return copied
+}
+
 // AsyncSearch 异步搜索基础方法
+// 相同插件、关键词和参数的并发搜索只执行一次，其余请求共享结果，等待超过响应超时时间时返回空结果
 func (p *BaseAsyncPlugin) AsyncSearch(
 	keyword string,
 	searchFunc func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error),
 	mainCacheKey string,
 	ext map[string]interface{},
 ) ([]model.SearchResult, error) {
+	mainCacheKey = mainCacheKeyFrom(ext, mainCacheKey)
+	flightKey := util.FlightKey(fmt.Sprintf("search:%s:%s", p.name, keyword), ext)
+	value, err, shared := asyncSearchFlight.Do(flightKey, asyncResponseTimeout(), func() (interface{}, error) {
+		results, final, err := p.asyncSearch(keyword, searchFunc, mainCacheKey, ext)
+		return asyncSearchOutcome{results: results, final: final}, err
+	})
+	if err == util.ErrFlightTimeout {
+		markSearchPartial(ext)
+		return []model.SearchResult{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	outcome := value.(asyncSearchOutcome)
+	if !outcome.final {
+		markSearchPartial(ext)
+	}
+	results := outcome.results
+	if shared {
+		results = copyResults(results)
+	}
+	return results, nil
+}
+
+// asyncSearch 异步搜索实现，返回结果及其是否为最终结果
+func (p *BaseAsyncPlugin) asyncSearch(
+	keyword string,
+	searchFunc func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error),
+	mainCacheKey string,
+	ext map[string]interface{},
+) ([]model.SearchResult, bool, error) {
 	// 确保ext不为nil
 	if ext == nil {
 		ext = make(map[string]interface{})
@@ -556,12 +696,14 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 	
 	now := time.Now()
 	
+	// 本次搜索的出站请求预算（由Service层注入）
+	client, backgroundClient := p.budgetedClients(ext)
+	
 	// 修改缓存键，确保包含插件名称
 	pluginSpecificCacheKey := fmt.Sprintf("%s:%s", p.name, keyword)
 	
 	// 检查缓存
-	if cachedItems, ok := apiResponseCache.Load(pluginSpecificCacheKey); ok {
-		cachedResult := cachedItems.(cachedResponse)
+	if cachedResult, ok := apiResponseCache.Load(pluginSpecificCacheKey); ok {
 		
 		// 缓存完全有效（未过期且完整）
 		if time.Since(cachedResult.Timestamp) < p.cacheTTL && cachedResult.Complete {
@@ -573,7 +715,7 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 				go p.refreshCacheInBackground(keyword, pluginSpecificCacheKey, searchFunc, cachedResult, mainCacheKey, ext)
 			}
 			
-			return cachedResult.Results, nil
+			return cachedResult.Results, true, nil
 		}
 		
 		// 缓存已过期但有结果，启动后台刷新，同时返回旧结果
@@ -591,23 +733,27 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 					p.name, pluginSpecificCacheKey, time.Since(cachedResult.Timestamp))
 			}
 			
-			return cachedResult.Results, nil
+			// 过期或不完整的缓存结果不是最终结果
+			return cachedResult.Results, false, nil
 		}
 	}
 	
 	recordCacheMiss()
 	
 	// 创建通道
-	resultChan := make(chan []model.SearchResult, 1)
+	resultChan := make(chan asyncSearchOutcome, 1)
 	errorChan := make(chan error, 1)
 	doneChan := make(chan struct{})
 	
+	// 搜索函数内部再次调用AsyncSearch时（如插件的Search方法），通过独立的搜索状态得知其结果是否为最终结果
+	searchExt, searchStatus := WithSearchStatus(ext)
+	
 	// 启动后台处理
 	go func() {
 		// 尝试获取工作槽
 		if !acquireWorkerSlot() {
 			// 工作池已满，使用快速响应客户端直接处理
-			results, err := searchFunc(p.client, keyword, ext)
+			results, err := searchFunc(client, keyword, searchExt)
 			if err != nil {
 				select {
 				case errorChan <- err:
@@ -615,9 +761,10 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 				}
 				return
 			}
+			final := searchStatus.Final()
 			
 			select {
-			case resultChan <- results:
+			case resultChan <- asyncSearchOutcome{results: results, final: final}:
 			default:
 			}
 			
@@ -625,20 +772,21 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 			apiResponseCache.Store(pluginSpecificCacheKey, cachedResponse{
 				Results:     results,
 				Timestamp:   now,
-				Complete:    true,
+				Complete:    final,
 				LastAccess:  now,
 				AccessCount: 1,
 			})
 			
-			// 🔧 工作池满时短超时(默认4秒)内完成，这是完整结果
-			p.updateMainCacheWithFinal(mainCacheKey, results, true)
+			// 🔧 工作池满时短超时(默认4秒)内完成，搜索函数未超时即为完整结果
+			p.updateMainCacheWithFinal(mainCacheKey, results, final, keyword)
 			
 			return
 		}
 		defer releaseWorkerSlot()
 		
 		// 执行搜索
-		results, err := searchFunc(p.backgroundClient, keyword, ext)
+		results, err := searchFunc(backgroundClient, keyword, searchExt)
+		final := searchStatus.Final()
 		
 		// 检查是否已经响应
 		select {
@@ -649,8 +797,7 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 				var accessCount int = 1
 				var lastAccess time.Time = now
 				
-				if oldCache, ok := apiResponseCache.Load(pluginSpecificCacheKey); ok {
-					oldCachedResult := oldCache.(cachedResponse)
+				if oldCachedResult, ok := apiResponseCache.Load(pluginSpecificCacheKey); ok {
 					accessCount = oldCachedResult.AccessCount
 					lastAccess = oldCachedResult.LastAccess
 					
@@ -681,14 +828,14 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 				apiResponseCache.Store(pluginSpecificCacheKey, cachedResponse{
 					Results:     results,
 					Timestamp:   now,
-					Complete:    true,
+					Complete:    final,
 					LastAccess:  lastAccess,
 					AccessCount: accessCount,
 				})
 				recordAsyncCompletion()
 				
-				// 异步插件后台完成时更新主缓存（标记为最终结果）
-				p.updateMainCacheWithFinal(mainCacheKey, results, true)
+				// 异步插件后台完成时更新主缓存（搜索函数未超时即为最终结果）
+				p.updateMainCacheWithFinal(mainCacheKey, results, final, keyword)
 				
 				// 异步插件本地缓存系统已移除
 			}
@@ -701,8 +848,7 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 				}
 			} else {
 				// 检查是否存在旧缓存用于合并
-				if oldCache, ok := apiResponseCache.Load(pluginSpecificCacheKey); ok {
-					oldCachedResult := oldCache.(cachedResponse)
+				if oldCachedResult, ok := apiResponseCache.Load(pluginSpecificCacheKey); ok {
 					if len(oldCachedResult.Results) > 0 {
 						// 创建合并结果集
 						mergedResults := make([]model.SearchResult, 0, len(results) + len(oldCachedResult.Results))
@@ -727,7 +873,7 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 				}
 				
 				select {
-				case resultChan <- results:
+				case resultChan <- asyncSearchOutcome{results: results, final: final}:
 				default:
 				}
 				
@@ -735,13 +881,13 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 				apiResponseCache.Store(pluginSpecificCacheKey, cachedResponse{
 					Results:     results,
 					Timestamp:   now,
-					Complete:    true,
+					Complete:    final,
 					LastAccess:  now,
 					AccessCount: 1,
 				})
 				
-				// 🔧 短超时(默认4秒)内正常完成，这是完整的最终结果
-				p.updateMainCacheWithFinal(mainCacheKey, results, true)
+				// 🔧 短超时(默认4秒)内正常完成，搜索函数未超时即为完整的最终结果
+				p.updateMainCacheWithFinal(mainCacheKey, results, final, keyword)
 				
 				// 异步插件本地缓存系统已移除
 			}
@@ -756,12 +902,12 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 	
 	// 等待响应超时或结果
 	select {
-	case results := <-resultChan:
+	case outcome := <-resultChan:
 		close(doneChan)
-		return results, nil
+		return outcome.results, outcome.final, nil
 	case err := <-errorChan:
 		close(doneChan)
-		return nil, err
+		return nil, false, err
 	case <-time.After(responseTimeout):
 		// 插件响应超时，后台继续处理（优化完成，日志简化）
 		
@@ -771,14 +917,13 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 		}()
 		
 		// 检查是否有部分缓存可用
-		if cachedItems, ok := apiResponseCache.Load(pluginSpecificCacheKey); ok {
-			cachedResult := cachedItems.(cachedResponse)
+		if cachedResult, ok := apiResponseCache.Load(pluginSpecificCacheKey); ok {
 			if len(cachedResult.Results) > 0 {
 				// 有部分缓存可用，记录访问并返回
 				recordCacheAccess(pluginSpecificCacheKey)
 				fmt.Printf("[%s] 响应超时，返回部分缓存: %s (项目数: %d)\n", 
 					p.name, pluginSpecificCacheKey, len(cachedResult.Results))
-				return cachedResult.Results, nil
+				return cachedResult.Results, false, nil
 			}
 		}
 		
@@ -792,19 +937,55 @@ func (p *BaseAsyncPlugin) AsyncSearch(
 		})
 		
 		// 🔧 修复：4秒超时时也要更新主缓存，标记为部分结果（空结果）
-		p.updateMainCacheWithFinal(mainCacheKey, []model.SearchResult{}, false)
+		p.updateMainCacheWithFinal(mainCacheKey, []model.SearchResult{}, false, keyword)
 		
 		// fmt.Printf("[%s] 响应超时，后台继续处理: %s\n", p.name, pluginSpecificCacheKey)
-		return []model.SearchResult{}, nil
+		return []model.SearchResult{}, false, nil
 	}
 }
 
 // AsyncSearchWithResult 异步搜索方法，返回PluginSearchResult
+// 与AsyncSearch一样合并相同插件、关键词和参数的并发搜索
 func (p *BaseAsyncPlugin) AsyncSearchWithResult(
 	keyword string,
 	searchFunc func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error),
 	mainCacheKey string,
 	ext map[string]interface{},
+) (model.PluginSearchResult, error) {
+	mainCacheKey = mainCacheKeyFrom(ext, mainCacheKey)
+	flightKey := util.FlightKey(fmt.Sprintf("result:%s:%s", p.name, keyword), ext)
+	value, err, shared := asyncSearchFlight.Do(flightKey, asyncResponseTimeout(), func() (interface{}, error) {
+		return p.asyncSearchWithResult(keyword, searchFunc, mainCacheKey, ext)
+	})
+	if err == util.ErrFlightTimeout {
+		markSearchPartial(ext)
+		return model.PluginSearchResult{
+			Results:   []model.SearchResult{},
+			IsFinal:   false,
+			Timestamp: time.Now(),
+			Source:    p.name,
+			Message:   "处理中，后台继续...",
+		}, nil
+	}
+	if err != nil {
+		return model.PluginSearchResult{}, err
+	}
+	result := value.(model.PluginSearchResult)
+	if !result.IsFinal {
+		markSearchPartial(ext)
+	}
+	if shared {
+		result.Results = copyResults(result.Results)
+	}
+	return result, nil
+}
+
+// asyncSearchWithResult 异步搜索实现，返回PluginSearchResult
+func (p *BaseAsyncPlugin) asyncSearchWithResult(
+	keyword string,
+	searchFunc func(*http.Client, string, map[string]interface{}) ([]model.SearchResult, error),
+	mainCacheKey string,
+	ext map[string]interface{},
 ) (model.PluginSearchResult, error) {
 	// 确保ext不为nil
 	if ext == nil {
@@ -813,12 +994,14 @@ func (p *BaseAsyncPlugin) AsyncSearchWithResult(
 	
 	now := time.Now()
 	
+	// 本次搜索的出站请求预算（由Service层注入）
+	client, backgroundClient := p.budgetedClients(ext)
+	
 	// 修改缓存键，确保包含插件名称
 	pluginSpecificCacheKey := fmt.Sprintf("%s:%s", p.name, keyword)
 	
 	// 检查缓存
-	if cachedItems, ok := apiResponseCache.Load(pluginSpecificCacheKey); ok {
-		cachedResult := cachedItems.(cachedResponse)
+	if cachedResult, ok := apiResponseCache.Load(pluginSpecificCacheKey); ok {
 		
 		// 缓存完全有效（未过期且完整）
 		if time.Since(cachedResult.Timestamp) < p.cacheTTL && cachedResult.Complete {
@@ -880,7 +1063,7 @@ func (p *BaseAsyncPlugin) AsyncSearchWithResult(
 		// 尝试获取工作槽
 		if !acquireWorkerSlot() {
 			// 工作池已满，使用快速响应客户端直接处理
-			results, err := searchFunc(p.client, keyword, ext)
+			results, err := searchFunc(client, keyword, ext)
 			if err != nil {
 				select {
 				case errorChan <- err:
@@ -898,7 +1081,7 @@ func (p *BaseAsyncPlugin) AsyncSearchWithResult(
 		defer releaseWorkerSlot()
 		
 		// 使用长超时客户端进行搜索
-		results, err := searchFunc(p.backgroundClient, keyword, ext)
+		results, err := searchFunc(backgroundClient, keyword, ext)
 		if err != nil {
 			select {
 			case errorChan <- err:
@@ -934,7 +1117,7 @@ func (p *BaseAsyncPlugin) AsyncSearchWithResult(
 		// 🔧 恢复主缓存更新：使用统一的GOB序列化
 		// 传递原始数据，由主程序负责序列化
 		if mainCacheKey != "" && p.mainCacheUpdater != nil {
-			err := p.mainCacheUpdater(mainCacheKey, results, p.cacheTTL, true, p.currentKeyword)
+			err := p.mainCacheUpdater(mainCacheKey, results, p.cacheTTL, true, keyword)
 			if err != nil {
 				fmt.Printf("❌ [%s] 及时完成缓存更新失败: %s | 错误: %v\n", p.name, mainCacheKey, err)
 			}
@@ -1011,7 +1194,7 @@ func (p *BaseAsyncPlugin) completeSearchInBackground(
 	// 🔧 恢复主缓存更新：使用统一的GOB序列化
 	// 传递原始数据，由主程序负责序列化
 	if mainCacheKey != "" && p.mainCacheUpdater != nil {
-		err := p.mainCacheUpdater(mainCacheKey, results, p.cacheTTL, true, p.currentKeyword)
+		err := p.mainCacheUpdater(mainCacheKey, results, p.cacheTTL, true, keyword)
 		if err != nil {
 			fmt.Printf("❌ [%s] 后台完成缓存更新失败: %s | 错误: %v\n", p.name, mainCacheKey, err)
 		}
@@ -1076,7 +1259,7 @@ func (p *BaseAsyncPlugin) refreshCacheInBackground(
 	})
 	
 	// 🔥 异步插件后台刷新完成时更新主缓存（标记为最终结果）
-	p.updateMainCacheWithFinal(originalCacheKey, mergedResults, true)
+	p.updateMainCacheWithFinal(originalCacheKey, mergedResults, true, keyword)
 	
 	// 记录刷新时间
 	refreshTime := time.Since(refreshStart)
@@ -1091,12 +1274,12 @@ func (p *BaseAsyncPlugin) refreshCacheInBackground(
 // ============================================================
 
 // updateMainCache 更新主缓存系统（兼容性方法，默认IsFinal=true）
-func (p *BaseAsyncPlugin) updateMainCache(cacheKey string, results []model.SearchResult) {
-	p.updateMainCacheWithFinal(cacheKey, results, true)
+func (p *BaseAsyncPlugin) updateMainCache(cacheKey string, results []model.SearchResult, keyword string) {
+	p.updateMainCacheWithFinal(cacheKey, results, true, keyword)
 }
 
-// updateMainCacheWithFinal 更新主缓存系统，支持IsFinal参数
-func (p *BaseAsyncPlugin) updateMainCacheWithFinal(cacheKey string, results []model.SearchResult, isFinal bool) {
+// updateMainCacheWithFinal 更新主缓存系统，支持IsFinal参数，keyword为本次搜索的关键词
+func (p *BaseAsyncPlugin) updateMainCacheWithFinal(cacheKey string, results []model.SearchResult, isFinal bool, keyword string) {
 	// 如果主缓存更新函数为空或缓存键为空，直接返回
 	if p.mainCacheUpdater == nil || cacheKey == "" {
 		return
@@ -1126,7 +1309,7 @@ func (p *BaseAsyncPlugin) updateMainCacheWithFinal(cacheKey string, results []mo
 	// 🔧 恢复异步插件缓存更新，使用修复后的统一序列化
 	// 传递原始数据，由主程序负责GOB序列化
 	if p.mainCacheUpdater != nil {
-		err := p.mainCacheUpdater(cacheKey, results, p.cacheTTL, isFinal, p.currentKeyword)
+		err := p.mainCacheUpdater(cacheKey, results, p.cacheTTL, isFinal, keyword)
 		if err != nil {
 			fmt.Printf("❌ [%s] 主缓存更新失败: %s | 错误: %v\n", p.name, cacheKey, err)
 		}
@@ -0,0 +1,127 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 记录关键词访问时使用的特殊来源名
+const (
+	AccessSourceTG         = "tg" // TG频道搜索
+	AccessSourceAllPlugins = "*"  // 未指定插件（搜索全部插件）
+)
+
+// keywordAccessCount 关键词访问计数，与插件内存缓存的访问计数分开维护，
+// 插件内部的缓存命中不会计入热门排行
+var keywordAccessCount = newAccessCounter()
+
+// KeywordPopularity 热门关键词及其被访问的来源
+type KeywordPopularity struct {
+	Keyword    string   `json:"keyword"`
+	Count      int      `json:"count"`
+	Plugins    []string `json:"plugins,omitempty"` // 指定搜索过的插件
+	AllPlugins bool     `json:"all_plugins"`       // 是否搜索过全部插件
+	TG         bool     `json:"tg"`                // 是否搜索过TG频道
+}
+
+// RecordKeywordAccess 记录一次关键词访问（每次搜索请求计数一次）
+// sources为插件名，或AccessSourceTG、AccessSourceAllPlugins
+func RecordKeywordAccess(keyword string, sources ...string) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return
+	}
+	for _, source := range sources {
+		if source == "" {
+			continue
+		}
+		keywordAccessCount.Increment(fmt.Sprintf("%s:%s", source, keyword))
+	}
+}
+
+// HotKeywords 按访问次数返回最热门的关键词，limit<=0表示不限制
+// 同一关键词的访问次数取各来源中的最大值，避免一次搜索多个插件被重复计数
+func HotKeywords(limit int) []KeywordPopularity {
+	byKeyword := make(map[string]*KeywordPopularity)
+	keywordAccessCount.Range(func(key string, count int) bool {
+		source, keyword := splitAPICacheKey(key)
+		normalized := strings.ToLower(strings.TrimSpace(keyword))
+		if normalized == "" || count <= 0 {
+			return true
+		}
+
+		item, ok := byKeyword[normalized]
+		if !ok {
+			item = &KeywordPopularity{Keyword: keyword}
+			byKeyword[normalized] = item
+		}
+		if count > item.Count {
+			item.Count = count
+		}
+		switch source {
+		case AccessSourceTG:
+			item.TG = true
+		case AccessSourceAllPlugins:
+			item.AllPlugins = true
+		default:
+			item.Plugins = append(item.Plugins, source)
+		}
+		return true
+	})
+
+	hot := make([]KeywordPopularity, 0, len(byKeyword))
+	for _, item := range byKeyword {
+		sort.Strings(item.Plugins)
+		hot = append(hot, *item)
+	}
+	sort.Slice(hot, func(i, j int) bool {
+		if hot[i].Count != hot[j].Count {
+			return hot[i].Count > hot[j].Count
+		}
+		return hot[i].Keyword < hot[j].Keyword
+	})
+	if limit > 0 && len(hot) > limit {
+		hot = hot[:limit]
+	}
+	return hot
+}
+
+// DecayKeywordAccess 将所有访问计数减半并删除归零的项，使热门排行反映近期访问
+func DecayKeywordAccess() {
+	keywordAccessCount.Decay()
+}
+
+// BackgroundWorkerLoad 返回后台工作池的占用数和容量
+func BackgroundWorkerLoad() (int, int) {
+	if backgroundWorkerPool == nil {
+		return 0, 0
+	}
+	return len(backgroundWorkerPool), cap(backgroundWorkerPool)
+}
+
+// SubmitBackgroundTask 在后台工作池中执行任务，工作池已满时不执行并返回false
+func SubmitBackgroundTask(task func()) bool {
+	if backgroundWorkerPool == nil || !acquireWorkerSlot() {
+		return false
+	}
+	go func() {
+		defer releaseWorkerSlot()
+		task()
+	}()
+	return true
+}
+
+// MarkAPICacheStale 将插件内存缓存项标记为已过期
+// 下次搜索时插件先返回旧结果，再通过后台工作池刷新（受工作池容量限制）
+func MarkAPICacheStale(pluginName, keyword string) bool {
+	key := fmt.Sprintf("%s:%s", pluginName, keyword)
+	cached, ok := apiResponseCache.Load(key)
+	if !ok {
+		return false
+	}
+	cached.Timestamp = time.Time{}
+	apiResponseCache.Store(key, cached)
+	return true
+}
@@ -1,21 +1,15 @@
 package qqpd
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
 	"crypto/sha256"
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
-	"path/filepath"
 	"regexp"
-	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -23,6 +17,8 @@ import (
 
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/plugin/accounts"
+	"pansou/util"
 	"pansou/util/json"
 
 	"github.com/gin-gonic/gin"
@@ -35,11 +31,6 @@ const (
 	DebugLog              = false // 调试日志开关（临时开启排查问题）
 )
 
-// 存储目录 - 从环境变量动态获取
-var StorageDir string
-
-// 初始化存储目录
-
 // HTML模板（完整的管理页面）
 const HTMLTemplate = `<!DOCTYPE html>
 <html lang="zh-CN">
@@ -476,23 +467,18 @@ languan8K115"></textarea>
 // QQPDPlugin 插件结构
 type QQPDPlugin struct {
 	*plugin.BaseAsyncPlugin
-	users       sync.Map // 内存缓存：hash -> *User
+	accounts    *accounts.Manager // 账号管理：hash -> *User
 	mu          sync.RWMutex
 	initialized bool // 初始化状态标记
 }
 
 // User 用户数据结构
 type User struct {
-	Hash            string            `json:"hash"`
-	QQMasked        string            `json:"qq_masked"`
-	Cookie          string            `json:"cookie"`
-	Status          string            `json:"status"`
-	Channels        []string          `json:"channels"`
-	ChannelGuildIDs map[string]string `json:"channel_guild_ids"` // 频道号->guild_id映射（持久化缓存）
-	CreatedAt       time.Time         `json:"created_at"`
-	LoginAt         time.Time         `json:"login_at"`
-	ExpireAt        time.Time         `json:"expire_at"`
-	LastAccessAt    time.Time         `json:"last_access_at"`
+	accounts.Account                   // 账号ID、状态（pending/active/expired）、登录和过期时间、健康状态
+	QQMasked         string            `json:"qq_masked"`
+	Cookie           string            `json:"cookie"`
+	Channels         []string          `json:"channels"`
+	ChannelGuildIDs  map[string]string `json:"channel_guild_ids"` // 频道号->guild_id映射（持久化缓存）
 
 	// 二维码相关（不持久化）
 	QRCodeCache     []byte    `json:"-"` // 二维码缓存
@@ -512,6 +498,32 @@ func init() {
 	p := &QQPDPlugin{
 		BaseAsyncPlugin: plugin.NewBaseAsyncPlugin("qqpd", 3),
 	}
+	p.accounts = accounts.NewManager(accounts.Options{
+		Plugin:    "qqpd",
+		New:       func() accounts.Record { return &User{} },
+		LegacyDir: "qqpd_users",
+		HashID:    p.generateHash,
+		Details: func(r accounts.Record) map[string]interface{} {
+			user := r.(*User)
+			return map[string]interface{}{"qq_masked": user.QQMasked, "channel_count": len(user.Channels)}
+		},
+		OnExpire: func(r accounts.Record) {
+			r.(*User).Cookie = ""
+		},
+		// 定期刷新Cookie中的动态字段并检测是否仍然有效
+		KeepAlive:         p.keepCookieAlive,
+		KeepAliveInterval: time.Hour,
+		KeepAliveDelay:    5 * time.Minute,
+		Actions: map[string]accounts.ActionFunc{
+			"get_status":     p.handleGetStatus,
+			"refresh_qrcode": p.handleRefreshQRCode,
+			"logout":         p.handleLogout,
+			"set_channels":   p.handleSetChannelsWithData,
+			"test_search":    p.handleTestSearchWithData,
+			"manual_login":   p.handleManualLogin, // 测试用：手动设置登录状态
+			"check_login":    p.handleCheckLogin,  // 检查登录状态（扫码后调用）
+		},
+	})
 
 	plugin.RegisterGlobalPlugin(p)
 }
@@ -522,24 +534,11 @@ func (p *QQPDPlugin) Initialize() error {
 		return nil
 	}
 
-	// 初始化存储目录路径
-	cachePath := os.Getenv("CACHE_PATH")
-	if cachePath == "" {
-		cachePath = "./cache"
-	}
-	StorageDir = filepath.Join(cachePath, "qqpd_users")
-
-	// 初始化存储目录
-	if err := os.MkdirAll(StorageDir, 0755); err != nil {
-		return fmt.Errorf("创建存储目录失败: %v", err)
+	// 加载所有用户，启动Cookie保活和定期清理任务
+	if err := p.accounts.Start(); err != nil {
+		return err
 	}
 
-	// 加载所有用户到内存
-	p.loadAllUsers()
-
-	// 启动定期清理任务
-	go p.startCleanupTask()
-
 	p.initialized = true
 	return nil
 }
@@ -576,8 +575,8 @@ func (p *QQPDPlugin) SearchWithResult(keyword string, ext map[string]interface{}
 		fmt.Printf("[QQPD] ========== 开始搜索: %s ==========\n", keyword)
 	}
 
-	// 1. 获取所有有效用户
-	users := p.getActiveUsers()
+	// 1. 选择有效用户（最近最少使用的优先，最多MaxConcurrentUsers个）
+	users := p.acquireUsers()
 	if DebugLog {
 		fmt.Printf("[QQPD] 找到 %d 个有效用户\n", len(users))
 	}
@@ -589,17 +588,6 @@ func (p *QQPDPlugin) SearchWithResult(keyword string, ext map[string]interface{}
 		return model.PluginSearchResult{Results: []model.SearchResult{}, IsFinal: true}, nil
 	}
 
-	// 2. 限制用户数量（取最近活跃的）
-	if len(users) > MaxConcurrentUsers {
-		sort.Slice(users, func(i, j int) bool {
-			return users[i].LastAccessAt.After(users[j].LastAccessAt)
-		})
-		users = users[:MaxConcurrentUsers]
-		if DebugLog {
-			fmt.Printf("[QQPD] 限制用户数量为: %d\n", MaxConcurrentUsers)
-		}
-	}
-
 	// 3. 收集并去重频道，智能分配给用户
 	tasks := p.buildChannelTasks(users)
 	if DebugLog {
@@ -630,141 +618,47 @@ func (p *QQPDPlugin) SearchWithResult(keyword string, ext map[string]interface{}
 	}, nil
 }
 
-// ============ 内存缓存管理 ============
+// ============ 用户管理 ============
 
-// loadAllUsers 启动时加载所有用户到内存
-func (p *QQPDPlugin) loadAllUsers() {
-	files, err := ioutil.ReadDir(StorageDir)
-	if err != nil {
-		return
-	}
-
-	count := 0
-	for _, file := range files {
-		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
-			continue
-		}
-
-		filePath := filepath.Join(StorageDir, file.Name())
-		data, err := ioutil.ReadFile(filePath)
-		if err != nil {
-			continue
-		}
-
-		var user User
-		if err := json.Unmarshal(data, &user); err != nil {
-			continue
-		}
-
-		// 加载到内存
-		p.users.Store(user.Hash, &user)
-		count++
-	}
-
-	fmt.Printf("[QQPD] 已加载 %d 个用户到内存\n", count)
-}
-
-// getUserByHash 获取用户（从内存）
+// getUserByHash 获取用户
 func (p *QQPDPlugin) getUserByHash(hash string) (*User, bool) {
-	value, ok := p.users.Load(hash)
+	r, ok := p.accounts.Get(hash)
 	if !ok {
 		return nil, false
 	}
-	return value.(*User), true
+	return r.(*User), true
 }
 
-// saveUser 保存用户（内存+文件）
+// saveUser 保存用户
 func (p *QQPDPlugin) saveUser(user *User) error {
-	// 更新内存
-	p.users.Store(user.Hash, user)
-
-	// 持久化到文件
-	return p.persistUser(user)
+	return p.accounts.Save(user)
 }
 
-// persistUser 持久化用户到文件
-func (p *QQPDPlugin) persistUser(user *User) error {
-	filePath := filepath.Join(StorageDir, user.Hash+".json")
-
-	data, err := json.MarshalIndent(user, "", "  ")
-	if err != nil {
-		return err
+// acquireUsers 选择已登录且配置了频道的用户（已过期的用户由账号管理器标记为expired）
+func (p *QQPDPlugin) acquireUsers() []*User {
+	records := p.accounts.Acquire(MaxConcurrentUsers, func(r accounts.Record) bool {
+		user := r.(*User)
+		return user.Cookie != "" && len(user.Channels) > 0
+	})
+	users := make([]*User, 0, len(records))
+	for _, r := range records {
+		users = append(users, r.(*User))
 	}
-
-	return ioutil.WriteFile(filePath, data, 0644)
-}
-
-// deleteUser 删除用户（内存+文件）
-func (p *QQPDPlugin) deleteUser(hash string) error {
-	// 从内存删除
-	p.users.Delete(hash)
-
-	// 从文件删除
-	filePath := filepath.Join(StorageDir, hash+".json")
-	return os.Remove(filePath)
+	return users
 }
 
-// getActiveUsers 获取有效的活跃用户
-func (p *QQPDPlugin) getActiveUsers() []*User {
-	var users []*User
-
-	totalUsers := 0
-	activeUsers := 0
-	expiredUsers := 0
-	noChannelUsers := 0
-
-	p.users.Range(func(key, value interface{}) bool {
-		user := value.(*User)
-		totalUsers++
-
-		// 双重过滤
-		if user.Status != "active" {
-			if DebugLog && totalUsers <= 3 {
-				fmt.Printf("[QQPD]   用户%s: 状态=%s (非active，跳过)\n", user.Hash[:8]+"...", user.Status)
-			}
-			return true
-		}
-
-		// 检查Cookie是否过期（根据ExpireAt时间判断）
-		if !user.ExpireAt.IsZero() && time.Now().After(user.ExpireAt) {
-			// Cookie已过期，标记用户状态为过期
-			expiredUsers++
-			user.Status = "expired"
-			user.Cookie = "" // 清空Cookie
-			p.saveUser(user)
-			if DebugLog && expiredUsers <= 3 {
-				fmt.Printf("[QQPD]   用户%s: Cookie已过期 (过期时间: %s)\n", user.Hash[:8]+"...", user.ExpireAt.Format("2006-01-02 15:04:05"))
-			}
-			return true
-		}
-
-		if len(user.Channels) == 0 {
-			noChannelUsers++
-			if DebugLog && noChannelUsers <= 3 {
-				fmt.Printf("[QQPD]   用户%s: 频道数=0 (跳过)\n", user.Hash[:8]+"...")
-			}
-			return true
-		}
-
-		// 通过所有过滤
-		activeUsers++
-		if DebugLog && activeUsers <= 3 {
-			remainingDays := 0
-			if !user.ExpireAt.IsZero() {
-				remainingDays = int(time.Until(user.ExpireAt).Hours() / 24)
-			}
-			fmt.Printf("[QQPD]   用户%s: 有效 (频道数=%d, 剩余有效期=%d天)\n", user.Hash[:8]+"...", len(user.Channels), remainingDays)
-		}
-		users = append(users, user)
-		return true
-	})
-
-	if DebugLog {
-		fmt.Printf("[QQPD] 用户统计: 总数=%d, 有效=%d, 已过期=%d, 无频道=%d\n",
-			totalUsers, activeUsers, expiredUsers, noChannelUsers)
+// keepCookieAlive 刷新Cookie中的动态字段并检测是否仍然有效（由账号管理器定期调用）
+func (p *QQPDPlugin) keepCookieAlive(r accounts.Record) error {
+	user := r.(*User)
+	refreshedCookie := p.refreshCookie(user.Cookie)
+	if refreshedCookie != user.Cookie {
+		user.Cookie = refreshedCookie
+		p.saveUser(user)
 	}
-
-	return users
+	if !p.testCookieValid(refreshedCookie) {
+		return fmt.Errorf("Cookie无效或已失效")
+	}
+	return nil
 }
 
 // ============ HTTP路由处理 ============
@@ -804,24 +698,7 @@ func (p *QQPDPlugin) handleManagePagePOST(c *gin.Context) {
 	}
 
 	// 根据action路由到不同的处理函数
-	switch action {
-	case "get_status":
-		p.handleGetStatus(c, hash)
-	case "refresh_qrcode":
-		p.handleRefreshQRCode(c, hash)
-	case "logout":
-		p.handleLogout(c, hash)
-	case "set_channels":
-		p.handleSetChannelsWithData(c, hash, reqData)
-	case "test_search":
-		p.handleTestSearchWithData(c, hash, reqData)
-	case "manual_login":
-		// 测试用：手动设置登录状态
-		p.handleManualLogin(c, hash, reqData)
-	case "check_login":
-		// 检查登录状态（扫码后调用）
-		p.handleCheckLogin(c, hash)
-	default:
+	if !p.accounts.HandleAction(c, hash, action, reqData) {
 		respondError(c, "未知的操作类型: "+action)
 	}
 }
@@ -829,19 +706,24 @@ func (p *QQPDPlugin) handleManagePagePOST(c *gin.Context) {
 // ============ POST Action处理 ============
 
 // handleGetStatus 获取状态
-func (p *QQPDPlugin) handleGetStatus(c *gin.Context, hash string) {
+func (p *QQPDPlugin) handleGetStatus(c *gin.Context, hash string, _ map[string]interface{}) {
 	user, exists := p.getUserByHash(hash)
 
 	if !exists {
 		// 创建新用户（内存+文件）
 		user = &User{
-			Hash:         hash,
-			Status:       "pending",
-			Channels:     []string{},
-			CreatedAt:    time.Now(),
-			LastAccessAt: time.Now(),
+			Account: accounts.Account{
+				Hash:         hash,
+				Status:       accounts.StatusPending,
+				CreatedAt:    time.Now(),
+				LastAccessAt: time.Now(),
+			},
+			Channels: []string{},
+		}
+		if err := p.saveUser(user); err != nil {
+			respondError(c, "创建用户失败: "+err.Error())
+			return
 		}
-		p.saveUser(user)
 	} else {
 		// 更新最后访问时间
 		user.LastAccessAt = time.Now()
@@ -850,7 +732,7 @@ func (p *QQPDPlugin) handleGetStatus(c *gin.Context, hash string) {
 
 	// 检查登录状态（简化逻辑）
 	loggedIn := false
-	if user.Status == "active" && user.Cookie != "" {
+	if user.Status == accounts.StatusActive && user.Cookie != "" {
 		// 状态是active且有Cookie，刷新cookies（更新uuid等动态字段）
 		refreshedCookie := p.refreshCookie(user.Cookie)
 		if refreshedCookie != user.Cookie {
@@ -858,12 +740,12 @@ func (p *QQPDPlugin) handleGetStatus(c *gin.Context, hash string) {
 			p.saveUser(user)
 		}
 		loggedIn = true
-	} else if user.Status == "active" && user.Cookie == "" {
+	} else if user.Status == accounts.StatusActive && user.Cookie == "" {
 		// 状态是active但Cookie为空，异常情况，重置为pending
 		if DebugLog {
 			fmt.Printf("[QQPD] 用户 %s 状态异常（active但Cookie为空），重置为pending\n", hash[:8]+"...")
 		}
-		user.Status = "pending"
+		user.Status = accounts.StatusPending
 		user.QQMasked = ""
 		p.saveUser(user)
 	}
@@ -920,7 +802,7 @@ func (p *QQPDPlugin) handleGetStatus(c *gin.Context, hash string) {
 }
 
 // handleRefreshQRCode 刷新二维码
-func (p *QQPDPlugin) handleRefreshQRCode(c *gin.Context, hash string) {
+func (p *QQPDPlugin) handleRefreshQRCode(c *gin.Context, hash string, _ map[string]interface{}) {
 	user, exists := p.getUserByHash(hash)
 	if !exists {
 		respondError(c, "用户不存在")
@@ -947,7 +829,7 @@ func (p *QQPDPlugin) handleRefreshQRCode(c *gin.Context, hash string) {
 }
 
 // handleLogout 退出登录
-func (p *QQPDPlugin) handleLogout(c *gin.Context, hash string) {
+func (p *QQPDPlugin) handleLogout(c *gin.Context, hash string, _ map[string]interface{}) {
 	user, exists := p.getUserByHash(hash)
 	if !exists {
 		respondError(c, "用户不存在")
@@ -956,7 +838,7 @@ func (p *QQPDPlugin) handleLogout(c *gin.Context, hash string) {
 
 	// 清除Cookie
 	user.Cookie = ""
-	user.Status = "pending"
+	user.Status = accounts.StatusPending
 	user.QQMasked = ""
 
 	if err := p.saveUser(user); err != nil {
@@ -974,7 +856,7 @@ func (p *QQPDPlugin) handleLogout(c *gin.Context, hash string) {
 }
 
 // handleCheckLogin 检查登录状态（前端轮询调用）
-func (p *QQPDPlugin) handleCheckLogin(c *gin.Context, hash string) {
+func (p *QQPDPlugin) handleCheckLogin(c *gin.Context, hash string, _ map[string]interface{}) {
 	user, exists := p.getUserByHash(hash)
 	if !exists {
 		respondError(c, "用户不存在")
@@ -997,7 +879,7 @@ func (p *QQPDPlugin) handleCheckLogin(c *gin.Context, hash string) {
 	if loginResult.Status == "success" {
 		// 登录成功，更新用户信息
 		user.Cookie = loginResult.Cookie
-		user.Status = "active"
+		user.Status = accounts.StatusActive
 		user.QQMasked = loginResult.QQMasked
 		user.LoginAt = time.Now()
 		// QQ Cookie的实际有效期通常是2天，设置为2天后过期（留一点缓冲时间）
@@ -1059,7 +941,7 @@ func (p *QQPDPlugin) handleManualLogin(c *gin.Context, hash string, reqData map[
 
 	// 更新用户状态
 	user.Cookie = cookie
-	user.Status = "active"
+	user.Status = accounts.StatusActive
 	user.QQMasked = qqMasked
 	user.LoginAt = time.Now()
 	// QQ Cookie的实际有效期通常是2天，设置为2天后过期（留一点缓冲时间）
@@ -1413,9 +1295,9 @@ func (p *QQPDPlugin) extractGuildIDFromChannelNumber(channelNumber string) strin
 
 	client := &http.Client{
 		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
+		Transport: util.NewPluginTransport("qqpd", &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
+		}),
 	}
 
 	resp, err := client.Get(url)
@@ -1507,9 +1389,9 @@ func (p *QQPDPlugin) searchSingleChannel(keyword, cookieStr, channelID, guildID
 	// 创建HTTP请求
 	client := &http.Client{
 		Timeout: 15 * time.Second,
-		Transport: &http.Transport{
+		Transport: util.NewPluginTransport("qqpd", &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
+		}),
 	}
 
 	req, err := http.NewRequest("POST", apiURL, strings.NewReader(string(payloadBytes)))
@@ -1763,9 +1645,9 @@ func (p *QQPDPlugin) checkQRLoginStatus(qrsig string) (*LoginResult, error) {
 
 	client := &http.Client{
 		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
+		Transport: util.NewPluginTransport("qqpd", &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
+		}),
 	}
 
 	req, err := http.NewRequest("GET", loginCheckURL, nil)
@@ -1875,9 +1757,9 @@ func (p *QQPDPlugin) fetchFullCookie(uin, ptsigx, setCookieHeader string) (strin
 
 	client := &http.Client{
 		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
+		Transport: util.NewPluginTransport("qqpd", &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
+		}),
 	}
 
 	req, err := http.NewRequest("GET", checkSigURL, nil)
@@ -1985,9 +1867,9 @@ func (p *QQPDPlugin) refreshCookie(cookieStr string) string {
 	pdURL := "https://pd.qq.com/explore"
 	client := &http.Client{
 		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
+		Transport: util.NewPluginTransport("qqpd", &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
+		}),
 	}
 
 	req, err := http.NewRequest("GET", pdURL, nil)
@@ -2071,9 +1953,9 @@ func (p *QQPDPlugin) generateQRCodeWithSig() ([]byte, string, error) {
 
 	client := &http.Client{
 		Timeout: 15 * time.Second,
-		Transport: &http.Transport{
+		Transport: util.NewPluginTransport("qqpd", &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
+		}),
 	}
 
 	resp, err := client.Get(qrcodeURL)
@@ -2165,7 +2047,7 @@ func (p *QQPDPlugin) testCookieValid(cookieStr string) bool {
 		"cond":          map[string]interface{}{"channel_ids": []string{}, "feed_rank_type": 0, "type_list": []int{2, 3}},
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Transport: util.NewPluginTransport("qqpd", nil), Timeout: 10 * time.Second}
 	payloadBytes, _ := json.Marshal(payload)
 
 	req, err := http.NewRequest("POST", testURL, strings.NewReader(string(payloadBytes)))
@@ -2291,133 +2173,3 @@ func respondError(c *gin.Context, message string) {
 		"data":    nil,
 	})
 }
-
-// ============ Cookie加密 ============
-
-// getEncryptionKey 获取加密密钥
-func getEncryptionKey() []byte {
-	key := os.Getenv("QQPD_ENCRYPTION_KEY")
-	if key == "" {
-		key = "default-32-byte-key-change-me!" // 32字节
-	}
-	return []byte(key)[:32]
-}
-
-// encryptCookie 加密Cookie
-func encryptCookie(plaintext string) (string, error) {
-	key := getEncryptionKey()
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
-	}
-
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
-}
-
-// decryptCookie 解密Cookie
-func decryptCookie(encrypted string) (string, error) {
-	key := getEncryptionKey()
-
-	ciphertext, err := base64.StdEncoding.DecodeString(encrypted)
-	if err != nil {
-		return "", err
-	}
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
-	}
-
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return "", err
-	}
-
-	return string(plaintext), nil
-}
-
-// ============ 定期清理 ============
-
-// startCleanupTask 定期清理任务
-func (p *QQPDPlugin) startCleanupTask() {
-	ticker := time.NewTicker(24 * time.Hour)
-	for range ticker.C {
-		deleted := p.cleanupExpiredUsers()
-		marked := p.markInactiveUsers()
-
-		if deleted > 0 || marked > 0 {
-			fmt.Printf("[QQPD] 清理任务完成: 删除 %d 个过期用户, 标记 %d 个不活跃用户\n", deleted, marked)
-		}
-	}
-}
-
-// cleanupExpiredUsers 清理过期用户（从内存和文件）
-func (p *QQPDPlugin) cleanupExpiredUsers() int {
-	deletedCount := 0
-	now := time.Now()
-	expireThreshold := now.AddDate(0, 0, -30) // 30天前
-
-	// 遍历内存中的用户
-	p.users.Range(func(key, value interface{}) bool {
-		user := value.(*User)
-
-		// 删除条件：状态为expired且超过30天未访问
-		if user.Status == "expired" && user.LastAccessAt.Before(expireThreshold) {
-			if err := p.deleteUser(user.Hash); err == nil {
-				deletedCount++
-			}
-		}
-		return true
-	})
-
-	return deletedCount
-}
-
-// markInactiveUsers 标记长期未使用的用户为过期
-func (p *QQPDPlugin) markInactiveUsers() int {
-	markedCount := 0
-	now := time.Now()
-	inactiveThreshold := now.AddDate(0, 0, -90) // 90天前
-
-	// 遍历内存中的用户
-	p.users.Range(func(key, value interface{}) bool {
-		user := value.(*User)
-
-		// 标记条件：超过90天未访问
-		if user.LastAccessAt.Before(inactiveThreshold) && user.Status != "expired" {
-			user.Status = "expired"
-			user.Cookie = "" // 清空Cookie
-
-			// 更新内存和文件
-			if err := p.saveUser(user); err == nil {
-				markedCount++
-			}
-		}
-		return true
-	})
-
-	return markedCount
-}
\ No newline at end of file
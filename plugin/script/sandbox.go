@@ -0,0 +1,633 @@
+package script
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/robertkrimen/otto"
+
+	"pansou/util"
+	jsonutil "pansou/util/json"
+)
+
+const (
+	// 检查执行时间的间隔
+	watchInterval = 10 * time.Millisecond
+	// 检查堆内存增长的间隔
+	heapCheckInterval = 100 * time.Millisecond
+	// 执行期间堆内存增长的上限为数据量上限的倍数（解释器中的对象比原始数据大得多）
+	heapGrowthFactor = 4
+	// 两次强制垃圾回收的最小间隔，多个脚本同时超限时共用一次回收结果
+	minGCInterval = time.Second
+	// 函数调用深度上限
+	maxStackDepth = 1000
+	// html.select 返回的元素数上限
+	maxSelectItems = 5000
+	// util.extractNetDiskLinks 返回的链接数上限
+	maxExtractedLinks = 1000
+	// console.log 单条日志的长度上限
+	maxLogLength = 2000
+	// 被中止后仍卡在内置函数中的解释器数上限，超过时拒绝执行新的脚本
+	maxAbandonedRuns = 16
+	// 默认请求头
+	defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36"
+)
+
+var (
+	errCPULimit    = errors.New("脚本执行时间超过限制")
+	errMemoryLimit = errors.New("脚本读入的数据量或内存用量超过限制")
+	errDeadline    = errors.New("脚本执行超时")
+	errAbandoned   = fmt.Errorf("被中止的脚本仍有%d个以上在后台运行，暂不执行新的脚本", maxAbandonedRuns)
+)
+
+// abandonedRuns 已被中止、但解释器goroutine尚未退出的执行数
+var abandonedRuns int64
+
+// AbandonedRuns 已被中止、但仍在后台运行的脚本数
+func AbandonedRuns() int64 {
+	return atomic.LoadInt64(&abandonedRuns)
+}
+
+// 执行状态：解释器goroutine和run之间只有一方能完成状态转换
+const (
+	runRunning int32 = iota
+	runFinished
+	runAbandoned
+)
+
+// limits 单次执行的资源限制
+type limits struct {
+	cpu         time.Duration // 执行时间上限，不含等待HTTP请求的时间
+	memory      int64         // 辅助函数读入或创建的数据量上限，执行期间堆内存增长不超过它的heapGrowthFactor倍（尽力而为）
+	maxRequests int           // HTTP请求数上限
+}
+
+// sandbox 一次脚本执行的运行环境：独立的解释器实例、辅助函数和资源计量
+// 超出限制时在解释器所在的goroutine中调用runtime.Goexit结束执行，脚本中的try/catch无法拦截
+// 解释器卡在耗时的内置函数中时无法及时结束，run不等待它而直接返回，goroutine在下一条语句前退出；
+// 这样的goroutine计入abandonedRuns，超过maxAbandonedRuns时拒绝新的执行
+type sandbox struct {
+	vm     *otto.Otto
+	name   string
+	ctx    context.Context
+	client *http.Client // 为nil时不允许发送请求（加载脚本时）
+	limits limits
+
+	mu          sync.Mutex
+	nativeTime  time.Duration // 已完成的HTTP请求耗时
+	nativeStart time.Time     // 正在进行的HTTP请求开始时间
+	memUsed     int64
+	requests    int
+	haltErr     error
+}
+
+func newSandbox(ctx context.Context, name string, client *http.Client, l limits) *sandbox {
+	vm := otto.New()
+	vm.Interrupt = make(chan func(), 1)
+	vm.SetStackDepthLimit(maxStackDepth)
+	s := &sandbox{vm: vm, name: name, ctx: ctx, client: client, limits: l}
+	s.install()
+	return s
+}
+
+// install 注册辅助函数：console、http、html、util
+func (s *sandbox) install() {
+	s.setObject("console", map[string]func(otto.FunctionCall) otto.Value{
+		"log":   s.consoleLog,
+		"error": s.consoleLog,
+	})
+	s.setObject("http", map[string]func(otto.FunctionCall) otto.Value{
+		"get":  func(call otto.FunctionCall) otto.Value { return s.httpRequest(call, "GET") },
+		"post": func(call otto.FunctionCall) otto.Value { return s.httpRequest(call, "POST") },
+	})
+	s.setObject("html", map[string]func(otto.FunctionCall) otto.Value{
+		"select": s.htmlSelect,
+	})
+	s.setObject("util", map[string]func(otto.FunctionCall) otto.Value{
+		"extractNetDiskLinks": s.extractNetDiskLinks,
+		"getLinkType":         s.getLinkType,
+	})
+}
+
+// setObject 创建包含辅助函数的全局对象
+func (s *sandbox) setObject(name string, functions map[string]func(otto.FunctionCall) otto.Value) {
+	obj, _ := s.vm.Object(`({})`)
+	for key, fn := range functions {
+		obj.Set(key, fn)
+	}
+	s.vm.Set(name, obj)
+}
+
+// run 在独立的goroutine中执行fn，同时检查执行时间、堆内存增长和截止时间
+// 超出限制或ctx取消后立即返回，不等待解释器结束（每次执行使用独立的sandbox，done有缓冲，不会阻塞goroutine）
+func (s *sandbox) run(fn func() (interface{}, error)) (interface{}, error) {
+	if atomic.LoadInt64(&abandonedRuns) >= maxAbandonedRuns {
+		return nil, errAbandoned
+	}
+	type outcome struct {
+		value interface{}
+		err   error
+	}
+	done := make(chan outcome, 1)
+	state := runRunning
+	go func() {
+		finished := false
+		defer func() {
+			// run已经返回时，解释器goroutine退出后不再计入被中止的执行
+			if !atomic.CompareAndSwapInt32(&state, runRunning, runFinished) {
+				atomic.AddInt64(&abandonedRuns, -1)
+			}
+		}()
+		defer func() {
+			if finished {
+				return
+			}
+			if caught := recover(); caught != nil {
+				done <- outcome{err: fmt.Errorf("脚本执行出错: %v", caught)}
+				return
+			}
+			// runtime.Goexit结束执行
+			done <- outcome{err: s.haltError()}
+		}()
+		value, err := fn()
+		finished = true
+		done <- outcome{value: value, err: err}
+	}()
+
+	start := time.Now()
+	heapBase := heapAlloc()
+	lastHeapCheck := start
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case result := <-done:
+			return result.value, result.err
+		case <-s.ctx.Done():
+			return nil, s.abandon(&state, errDeadline)
+		case now := <-ticker.C:
+			if s.cpuTime(start) > s.limits.cpu {
+				return nil, s.abandon(&state, errCPULimit)
+			}
+			if now.Sub(lastHeapCheck) >= heapCheckInterval {
+				lastHeapCheck = now
+				if s.heapExceeded(heapBase) {
+					return nil, s.abandon(&state, errMemoryLimit)
+				}
+			}
+		}
+	}
+}
+
+// abandon 结束执行且不等待解释器，解释器goroutine尚未退出时计入abandonedRuns
+func (s *sandbox) abandon(state *int32, err error) error {
+	err = s.halt(err)
+	atomic.AddInt64(&abandonedRuns, 1)
+	if !atomic.CompareAndSwapInt32(state, runRunning, runAbandoned) {
+		// 解释器已经结束
+		atomic.AddInt64(&abandonedRuns, -1)
+	}
+	return err
+}
+
+var (
+	gcMu   sync.Mutex
+	lastGC time.Time
+)
+
+// heapAlloc 当前堆上存活和尚未回收的对象大小
+func heapAlloc() uint64 {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc
+}
+
+// heapExceeded 执行期间堆内存增长是否超过上限
+// 只是尽力而为的兜底检查：堆内存是整个进程共享的，同时执行的其他请求或脚本会影响结果，
+// 增长超过上限时先强制回收垃圾再确认，避免把其他请求的临时对象算到脚本头上；
+// 脚本自身的数据量以辅助函数中的charge计量为准
+func (s *sandbox) heapExceeded(base uint64) bool {
+	if s.limits.memory <= 0 {
+		return false
+	}
+	limit := uint64(s.limits.memory) * heapGrowthFactor
+	if heapAlloc() <= base+limit {
+		return false
+	}
+	gcMu.Lock()
+	if time.Since(lastGC) >= minGCInterval {
+		runtime.GC()
+		lastGC = time.Now()
+	}
+	gcMu.Unlock()
+	return heapAlloc() > base+limit
+}
+
+// halt 请求解释器在下一条语句前结束执行，返回最先记录的结束原因
+func (s *sandbox) halt(err error) error {
+	s.mu.Lock()
+	if s.haltErr != nil {
+		err = s.haltErr
+		s.mu.Unlock()
+		return err
+	}
+	s.haltErr = err
+	s.mu.Unlock()
+	select {
+	case s.vm.Interrupt <- func() { runtime.Goexit() }:
+	default:
+	}
+	return err
+}
+
+// abort 在辅助函数中（解释器所在的goroutine）立即结束执行
+func (s *sandbox) abort(err error) {
+	s.mu.Lock()
+	if s.haltErr == nil {
+		s.haltErr = err
+	}
+	s.mu.Unlock()
+	runtime.Goexit()
+}
+
+func (s *sandbox) haltError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.haltErr == nil {
+		return errDeadline
+	}
+	return s.haltErr
+}
+
+// cpuTime 执行时间（总耗时减去等待HTTP请求的时间）
+func (s *sandbox) cpuTime(start time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	native := s.nativeTime
+	if !s.nativeStart.IsZero() {
+		native += time.Since(s.nativeStart)
+	}
+	return time.Since(start) - native
+}
+
+// enterNative 开始等待HTTP请求，返回结束时调用的函数
+func (s *sandbox) enterNative() func() {
+	s.mu.Lock()
+	s.nativeStart = time.Now()
+	s.mu.Unlock()
+	return func() {
+		s.mu.Lock()
+		s.nativeTime += time.Since(s.nativeStart)
+		s.nativeStart = time.Time{}
+		s.mu.Unlock()
+	}
+}
+
+// charge 计入辅助函数读入或创建的数据量（响应、选择结果、提取的链接等），超出限制时结束执行
+func (s *sandbox) charge(n int) {
+	s.mu.Lock()
+	s.memUsed += int64(n)
+	over := s.memUsed > s.limits.memory
+	s.mu.Unlock()
+	if over {
+		s.abort(errMemoryLimit)
+	}
+}
+
+// remainingMemory 剩余可读入的数据量
+func (s *sandbox) remainingMemory() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.limits.memory - s.memUsed
+}
+
+// throw 抛出脚本可以捕获的异常
+func (s *sandbox) throw(format string, args ...interface{}) {
+	panic(s.vm.MakeCustomError("Error", fmt.Sprintf(format, args...)))
+}
+
+// newObject 把Go的map转换为脚本对象
+func (s *sandbox) newObject(fields map[string]interface{}) otto.Value {
+	obj, _ := s.vm.Object(`({})`)
+	for key, value := range fields {
+		obj.Set(key, value)
+	}
+	return obj.Value()
+}
+
+// newArray 把Go的切片转换为脚本数组
+func (s *sandbox) newArray(items []interface{}) otto.Value {
+	arr, _ := s.vm.Object(`([])`)
+	for _, item := range items {
+		arr.Call("push", item)
+	}
+	return arr.Value()
+}
+
+// exportMap 把脚本对象参数转换为map，不是对象时返回nil
+func exportMap(value otto.Value) map[string]interface{} {
+	if !value.IsObject() {
+		return nil
+	}
+	exported, _ := value.Export()
+	m, _ := exported.(map[string]interface{})
+	return m
+}
+
+// consoleLog console.log(...)：写入日志，超过maxLogLength的部分不再转换
+func (s *sandbox) consoleLog(call otto.FunctionCall) otto.Value {
+	var sb strings.Builder
+	for i, arg := range call.ArgumentList {
+		if sb.Len() > maxLogLength {
+			break
+		}
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		part := arg.String()
+		if remaining := maxLogLength + 1 - sb.Len(); len(part) > remaining {
+			part = part[:remaining]
+		}
+		sb.WriteString(part)
+	}
+	line := sb.String()
+	if len(line) > maxLogLength {
+		line = line[:maxLogLength] + "..."
+	}
+	fmt.Printf("[script:%s] %s\n", s.name, line)
+	return otto.UndefinedValue()
+}
+
+// httpRequest http.get(url, options) 和 http.post(url, body, options)
+// options: {headers: {...}, json: true}；body为对象时按表单编码，json为true时按JSON编码
+// 返回 {status, url, headers, body}，请求经过PanSou的出站限流
+func (s *sandbox) httpRequest(call otto.FunctionCall, method string) otto.Value {
+	if s.client == nil {
+		s.throw("加载脚本时不能发送请求")
+	}
+	rawURL := call.Argument(0).String()
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		s.throw("无效的地址: %s", rawURL)
+	}
+
+	optionsArg := call.Argument(1)
+	var body io.Reader
+	contentType := ""
+	if method == "POST" {
+		optionsArg = call.Argument(2)
+		options := exportMap(optionsArg)
+		bodyArg := call.Argument(1)
+		switch {
+		case bodyArg.IsObject() && options["json"] == true:
+			exported, _ := bodyArg.Export()
+			data, err := jsonutil.Marshal(exported)
+			if err != nil {
+				s.throw("请求体编码失败: %v", err)
+			}
+			body, contentType = strings.NewReader(string(data)), "application/json"
+		case bodyArg.IsObject():
+			form := url.Values{}
+			for key, value := range exportMap(bodyArg) {
+				form.Set(key, fmt.Sprint(value))
+			}
+			body, contentType = strings.NewReader(form.Encode()), "application/x-www-form-urlencoded"
+		case bodyArg.IsDefined() && !bodyArg.IsNull():
+			body, contentType = strings.NewReader(bodyArg.String()), "application/x-www-form-urlencoded"
+		}
+	}
+
+	s.mu.Lock()
+	s.requests++
+	over := s.requests > s.limits.maxRequests
+	s.mu.Unlock()
+	if over {
+		s.throw("HTTP请求数超过限制(%d)", s.limits.maxRequests)
+	}
+
+	req, err := http.NewRequestWithContext(s.ctx, method, rawURL, body)
+	if err != nil {
+		s.throw("创建请求失败: %v", err)
+	}
+	req.Header.Set("User-Agent", defaultUserAgent)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if headers, ok := exportMap(optionsArg)["headers"].(map[string]interface{}); ok {
+		for key, value := range headers {
+			req.Header.Set(key, fmt.Sprint(value))
+		}
+	}
+
+	leave := s.enterNative()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		leave()
+		s.throw("请求失败: %v", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, s.remainingMemory()+1))
+	resp.Body.Close()
+	leave()
+	s.charge(len(data))
+	if err != nil {
+		s.throw("读取响应失败: %v", err)
+	}
+
+	headers := make(map[string]interface{}, len(resp.Header))
+	headerSize := 0
+	for key := range resp.Header {
+		value := resp.Header.Get(key)
+		headers[strings.ToLower(key)] = value
+		headerSize += len(key) + len(value)
+	}
+	s.charge(headerSize)
+	return s.newObject(map[string]interface{}{
+		"status":  resp.StatusCode,
+		"url":     resp.Request.URL.String(),
+		"headers": s.newObject(headers),
+		"body":    string(data),
+	})
+}
+
+// htmlSelect html.select(html, selector)：返回匹配元素的数组 [{text, html, attrs}]，最多maxSelectItems个
+func (s *sandbox) htmlSelect(call otto.FunctionCall) otto.Value {
+	input := call.Argument(0).String()
+	if int64(len(input)) > s.limits.memory {
+		s.abort(errMemoryLimit)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(input))
+	if err != nil {
+		s.throw("解析HTML失败: %v", err)
+	}
+	selector := call.Argument(1).String()
+	var selection *goquery.Selection
+	func() {
+		// 无效的选择器会导致panic
+		defer func() {
+			if recover() != nil {
+				selection = nil
+			}
+		}()
+		selection = doc.Find(selector)
+	}()
+	if selection == nil {
+		s.throw("无效的选择器: %s", selector)
+	}
+
+	if selection.Length() > maxSelectItems {
+		selection = selection.Slice(0, maxSelectItems)
+	}
+	items := make([]interface{}, 0, selection.Length())
+	selection.Each(func(_ int, sel *goquery.Selection) {
+		text := strings.TrimSpace(sel.Text())
+		inner, _ := sel.Html()
+		attrs := make(map[string]interface{})
+		size := len(text) + len(inner)
+		if len(sel.Nodes) > 0 {
+			for _, attr := range sel.Nodes[0].Attr {
+				attrs[attr.Key] = attr.Val
+				size += len(attr.Key) + len(attr.Val)
+			}
+		}
+		s.charge(size)
+		items = append(items, s.newObject(map[string]interface{}{
+			"text":  text,
+			"html":  inner,
+			"attrs": s.newObject(attrs),
+		}))
+	})
+	return s.newArray(items)
+}
+
+// extractNetDiskLinks util.extractNetDiskLinks(text)：提取文本中的网盘链接，最多maxExtractedLinks个
+func (s *sandbox) extractNetDiskLinks(call otto.FunctionCall) otto.Value {
+	text := call.Argument(0).String()
+	if int64(len(text)) > s.limits.memory {
+		s.abort(errMemoryLimit)
+	}
+	links := util.ExtractNetDiskLinks(text)
+	if len(links) > maxExtractedLinks {
+		links = links[:maxExtractedLinks]
+	}
+	items := make([]interface{}, 0, len(links))
+	size := 0
+	for _, link := range links {
+		items = append(items, link)
+		size += len(link)
+	}
+	s.charge(size)
+	return s.newArray(items)
+}
+
+// getLinkType util.getLinkType(url)：识别链接的网盘类型
+func (s *sandbox) getLinkType(call otto.FunctionCall) otto.Value {
+	value, _ := s.vm.ToValue(util.GetLinkType(call.Argument(0).String()))
+	return value
+}
+
+// toJSValue 把Go的值转换为脚本中的普通对象（经过JSON），计入数据量
+func (s *sandbox) toJSValue(value interface{}) (otto.Value, error) {
+	data, err := jsonutil.Marshal(value)
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	s.charge(len(data))
+	return s.vm.Call("JSON.parse", nil, string(data))
+}
+
+// metadata 脚本通过全局变量 plugin 声明的信息
+type metadata struct {
+	Priority          int
+	SkipServiceFilter bool
+}
+
+// load 执行脚本顶层代码，检查search函数并读取 plugin 声明
+func (s *sandbox) load(program *otto.Script) (metadata, error) {
+	value, err := s.run(func() (interface{}, error) {
+		if _, err := s.vm.Run(program); err != nil {
+			return nil, err
+		}
+		search, _ := s.vm.Get("search")
+		if !search.IsFunction() {
+			return nil, errors.New("脚本未定义 search(keyword, ext) 函数")
+		}
+
+		meta := metadata{Priority: defaultPriority}
+		declared, _ := s.vm.Get("plugin")
+		fields := exportMap(declared)
+		if priority, ok := toInt(fields["priority"]); ok && priority >= 1 && priority <= 4 {
+			meta.Priority = priority
+		}
+		if skip, ok := fields["skipServiceFilter"].(bool); ok {
+			meta.SkipServiceFilter = skip
+		}
+		return meta, nil
+	})
+	if err != nil {
+		return metadata{}, err
+	}
+	return value.(metadata), nil
+}
+
+// search 执行脚本顶层代码后调用 search(keyword, ext)，返回JSON序列化后的结果
+func (s *sandbox) search(program *otto.Script, keyword string, ext map[string]interface{}) ([]byte, error) {
+	value, err := s.run(func() (interface{}, error) {
+		if _, err := s.vm.Run(program); err != nil {
+			return nil, err
+		}
+		extValue, err := s.toJSValue(ext)
+		if err != nil {
+			return nil, err
+		}
+		search, _ := s.vm.Get("search")
+		if !search.IsFunction() {
+			return nil, errors.New("脚本未定义 search(keyword, ext) 函数")
+		}
+		ret, err := search.Call(otto.UndefinedValue(), keyword, extValue)
+		if err != nil {
+			return nil, err
+		}
+		if ret.IsUndefined() || ret.IsNull() {
+			return []byte("[]"), nil
+		}
+		// 经过JSON.stringify，Date会转换为ISO时间字符串
+		encoded, err := s.vm.Call("JSON.stringify", nil, ret)
+		if err != nil {
+			return nil, err
+		}
+		data := []byte(encoded.String())
+		s.charge(len(data))
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]byte), nil
+}
+
+// toInt 把导出的数字转换为int
+func toInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return int(n), err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,360 @@
+// Package script 脚本插件：插件目录中的每个 .js 文件注册为一个搜索插件，文件修改后自动重新加载
+//
+// 脚本需要定义 search(keyword, ext) 函数并返回结果数组，可以通过全局变量 plugin 声明优先级和过滤设置：
+//
+//	var plugin = {priority: 2, skipServiceFilter: false};
+//	function search(keyword, ext) {
+//	    var resp = http.get("https://example.com/search?q=" + encodeURIComponent(keyword));
+//	    return html.select(resp.body, ".item").map(function (item) {
+//	        return {title: item.text, links: util.extractNetDiskLinks(item.html).map(function (u) { return {url: u}; })};
+//	    });
+//	}
+//
+// 每次搜索使用独立的解释器实例，受执行时间、读入数据量和请求数限制
+package script
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robertkrimen/otto"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/plugin"
+	"pansou/util"
+)
+
+const (
+	// 未声明优先级时的默认值
+	defaultPriority = 3
+	// 加载脚本（执行顶层代码）的超时时间
+	loadTimeout = 5 * time.Second
+)
+
+// namePattern 插件名称（文件名）只能包含小写字母、数字和下划线（结果ID以 名称- 开头）
+var namePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+var (
+	registered   []*ScriptPlugin
+	registeredMu sync.Mutex
+)
+
+// Register 加载插件目录中的脚本并注册，返回注册的数量
+// 启动后新增的脚本需要重启才会注册，已注册的脚本修改或删除后自动生效
+func Register(pm *plugin.PluginManager) int {
+	if config.AppConfig == nil || config.AppConfig.ScriptPluginsDir == "" {
+		return 0
+	}
+	dir := config.AppConfig.ScriptPluginsDir
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		fmt.Printf("[script] 读取脚本目录失败: %v\n", err)
+		return 0
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	count := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".js") {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSuffix(entry.Name(), ".js"))
+		if !namePattern.MatchString(name) {
+			fmt.Printf("[script] 跳过 %s：文件名只能包含小写字母、数字和下划线\n", entry.Name())
+			continue
+		}
+		if _, exists := plugin.GetPluginByName(name); exists {
+			fmt.Printf("[script] 跳过 %s：插件名称 %s 已被使用\n", entry.Name(), name)
+			continue
+		}
+
+		p := &ScriptPlugin{path: filepath.Join(dir, entry.Name())}
+		meta, err := p.load(name)
+		if err != nil {
+			fmt.Printf("[script] 加载 %s 失败: %v\n", entry.Name(), err)
+			continue
+		}
+		p.BaseAsyncPlugin = plugin.NewBaseAsyncPluginWithFilter(name, meta.Priority, meta.SkipServiceFilter)
+		plugin.RegisterGlobalPlugin(p)
+		pm.RegisterPlugin(p)
+
+		registeredMu.Lock()
+		registered = append(registered, p)
+		registeredMu.Unlock()
+		count++
+	}
+
+	if count > 0 {
+		go watch(config.AppConfig.ScriptReloadInterval)
+	}
+	return count
+}
+
+// watch 定期检查脚本文件，修改后重新加载，删除后停用
+func watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		registeredMu.Lock()
+		plugins := registered
+		registeredMu.Unlock()
+		for _, p := range plugins {
+			p.checkReload()
+		}
+	}
+}
+
+// Status 脚本插件状态
+type Status struct {
+	Name     string    `json:"name"`
+	File     string    `json:"file"`
+	LoadedAt time.Time `json:"loaded_at"`
+	Removed  bool      `json:"removed,omitempty"`
+	Error    string    `json:"error,omitempty"` // 最近一次重新加载失败的原因（仍使用上一个版本）
+}
+
+// Statuses 获取所有脚本插件的状态
+func Statuses() []Status {
+	registeredMu.Lock()
+	plugins := registered
+	registeredMu.Unlock()
+
+	statuses := make([]Status, 0, len(plugins))
+	for _, p := range plugins {
+		p.mu.RLock()
+		statuses = append(statuses, Status{
+			Name:     p.Name(),
+			File:     filepath.Base(p.path),
+			LoadedAt: p.loadedAt,
+			Removed:  p.removed,
+			Error:    p.loadErr,
+		})
+		p.mu.RUnlock()
+	}
+	return statuses
+}
+
+// ScriptPlugin JavaScript脚本插件
+type ScriptPlugin struct {
+	*plugin.BaseAsyncPlugin
+	path string
+
+	mu       sync.RWMutex
+	program  *otto.Script
+	modTime  time.Time
+	size     int64
+	loadedAt time.Time
+	loadErr  string
+	removed  bool
+}
+
+// scriptLimits 当前配置的资源限制
+func scriptLimits() limits {
+	return limits{
+		cpu:         config.AppConfig.ScriptCPULimit,
+		memory:      config.AppConfig.ScriptMemoryLimit,
+		maxRequests: config.AppConfig.ScriptMaxRequests,
+	}
+}
+
+// load 读取并编译脚本，执行顶层代码检查后替换当前版本
+func (p *ScriptPlugin) load(name string) (metadata, error) {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return metadata{}, err
+	}
+	src, err := os.ReadFile(p.path)
+	if err != nil {
+		return metadata{}, err
+	}
+	// 无论是否成功都记录文件版本，避免重复加载同一个有错误的版本
+	p.mu.Lock()
+	p.modTime, p.size = info.ModTime(), info.Size()
+	p.mu.Unlock()
+
+	program, err := otto.New().Compile(p.path, src)
+	if err != nil {
+		return metadata{}, fmt.Errorf("语法错误: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+	meta, err := newSandbox(ctx, name, nil, scriptLimits()).load(program)
+	if err != nil {
+		return metadata{}, err
+	}
+
+	p.mu.Lock()
+	p.program = program
+	p.loadedAt = time.Now()
+	p.loadErr = ""
+	p.removed = false
+	p.mu.Unlock()
+	return meta, nil
+}
+
+// checkReload 文件修改后重新加载，加载失败时继续使用上一个版本
+func (p *ScriptPlugin) checkReload() {
+	info, err := os.Stat(p.path)
+	p.mu.Lock()
+	if err != nil {
+		if os.IsNotExist(err) && !p.removed {
+			p.removed = true
+			fmt.Printf("[script:%s] 脚本已删除，停用插件\n", p.Name())
+		}
+		p.mu.Unlock()
+		return
+	}
+	changed := p.removed || !info.ModTime().Equal(p.modTime) || info.Size() != p.size
+	p.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	if _, err := p.load(p.Name()); err != nil {
+		p.mu.Lock()
+		p.loadErr = err.Error()
+		p.mu.Unlock()
+		fmt.Printf("[script:%s] 重新加载失败，继续使用上一个版本: %v\n", p.Name(), err)
+		return
+	}
+	fmt.Printf("[script:%s] 已重新加载\n", p.Name())
+}
+
+// Search 执行搜索并返回结果（兼容性方法）
+func (p *ScriptPlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	result, err := p.SearchWithResult(keyword, ext)
+	if err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// SearchWithResult 执行搜索并返回包含IsFinal标记的结果
+func (p *ScriptPlugin) SearchWithResult(keyword string, ext map[string]interface{}) (model.PluginSearchResult, error) {
+	return p.AsyncSearchWithResult(keyword, p.searchImpl, p.MainCacheKey, ext)
+}
+
+// searchImpl 在沙箱中执行脚本的search函数，截止时间取客户端的超时时间
+func (p *ScriptPlugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
+	p.mu.RLock()
+	program, removed := p.program, p.removed
+	p.mu.RUnlock()
+	if removed {
+		return nil, fmt.Errorf("[%s] 脚本文件已删除", p.Name())
+	}
+
+	timeout := client.Timeout
+	if timeout <= 0 {
+		timeout = config.AppConfig.PluginTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// 内部参数（以_开头）不传给脚本
+	scriptExt := make(map[string]interface{})
+	for key, value := range ext {
+		if !strings.HasPrefix(key, "_") {
+			scriptExt[key] = value
+		}
+	}
+
+	data, err := newSandbox(ctx, p.Name(), client, scriptLimits()).search(program, keyword, scriptExt)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] 脚本执行失败: %w", p.Name(), err)
+	}
+	var results []scriptResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("[%s] search 应返回结果数组: %w", p.Name(), err)
+	}
+	return p.convertResults(results), nil
+}
+
+// scriptResult 脚本返回的结果，datetime可以是时间字符串或时间戳
+type scriptResult struct {
+	UniqueID  string      `json:"unique_id"`
+	MessageID string      `json:"message_id"`
+	Title     string      `json:"title"`
+	Content   string      `json:"content"`
+	Datetime  interface{} `json:"datetime"`
+	Links     []struct {
+		Type      string `json:"type"`
+		URL       string `json:"url"`
+		Password  string `json:"password"`
+		WorkTitle string `json:"work_title"`
+	} `json:"links"`
+	Tags   []string `json:"tags"`
+	Images []string `json:"images"`
+}
+
+// convertResults 转换脚本结果：UniqueID以 插件名- 开头，补全链接类型，丢弃没有链接的结果
+func (p *ScriptPlugin) convertResults(results []scriptResult) []model.SearchResult {
+	prefix := p.Name() + "-"
+	converted := make([]model.SearchResult, 0, len(results))
+	for _, r := range results {
+		links := make([]model.Link, 0, len(r.Links))
+		for _, link := range r.Links {
+			if link.URL == "" {
+				continue
+			}
+			if link.Type == "" {
+				link.Type = util.GetLinkType(link.URL)
+			}
+			links = append(links, model.Link{Type: link.Type, URL: link.URL, Password: link.Password, WorkTitle: link.WorkTitle})
+		}
+		if len(links) == 0 {
+			continue
+		}
+
+		uniqueID := r.UniqueID
+		if uniqueID == "" {
+			uniqueID = r.MessageID
+		}
+		if uniqueID == "" {
+			uniqueID = links[0].URL
+		}
+		if !strings.HasPrefix(uniqueID, prefix) {
+			uniqueID = prefix + uniqueID
+		}
+		converted = append(converted, model.SearchResult{
+			MessageID: r.MessageID,
+			UniqueID:  uniqueID,
+			Datetime:  parseDatetime(r.Datetime),
+			Title:     r.Title,
+			Content:   r.Content,
+			Links:     links,
+			Tags:      r.Tags,
+			Images:    r.Images,
+		})
+	}
+	return converted
+}
+
+// parseDatetime 解析脚本返回的时间：时间字符串，或Unix时间戳（秒或毫秒）
+func parseDatetime(value interface{}) time.Time {
+	switch v := value.(type) {
+	case string:
+		for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), time.Local); err == nil {
+				return t
+			}
+		}
+	case float64:
+		if v > 1e12 {
+			return time.UnixMilli(int64(v))
+		}
+		if v > 0 {
+			return time.Unix(int64(v), 0)
+		}
+	}
+	return time.Time{}
+}
@@ -14,10 +14,14 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 // 常量定义
 const (
+	// 网站URL（默认地址，可通过 PLUGIN_MIRRORS 配置镜像）
+	SiteURL = "https://thpibay.xyz"
+	
 	// 搜索URL格式 - 第1页
 	SearchURL = "https://thpibay.xyz/search/%s/1/99/0"
 	
@@ -95,17 +99,20 @@ func createOptimizedHTTPClient() *http.Client {
 	}
 	
 	return &http.Client{
-		Transport: transport,
+		Transport: util.NewPluginTransport("thepiratebay", transport),
 		Timeout:   DefaultTimeout,
 	}
 }
 
 // NewThePirateBayPlugin 创建新的海盗湾搜索异步插件
 func NewThePirateBayPlugin() *ThePirateBayPlugin {
-	return &ThePirateBayPlugin{
+	p := &ThePirateBayPlugin{
 		BaseAsyncPlugin: plugin.NewBaseAsyncPluginWithFilter("thepiratebay", 3, true), // 跳过Service层过滤
-		optimizedClient: createOptimizedHTTPClient(),
 	}
+	// 域名经常变更，请求按 SiteURL 构造，由插件客户端改写到当前可用的镜像
+	p.SetBaseURLs(SiteURL)
+	p.optimizedClient = p.MirrorClient(createOptimizedHTTPClient())
+	return p
 }
 
 // 初始化插件
@@ -146,7 +153,7 @@ func (p *ThePirateBayPlugin) SearchWithResult(keyword string, ext map[string]int
 func (p *ThePirateBayPlugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	// 使用优化的客户端
 	if p.optimizedClient != nil {
-		client = p.optimizedClient
+		client = util.BudgetedClient(p.optimizedClient, ext)
 	}
 	
 	// 检查是否提供了英文标题参数 - 对英文搜索更友好
@@ -371,7 +378,7 @@ func (p *ThePirateBayPlugin) parseSearchResultItem(s *goquery.Selection) *model.
 	
 	// 补全URL
 	if strings.HasPrefix(detailURL, "/") {
-		detailURL = "https://thpibay.xyz" + detailURL
+		detailURL = p.BaseURL() + detailURL
 	}
 	
 	// 提取种子ID
@@ -13,6 +13,7 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 const (
@@ -99,7 +100,8 @@ func (p *U3c3Plugin) getSearch2Parameter() (string, error) {
 	}
 
 	client := &http.Client{
-		Timeout: 30 * time.Second,
+		Transport: util.NewPluginTransport("u3c3", nil),
+		Timeout:   30 * time.Second,
 	}
 
 	req, err := http.NewRequest("GET", BaseURL, nil)
@@ -217,7 +219,8 @@ func (p *U3c3Plugin) doSearch(keyword, search2 string) ([]model.SearchResult, er
 	}
 
 	client := &http.Client{
-		Timeout: 30 * time.Second,
+		Transport: util.NewPluginTransport("u3c3", nil),
+		Timeout:   30 * time.Second,
 	}
 
 	req, err := http.NewRequest("GET", searchURL, nil)
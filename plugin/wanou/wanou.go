@@ -13,6 +13,7 @@ import (
 
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"pansou/util/json"
 )
 
@@ -74,7 +75,7 @@ func createOptimizedHTTPClient() *http.Client {
 	}
 
 	return &http.Client{
-		Transport: transport,
+		Transport: util.NewPluginTransport("wanou", transport),
 		Timeout:   DefaultTimeout,
 	}
 }
@@ -113,7 +114,7 @@ func (p *WanouAsyncPlugin) searchImpl(client *http.Client, keyword string, ext m
 
 	// 使用优化的客户端
 	if p.optimizedClient != nil {
-		client = p.optimizedClient
+		client = util.BudgetedClient(p.optimizedClient, ext)
 	}
 
 	// 构建API搜索URL
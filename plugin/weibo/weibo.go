@@ -6,20 +6,19 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"os"
-	"path/filepath"
 	"regexp"
-	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/plugin/accounts"
+	"pansou/util"
 	"pansou/util/json"
 
 	"github.com/gin-gonic/gin"
@@ -32,8 +31,6 @@ const (
 	DebugLog           = false
 )
 
-var StorageDir string
-
 
 const HTMLTemplate = `<!DOCTYPE html>
 <html lang="zh-CN">
@@ -408,21 +405,16 @@ const HTMLTemplate = `<!DOCTYPE html>
 
 type WeiboPlugin struct {
 	*plugin.BaseAsyncPlugin
-	users       sync.Map
+	accounts    *accounts.Manager
 	mu          sync.RWMutex
 	initialized bool
 }
 
 type User struct {
-	Hash         string    `json:"hash"`
-	Cookie       string    `json:"cookie"`
-	Status       string    `json:"status"`
-	UserIDs      []string  `json:"user_ids"`
-	CreatedAt    time.Time `json:"created_at"`
-	LoginAt      time.Time `json:"login_at"`
-	ExpireAt     time.Time `json:"expire_at"`
-	LastAccessAt time.Time `json:"last_access_at"`
-	LastRefresh  time.Time `json:"last_refresh"` // Cookie上次刷新时间
+	accounts.Account
+	Cookie      string    `json:"cookie"`
+	UserIDs     []string  `json:"user_ids"`
+	LastRefresh time.Time `json:"last_refresh"` // Cookie上次刷新时间
 
 	QRCodeCache     []byte    `json:"-"`
 	QRCodeCacheTime time.Time `json:"-"`
@@ -438,6 +430,30 @@ func init() {
 	p := &WeiboPlugin{
 		BaseAsyncPlugin: plugin.NewBaseAsyncPlugin("weibo", 3),
 	}
+	p.accounts = accounts.NewManager(accounts.Options{
+		Plugin:    "weibo",
+		New:       func() accounts.Record { return &User{} },
+		LegacyDir: "weibo_users",
+		HashID:    p.generateHash,
+		Details: func(r accounts.Record) map[string]interface{} {
+			return map[string]interface{}{"user_id_count": len(r.(*User).UserIDs)}
+		},
+		OnExpire: func(r accounts.Record) {
+			r.(*User).Cookie = ""
+		},
+		// 每小时刷新一次短期令牌（XSRF-TOKEN等）
+		KeepAlive:         p.keepCookieAlive,
+		KeepAliveInterval: time.Hour,
+		KeepAliveDelay:    5 * time.Minute,
+		Actions: map[string]accounts.ActionFunc{
+			"get_status":     p.handleGetStatus,
+			"refresh_qrcode": p.handleRefreshQRCode,
+			"logout":         p.handleLogout,
+			"set_user_ids":   p.handleSetUserIDs,
+			"test_search":    p.handleTestSearch,
+			"check_login":    p.handleCheckLogin,
+		},
+	})
 
 	plugin.RegisterGlobalPlugin(p)
 }
@@ -448,20 +464,10 @@ func (p *WeiboPlugin) Initialize() error {
 		return nil
 	}
 
-	// 初始化存储目录路径
-	cachePath := os.Getenv("CACHE_PATH")
-	if cachePath == "" {
-		cachePath = "./cache"
-	}
-	StorageDir = filepath.Join(cachePath, "weibo_users")
-
-	if err := os.MkdirAll(StorageDir, 0755); err != nil {
-		return fmt.Errorf("创建存储目录失败: %v", err)
+	if err := p.accounts.Start(); err != nil {
+		return err
 	}
 
-	p.loadAllUsers()
-	go p.startCleanupTask()
-
 	p.initialized = true
 	return nil
 }
@@ -492,7 +498,7 @@ func (p *WeiboPlugin) SearchWithResult(keyword string, ext map[string]interface{
 		fmt.Printf("[Weibo] ========== 开始搜索: %s ==========\n", keyword)
 	}
 
-	users := p.getActiveUsers()
+	users := p.acquireUsers()
 	if DebugLog {
 		fmt.Printf("[Weibo] 找到 %d 个有效用户\n", len(users))
 	}
@@ -504,13 +510,6 @@ func (p *WeiboPlugin) SearchWithResult(keyword string, ext map[string]interface{
 		return model.PluginSearchResult{Results: []model.SearchResult{}, IsFinal: true}, nil
 	}
 
-	if len(users) > MaxConcurrentUsers {
-		sort.Slice(users, func(i, j int) bool {
-			return users[i].LastAccessAt.After(users[j].LastAccessAt)
-		})
-		users = users[:MaxConcurrentUsers]
-	}
-
 	tasks := p.buildUserTasks(users)
 	results := p.executeTasks(tasks, keyword)
 
@@ -525,90 +524,41 @@ func (p *WeiboPlugin) SearchWithResult(keyword string, ext map[string]interface{
 	}, nil
 }
 
-func (p *WeiboPlugin) loadAllUsers() {
-	files, err := ioutil.ReadDir(StorageDir)
-	if err != nil {
-		return
-	}
-
-	count := 0
-	for _, file := range files {
-		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
-			continue
-		}
-
-		filePath := filepath.Join(StorageDir, file.Name())
-		data, err := ioutil.ReadFile(filePath)
-		if err != nil {
-			continue
-		}
-
-		var user User
-		if err := json.Unmarshal(data, &user); err != nil {
-			continue
-		}
-
-		p.users.Store(user.Hash, &user)
-		count++
-	}
-
-	fmt.Printf("[Weibo] 已加载 %d 个用户到内存\n", count)
-}
-
 func (p *WeiboPlugin) getUserByHash(hash string) (*User, bool) {
-	value, ok := p.users.Load(hash)
+	r, ok := p.accounts.Get(hash)
 	if !ok {
 		return nil, false
 	}
-	return value.(*User), true
+	return r.(*User), true
 }
 
 func (p *WeiboPlugin) saveUser(user *User) error {
-	p.users.Store(user.Hash, user)
-	return p.persistUser(user)
+	return p.accounts.Save(user)
 }
 
-func (p *WeiboPlugin) persistUser(user *User) error {
-	filePath := filepath.Join(StorageDir, user.Hash+".json")
-	data, err := json.MarshalIndent(user, "", "  ")
-	if err != nil {
-		return err
+// acquireUsers 选择已登录且配置了微博用户ID的用户（最近最少使用的优先）
+func (p *WeiboPlugin) acquireUsers() []*User {
+	records := p.accounts.Acquire(MaxConcurrentUsers, func(r accounts.Record) bool {
+		user := r.(*User)
+		return user.Cookie != "" && len(user.UserIDs) > 0
+	})
+	users := make([]*User, 0, len(records))
+	for _, r := range records {
+		users = append(users, r.(*User))
 	}
-	return ioutil.WriteFile(filePath, data, 0644)
-}
-
-func (p *WeiboPlugin) deleteUser(hash string) error {
-	p.users.Delete(hash)
-	filePath := filepath.Join(StorageDir, hash+".json")
-	return os.Remove(filePath)
+	return users
 }
 
-func (p *WeiboPlugin) getActiveUsers() []*User {
-	var users []*User
-
-	p.users.Range(func(key, value interface{}) bool {
-		user := value.(*User)
-		
-		if user.Status != "active" {
-			return true
-		}
-
-		if !user.ExpireAt.IsZero() && time.Now().After(user.ExpireAt) {
-			user.Status = "expired"
-			user.Cookie = ""
-			p.saveUser(user)
-			return true
-		}
-
-		if len(user.UserIDs) == 0 {
-			return true
-		}
-
-		users = append(users, user)
-		return true
-	})
-
-	return users
+// keepCookieAlive 刷新Cookie中的短期令牌（由账号管理器定期调用）
+func (p *WeiboPlugin) keepCookieAlive(r accounts.Record) error {
+	user := r.(*User)
+	refreshedCookie, err := p.refreshCookie(user.Cookie)
+	if err != nil {
+		return err
+	}
+	user.Cookie = refreshedCookie
+	user.LastRefresh = time.Now()
+	return p.saveUser(user)
 }
 
 func (p *WeiboPlugin) handleManagePage(c *gin.Context) {
@@ -638,43 +588,35 @@ func (p *WeiboPlugin) handleManagePagePOST(c *gin.Context) {
 		return
 	}
 
-	switch action {
-	case "get_status":
-		p.handleGetStatus(c, hash)
-	case "refresh_qrcode":
-		p.handleRefreshQRCode(c, hash)
-	case "logout":
-		p.handleLogout(c, hash)
-	case "set_user_ids":
-		p.handleSetUserIDs(c, hash, reqData)
-	case "test_search":
-		p.handleTestSearch(c, hash, reqData)
-	case "check_login":
-		p.handleCheckLogin(c, hash)
-	default:
+	if !p.accounts.HandleAction(c, hash, action, reqData) {
 		respondError(c, "未知的操作类型: "+action)
 	}
 }
 
-func (p *WeiboPlugin) handleGetStatus(c *gin.Context, hash string) {
+func (p *WeiboPlugin) handleGetStatus(c *gin.Context, hash string, _ map[string]interface{}) {
 	user, exists := p.getUserByHash(hash)
 
 	if !exists {
 		user = &User{
-			Hash:         hash,
-			Status:       "pending",
-			UserIDs:      []string{},
-			CreatedAt:    time.Now(),
-			LastAccessAt: time.Now(),
+			Account: accounts.Account{
+				Hash:         hash,
+				Status:       accounts.StatusPending,
+				CreatedAt:    time.Now(),
+				LastAccessAt: time.Now(),
+			},
+			UserIDs: []string{},
+		}
+		if err := p.saveUser(user); err != nil {
+			respondError(c, "创建用户失败: "+err.Error())
+			return
 		}
-		p.saveUser(user)
 	} else {
 		user.LastAccessAt = time.Now()
 		p.saveUser(user)
 	}
 
 	loggedIn := false
-	if user.Status == "active" && user.Cookie != "" {
+	if user.Status == accounts.StatusActive && user.Cookie != "" {
 		loggedIn = true
 	}
 	
@@ -721,7 +663,7 @@ func (p *WeiboPlugin) handleGetStatus(c *gin.Context, hash string) {
 	respondSuccess(c, "获取成功", responseData)
 }
 
-func (p *WeiboPlugin) handleRefreshQRCode(c *gin.Context, hash string) {
+func (p *WeiboPlugin) handleRefreshQRCode(c *gin.Context, hash string, _ map[string]interface{}) {
 	user, exists := p.getUserByHash(hash)
 	if !exists {
 		respondError(c, "用户不存在")
@@ -746,7 +688,7 @@ func (p *WeiboPlugin) handleRefreshQRCode(c *gin.Context, hash string) {
 	})
 }
 
-func (p *WeiboPlugin) handleLogout(c *gin.Context, hash string) {
+func (p *WeiboPlugin) handleLogout(c *gin.Context, hash string, _ map[string]interface{}) {
 	user, exists := p.getUserByHash(hash)
 	if !exists {
 		respondError(c, "用户不存在")
@@ -754,7 +696,7 @@ func (p *WeiboPlugin) handleLogout(c *gin.Context, hash string) {
 	}
 
 	user.Cookie = ""
-	user.Status = "pending"
+	user.Status = accounts.StatusPending
 
 	if err := p.saveUser(user); err != nil {
 		respondError(c, "退出失败")
@@ -766,7 +708,7 @@ func (p *WeiboPlugin) handleLogout(c *gin.Context, hash string) {
 	})
 }
 
-func (p *WeiboPlugin) handleCheckLogin(c *gin.Context, hash string) {
+func (p *WeiboPlugin) handleCheckLogin(c *gin.Context, hash string, _ map[string]interface{}) {
 	user, exists := p.getUserByHash(hash)
 	if !exists {
 		respondError(c, "用户不存在")
@@ -791,7 +733,7 @@ func (p *WeiboPlugin) handleCheckLogin(c *gin.Context, hash string) {
 		fmt.Printf("[Weibo DEBUG] 登录成功! 开始更新用户状态...\n")
 		
 		user.Cookie = loginResult.Cookie
-		user.Status = "active"
+		user.Status = accounts.StatusActive
 		user.LoginAt = time.Now()
 		user.ExpireAt = time.Now().AddDate(0, 0, 30)
 		user.Qrsig = ""
@@ -800,10 +742,7 @@ func (p *WeiboPlugin) handleCheckLogin(c *gin.Context, hash string) {
 		fmt.Printf("[Weibo DEBUG] 更新后 - Status: %s, Cookie长度: %d\n", user.Status, len(user.Cookie))
 
 		// 保存到内存和文件
-		p.users.Store(hash, user)
-		fmt.Printf("[Weibo DEBUG] 已保存到内存\n")
-		
-		if err := p.persistUser(user); err != nil {
+		if err := p.saveUser(user); err != nil {
 			fmt.Printf("[Weibo DEBUG] 持久化失败: %v\n", err)
 			respondError(c, "保存失败: "+err.Error())
 			return
@@ -958,27 +897,10 @@ func (p *WeiboPlugin) buildUserTasks(users []*User) []UserTask {
 			}
 		}
 
-		// 检查是否需要刷新Cookie（每小时刷新一次）
-		cookie := selectedUser.Cookie
-		if time.Since(selectedUser.LastRefresh) > time.Hour {
-			if DebugLog {
-				fmt.Printf("[Weibo] Cookie已使用超过1小时，刷新短期令牌...\n")
-			}
-			refreshedCookie := p.refreshCookie(cookie)
-			if refreshedCookie != cookie {
-				selectedUser.Cookie = refreshedCookie
-				selectedUser.LastRefresh = time.Now()
-				p.saveUser(selectedUser)
-				cookie = refreshedCookie
-				if DebugLog {
-					fmt.Printf("[Weibo] Cookie刷新成功\n")
-				}
-			}
-		}
-		
+		// Cookie中的短期令牌由账号管理器每小时刷新一次
 		tasks = append(tasks, UserTask{
 			UserID: uid,
-			Cookie: cookie,
+			Cookie: selectedUser.Cookie,
 		})
 
 		userTaskCount[selectedUser.Hash]++
@@ -987,37 +909,38 @@ func (p *WeiboPlugin) buildUserTasks(users []*User) []UserTask {
 	return tasks
 }
 
-func (p *WeiboPlugin) refreshCookie(cookieStr string) string {
+func (p *WeiboPlugin) refreshCookie(cookieStr string) (string, error) {
 	// 访问PC端和移动端首页刷新短期令牌（XSRF-TOKEN等）
 	client := &http.Client{
-		Timeout: 10 * time.Second,
+		Transport: util.NewPluginTransport("weibo", nil),
+		Timeout:   10 * time.Second,
 	}
 	
 	// 访问PC端首页
 	reqPC, err := http.NewRequest("GET", "https://weibo.com/", nil)
 	if err != nil {
-		return cookieStr
+		return cookieStr, err
 	}
 	reqPC.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
 	reqPC.Header.Set("Cookie", cookieStr)
 	
 	respPC, err := client.Do(reqPC)
 	if err != nil {
-		return cookieStr
+		return cookieStr, err
 	}
 	respPC.Body.Close()
 	
 	// 访问移动端首页
 	reqMobile, err := http.NewRequest("GET", "https://m.weibo.cn/", nil)
 	if err != nil {
-		return cookieStr
+		return cookieStr, err
 	}
 	reqMobile.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 14_0 like Mac OS X) AppleWebKit/605.1.15")
 	reqMobile.Header.Set("Cookie", cookieStr)
 	
 	respMobile, err := client.Do(reqMobile)
 	if err != nil {
-		return cookieStr
+		return cookieStr, err
 	}
 	respMobile.Body.Close()
 	
@@ -1053,7 +976,7 @@ func (p *WeiboPlugin) refreshCookie(cookieStr string) string {
 		parts = append(parts, fmt.Sprintf("%s=%s", k, v))
 	}
 	
-	return strings.Join(parts, "; ")
+	return strings.Join(parts, "; "), nil
 }
 
 func (p *WeiboPlugin) executeTasks(tasks []UserTask, keyword string) []model.SearchResult {
@@ -1088,7 +1011,8 @@ func (p *WeiboPlugin) searchUserWeibo(uid, cookie, keyword string) []model.Searc
 	maxPages := 3
 
 	client := &http.Client{
-		Timeout: 30 * time.Second,
+		Transport: util.NewPluginTransport("weibo", nil),
+		Timeout:   30 * time.Second,
 	}
 
 	for page := 1; page <= maxPages; page++ {
@@ -1288,7 +1212,8 @@ func (p *WeiboPlugin) getComments(weiboID, cookie string, maxComments int) []Com
 	maxIDType := 0
 	
 	client := &http.Client{
-		Timeout: 30 * time.Second,
+		Transport: util.NewPluginTransport("weibo", nil),
+		Timeout:   30 * time.Second,
 	}
 	
 	for len(comments) < maxComments {
@@ -1425,7 +1350,8 @@ func extractURLsFromComment(htmlText string) []string {
 // fetchPageAndExtractLinks 抓取页面内容并提取网盘链接
 func fetchPageAndExtractLinks(pageURL string, datetime time.Time) []model.Link {
 	client := &http.Client{
-		Timeout: 15 * time.Second,
+		Transport: util.NewPluginTransport("weibo", nil),
+		Timeout:   15 * time.Second,
 	}
 	
 	req, err := http.NewRequest("GET", pageURL, nil)
@@ -1675,7 +1601,8 @@ func (p *WeiboPlugin) checkQRLoginStatus(qrsig string) (*LoginResult, error) {
 	fmt.Printf("[Weibo DEBUG] checkURL: %s\n", checkURL)
 	
 	client := &http.Client{
-		Timeout: 15 * time.Second,
+		Transport: util.NewPluginTransport("weibo", nil),
+		Timeout:   15 * time.Second,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
@@ -1780,7 +1707,7 @@ func (p *WeiboPlugin) generateQRCodeWithSig() ([]byte, string, error) {
 	timestamp := time.Now().UnixMilli()
 	infoURL := fmt.Sprintf("https://passport.weibo.com/sso/v2/qrcode/image?entry=miniblog&size=180&callback=STK_%d", timestamp)
 	
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := &http.Client{Transport: util.NewPluginTransport("weibo", nil), Timeout: 15 * time.Second}
 	
 	req, err := http.NewRequest("GET", infoURL, nil)
 	if err != nil {
@@ -1859,8 +1786,9 @@ func (p *WeiboPlugin) initCookieFromAlt(alt string) (string, error) {
 	}
 	
 	client := &http.Client{
-		Timeout: 30 * time.Second,
-		Jar:     jar,
+		Transport: util.NewPluginTransport("weibo", nil),
+		Timeout:   30 * time.Second,
+		Jar:       jar,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			// 允许重定向，但保留Cookie
 			return nil
@@ -2010,54 +1938,3 @@ func respondError(c *gin.Context, message string) {
 		"data":    nil,
 	})
 }
-
-func (p *WeiboPlugin) startCleanupTask() {
-	ticker := time.NewTicker(24 * time.Hour)
-	for range ticker.C {
-		deleted := p.cleanupExpiredUsers()
-		marked := p.markInactiveUsers()
-
-		if deleted > 0 || marked > 0 {
-			fmt.Printf("[Weibo] 清理任务完成: 删除 %d 个过期用户, 标记 %d 个不活跃用户\n", deleted, marked)
-		}
-	}
-}
-
-func (p *WeiboPlugin) cleanupExpiredUsers() int {
-	deletedCount := 0
-	now := time.Now()
-	expireThreshold := now.AddDate(0, 0, -30)
-
-	p.users.Range(func(key, value interface{}) bool {
-		user := value.(*User)
-		if user.Status == "expired" && user.LastAccessAt.Before(expireThreshold) {
-			if err := p.deleteUser(user.Hash); err == nil {
-				deletedCount++
-			}
-		}
-		return true
-	})
-
-	return deletedCount
-}
-
-func (p *WeiboPlugin) markInactiveUsers() int {
-	markedCount := 0
-	now := time.Now()
-	inactiveThreshold := now.AddDate(0, 0, -90)
-
-	p.users.Range(func(key, value interface{}) bool {
-		user := value.(*User)
-		if user.LastAccessAt.Before(inactiveThreshold) && user.Status != "expired" {
-			user.Status = "expired"
-			user.Cookie = ""
-
-			if err := p.saveUser(user); err == nil {
-				markedCount++
-			}
-		}
-		return true
-	})
-
-	return markedCount
-}
@@ -15,6 +15,7 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 const (
@@ -172,6 +173,7 @@ func (p *Xb6vPlugin) searchImpl(client *http.Client, keyword string, ext map[str
 	
 	// 创建不自动重定向的客户端
 	noRedirectClient := &http.Client{
+		Transport: util.NewPluginTransport("xb6v", nil),
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
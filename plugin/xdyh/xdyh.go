@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"pansou/util/json"
 	"strings"
 	"sync"
@@ -76,7 +77,7 @@ func createOptimizedHTTPClient() *http.Client {
 		DisableKeepAlives:   false,
 		ForceAttemptHTTP2:   true,
 	}
-	return &http.Client{Transport: transport, Timeout: DefaultTimeout}
+	return &http.Client{Transport: util.NewPluginTransport(pluginName, transport), Timeout: DefaultTimeout}
 }
 
 // NewXdyhPlugin 创建新的XDYH异步插件
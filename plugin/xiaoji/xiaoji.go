@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"regexp"
 	"strings"
 	"sync"
@@ -88,7 +89,7 @@ func createOptimizedHTTPClient() *http.Client {
 		DisableKeepAlives:   false,
 		ForceAttemptHTTP2:   true,
 	}
-	return &http.Client{Transport: transport, Timeout: DefaultTimeout}
+	return &http.Client{Transport: util.NewPluginTransport(pluginName, transport), Timeout: DefaultTimeout}
 }
 
 // NewXiaojiPlugin 创建新的小鸡影视异步插件
@@ -151,7 +152,7 @@ func (p *XiaojiAsyncPlugin) searchImpl(client *http.Client, keyword string, ext
 	}
 	
 	// 8. 解析搜索结果
-	results := p.parseSearchResults(doc, keyword)
+	results := p.parseSearchResults(doc, keyword, util.BudgetedClient(p.optimizedClient, ext))
 	
 	// 9. 关键词过滤
 	return plugin.FilterResultsByKeyword(results, keyword), nil
@@ -198,12 +199,12 @@ func (p *XiaojiAsyncPlugin) doRequestWithRetry(req *http.Request, client *http.C
 }
 
 // parseSearchResults 解析搜索结果
-func (p *XiaojiAsyncPlugin) parseSearchResults(doc *goquery.Document, keyword string) []model.SearchResult {
+func (p *XiaojiAsyncPlugin) parseSearchResults(doc *goquery.Document, keyword string, detailClient *http.Client) []model.SearchResult {
 	results := make([]model.SearchResult, 0)
 	
 	// 查找所有搜索结果项
 	doc.Find("article.poster-item").Each(func(i int, s *goquery.Selection) {
-		result := p.parseSearchResultItem(s, keyword)
+		result := p.parseSearchResultItem(s, keyword, detailClient)
 		if result != nil {
 			results = append(results, *result)
 		}
@@ -213,7 +214,7 @@ func (p *XiaojiAsyncPlugin) parseSearchResults(doc *goquery.Document, keyword st
 }
 
 // parseSearchResultItem 解析单个搜索结果项
-func (p *XiaojiAsyncPlugin) parseSearchResultItem(s *goquery.Selection, keyword string) *model.SearchResult {
+func (p *XiaojiAsyncPlugin) parseSearchResultItem(s *goquery.Selection, keyword string, detailClient *http.Client) *model.SearchResult {
 	// 1. 提取详情页链接
 	detailLink, exists := s.Find(".poster-link").Attr("href")
 	if !exists || detailLink == "" {
@@ -266,7 +267,7 @@ func (p *XiaojiAsyncPlugin) parseSearchResultItem(s *goquery.Selection, keyword
 	}
 	
 	// 10. 获取详情页的下载链接
-	links := p.fetchDetailPageLinks(detailLink)
+	links := p.fetchDetailPageLinks(detailClient, detailLink)
 	
 	// 11. 创建搜索结果
 	result := &model.SearchResult{
@@ -288,7 +289,7 @@ func (p *XiaojiAsyncPlugin) parseSearchResultItem(s *goquery.Selection, keyword
 }
 
 // fetchDetailPageLinks 获取详情页的下载链接
-func (p *XiaojiAsyncPlugin) fetchDetailPageLinks(detailURL string) []model.Link {
+func (p *XiaojiAsyncPlugin) fetchDetailPageLinks(client *http.Client, detailURL string) []model.Link {
 	// 1. 检查缓存
 	if cached, ok := detailCache.Load(detailURL); ok {
 		if links, ok := cached.([]model.Link); ok {
@@ -309,7 +310,7 @@ func (p *XiaojiAsyncPlugin) fetchDetailPageLinks(detailURL string) []model.Link
 	p.setRequestHeaders(req)
 	
 	// 4. 发送请求
-	resp, err := p.doRequestWithRetry(req, p.optimizedClient)
+	resp, err := p.doRequestWithRetry(req, client)
 	if err != nil {
 		return nil
 	}
@@ -15,6 +15,7 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 const (
@@ -94,9 +95,9 @@ func (p *XiaozhangPlugin) doRequest(client *http.Client, url string, referer str
 	// 创建临时客户端，控制重定向行为
 	tempClient := &http.Client{
 		Timeout: client.Timeout,
-		Transport: &http.Transport{
+		Transport: util.NewPluginTransport("xiaozhang", &http.Transport{
 			DisableCompression: true, // 禁用自动gzip解压，我们手动处理
-		},
+		}),
 	}
 	
 	if !followRedirect {
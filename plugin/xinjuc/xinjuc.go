@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"regexp"
 	"strings"
 	"sync"
@@ -89,7 +90,7 @@ func createOptimizedHTTPClient() *http.Client {
 		IdleConnTimeout:     IdleConnTimeout,
 		DisableKeepAlives:   false,
 	}
-	return &http.Client{Transport: transport, Timeout: DefaultTimeout}
+	return &http.Client{Transport: util.NewPluginTransport("xinjuc", transport), Timeout: DefaultTimeout}
 }
 
 // NewXinjucPlugin 创建新的新剧坊插件
@@ -16,6 +16,7 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"pansou/util/json"
 )
 
@@ -88,7 +89,8 @@ func (p *XysPlugin) Description() string {
 
 // Search 搜索接口
 func (p *XysPlugin) Search(keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
-	return p.searchImpl(&http.Client{Timeout: 30 * time.Second}, keyword, ext)
+	client := &http.Client{Transport: util.NewPluginTransport(PluginName, nil), Timeout: 30 * time.Second}
+	return p.searchImpl(util.BudgetedClient(client, ext), keyword, ext)
 }
 
 // searchImpl 搜索实现
@@ -16,6 +16,7 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 )
 
 const (
@@ -238,7 +239,7 @@ func (p *YuhuagePlugin) fetchDetailLinks(detailURL string) []model.Link {
 		}
 	}
 
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := &http.Client{Transport: util.NewPluginTransport("yuhuage", nil), Timeout: 15 * time.Second}
 	
 	for retry := 0; retry <= MaxRetryCount; retry++ {
 		req, err := http.NewRequest("GET", detailURL, nil)
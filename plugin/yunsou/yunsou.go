@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"pansou/util/json"
 	"regexp"
 	"strings"
@@ -91,7 +92,7 @@ func createOptimizedHTTPClient() *http.Client {
 		DisableKeepAlives:   false,
 	}
 	return &http.Client{
-		Transport: transport,
+		Transport: util.NewPluginTransport(pluginName, transport),
 		Timeout:   defaultTimeout,
 	}
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/util"
 	"pansou/util/json"
 )
 
@@ -34,11 +35,11 @@ func init() {
 		BaseAsyncPlugin: plugin.NewBaseAsyncPlugin("zxzj", 3),
 		client: &http.Client{
 			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
+			Transport: util.NewPluginTransport("zxzj", &http.Transport{
 				MaxIdleConns:        100,
 				MaxIdleConnsPerHost: 10,
 				IdleConnTimeout:     90 * time.Second,
-			},
+			}),
 		},
 	}
 	plugin.RegisterGlobalPlugin(p)
@@ -58,8 +59,9 @@ func (p *ZXZJPlugin) SearchWithResult(keyword string, ext map[string]interface{}
 
 func (p *ZXZJPlugin) searchImpl(client *http.Client, keyword string, ext map[string]interface{}) ([]model.SearchResult, error) {
 	searchURL := fmt.Sprintf("%s%s?wd=%s&submit=", baseURL, searchPath, url.QueryEscape(keyword))
+	client = util.BudgetedClient(p.client, ext)
 	
-	items, err := p.fetchSearchResults(searchURL)
+	items, err := p.fetchSearchResults(client, searchURL)
 	if err != nil {
 		return nil, err
 	}
@@ -72,7 +74,7 @@ func (p *ZXZJPlugin) searchImpl(client *http.Client, keyword string, ext map[str
 		items = items[:maxResults]
 	}
 	
-	results := p.processDetailPages(items)
+	results := p.processDetailPages(client, items)
 	
 	return plugin.FilterResultsByKeyword(results, keyword), nil
 }
@@ -83,7 +85,7 @@ type searchItem struct {
 	DetailURL string
 }
 
-func (p *ZXZJPlugin) fetchSearchResults(searchURL string) ([]searchItem, error) {
+func (p *ZXZJPlugin) fetchSearchResults(client *http.Client, searchURL string) ([]searchItem, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	
@@ -97,7 +99,7 @@ func (p *ZXZJPlugin) fetchSearchResults(searchURL string) ([]searchItem, error)
 	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
 	req.Header.Set("Referer", baseURL)
 	
-	resp, err := p.doRequestWithRetry(req, p.client)
+	resp, err := p.doRequestWithRetry(req, client)
 	if err != nil {
 		return nil, fmt.Errorf("[%s] 搜索请求失败: %w", p.Name(), err)
 	}
@@ -141,7 +143,7 @@ func (p *ZXZJPlugin) fetchSearchResults(searchURL string) ([]searchItem, error)
 	return items, nil
 }
 
-func (p *ZXZJPlugin) processDetailPages(items []searchItem) []model.SearchResult {
+func (p *ZXZJPlugin) processDetailPages(client *http.Client, items []searchItem) []model.SearchResult {
 	var results []model.SearchResult
 	var mu sync.Mutex
 	var wg sync.WaitGroup
@@ -154,7 +156,7 @@ func (p *ZXZJPlugin) processDetailPages(items []searchItem) []model.SearchResult
 			sem <- struct{}{}
 			defer func() { <-sem }()
 			
-			result := p.processDetailPage(it)
+			result := p.processDetailPage(client, it)
 			if result != nil {
 				mu.Lock()
 				results = append(results, *result)
@@ -167,7 +169,7 @@ func (p *ZXZJPlugin) processDetailPages(items []searchItem) []model.SearchResult
 	return results
 }
 
-func (p *ZXZJPlugin) processDetailPage(item searchItem) *model.SearchResult {
+func (p *ZXZJPlugin) processDetailPage(client *http.Client, item searchItem) *model.SearchResult {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	
@@ -178,7 +180,7 @@ func (p *ZXZJPlugin) processDetailPage(item searchItem) *model.SearchResult {
 	
 	p.setHeaders(req, baseURL)
 	
-	resp, err := p.doRequestWithRetry(req, p.client)
+	resp, err := p.doRequestWithRetry(req, client)
 	if err != nil {
 		return nil
 	}
@@ -223,7 +225,7 @@ func (p *ZXZJPlugin) processDetailPage(item searchItem) *model.SearchResult {
 		return nil
 	}
 	
-	links := p.fetchPanLinks(playLinks)
+	links := p.fetchPanLinks(client, playLinks)
 	if len(links) == 0 {
 		return nil
 	}
@@ -301,7 +303,7 @@ func (p *ZXZJPlugin) detectPanType(title string) string {
 	return ""
 }
 
-func (p *ZXZJPlugin) fetchPanLinks(playLinks []playLink) []model.Link {
+func (p *ZXZJPlugin) fetchPanLinks(client *http.Client, playLinks []playLink) []model.Link {
 	var links []model.Link
 	var mu sync.Mutex
 	var wg sync.WaitGroup
@@ -314,7 +316,7 @@ func (p *ZXZJPlugin) fetchPanLinks(playLinks []playLink) []model.Link {
 			sem <- struct{}{}
 			defer func() { <-sem }()
 			
-			link := p.fetchSinglePanLink(playLink)
+			link := p.fetchSinglePanLink(client, playLink)
 			if link != nil {
 				mu.Lock()
 				links = append(links, *link)
@@ -327,7 +329,7 @@ func (p *ZXZJPlugin) fetchPanLinks(playLinks []playLink) []model.Link {
 	return links
 }
 
-func (p *ZXZJPlugin) fetchSinglePanLink(pl playLink) *model.Link {
+func (p *ZXZJPlugin) fetchSinglePanLink(client *http.Client, pl playLink) *model.Link {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	
@@ -338,7 +340,7 @@ func (p *ZXZJPlugin) fetchSinglePanLink(pl playLink) *model.Link {
 	
 	p.setHeaders(req, baseURL)
 	
-	resp, err := p.doRequestWithRetry(req, p.client)
+	resp, err := p.doRequestWithRetry(req, client)
 	if err != nil {
 		return nil
 	}
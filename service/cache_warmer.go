@@ -0,0 +1,309 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"pansou/config"
+	"pansou/plugin"
+	"pansou/util/cache"
+)
+
+// 缓存项已用时间超过TTL的该比例时进行预热刷新
+const cacheWarmRefreshRatio = 0.7
+
+// CacheWarmRound 一轮预热的执行结果
+type CacheWarmRound struct {
+	StartedAt time.Time `json:"started_at"`
+	Duration  string    `json:"duration"`
+	Keywords  int       `json:"keywords"` // 检查的关键词数
+	Plugins   int       `json:"plugins"`  // 提交刷新的插件缓存项数
+	Channels  int       `json:"channels"` // 提交刷新的频道缓存项数
+	Paused    bool      `json:"paused"`   // 是否因后台工作池繁忙而提前结束
+	Skipped   bool      `json:"skipped"`  // 是否因上一轮仍在执行而跳过
+}
+
+// CacheWarmStatus 缓存预热状态
+type CacheWarmStatus struct {
+	Enabled   bool                       `json:"enabled"`
+	Interval  string                     `json:"interval"`
+	Top       int                        `json:"top"`
+	MaxLoad   int                        `json:"max_load"`
+	Keywords  []string                   `json:"keywords"` // 管理员指定的关键词
+	Hot       []plugin.KeywordPopularity `json:"hot"`      // 按访问次数排名的热门关键词
+	Running   bool                       `json:"running"`
+	LastRound *CacheWarmRound            `json:"last_round,omitempty"`
+}
+
+// CacheWarmer 缓存预热调度器：定期在后台工作池空闲时刷新热门关键词即将过期的缓存
+type CacheWarmer struct {
+	service   *SearchService
+	mu        sync.Mutex
+	keywords  []string
+	lastRound *CacheWarmRound
+	running   int32
+
+	// pending 已提交到后台工作池、尚未完成的刷新任务（按缓存键去重）
+	pending sync.Map
+	// emptyUntil 预热时返回空结果的插件缓存键及其标记的过期时间，
+	// 空结果不会写入缓存，标记期间不再重复预热
+	emptyUntil map[string]time.Time
+}
+
+var (
+	globalCacheWarmer     *CacheWarmer
+	globalCacheWarmerOnce sync.Once
+)
+
+// startCacheWarmer 创建全局缓存预热调度器，启用时启动定期预热
+func startCacheWarmer(s *SearchService) {
+	globalCacheWarmerOnce.Do(func() {
+		globalCacheWarmer = &CacheWarmer{
+			service:    s,
+			keywords:   config.AppConfig.CacheWarmKeywords,
+			emptyUntil: make(map[string]time.Time),
+		}
+		if config.AppConfig.CacheWarmEnabled {
+			go globalCacheWarmer.loop()
+		}
+	})
+}
+
+// GetCacheWarmer 获取全局缓存预热调度器（搜索服务创建前为nil）
+func GetCacheWarmer() *CacheWarmer {
+	return globalCacheWarmer
+}
+
+// loop 定期执行预热，每轮结束后衰减访问计数
+func (w *CacheWarmer) loop() {
+	ticker := time.NewTicker(config.AppConfig.CacheWarmInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		round := w.RunOnce()
+		if !round.Skipped && (round.Plugins > 0 || round.Channels > 0) {
+			fmt.Printf("[缓存预热] 检查关键词: %d, 刷新插件: %d, 刷新频道: %d, 耗时: %s\n",
+				round.Keywords, round.Plugins, round.Channels, round.Duration)
+		}
+		plugin.DecayKeywordAccess()
+	}
+}
+
+// SetKeywords 设置管理员指定的预热关键词
+func (w *CacheWarmer) SetKeywords(keywords []string) []string {
+	cleaned := make([]string, 0, len(keywords))
+	seen := make(map[string]bool)
+	for _, keyword := range keywords {
+		keyword = strings.TrimSpace(keyword)
+		normalized := strings.ToLower(keyword)
+		if keyword == "" || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		cleaned = append(cleaned, keyword)
+	}
+
+	w.mu.Lock()
+	w.keywords = cleaned
+	w.mu.Unlock()
+	return cleaned
+}
+
+// Status 获取预热状态
+func (w *CacheWarmer) Status() CacheWarmStatus {
+	w.mu.Lock()
+	keywords := append([]string{}, w.keywords...)
+	lastRound := w.lastRound
+	w.mu.Unlock()
+
+	return CacheWarmStatus{
+		Enabled:   config.AppConfig.CacheWarmEnabled,
+		Interval:  config.AppConfig.CacheWarmInterval.String(),
+		Top:       config.AppConfig.CacheWarmTop,
+		MaxLoad:   config.AppConfig.CacheWarmMaxLoad,
+		Keywords:  keywords,
+		Hot:       plugin.HotKeywords(config.AppConfig.CacheWarmTop),
+		Running:   w.Running(),
+		LastRound: lastRound,
+	}
+}
+
+// Running 是否正在执行预热
+func (w *CacheWarmer) Running() bool {
+	return atomic.LoadInt32(&w.running) == 1
+}
+
+// targets 本轮需要预热的关键词：管理员指定的关键词（全部来源）在前，其后为热门关键词
+func (w *CacheWarmer) targets() []plugin.KeywordPopularity {
+	w.mu.Lock()
+	keywords := append([]string{}, w.keywords...)
+	w.mu.Unlock()
+
+	targets := make([]plugin.KeywordPopularity, 0, len(keywords)+config.AppConfig.CacheWarmTop)
+	seen := make(map[string]bool)
+	for _, keyword := range keywords {
+		seen[strings.ToLower(keyword)] = true
+		targets = append(targets, plugin.KeywordPopularity{Keyword: keyword, AllPlugins: true, TG: true})
+	}
+	if config.AppConfig.CacheWarmTop > 0 {
+		for _, item := range plugin.HotKeywords(config.AppConfig.CacheWarmTop) {
+			if !seen[strings.ToLower(item.Keyword)] {
+				targets = append(targets, item)
+			}
+		}
+	}
+	return targets
+}
+
+// headroom 后台工作池中可供预热使用的空闲槽数，为0时暂停预热
+func (w *CacheWarmer) headroom() int {
+	used, capacity := plugin.BackgroundWorkerLoad()
+	if capacity == 0 {
+		return 1
+	}
+	limit := capacity * config.AppConfig.CacheWarmMaxLoad / 100
+	if limit < 1 {
+		limit = 1
+	}
+	if used >= limit {
+		return 0
+	}
+	return limit - used
+}
+
+// needsRefresh 缓存项缺失或即将过期，且没有正在执行的刷新任务和有效的空结果标记时需要刷新
+func (w *CacheWarmer) needsRefresh(key string, refreshAge time.Duration) bool {
+	if _, running := w.pending.Load(key); running {
+		return false
+	}
+	w.mu.Lock()
+	until, empty := w.emptyUntil[key]
+	w.mu.Unlock()
+	if empty && time.Now().Before(until) {
+		return false
+	}
+	entry, hit := enhancedTwoLevelCache.GetSourceEntry(key)
+	return !hit || !entry.IsFinal || time.Since(entry.UpdatedAt) >= refreshAge
+}
+
+// markEmpty 记录插件预热返回了空结果，在refreshAge内不再预热该缓存项
+func (w *CacheWarmer) markEmpty(key string, refreshAge time.Duration) {
+	w.mu.Lock()
+	w.emptyUntil[key] = time.Now().Add(refreshAge)
+	w.mu.Unlock()
+}
+
+// pruneEmptyMarks 删除已过期的空结果标记
+func (w *CacheWarmer) pruneEmptyMarks() {
+	now := time.Now()
+	w.mu.Lock()
+	for key, until := range w.emptyUntil {
+		if now.After(until) {
+			delete(w.emptyUntil, key)
+		}
+	}
+	w.mu.Unlock()
+}
+
+// submit 将刷新任务提交到后台工作池，工作池占用超过预热阈值或已满时返回false
+func (w *CacheWarmer) submit(key string, job func()) bool {
+	if w.headroom() == 0 {
+		return false
+	}
+	if _, running := w.pending.LoadOrStore(key, struct{}{}); running {
+		return true
+	}
+	if !plugin.SubmitBackgroundTask(func() {
+		defer w.pending.Delete(key)
+		job()
+	}) {
+		w.pending.Delete(key)
+		return false
+	}
+	return true
+}
+
+// RunOnce 立即执行一轮预热：将缺失或即将过期的缓存项逐个提交到后台工作池刷新，
+// 工作池繁忙时提前结束（返回时刷新任务可能仍在后台执行）
+func (w *CacheWarmer) RunOnce() CacheWarmRound {
+	round := CacheWarmRound{StartedAt: time.Now()}
+	if !atomic.CompareAndSwapInt32(&w.running, 0, 1) {
+		round.Skipped = true
+		return round
+	}
+	defer atomic.StoreInt32(&w.running, 0)
+
+	if enhancedTwoLevelCache == nil || !config.AppConfig.CacheEnabled {
+		return round
+	}
+
+	ttl := time.Duration(config.AppConfig.CacheTTLMinutes) * time.Minute
+	refreshAge := time.Duration(float64(ttl) * cacheWarmRefreshRatio)
+	w.pruneEmptyMarks()
+
+targets:
+	for _, target := range w.targets() {
+		if w.headroom() == 0 {
+			round.Paused = true
+			break
+		}
+		round.Keywords++
+		keyword := target.Keyword
+
+		// 刷新TG频道，每个频道一个任务
+		if target.TG {
+			for _, channel := range config.AppConfig.DefaultChannels {
+				key := cache.GenerateTGChannelCacheKey(keyword, channel)
+				if !w.needsRefresh(key, refreshAge) {
+					continue
+				}
+				channel := channel
+				if !w.submit(key, func() {
+					w.service.searchTG(keyword, []string{channel}, true, false, nil)
+				}) {
+					round.Paused = true
+					break targets
+				}
+				round.Channels++
+			}
+		}
+
+		// 刷新插件：先将插件内存缓存标记为过期，每个插件一个任务
+		if !config.AppConfig.AsyncPluginEnabled || w.service.pluginManager == nil {
+			continue
+		}
+		wanted := make(map[string]bool)
+		for _, name := range target.Plugins {
+			wanted[strings.ToLower(name)] = true
+		}
+		for _, p := range w.service.pluginManager.GetPlugins() {
+			name := p.Name()
+			if !target.AllPlugins && !wanted[strings.ToLower(name)] {
+				continue
+			}
+			key := cache.GeneratePluginSourceCacheKey(keyword, name)
+			if !w.needsRefresh(key, refreshAge) {
+				continue
+			}
+			if !w.submit(key, func() {
+				plugin.MarkAPICacheStale(name, keyword)
+				results, err := w.service.searchPlugins(keyword, []string{name}, true, 1, nil)
+				if err == nil && len(results) == 0 {
+					w.markEmpty(key, refreshAge)
+				}
+			}) {
+				round.Paused = true
+				break targets
+			}
+			round.Plugins++
+		}
+	}
+
+	round.Duration = time.Since(round.StartedAt).Round(time.Millisecond).String()
+	w.mu.Lock()
+	w.lastRound = &round
+	w.mu.Unlock()
+	return round
+}
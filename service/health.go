@@ -0,0 +1,46 @@
+package service
+
+import (
+	"pansou/config"
+	"pansou/plugin/external"
+	"pansou/plugin/script"
+)
+
+// HealthStatus 服务健康状态及可用的频道、插件，供健康检查接口和MCP工具使用
+func HealthStatus(s *SearchService) map[string]interface{} {
+	pluginCount := 0
+	pluginNames := []string{}
+	pluginsEnabled := config.AppConfig.AsyncPluginEnabled
+
+	if pluginsEnabled && s != nil && s.GetPluginManager() != nil {
+		plugins := s.GetPluginManager().GetPlugins()
+		pluginCount = len(plugins)
+		for _, p := range plugins {
+			pluginNames = append(pluginNames, p.Name())
+		}
+	}
+
+	channels := config.AppConfig.DefaultChannels
+	channelsCount := len(channels)
+
+	status := map[string]interface{}{
+		"status":          "ok",
+		"auth_enabled":    config.AppConfig.AuthEnabled,
+		"plugins_enabled": pluginsEnabled,
+		"channels":        channels,
+		"channels_count":  channelsCount,
+	}
+
+	if pluginsEnabled {
+		status["plugin_count"] = pluginCount
+		status["plugins"] = pluginNames
+		if externalPlugins := external.Statuses(); len(externalPlugins) > 0 {
+			status["external_plugins"] = externalPlugins
+		}
+		if scriptPlugins := script.Statuses(); len(scriptPlugins) > 0 {
+			status["script_plugins"] = scriptPlugins
+			status["script_abandoned_runs"] = script.AbandonedRuns()
+		}
+	}
+	return status
+}
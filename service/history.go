@@ -0,0 +1,300 @@
+package service
+
+import (
+	"bufio"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pansou/config"
+	"pansou/util/json"
+)
+
+// 搜索历史文件名（位于缓存目录下，每行一条JSON记录）
+const historyFileName = "search_history.jsonl"
+
+// HistoryEntry 一条搜索记录（字段名尽量短，减少磁盘占用）
+type HistoryEntry struct {
+	User       string   `json:"u,omitempty"`
+	Keyword    string   `json:"k"`
+	SourceType string   `json:"s,omitempty"`
+	Plugins    []string `json:"p,omitempty"`
+	CloudTypes []string `json:"c,omitempty"`
+	Total      int      `json:"n"`
+	Time       int64    `json:"t"`
+}
+
+// HistoryItem 对外返回的历史记录
+type HistoryItem struct {
+	Keyword    string    `json:"keyword"`
+	SourceType string    `json:"src,omitempty"`
+	Plugins    []string  `json:"plugins,omitempty"`
+	CloudTypes []string  `json:"cloud_types,omitempty"`
+	Total      int       `json:"total"`
+	SearchedAt time.Time `json:"searched_at"`
+}
+
+// TrendingKeyword 热搜关键词
+type TrendingKeyword struct {
+	Keyword string  `json:"keyword"`
+	Count   int     `json:"count"`
+	Score   float64 `json:"score"`
+}
+
+// HistoryStore 搜索历史存储：内存保存全部记录，磁盘按行追加，定期压缩重写
+type HistoryStore struct {
+	mu       sync.Mutex
+	path     string
+	entries  []HistoryEntry // 按时间先后排列
+	file     *os.File
+	appended int // 上次压缩后追加的行数
+}
+
+var (
+	globalHistoryStore     *HistoryStore
+	globalHistoryStoreOnce sync.Once
+)
+
+// GetHistoryStore 获取全局搜索历史存储（首次调用时加载并启动定期清理）
+func GetHistoryStore() *HistoryStore {
+	globalHistoryStoreOnce.Do(func() {
+		globalHistoryStore = &HistoryStore{
+			path: filepath.Join(config.AppConfig.CachePath, historyFileName),
+		}
+		globalHistoryStore.load()
+
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+			for range ticker.C {
+				globalHistoryStore.mu.Lock()
+				globalHistoryStore.compactLocked()
+				globalHistoryStore.mu.Unlock()
+			}
+		}()
+	})
+	return globalHistoryStore
+}
+
+// NormalizeKeyword 关键词规范化：去除首尾空白、合并连续空白并转为小写
+func NormalizeKeyword(keyword string) string {
+	return strings.ToLower(strings.Join(strings.Fields(keyword), " "))
+}
+
+// load 从磁盘加载历史记录并压缩
+func (h *HistoryStore) load() {
+	if file, err := os.Open(h.path); err == nil {
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var entry HistoryEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil && entry.Keyword != "" {
+				h.entries = append(h.entries, entry)
+			}
+		}
+		file.Close()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.compactLocked()
+}
+
+// Record 记录一次搜索
+func (h *HistoryStore) Record(user, keyword, sourceType string, plugins, cloudTypes []string, total int) {
+	if !config.AppConfig.HistoryEnabled {
+		return
+	}
+	keyword = NormalizeKeyword(keyword)
+	if keyword == "" {
+		return
+	}
+
+	entry := HistoryEntry{
+		User:       user,
+		Keyword:    keyword,
+		SourceType: sourceType,
+		Plugins:    plugins,
+		CloudTypes: cloudTypes,
+		Total:      total,
+		Time:       time.Now().Unix(),
+	}
+	// 隐私模式只保留关键词和时间
+	if config.AppConfig.HistoryPrivacyMode {
+		entry = HistoryEntry{Keyword: keyword, Total: total, Time: entry.Time}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.entries = append(h.entries, entry)
+	if h.file != nil {
+		if data, err := json.Marshal(entry); err == nil {
+			h.file.Write(append(data, '\n'))
+			h.appended++
+		}
+	}
+
+	// 内存或文件膨胀到一定程度时压缩
+	if len(h.entries) > config.AppConfig.HistoryMaxEntries+config.AppConfig.HistoryMaxEntries/10 ||
+		h.appended > config.AppConfig.HistoryMaxEntries {
+		h.compactLocked()
+	}
+}
+
+// List 获取用户最近的搜索历史（新的在前），未启用认证时user为空，即全局历史
+func (h *HistoryStore) List(user string, limit int) []HistoryItem {
+	items := make([]HistoryItem, 0)
+	if config.AppConfig.HistoryPrivacyMode {
+		return items
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := len(h.entries) - 1; i >= 0 && len(items) < limit; i-- {
+		entry := h.entries[i]
+		if entry.User != user {
+			continue
+		}
+		items = append(items, HistoryItem{
+			Keyword:    entry.Keyword,
+			SourceType: entry.SourceType,
+			Plugins:    entry.Plugins,
+			CloudTypes: entry.CloudTypes,
+			Total:      entry.Total,
+			SearchedAt: time.Unix(entry.Time, 0),
+		})
+	}
+	return items
+}
+
+// Trending 统计时间窗口内的热搜关键词，按时间衰减后的得分排序
+// 衰减半衰期为窗口的四分之一，越近的搜索权重越高
+func (h *HistoryStore) Trending(window time.Duration, limit int) []TrendingKeyword {
+	now := time.Now()
+	since := now.Add(-window).Unix()
+	halfLife := (window / 4).Seconds()
+
+	h.mu.Lock()
+	counts := make(map[string]*TrendingKeyword)
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		entry := h.entries[i]
+		if entry.Time < since {
+			break
+		}
+		item, ok := counts[entry.Keyword]
+		if !ok {
+			item = &TrendingKeyword{Keyword: entry.Keyword}
+			counts[entry.Keyword] = item
+		}
+		age := float64(now.Unix() - entry.Time)
+		item.Count++
+		item.Score += math.Pow(0.5, age/halfLife)
+	}
+	h.mu.Unlock()
+
+	trending := make([]TrendingKeyword, 0, len(counts))
+	for _, item := range counts {
+		item.Score = math.Round(item.Score*1000) / 1000
+		trending = append(trending, *item)
+	}
+	sort.Slice(trending, func(i, j int) bool {
+		if trending[i].Score != trending[j].Score {
+			return trending[i].Score > trending[j].Score
+		}
+		return trending[i].Keyword < trending[j].Keyword
+	})
+	if len(trending) > limit {
+		trending = trending[:limit]
+	}
+	return trending
+}
+
+// Delete 删除用户的搜索历史，keyword为空时删除全部，返回删除条数
+func (h *HistoryStore) Delete(user, keyword string) int {
+	keyword = NormalizeKeyword(keyword)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	kept := h.entries[:0]
+	removed := 0
+	for _, entry := range h.entries {
+		if entry.User == user && (keyword == "" || entry.Keyword == keyword) {
+			removed++
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	h.entries = kept
+	if removed > 0 {
+		h.compactLocked()
+	}
+	return removed
+}
+
+// compactLocked 按保留时长和条数上限清理记录，并重写磁盘文件（调用方需持有锁）
+func (h *HistoryStore) compactLocked() {
+	cutoff := time.Now().Add(-config.AppConfig.HistoryRetention).Unix()
+	perUserLimit := config.AppConfig.HistoryPerUserLimit
+
+	// 从新到旧遍历，超过用户条数上限的旧记录丢弃
+	userCounts := make(map[string]int)
+	kept := make([]HistoryEntry, 0, len(h.entries))
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		entry := h.entries[i]
+		if entry.Time < cutoff {
+			break
+		}
+		// 全局记录（未认证）不受单用户上限限制
+		if entry.User != "" {
+			if userCounts[entry.User] >= perUserLimit {
+				continue
+			}
+			userCounts[entry.User]++
+		}
+		kept = append(kept, entry)
+		if len(kept) >= config.AppConfig.HistoryMaxEntries {
+			break
+		}
+	}
+	for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+		kept[i], kept[j] = kept[j], kept[i]
+	}
+	h.entries = kept
+
+	if h.file != nil {
+		h.file.Close()
+		h.file = nil
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return
+	}
+
+	// 写入临时文件后替换，避免中途崩溃丢失历史
+	tmpPath := h.path + ".tmp"
+	tmpFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return
+	}
+	writer := bufio.NewWriter(tmpFile)
+	for _, entry := range h.entries {
+		if data, err := json.Marshal(entry); err == nil {
+			writer.Write(data)
+			writer.WriteByte('\n')
+		}
+	}
+	writer.Flush()
+	tmpFile.Close()
+	if err := os.Rename(tmpPath, h.path); err != nil {
+		return
+	}
+
+	h.file, _ = os.OpenFile(h.path, os.O_APPEND|os.O_WRONLY, 0600)
+	h.appended = 0
+}
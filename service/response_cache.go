@@ -0,0 +1,273 @@
+package service
+
+import (
+	"container/list"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"pansou/config"
+	"pansou/model"
+	"pansou/util"
+	"pansou/util/cache"
+)
+
+// ResponseCacheEntry 缓存的完整搜索响应：已序列化的JSON及其gzip压缩结果
+// 命中时直接输出，跳过结果合并、排序、链接提取、过滤和序列化
+type ResponseCacheEntry struct {
+	JSON    []byte
+	Gzip    []byte // 未启用压缩或小于压缩阈值时为nil
+	Total   int
+	key     string
+	keyword string
+	version cache.SourceVersion
+	expiry  time.Time
+}
+
+// size 缓存项占用的字节数
+func (e *ResponseCacheEntry) size() int64 {
+	return int64(len(e.JSON) + len(e.Gzip))
+}
+
+// ResponseCacheStats 响应缓存统计
+type ResponseCacheStats struct {
+	Entries     int    `json:"entries"`
+	Bytes       int64  `json:"bytes"`
+	MaxBytes    int64  `json:"max_bytes"`
+	TTL         string `json:"ttl"`
+	Hits        int64  `json:"hits"`
+	Misses      int64  `json:"misses"`
+	Invalidated int64  `json:"invalidated"` // 来源缓存项变更或过期而失效的次数
+	Evictions   int64  `json:"evictions"`   // 超出容量而淘汰的次数
+}
+
+// ResponseCache 完整搜索响应的缓存，按规范化后的请求参数索引，按最近使用顺序淘汰
+// 缓存项记录生成时各来源的版本，任一来源缓存项变更后失效
+type ResponseCache struct {
+	mu       sync.Mutex
+	entries  map[string]*list.Element
+	lru      *list.List
+	bytes    int64
+	maxBytes int64
+	ttl      time.Duration
+
+	hits        int64
+	misses      int64
+	invalidated int64
+	evictions   int64
+}
+
+var (
+	globalResponseCache     *ResponseCache
+	globalResponseCacheOnce sync.Once
+)
+
+// GetResponseCache 获取全局响应缓存，未启用缓存时返回nil
+func GetResponseCache() *ResponseCache {
+	if !config.AppConfig.CacheEnabled || !config.AppConfig.ResponseCacheEnabled {
+		return nil
+	}
+	globalResponseCacheOnce.Do(func() {
+		globalResponseCache = NewResponseCache(config.AppConfig.ResponseCacheTTL, int64(config.AppConfig.ResponseCacheMaxMB)*1024*1024)
+	})
+	return globalResponseCache
+}
+
+// NewResponseCache 创建响应缓存
+// 有效期不能超过来源版本的保留时长，否则无法可靠判断来源是否变更
+func NewResponseCache(ttl time.Duration, maxBytes int64) *ResponseCache {
+	if ttl <= 0 || ttl >= cache.SourceVersionRetention {
+		ttl = cache.SourceVersionRetention / 2
+	}
+	return &ResponseCache{
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		maxBytes: maxBytes,
+		ttl:      ttl,
+	}
+}
+
+// ResponseStatusExtKey ext中本次请求的响应状态（以下划线开头，不参与请求合并键）
+const ResponseStatusExtKey = "_response_status"
+
+// ResponseStatus 记录本次请求的响应是否完整
+// 有来源搜索失败、超时或仅缓存模式下缺少来源时标记为不完整，不完整的响应不写入响应缓存
+type ResponseStatus struct {
+	incomplete int32
+}
+
+// Complete 响应是否包含所有来源的最终结果
+func (s *ResponseStatus) Complete() bool {
+	return atomic.LoadInt32(&s.incomplete) == 0
+}
+
+// WithResponseStatus 返回带有新响应状态的ext副本
+func WithResponseStatus(ext map[string]interface{}) (map[string]interface{}, *ResponseStatus) {
+	copied := make(map[string]interface{}, len(ext)+1)
+	for k, v := range ext {
+		copied[k] = v
+	}
+	status := &ResponseStatus{}
+	copied[ResponseStatusExtKey] = status
+	return copied, status
+}
+
+// markResponseIncomplete 将ext中的响应状态标记为不完整（没有响应状态时忽略）
+func markResponseIncomplete(ext map[string]interface{}) {
+	if status, ok := ext[ResponseStatusExtKey].(*ResponseStatus); ok {
+		atomic.StoreInt32(&status.incomplete, 1)
+	}
+}
+
+// ResponseCacheKey 由规范化后的完整请求生成响应缓存键
+// 频道、插件、网盘类型和过滤词与顺序无关；并发数等不影响结果的参数不参与生成键
+func ResponseCacheKey(req model.SearchRequest) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "kw=%s|src=%s|res=%s", strings.TrimSpace(req.Keyword), req.SourceType, req.ResultType)
+	fmt.Fprintf(&sb, "|channels=%s", sortedJoin(req.Channels, false))
+	fmt.Fprintf(&sb, "|plugins=%s", sortedJoin(req.Plugins, true))
+	fmt.Fprintf(&sb, "|cloud_types=%s", sortedJoin(req.CloudTypes, true))
+	if req.Filter != nil {
+		fmt.Fprintf(&sb, "|include=%s|exclude=%s", sortedJoin(req.Filter.Include, true), sortedJoin(req.Filter.Exclude, true))
+	}
+	if cacheOnly, _ := req.Ext[CacheOnlyExtKey].(bool); cacheOnly {
+		sb.WriteString("|cache_only")
+	}
+	if federated, _ := req.Ext[FederatedExtKey].(bool); federated {
+		sb.WriteString("|federated")
+	}
+
+	hash := md5.Sum([]byte(util.FlightKey(sb.String(), req.Ext)))
+	return "response:" + hex.EncodeToString(hash[:])
+}
+
+// sortedJoin 去除空值后排序拼接
+func sortedJoin(values []string, lower bool) string {
+	cleaned := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if lower {
+			v = strings.ToLower(v)
+		}
+		if v != "" {
+			cleaned = append(cleaned, v)
+		}
+	}
+	sort.Strings(cleaned)
+	return strings.Join(cleaned, ",")
+}
+
+// Version 获取关键词当前的来源版本，应在开始搜索前获取并在写入响应缓存时传入
+func (c *ResponseCache) Version(keyword string) cache.SourceVersion {
+	return cache.CurrentSourceVersion(keyword)
+}
+
+// Get 获取响应缓存项，过期或来源已变更的缓存项视为未命中并删除
+func (c *ResponseCache) Get(key string) (*ResponseCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		atomic.AddInt64(&c.misses, 1)
+		return nil, false
+	}
+	entry := elem.Value.(*ResponseCacheEntry)
+	if time.Now().After(entry.expiry) || entry.version != cache.CurrentSourceVersion(entry.keyword) {
+		c.removeElement(elem)
+		atomic.AddInt64(&c.invalidated, 1)
+		atomic.AddInt64(&c.misses, 1)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+	atomic.AddInt64(&c.hits, 1)
+	return entry, true
+}
+
+// Set 写入响应缓存项
+// version为开始搜索前获取的来源版本，搜索期间来源已变更时不写入；不完整的响应由调用方根据ResponseStatus跳过
+func (c *ResponseCache) Set(key, keyword string, version cache.SourceVersion, data []byte, total int) {
+	if version != cache.CurrentSourceVersion(keyword) {
+		return
+	}
+
+	entry := &ResponseCacheEntry{
+		JSON:    data,
+		Total:   total,
+		key:     key,
+		keyword: keyword,
+		version: version,
+		expiry:  time.Now().Add(c.ttl),
+	}
+	if config.AppConfig.EnableCompression && len(data) >= config.AppConfig.MinSizeToCompress {
+		if compressed, err := util.CompressData(data); err == nil {
+			entry.Gzip = compressed
+		}
+	}
+	if entry.size() > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.bytes += entry.size()
+
+	for c.bytes > c.maxBytes {
+		oldest := c.lru.Back()
+		if oldest == nil {
+			break
+		}
+		c.removeElement(oldest)
+		atomic.AddInt64(&c.evictions, 1)
+	}
+}
+
+// removeElement 删除缓存项（调用方需持有锁）
+func (c *ResponseCache) removeElement(elem *list.Element) {
+	entry := elem.Value.(*ResponseCacheEntry)
+	c.lru.Remove(elem)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size()
+}
+
+// Clear 清空响应缓存
+func (c *ResponseCache) Clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count := len(c.entries)
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
+	return count
+}
+
+// Stats 获取响应缓存统计
+func (c *ResponseCache) Stats() ResponseCacheStats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	bytes := c.bytes
+	c.mu.Unlock()
+
+	return ResponseCacheStats{
+		Entries:     entries,
+		Bytes:       bytes,
+		MaxBytes:    c.maxBytes,
+		TTL:         c.ttl.String(),
+		Hits:        atomic.LoadInt64(&c.hits),
+		Misses:      atomic.LoadInt64(&c.misses),
+		Invalidated: atomic.LoadInt64(&c.invalidated),
+		Evictions:   atomic.LoadInt64(&c.evictions),
+	}
+}
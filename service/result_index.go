@@ -0,0 +1,78 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+
+	"pansou/model"
+)
+
+// 最近搜索结果索引最多保存的结果数
+const resultIndexMaxEntries = 20000
+
+// IndexedResult 索引中保存的结果（只保留标题和链接）
+type IndexedResult struct {
+	UniqueID string
+	Title    string
+	Links    []model.Link
+}
+
+// ResultIndex 最近搜索结果索引：按UniqueID查找结果的链接（如推送到下载器）
+// 超过上限时淘汰最久未出现的结果
+type ResultIndex struct {
+	mu    sync.Mutex
+	items map[string]*list.Element
+	lru   *list.List
+}
+
+var (
+	globalResultIndex     *ResultIndex
+	globalResultIndexOnce sync.Once
+)
+
+// GetResultIndex 获取全局搜索结果索引
+func GetResultIndex() *ResultIndex {
+	globalResultIndexOnce.Do(func() {
+		globalResultIndex = &ResultIndex{
+			items: make(map[string]*list.Element),
+			lru:   list.New(),
+		}
+	})
+	return globalResultIndex
+}
+
+// Record 记录搜索结果
+func (r *ResultIndex) Record(results []model.SearchResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, result := range results {
+		if result.UniqueID == "" || len(result.Links) == 0 {
+			continue
+		}
+		entry := &IndexedResult{UniqueID: result.UniqueID, Title: result.Title, Links: result.Links}
+		if elem, ok := r.items[result.UniqueID]; ok {
+			elem.Value = entry
+			r.lru.MoveToFront(elem)
+			continue
+		}
+		r.items[result.UniqueID] = r.lru.PushFront(entry)
+		for r.lru.Len() > resultIndexMaxEntries {
+			oldest := r.lru.Back()
+			r.lru.Remove(oldest)
+			delete(r.items, oldest.Value.(*IndexedResult).UniqueID)
+		}
+	}
+}
+
+// Lookup 按UniqueID查找结果
+func (r *ResultIndex) Lookup(uniqueID string) (IndexedResult, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elem, ok := r.items[uniqueID]
+	if !ok {
+		return IndexedResult{}, false
+	}
+	return *elem.Value.(*IndexedResult), true
+}
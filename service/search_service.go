@@ -15,6 +15,7 @@ import (
 	"pansou/config"
 	"pansou/model"
 	"pansou/plugin"
+	"pansou/plugin/peer"
 	"pansou/util"
 	"pansou/util/cache"
 	"pansou/util/pool"
@@ -49,6 +50,12 @@ func GetEnhancedTwoLevelCache() *cache.EnhancedTwoLevelCache {
 	return enhancedTwoLevelCache
 }
 
+// CacheOnlyExtKey ext中的仅缓存标记，为true时缓存未命中也不发起实际搜索
+const CacheOnlyExtKey = "_cache_only"
+
+// FederatedExtKey ext中的联邦请求标记，为true时（请求来自其他PanSou实例）不再请求远程实例，避免互相转发
+const FederatedExtKey = "_federated"
+
 // 优先关键词列表
 var priorityKeywords = []string{"合集", "系列", "全", "完", "最新", "附", "complete"}
 
@@ -83,6 +90,21 @@ func logAsyncCacheWithKeyword(keyword, cacheKey string, format string, args ...i
 	fmt.Printf(enhancedFormat, args...)
 }
 
+// 请求合并：相同TG频道组合、插件组合的并发搜索共享一次实际搜索
+var (
+	tgSearchFlight     = util.NewFlightGroup()
+	pluginSearchFlight = util.NewFlightGroup()
+)
+
+// CoalescingStats 获取搜索请求合并统计
+func CoalescingStats() map[string]util.FlightStats {
+	return map[string]util.FlightStats{
+		"tg":            tgSearchFlight.Stats(),
+		"plugins":       pluginSearchFlight.Stats(),
+		"plugin_search": plugin.AsyncSearchFlightStats(),
+	}
+}
+
 // 全局缓存实例和缓存是否初始化标志
 var (
 	enhancedTwoLevelCache *cache.EnhancedTwoLevelCache
@@ -224,9 +246,14 @@ func NewSearchService(pluginManager *plugin.PluginManager) *SearchService {
 		})
 	}
 
-	return &SearchService{
+	s := &SearchService{
 		pluginManager: pluginManager,
 	}
+	
+	// 创建缓存预热调度器（启用时定期预热热门关键词）
+	startCacheWarmer(s)
+	
+	return s
 }
 
 // injectMainCacheToAsyncPlugins 将主缓存系统注入到异步插件中
@@ -242,53 +269,32 @@ func injectMainCacheToAsyncPlugins(pluginManager *plugin.PluginManager, mainCach
 		plugin.SetGlobalCacheSerializer(serializer)
 	}
 	
-	// 创建缓存更新函数（支持IsFinal参数）- 接收原始数据并与现有缓存合并
+	// 创建缓存更新函数（支持IsFinal参数）- 每个插件只更新自己的来源缓存项，无需读取合并整个结果集
 	cacheUpdater := func(key string, newResults []model.SearchResult, ttl time.Duration, isFinal bool, keyword string, pluginName string) error {
 		// 优化：如果新结果为空，跳过缓存更新（避免无效操作）
 		if len(newResults) == 0 {
 			return nil
 		}
 		
-		// 获取现有缓存数据进行合并
-		var finalResults []model.SearchResult
-		if existingData, hit, err := mainCache.Get(key); err == nil && hit {
-			var existingResults []model.SearchResult
-			if err := mainCache.GetSerializer().Deserialize(existingData, &existingResults); err == nil {
-				// 合并新旧结果，去重保留最完整的数据
-				finalResults = mergeSearchResults(existingResults, newResults)
-				if config.AppConfig != nil && config.AppConfig.AsyncLogEnabled {
-					if keyword != "" {
-						fmt.Printf("🔄 [%s:%s] 更新缓存| 原有: %d + 新增: %d = 合并后: %d\n", 
-						pluginName, keyword, len(existingResults), len(newResults), len(finalResults))
-					}
-				}
-			} else {
-				// 反序列化失败，使用新结果
-				finalResults = newResults
-							if config.AppConfig != nil && config.AppConfig.AsyncLogEnabled {
-				displayKey := key[:8] + "..."
-				if keyword != "" {
-					fmt.Printf("[异步插件 %s] 缓存反序列化失败，使用新结果: %s(关键词:%s) | 结果数: %d\n", pluginName, displayKey, keyword, len(newResults))
-				} else {
-					fmt.Printf("[异步插件 %s] 缓存反序列化失败，使用新结果: %s | 结果数: %d\n", pluginName, key, len(newResults))
-				}
-			}
-			}
-		} else {
-			// 无现有缓存，直接使用新结果
-			finalResults = newResults
-					if config.AppConfig != nil && config.AppConfig.AsyncLogEnabled {
-			displayKey := key[:8] + "..."
-			if keyword != "" {
-				fmt.Printf("[异步插件 %s] 初始缓存创建: %s(关键词:%s) | 结果数: %d\n", pluginName, displayKey, keyword, len(newResults))
-			} else {
-				fmt.Printf("[异步插件 %s] 初始缓存创建: %s | 结果数: %d\n", pluginName, key, len(newResults))
+		// 已有最终结果时，不使用非最终结果覆盖；非最终结果只短期缓存，等待插件完成后覆盖
+		if !isFinal {
+			if existing, ok := mainCache.GetSourceEntry(key); ok && existing.IsFinal {
+				return nil
 			}
+			ttl = partialSourceTTL
 		}
+		
+		now := time.Now()
+		if config.AppConfig != nil && config.AppConfig.AsyncLogEnabled && keyword != "" {
+			fmt.Printf("🔄 [%s:%s] 更新缓存| 结果数: %d | 最终结果: %t\n", pluginName, keyword, len(newResults), isFinal)
 		}
 		
-		// 序列化合并后的结果
-		data, err := mainCache.GetSerializer().Serialize(finalResults)
+		// 序列化来源缓存项
+		data, err := mainCache.GetSerializer().Serialize(cache.SourceCacheEntry{
+			Results:   newResults,
+			IsFinal:   isFinal,
+			UpdatedAt: now,
+		})
 		if err != nil {
 			fmt.Printf("[缓存更新] 序列化失败: %s | 错误: %v\n", key, err)
 			return err
@@ -303,13 +309,13 @@ func injectMainCacheToAsyncPlugins(pluginManager *plugin.PluginManager, mainCach
 		if cacheWriteManager := globalCacheWriteManager; cacheWriteManager != nil {
 			operation := &cache.CacheOperation{
 				Key:          key,
-				Data:         finalResults,      // 使用原始数据而不是序列化后的
+				Data:         newResults,      // 使用原始数据而不是序列化后的
 				TTL:          ttl,
 				IsFinal:      isFinal,
 				PluginName:   pluginName,
 				Keyword:      keyword,
 				Priority:     2,                 // 中等优先级
-				Timestamp:    time.Now(),
+				Timestamp:    now,
 				DataSize:     len(data),         // 序列化后的数据大小
 			}
 			
@@ -428,6 +434,35 @@ func (s *SearchService) Search(keyword string, channels []string, concurrency in
 		concurrency = config.AppConfig.DefaultConcurrency
 	}
 
+	// 仅缓存模式：只返回已缓存的结果，不发起实际搜索
+	cacheOnly, _ := ext[CacheOnlyExtKey].(bool)
+	if cacheOnly {
+		forceRefresh = false
+	}
+
+	// 记录关键词访问次数，供缓存预热统计热门关键词
+	if sourceType == "all" || sourceType == "tg" {
+		plugin.RecordKeywordAccess(keyword, plugin.AccessSourceTG)
+	}
+	if sourceType == "all" || sourceType == "plugin" {
+		if plugins == nil {
+			plugin.RecordKeywordAccess(keyword, plugin.AccessSourceAllPlugins)
+		} else {
+			plugin.RecordKeywordAccess(keyword, plugins...)
+		}
+	}
+
+	// 本次搜索的出站请求预算，TG与插件共享
+	// 写入ext的副本，不修改调用方传入的参数（如请求体中的ext）
+	if budget := util.NewSearchRequestBudget(); budget != nil {
+		copied := make(map[string]interface{}, len(ext)+1)
+		for k, v := range ext {
+			copied[k] = v
+		}
+		copied[util.RequestBudgetExtKey] = budget
+		ext = copied
+	}
+
 	// 并行获取TG搜索和插件搜索结果
 	var tgResults []model.SearchResult
 	var pluginResults []model.SearchResult
@@ -440,7 +475,7 @@ func (s *SearchService) Search(keyword string, channels []string, concurrency in
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			tgResults, tgErr = s.searchTG(keyword, channels, forceRefresh)
+			tgResults, tgErr = s.searchTG(keyword, channels, forceRefresh, cacheOnly, ext)
 		}()
 	}
 	// 如果需要搜索插件（且插件功能已启用）
@@ -468,6 +503,9 @@ func (s *SearchService) Search(keyword string, channels []string, concurrency in
 	// 合并结果
 	allResults := mergeSearchResults(tgResults, pluginResults)
 
+	// 记录到最近结果索引，供按UniqueID推送下载使用
+	GetResultIndex().Record(allResults)
+
 	// 按照优化后的规则排序结果
 	sortResultsByTimeAndKeywords(allResults)
 
@@ -588,12 +626,12 @@ func getKeywordPriority(title string) int {
 }
 
 // 搜索单个频道
-func (s *SearchService) searchChannel(keyword string, channel string) ([]model.SearchResult, error) {
+func (s *SearchService) searchChannel(keyword string, channel string, budget *util.RequestBudget) ([]model.SearchResult, error) {
 	// 构建搜索URL
 	url := util.BuildSearchURL(channel, keyword, "")
 
-	// 使用全局HTTP客户端（已配置代理）
-	client := util.GetHTTPClient()
+	// 使用全局HTTP客户端（已配置代理），并计入本次搜索的请求预算
+	client := util.WithRequestBudget(util.GetHTTPClient(), budget)
 
 	// 创建一个带超时的上下文
 	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Second)
@@ -1099,20 +1137,7 @@ func mergeResultsByType(results []model.SearchResult, keyword string, cloudTypes
 			}
 			
 			// 确定数据来源
-			var source string
-			if result.Channel != "" {
-				// 来自TG频道
-				source = "tg:" + result.Channel
-			} else if result.UniqueID != "" && strings.Contains(result.UniqueID, "-") {
-				// 来自插件：UniqueID格式通常为 "插件名-ID"
-				parts := strings.SplitN(result.UniqueID, "-", 2)
-				if len(parts) >= 1 {
-					source = "plugin:" + parts[0]
-				}
-			} else {
-				// 无法确定来源，使用默认值
-				source = "unknown"
-			}
+			source := getResultSource(result)
 			
 			// 赋值给Note前，支持多个关键词裁剪
 			title = util.CutTitleByKeywords(title, []string{"简介", "描述"})
@@ -1208,78 +1233,106 @@ func mergeResultsByType(results []model.SearchResult, keyword string, cloudTypes
 }
 
 // searchTG 搜索TG频道
-func (s *SearchService) searchTG(keyword string, channels []string, forceRefresh bool) ([]model.SearchResult, error) {
-	// 生成缓存键
-	cacheKey := cache.GenerateTGCacheKey(keyword, channels)
-	
-	// 如果未启用强制刷新，尝试从缓存获取结果
-	if !forceRefresh && cacheInitialized && config.AppConfig.CacheEnabled {
-		var data []byte
-		var hit bool
-		var err error
-		
-		// 使用增强版缓存
-		if enhancedTwoLevelCache != nil {
-			data, hit, err = enhancedTwoLevelCache.Get(cacheKey)
-			
-			if err == nil && hit {
-				var results []model.SearchResult
-				if err := enhancedTwoLevelCache.GetSerializer().Deserialize(data, &results); err == nil {
-					// 直接返回缓存数据，不检查新鲜度
-					return results, nil
-				}
+// 每个频道的结果单独缓存，请求由各频道的缓存项组合而成，只搜索缓存中缺失的频道
+// ext中的响应状态在有频道缺失或未完成时标记为不完整
+func (s *SearchService) searchTG(keyword string, channels []string, forceRefresh bool, cacheOnly bool, ext map[string]interface{}) ([]model.SearchResult, error) {
+	useCache := cacheInitialized && config.AppConfig.CacheEnabled && enhancedTwoLevelCache != nil
+	
+	var results []model.SearchResult
+	
+	// 如果未启用强制刷新，先从各频道的缓存项中获取结果
+	missingChannels := channels
+	if !forceRefresh && useCache {
+		missingChannels = make([]string, 0, len(channels))
+		for _, channel := range channels {
+			entry, hit := enhancedTwoLevelCache.GetSourceEntry(cache.GenerateTGChannelCacheKey(keyword, channel))
+			if hit {
+				// 直接使用缓存数据，不检查新鲜度
+				results = append(results, entry.Results...)
+			} else {
+				missingChannels = append(missingChannels, channel)
 			}
 		}
 	}
 	
-	// 缓存未命中或强制刷新，执行实际搜索
-	var results []model.SearchResult
+	// 所有频道都命中缓存，或仅缓存模式下不执行实际搜索
+	if len(missingChannels) == 0 {
+		return results, nil
+	}
+	if cacheOnly {
+		// 缺少部分频道的结果，响应不能进入响应缓存
+		markResponseIncomplete(ext)
+		return results, nil
+	}
+	budget := util.RequestBudgetFromExt(ext)
+	
+	// 合并相同关键词和频道的并发搜索：只有一个请求实际搜索，其余请求等待并共享结果
+	flightKey := cache.GenerateTGCacheKey(keyword, missingChannels)
+	value, err, _ := tgSearchFlight.Do(flightKey, config.AppConfig.PluginTimeout, func() (interface{}, error) {
+		return s.fetchTGChannels(keyword, missingChannels, useCache, budget), nil
+	})
+	if err == nil {
+		fetched := value.(sourceFetchResult)
+		results = append(results, fetched.results...)
+		if !fetched.final {
+			// 有频道搜索失败或超时，本次搜索的响应不能进入响应缓存
+			markResponseIncomplete(ext)
+		}
+	} else {
+		markResponseIncomplete(ext)
+	}
 	
-	// 使用工作池并行搜索多个频道
+	return results, nil
+}
+
+// fetchTGChannels 并行搜索多个频道并缓存各频道的结果
+func (s *SearchService) fetchTGChannels(keyword string, channels []string, useCache bool, budget *util.RequestBudget) sourceFetchResult {
+	fetched := sourceFetchResult{final: true}
+	
+	// 使用工作池并行搜索
 	tasks := make([]pool.Task, 0, len(channels))
 	
 	for _, channel := range channels {
 		ch := channel // 创建副本，避免闭包问题
 		tasks = append(tasks, func() interface{} {
-			results, err := s.searchChannel(keyword, ch)
+			channelResults, err := s.searchChannel(keyword, ch, budget)
 			if err != nil {
 				return nil
 			}
-			return results
+			
+			// 异步缓存该频道的结果（搜索失败的频道不缓存，下次请求重新搜索）
+			// 频道搜索是同步的单次请求，成功返回即为该频道的最终结果
+			if useCache {
+				go func(res []model.SearchResult) {
+					ttl := time.Duration(config.AppConfig.CacheTTLMinutes) * time.Minute
+					enhancedTwoLevelCache.SetSourceEntry(cache.GenerateTGChannelCacheKey(keyword, ch), cache.SourceCacheEntry{
+						Results: res,
+						IsFinal: true,
+					}, ttl)
+				}(channelResults)
+			}
+			return channelResults
 		})
 	}
 	
-	// 执行搜索任务并获取结果
+	// 执行搜索任务并获取结果（超时未返回的任务结果为nil）
 	taskResults := pool.ExecuteBatchWithTimeout(tasks, len(channels), config.AppConfig.PluginTimeout)
 	
 	// 合并所有频道的结果
 	for _, result := range taskResults {
-		if result != nil {
-			channelResults := result.([]model.SearchResult)
-			results = append(results, channelResults...)
+		if result == nil {
+			// 频道搜索失败或超时，结果不完整
+			fetched.final = false
+			continue
 		}
+		fetched.results = append(fetched.results, result.([]model.SearchResult)...)
 	}
 	
-	// 异步缓存结果
-	if cacheInitialized && config.AppConfig.CacheEnabled {
-		go func(res []model.SearchResult) {
-			ttl := time.Duration(config.AppConfig.CacheTTLMinutes) * time.Minute
-			
-			// 使用增强版缓存
-			if enhancedTwoLevelCache != nil {
-				data, err := enhancedTwoLevelCache.GetSerializer().Serialize(res)
-				if err != nil {
-					return
-				}
-				enhancedTwoLevelCache.Set(cacheKey, data, ttl)
-			}
-		}(results)
-	}
-	
-	return results, nil
+	return fetched
 }
 
 // searchPlugins 搜索插件
+// 每个插件的结果单独缓存，请求由各插件的缓存项组合而成，只调用缓存中缺失或尚未完成的插件
 func (s *SearchService) searchPlugins(keyword string, plugins []string, forceRefresh bool, concurrency int, ext map[string]interface{}) ([]model.SearchResult, error) {
 	// 确保ext不为nil
 	if ext == nil {
@@ -1291,39 +1344,6 @@ func (s *SearchService) searchPlugins(keyword string, plugins []string, forceRef
         ext["refresh"] = true
     }
 	
-	// 生成缓存键
-	cacheKey := cache.GeneratePluginCacheKey(keyword, plugins)
-	
-	
-	// 如果未启用强制刷新，尝试从缓存获取结果
-	if !forceRefresh && cacheInitialized && config.AppConfig.CacheEnabled {
-		var data []byte
-		var hit bool
-		var err error
-		
-		// 使用增强版缓存
-		if enhancedTwoLevelCache != nil {
-			
-			// 使用Get方法，它会检查磁盘缓存是否有更新
-			// 如果磁盘缓存比内存缓存更新，会自动更新内存缓存并返回最新数据
-			data, hit, err = enhancedTwoLevelCache.Get(cacheKey)
-			
-			if err == nil && hit {
-				var results []model.SearchResult
-				if err := enhancedTwoLevelCache.GetSerializer().Deserialize(data, &results); err == nil {
-					// 返回缓存数据
-					fmt.Printf("✅ [%s] 命中缓存 结果数: %d\n", keyword,  len(results))
-					return results, nil
-				} else {
-					displayKey := cacheKey[:8] + "..."
-					fmt.Printf("[主服务] 缓存反序列化失败: %s(关键词:%s) | 错误: %v\n", displayKey, keyword, err)
-				}
-			}
-		}
-	}
-	
-	// 缓存未命中或强制刷新，执行实际搜索
-	
 	// 获取所有可用插件
 	var availablePlugins []plugin.AsyncSearchPlugin
 	if s.pluginManager != nil {
@@ -1361,77 +1381,179 @@ func (s *SearchService) searchPlugins(keyword string, plugins []string, forceRef
 			availablePlugins = allPlugins
 		}
 	}
+
+	// 来自其他实例的请求不再请求远程实例
+	if federated, _ := ext[FederatedExtKey].(bool); federated {
+		localPlugins := make([]plugin.AsyncSearchPlugin, 0, len(availablePlugins))
+		for _, p := range availablePlugins {
+			if !peer.IsPeer(p.Name()) {
+				localPlugins = append(localPlugins, p)
+			}
+		}
+		availablePlugins = localPlugins
+	}
+	
+	useCache := cacheInitialized && config.AppConfig.CacheEnabled && enhancedTwoLevelCache != nil
+	
+	// 如果未启用强制刷新，先从各插件的缓存项中获取结果
+	// 非最终结果（插件仍在后台搜索）的缓存项先保留，插件本次未返回结果时再使用
+	var cachedResults []model.SearchResult
+	partialResults := make(map[string][]model.SearchResult)
+	missingPlugins := availablePlugins
+	if !forceRefresh && useCache {
+		missingPlugins = make([]plugin.AsyncSearchPlugin, 0, len(availablePlugins))
+		for _, p := range availablePlugins {
+			entry, hit := enhancedTwoLevelCache.GetSourceEntry(cache.GeneratePluginSourceCacheKey(keyword, p.Name()))
+			if hit && entry.IsFinal {
+				cachedResults = append(cachedResults, entry.Results...)
+				continue
+			}
+			if hit {
+				partialResults[p.Name()] = entry.Results
+			}
+			missingPlugins = append(missingPlugins, p)
+		}
+		
+		if len(missingPlugins) == 0 {
+			fmt.Printf("✅ [%s] 命中缓存 结果数: %d\n", keyword, len(cachedResults))
+			return filterResultsWithLinks(cachedResults), nil
+		}
+	}
 	
+	// 仅缓存模式下不执行实际搜索，只返回已缓存的结果
+	if cacheOnly, _ := ext[CacheOnlyExtKey].(bool); cacheOnly {
+		for _, res := range partialResults {
+			cachedResults = append(cachedResults, res...)
+		}
+		if len(missingPlugins) > 0 {
+			// 包含未完成或缺失的插件结果，响应不能进入响应缓存
+			// 只标记本次请求，读取缓存不改变来源版本，不影响其他已缓存的响应
+			markResponseIncomplete(ext)
+		}
+		return filterResultsWithLinks(cachedResults), nil
+	}
+
 	// 控制并发数
 	if concurrency <= 0 {
 		// 使用配置中的默认值
 		concurrency = config.AppConfig.DefaultConcurrency
 	}
 	
+	// 合并相同关键词、插件和参数的并发搜索：只有一个请求实际搜索，其余请求等待并共享结果
+	missingNames := make([]string, 0, len(missingPlugins))
+	for _, p := range missingPlugins {
+		missingNames = append(missingNames, p.Name())
+	}
+	flightKey := util.FlightKey(cache.GeneratePluginCacheKey(keyword, missingNames), ext)
+	allResults := cachedResults
+	value, err, _ := pluginSearchFlight.Do(flightKey, config.AppConfig.PluginTimeout, func() (interface{}, error) {
+		return s.fetchPlugins(keyword, missingPlugins, partialResults, concurrency, useCache, ext), nil
+	})
+	if err == nil {
+		fetched := value.(sourceFetchResult)
+		allResults = append(allResults, fetched.results...)
+		if !fetched.final {
+			// 有插件未返回最终结果，本次搜索的响应不能进入响应缓存
+			// （在每个调用方拿到结果后标记，合并等待的请求同样不会缓存不完整的响应）
+			markResponseIncomplete(ext)
+		}
+	} else {
+		markResponseIncomplete(ext)
+	}
+	
+	return filterResultsWithLinks(allResults), nil
+}
+
+// sourceFetchResult 一组插件或频道的搜索结果，final表示所有来源都返回了最终结果
+type sourceFetchResult struct {
+	results []model.SearchResult
+	final   bool
+}
+
+// partialSourceTTL 非最终结果的来源缓存项有效期（插件在后台完成后会以最终结果覆盖）
+const partialSourceTTL = time.Minute
+
+// fetchPlugins 并行调用多个插件，返回合并后的结果
+// 插件超时或失败时使用partialResults中尚未完成的缓存结果
+func (s *SearchService) fetchPlugins(keyword string, plugins []plugin.AsyncSearchPlugin, partialResults map[string][]model.SearchResult, concurrency int, useCache bool, ext map[string]interface{}) sourceFetchResult {
 	// 使用工作池执行并行搜索
-	tasks := make([]pool.Task, 0, len(availablePlugins))
-	for _, p := range availablePlugins {
+	tasks := make([]pool.Task, 0, len(plugins))
+	for _, p := range plugins {
+		// 每个插件使用独立的搜索状态，由插件报告结果是否为最终结果
+		pluginExt, status := plugin.WithSearchStatus(ext)
+		cacheKey := cache.GeneratePluginSourceCacheKey(keyword, p.Name())
+		// 主缓存键（该插件自己的缓存项）随本次调用的ext传入，不修改被并发搜索共享的插件实例
+		pluginExt[plugin.MainCacheKeyExtKey] = cacheKey
 		plugin := p // 创建副本，避免闭包问题
 		tasks = append(tasks, func() interface{} {
-			// 设置主缓存键和当前关键词
-			plugin.SetMainCacheKey(cacheKey)
-			plugin.SetCurrentKeyword(keyword)
-			
 			// 调用异步插件的AsyncSearch方法
 			results, err := plugin.AsyncSearch(keyword, func(client *http.Client, kw string, extParams map[string]interface{}) ([]model.SearchResult, error) {
 				// 使用插件的Search方法作为搜索函数
+				// 请求预算随extParams传入，插件无论使用基础客户端还是自建客户端都从ext中取出预算
 				return plugin.Search(kw, extParams)
-			}, cacheKey, ext)
+			}, cacheKey, pluginExt)
 			
 			if err != nil {
-				return nil
+				// 插件失败时结果不完整，由调用方标记本次搜索的响应不能进入响应缓存
+				return sourceFetchResult{results: partialResults[plugin.Name()], final: false}
+			}
+			final := status.Final()
+			if !final && len(results) == 0 {
+				// 插件超时，使用尚未完成的缓存结果，由插件后台完成后更新缓存项
+				return sourceFetchResult{results: partialResults[plugin.Name()], final: false}
 			}
-			return results
+			
+			// 异步插件自身的缓存命中时不会回写主缓存，这里补写该插件的缓存项
+			// 只有插件报告完成的结果才标记为最终结果，其余结果以较短的有效期缓存，且不覆盖已有的最终结果
+			if useCache && len(results) > 0 {
+				go func(res []model.SearchResult) {
+					ttl := time.Duration(config.AppConfig.CacheTTLMinutes) * time.Minute
+					if !final {
+						if existing, ok := enhancedTwoLevelCache.GetSourceEntry(cacheKey); ok && existing.IsFinal {
+							return
+						}
+						ttl = partialSourceTTL
+					}
+					if err := enhancedTwoLevelCache.SetSourceEntry(cacheKey, cache.SourceCacheEntry{
+						Results: res,
+						IsFinal: final,
+					}, ttl); err != nil {
+						fmt.Printf("[主程序] 缓存更新失败: %s | 错误: %v\n", cacheKey, err)
+					}
+				}(results)
+			}
+			return sourceFetchResult{results: results, final: final}
 		})
 	}
 	
-	// 执行搜索任务并获取结果
+	// 执行搜索任务并获取结果（超过插件超时未返回的任务结果为nil）
 	results := pool.ExecuteBatchWithTimeout(tasks, concurrency, config.AppConfig.PluginTimeout)
 	
-	// 合并所有插件的结果，过滤掉无链接的结果
-	var allResults []model.SearchResult
+	// 合并所有插件的结果
+	fetched := sourceFetchResult{final: true}
 	for _, result := range results {
-		if result != nil {
-			pluginResults := result.([]model.SearchResult)
-			// 只添加有链接的结果到最终结果中
-			for _, pluginResult := range pluginResults {
-				if len(pluginResult.Links) > 0 {
-					allResults = append(allResults, pluginResult)
-				}
-			}
+		if result == nil {
+			fetched.final = false
+			continue
+		}
+		taskResult := result.(sourceFetchResult)
+		fetched.results = append(fetched.results, taskResult.results...)
+		if !taskResult.final {
+			fetched.final = false
 		}
 	}
-	
-	// 恢复主程序缓存更新：确保最终合并结果被正确缓存
-	if cacheInitialized && config.AppConfig.CacheEnabled {
-		go func(res []model.SearchResult, kw string, key string) {
-			ttl := time.Duration(config.AppConfig.CacheTTLMinutes) * time.Minute
-			
-			// 使用增强版缓存，确保与异步插件使用相同的序列化器
-			if enhancedTwoLevelCache != nil {
-				data, err := enhancedTwoLevelCache.GetSerializer().Serialize(res)
-				if err != nil {
-					fmt.Printf("[主程序] 缓存序列化失败: %s | 错误: %v\n", key, err)
-					return
-				}
-				
-			// 主程序最后更新，覆盖可能有问题的异步插件缓存
-			// 使用同步方式确保数据写入磁盘
-			enhancedTwoLevelCache.SetBothLevels(key, data, ttl)
-				if config.AppConfig != nil && config.AppConfig.AsyncLogEnabled {
-					fmt.Printf("[主程序] 缓存更新完成: %s | 结果数: %d", 
-						key, len(res))
-				}
-			}
-		}(allResults, keyword, cacheKey)
+	return fetched
+}
+
+// filterResultsWithLinks 过滤掉无链接的结果
+func filterResultsWithLinks(results []model.SearchResult) []model.SearchResult {
+	filtered := make([]model.SearchResult, 0, len(results))
+	for _, result := range results {
+		if len(result.Links) > 0 {
+			filtered = append(filtered, result)
+		}
 	}
-	
-	return allResults, nil
+	return filtered
 }
 
 
@@ -1459,8 +1581,18 @@ var (
 	pluginLevelCache = sync.Map{} // 插件等级缓存
 )
 
+// ResultSource 搜索结果的来源：tg:频道名、plugin:插件名 或 peer:远程实例名
+func ResultSource(result model.SearchResult) string {
+	return getResultSource(result)
+}
+
 // getResultSource 从SearchResult推断数据来源
 func getResultSource(result model.SearchResult) string {
+	if peer.IsPeer(result.UniqueID) {
+		// 来自远程实例：UniqueID格式为 "peer:实例名-原ID"
+		name, _, _ := strings.Cut(result.UniqueID, "-")
+		return name
+	}
 	if result.Channel != "" {
 		// 来自TG频道
 		return "tg:" + result.Channel
@@ -1497,6 +1629,13 @@ func getPluginLevelBySource(source string) int {
 		pluginLevelCache.Store(source, level)
 		return level
 	}
+
+	if parts[0] == "peer" {
+		// 远程实例的插件名即来源
+		level := getPluginPriorityByName(source)
+		pluginLevelCache.Store(source, level)
+		return level
+	}
 	
 	pluginLevelCache.Store(source, 3)
 	return 3
@@ -0,0 +1,146 @@
+package torznab
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Category Newznab分类
+type Category struct {
+	ID            int
+	Name          string
+	Subcategories []Category
+}
+
+// Newznab标准分类ID
+const (
+	CategoryConsole = 1000
+	CategoryMovies  = 2000
+	CategoryAudio   = 3000
+	CategoryPC      = 4000
+	CategoryPCGames = 4050
+	CategoryTV      = 5000
+	CategoryTVAnime = 5070
+	CategoryXXX     = 6000
+	CategoryBooks   = 7000
+	CategoryOther   = 8000
+)
+
+// Categories caps中声明的分类
+var Categories = []Category{
+	{ID: CategoryConsole, Name: "Console"},
+	{ID: CategoryMovies, Name: "Movies"},
+	{ID: CategoryAudio, Name: "Audio"},
+	{ID: CategoryPC, Name: "PC", Subcategories: []Category{{ID: CategoryPCGames, Name: "PC/Games"}}},
+	{ID: CategoryTV, Name: "TV", Subcategories: []Category{{ID: CategoryTVAnime, Name: "TV/Anime"}}},
+	{ID: CategoryXXX, Name: "XXX"},
+	{ID: CategoryBooks, Name: "Books"},
+	{ID: CategoryOther, Name: "Other"},
+}
+
+// categoryKeywords 插件分类名（标签或内容中的“分类”）到Newznab分类的映射，按顺序匹配
+// 覆盖nyaa（Anime/Live Action/Literature...）、thepiratebay（Video/TV shows/Applications...）、clmao（video/music...）等插件的分类
+var categoryKeywords = []struct {
+	keyword  string
+	category int
+}{
+	{"anime", CategoryTVAnime},
+	{"动漫", CategoryTVAnime},
+	{"动画", CategoryTVAnime},
+	{"番剧", CategoryTVAnime},
+	{"porn", CategoryXXX},
+	{"xxx", CategoryXXX},
+	{"成人", CategoryXXX},
+	{"tv shows", CategoryTV},
+	{"tv", CategoryTV},
+	{"live action", CategoryTV},
+	{"电视剧", CategoryTV},
+	{"剧集", CategoryTV},
+	{"综艺", CategoryTV},
+	{"movies", CategoryMovies},
+	{"movie", CategoryMovies},
+	{"电影", CategoryMovies},
+	{"影视", CategoryMovies},
+	{"video", CategoryMovies},
+	{"games", CategoryPCGames},
+	{"game", CategoryPCGames},
+	{"游戏", CategoryPCGames},
+	{"applications", CategoryPC},
+	{"software", CategoryPC},
+	{"软件", CategoryPC},
+	{"安装包", CategoryPC},
+	{"audio", CategoryAudio},
+	{"music", CategoryAudio},
+	{"音乐", CategoryAudio},
+	{"literature", CategoryBooks},
+	{"e-books", CategoryBooks},
+	{"document", CategoryBooks},
+	{"书籍", CategoryBooks},
+	{"文档", CategoryBooks},
+}
+
+// pluginCategories 只提供某类资源的插件的默认分类
+var pluginCategories = map[string]int{
+	"nyaa":  CategoryTVAnime,
+	"u3c3":  CategoryXXX,
+	"javdb": CategoryXXX,
+}
+
+var (
+	// 标题中的季、集标记，如 S01E02、S01、第2集、第1季、EP02
+	episodeTitleRegex = regexp.MustCompile(`(?i)\bS\d{1,2}(E\d{1,4})?\b|\bEP?\d{1,4}\b|第\s*[0-9一二三四五六七八九十百]+\s*[季集话話]|全\s*\d+\s*集`)
+	// 标题中的视频质量标记
+	videoTitleRegex = regexp.MustCompile(`(?i)\b(2160p|1080p|720p|4k|bluray|blu-ray|web-?dl|webrip|hdtv|remux|x26[45]|h\.?26[45]|hevc)\b`)
+	// 内容中的分类，如 “分类: Anime - English-translated”
+	contentCategoryRegex = regexp.MustCompile(`(?:分类|类别|Category)\s*[:：]\s*([^|,\n]+)`)
+)
+
+// categoryFromText 按插件分类名匹配Newznab分类，未匹配时返回0
+func categoryFromText(text string) int {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "" {
+		return 0
+	}
+	for _, k := range categoryKeywords {
+		if strings.Contains(text, k.keyword) {
+			return k.category
+		}
+	}
+	return 0
+}
+
+// DetectCategory 推断结果的分类：依次使用插件的分类标签、内容中的分类、插件默认分类和标题特征
+func DetectCategory(plugin string, title, content string, tags []string) int {
+	// 标签可能同时包含大类和小类（如thepiratebay的 Video、TV shows），一起匹配以取更具体的分类
+	if category := categoryFromText(strings.Join(tags, " | ")); category != 0 {
+		return category
+	}
+	if m := contentCategoryRegex.FindStringSubmatch(content); len(m) > 1 {
+		if category := categoryFromText(m[1]); category != 0 {
+			return category
+		}
+	}
+	if category, ok := pluginCategories[plugin]; ok {
+		return category
+	}
+	if episodeTitleRegex.MatchString(title) {
+		return CategoryTV
+	}
+	if videoTitleRegex.MatchString(title) {
+		return CategoryMovies
+	}
+	return CategoryOther
+}
+
+// MatchCategories 判断分类是否在请求的分类中（请求父分类时包含其子分类），未指定分类时全部匹配
+func MatchCategories(category int, requested []int) bool {
+	if len(requested) == 0 {
+		return true
+	}
+	for _, r := range requested {
+		if category == r || (r%1000 == 0 && category/1000 == r/1000) {
+			return true
+		}
+	}
+	return false
+}
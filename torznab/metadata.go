@@ -0,0 +1,229 @@
+package torznab
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// 内容中的大小，如 “大小: 1.2 GiB”、“文件大小: 700MB”、“Size 1.2 GB”
+	sizeRegex = regexp.MustCompile(`(?i)(?:大小|size)\s*[:：]?\s*([\d.]+)\s*([KMGTP]i?B|bytes|B)\b`)
+	// 内容中的做种数，如 “做种: 12”、“Seeders: 12”
+	seedersRegex = regexp.MustCompile(`(?i)(?:做种|seeders?)\s*[:：]?\s*(\d+)`)
+	// 内容中的下载数，如 “下载: 3”、“Leechers: 3”
+	leechersRegex = regexp.MustCompile(`(?i)(?:下载|leechers?)\s*[:：]?\s*(\d+)`)
+	// 内容中的完成数，如 “完成: 100”
+	grabsRegex = regexp.MustCompile(`(?:完成)\s*[:：]?\s*(\d+)`)
+	// 磁力链接中的info hash（40位十六进制或32位base32）
+	btihRegex = regexp.MustCompile(`(?i)urn:btih:([0-9a-f]{40}|[a-z2-7]{32})`)
+)
+
+// sizeUnits 大小单位对应的字节数
+var sizeUnits = map[string]float64{
+	"b":     1,
+	"bytes": 1,
+	"kb":    1e3,
+	"mb":    1e6,
+	"gb":    1e9,
+	"tb":    1e12,
+	"pb":    1e15,
+	"kib":   1 << 10,
+	"mib":   1 << 20,
+	"gib":   1 << 30,
+	"tib":   1 << 40,
+	"pib":   1 << 50,
+}
+
+// Metadata 从链接和插件内容中解析出的种子信息，未知的数值为-1
+type Metadata struct {
+	Size     int64
+	Seeders  int
+	Leechers int
+	Grabs    int
+	InfoHash string
+	Name     string // 链接中携带的文件名（磁力链接的dn、ed2k的文件名）
+}
+
+// ParseMetadata 解析链接和结果内容（及标签）中的大小、做种数、info hash等信息
+func ParseMetadata(linkURL, content string, tags []string) Metadata {
+	meta := Metadata{Size: -1, Seeders: -1, Leechers: -1, Grabs: -1}
+	text := content
+	if len(tags) > 0 {
+		text += " | " + strings.Join(tags, " | ")
+	}
+
+	switch {
+	case strings.HasPrefix(strings.ToLower(linkURL), "magnet:"):
+		parseMagnet(linkURL, &meta)
+	case strings.HasPrefix(strings.ToLower(linkURL), "ed2k://"):
+		parseED2K(linkURL, &meta)
+	}
+
+	if meta.Size < 0 {
+		if m := sizeRegex.FindStringSubmatch(text); len(m) > 2 {
+			meta.Size = parseSize(m[1], m[2])
+		}
+	}
+	meta.Seeders = firstInt(seedersRegex, text)
+	meta.Leechers = firstInt(leechersRegex, text)
+	meta.Grabs = firstInt(grabsRegex, text)
+	return meta
+}
+
+// parseMagnet 解析磁力链接的info hash、文件名和精确大小（xl）
+func parseMagnet(linkURL string, meta *Metadata) {
+	if m := btihRegex.FindStringSubmatch(linkURL); len(m) > 1 {
+		meta.InfoHash = normalizeInfoHash(m[1])
+	}
+	idx := strings.Index(linkURL, "?")
+	if idx < 0 {
+		return
+	}
+	query, err := url.ParseQuery(linkURL[idx+1:])
+	if err != nil {
+		return
+	}
+	meta.Name = query.Get("dn")
+	if xl, err := strconv.ParseInt(query.Get("xl"), 10, 64); err == nil && xl > 0 {
+		meta.Size = xl
+	}
+}
+
+// parseED2K 解析ed2k链接：ed2k://|file|文件名|大小|hash|/
+func parseED2K(linkURL string, meta *Metadata) {
+	parts := strings.Split(linkURL, "|")
+	if len(parts) < 5 || !strings.EqualFold(parts[1], "file") {
+		return
+	}
+	if name, err := url.PathUnescape(parts[2]); err == nil {
+		meta.Name = name
+	} else {
+		meta.Name = parts[2]
+	}
+	if size, err := strconv.ParseInt(parts[3], 10, 64); err == nil && size > 0 {
+		meta.Size = size
+	}
+}
+
+// normalizeInfoHash 统一为40位小写十六进制
+func normalizeInfoHash(hash string) string {
+	if len(hash) == 32 {
+		decoded, err := base32.StdEncoding.DecodeString(strings.ToUpper(hash))
+		if err != nil {
+			return ""
+		}
+		return hex.EncodeToString(decoded)
+	}
+	return strings.ToLower(hash)
+}
+
+// parseSize 按单位换算字节数，无法解析时返回-1
+func parseSize(value, unit string) int64 {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return -1
+	}
+	multiplier, ok := sizeUnits[strings.ToLower(unit)]
+	if !ok {
+		return -1
+	}
+	return int64(number * multiplier)
+}
+
+// firstInt 正则第一个分组对应的整数，未匹配时返回-1
+func firstInt(re *regexp.Regexp, text string) int {
+	m := re.FindStringSubmatch(text)
+	if len(m) < 2 {
+		return -1
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// 标题中的季、集编号
+var (
+	seasonEpisodeRegex = regexp.MustCompile(`(?i)\bS(\d{1,2})(?:E(\d{1,4}))?\b`)
+	seasonRegex        = regexp.MustCompile(`第\s*([0-9一二三四五六七八九十]+)\s*季|(?i)\bseason\s*(\d{1,2})\b`)
+	episodeRegex       = regexp.MustCompile(`第\s*([0-9一二三四五六七八九十百]+)\s*[集话話]|(?i)\bEP?(\d{1,4})\b`)
+)
+
+// MatchEpisode 判断标题是否符合请求的季、集（为0表示不限）
+// 标题没有季标记时视为第一季；没有集标记时视为整季合集，匹配任意集
+func MatchEpisode(title string, season, episode int) bool {
+	if season <= 0 && episode <= 0 {
+		return true
+	}
+
+	titleSeason, titleEpisode := -1, -1
+	if m := seasonEpisodeRegex.FindStringSubmatch(title); m != nil {
+		titleSeason, _ = strconv.Atoi(m[1])
+		if m[2] != "" {
+			titleEpisode, _ = strconv.Atoi(m[2])
+		}
+	}
+	if titleSeason < 0 {
+		if m := seasonRegex.FindStringSubmatch(title); m != nil {
+			titleSeason = parseNumber(m[1] + m[2])
+		}
+	}
+	if titleEpisode < 0 {
+		if m := episodeRegex.FindStringSubmatch(title); m != nil {
+			titleEpisode = parseNumber(m[1] + m[2])
+		}
+	}
+	if titleSeason < 0 {
+		titleSeason = 1
+	}
+
+	if season > 0 && titleSeason != season {
+		return false
+	}
+	if episode > 0 && titleEpisode >= 0 && titleEpisode != episode {
+		return false
+	}
+	return true
+}
+
+// chineseDigits 中文数字
+var chineseDigits = map[rune]int{'一': 1, '二': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+
+// parseNumber 解析阿拉伯数字或一百以内的中文数字，无法解析时返回-1
+func parseNumber(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+
+	total, current := 0, 0
+	for _, r := range s {
+		switch {
+		case chineseDigits[r] > 0:
+			current = chineseDigits[r]
+		case r == '十':
+			if current == 0 {
+				current = 1
+			}
+			total += current * 10
+			current = 0
+		case r == '百':
+			if current == 0 {
+				current = 1
+			}
+			total += current * 100
+			current = 0
+		default:
+			return -1
+		}
+	}
+	total += current
+	if total == 0 {
+		return -1
+	}
+	return total
+}
@@ -0,0 +1,285 @@
+// Package torznab 将搜索结果中的磁力和ed2k链接转换为Torznab格式，供Prowlarr、Jackett及Sonarr/Radarr等工具使用
+package torznab
+
+import (
+	"encoding/xml"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"pansou/model"
+)
+
+const (
+	// ServerTitle caps和RSS中的服务名称
+	ServerTitle = "PanSou"
+	// DefaultLimit 默认返回条数
+	DefaultLimit = 100
+	// MaxLimit 最多返回条数
+	MaxLimit = 500
+
+	torznabNamespace = "http://torznab.com/schemas/2015/feed"
+	atomNamespace    = "http://www.w3.org/2005/Atom"
+)
+
+// Torznab错误码
+const (
+	ErrorIncorrectCredentials = 100
+	ErrorMissingParameter     = 200
+	ErrorIncorrectParameter   = 201
+	ErrorNoSuchFunction       = 202
+	ErrorUnknown              = 900
+)
+
+// Error Torznab错误响应
+type Error struct {
+	XMLName     xml.Name `xml:"error"`
+	Code        int      `xml:"code,attr"`
+	Description string   `xml:"description,attr"`
+}
+
+// NewError 创建错误响应
+func NewError(code int, description string) *Error {
+	return &Error{Code: code, Description: description}
+}
+
+// Caps t=caps的响应
+type Caps struct {
+	XMLName    xml.Name       `xml:"caps"`
+	Server     capsServer     `xml:"server"`
+	Limits     capsLimits     `xml:"limits"`
+	Searching  capsSearching  `xml:"searching"`
+	Categories []capsCategory `xml:"categories>category"`
+}
+
+type capsServer struct {
+	Title string `xml:"title,attr"`
+}
+
+type capsLimits struct {
+	Max     int `xml:"max,attr"`
+	Default int `xml:"default,attr"`
+}
+
+type capsSearching struct {
+	Search      capsSearchType `xml:"search"`
+	TVSearch    capsSearchType `xml:"tv-search"`
+	MovieSearch capsSearchType `xml:"movie-search"`
+}
+
+type capsSearchType struct {
+	Available       string `xml:"available,attr"`
+	SupportedParams string `xml:"supportedParams,attr"`
+}
+
+type capsCategory struct {
+	ID     int            `xml:"id,attr"`
+	Name   string         `xml:"name,attr"`
+	Subcat []capsCategory `xml:"subcat"`
+}
+
+// NewCaps 服务能力描述
+func NewCaps() *Caps {
+	caps := &Caps{
+		Server: capsServer{Title: ServerTitle},
+		Limits: capsLimits{Max: MaxLimit, Default: DefaultLimit},
+		Searching: capsSearching{
+			Search:      capsSearchType{Available: "yes", SupportedParams: "q"},
+			TVSearch:    capsSearchType{Available: "yes", SupportedParams: "q,season,ep"},
+			MovieSearch: capsSearchType{Available: "yes", SupportedParams: "q"},
+		},
+	}
+	for _, c := range Categories {
+		category := capsCategory{ID: c.ID, Name: c.Name}
+		for _, sub := range c.Subcategories {
+			category.Subcat = append(category.Subcat, capsCategory{ID: sub.ID, Name: sub.Name})
+		}
+		caps.Categories = append(caps.Categories, category)
+	}
+	return caps
+}
+
+// Feed 搜索结果RSS
+type Feed struct {
+	XMLName   xml.Name    `xml:"rss"`
+	Version   string      `xml:"version,attr"`
+	AtomNS    string      `xml:"xmlns:atom,attr"`
+	TorznabNS string      `xml:"xmlns:torznab,attr"`
+	Channel   feedChannel `xml:"channel"`
+}
+
+type feedChannel struct {
+	Title       string       `xml:"title"`
+	Description string       `xml:"description"`
+	Response    feedResponse `xml:"torznab:response"`
+	Items       []Item       `xml:"item"`
+}
+
+type feedResponse struct {
+	Offset int `xml:"offset,attr"`
+	Total  int `xml:"total,attr"`
+}
+
+// Item RSS中的一个种子
+type Item struct {
+	Title       string        `xml:"title"`
+	GUID        string        `xml:"guid"`
+	Link        string        `xml:"link"`
+	Description string        `xml:"description,omitempty"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	Size        int64         `xml:"size,omitempty"`
+	Category    int           `xml:"category"`
+	Enclosure   itemEnclosure `xml:"enclosure"`
+	Attributes  []itemAttr    `xml:"torznab:attr"`
+
+	publishedAt time.Time
+}
+
+type itemEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type itemAttr struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// NewFeed 创建RSS，items为当前页的条目，total为过滤后的总数
+func NewFeed(items []Item, offset, total int) *Feed {
+	return &Feed{
+		Version:   "2.0",
+		AtomNS:    atomNamespace,
+		TorznabNS: torznabNamespace,
+		Channel: feedChannel{
+			Title:       ServerTitle,
+			Description: "PanSou Torznab feed",
+			Response:    feedResponse{Offset: offset, Total: total},
+			Items:       items,
+		},
+	}
+}
+
+// Query 搜索条件
+type Query struct {
+	Season     int   // 季，0表示不限
+	Episode    int   // 集，0表示不限
+	Categories []int // 请求的分类，为空表示不限
+}
+
+// BuildItems 将搜索结果中的磁力和ed2k链接转换为条目，同一链接只保留一次，按发布时间倒序排列
+// sourceOf 返回结果的来源（tg:频道名 或 plugin:插件名），作为条目的description
+func BuildItems(results []model.SearchResult, query Query, sourceOf func(model.SearchResult) string) []Item {
+	items := make([]Item, 0)
+	seen := make(map[string]bool)
+	for _, result := range results {
+		source := sourceOf(result)
+		pluginName := strings.TrimPrefix(source, "plugin:")
+		category := DetectCategory(pluginName, result.Title, result.Content, result.Tags)
+
+		for _, link := range result.Links {
+			if link.Type != "magnet" && link.Type != "ed2k" {
+				continue
+			}
+			meta := ParseMetadata(link.URL, result.Content, result.Tags)
+			guid := link.URL
+			if meta.InfoHash != "" {
+				guid = meta.InfoHash
+			}
+			if seen[guid] {
+				continue
+			}
+
+			title := itemTitle(result, link, meta)
+			if !MatchEpisode(title, query.Season, query.Episode) || !MatchCategories(category, query.Categories) {
+				continue
+			}
+			seen[guid] = true
+
+			items = append(items, newItem(title, guid, source, link, meta, category, result.Datetime))
+		}
+	}
+
+	sortItems(items)
+	return items
+}
+
+// itemTitle 条目标题：优先使用链接对应的作品标题，其次是结果标题，最后是链接中的文件名
+func itemTitle(result model.SearchResult, link model.Link, meta Metadata) string {
+	for _, title := range []string{link.WorkTitle, result.Title, meta.Name} {
+		if title = strings.TrimSpace(title); title != "" {
+			return title
+		}
+	}
+	return link.URL
+}
+
+// newItem 创建条目，未知的大小、做种数和发布时间不输出
+func newItem(title, guid, source string, link model.Link, meta Metadata, category int, resultTime time.Time) Item {
+	item := Item{
+		Title:       title,
+		GUID:        guid,
+		Link:        link.URL,
+		Description: source,
+		Category:    category,
+		Enclosure: itemEnclosure{
+			URL:  link.URL,
+			Type: "application/x-bittorrent",
+		},
+	}
+	if link.Type == "ed2k" {
+		item.Enclosure.Type = "application/x-ed2k"
+	}
+
+	publishedAt := link.Datetime
+	if publishedAt.IsZero() {
+		publishedAt = resultTime
+	}
+	if !publishedAt.IsZero() {
+		item.PubDate = publishedAt.Format(time.RFC1123Z)
+		item.publishedAt = publishedAt
+	}
+
+	item.Attributes = append(item.Attributes, itemAttr{Name: "category", Value: strconv.Itoa(category)})
+	if meta.Size >= 0 {
+		item.Size = meta.Size
+		item.Enclosure.Length = meta.Size
+		item.Attributes = append(item.Attributes, itemAttr{Name: "size", Value: strconv.FormatInt(meta.Size, 10)})
+	}
+	if meta.Seeders >= 0 {
+		item.Attributes = append(item.Attributes, itemAttr{Name: "seeders", Value: strconv.Itoa(meta.Seeders)})
+		peers := meta.Seeders
+		if meta.Leechers > 0 {
+			peers += meta.Leechers
+		}
+		item.Attributes = append(item.Attributes, itemAttr{Name: "peers", Value: strconv.Itoa(peers)})
+	}
+	if meta.Grabs >= 0 {
+		item.Attributes = append(item.Attributes, itemAttr{Name: "grabs", Value: strconv.Itoa(meta.Grabs)})
+	}
+	if meta.InfoHash != "" {
+		item.Attributes = append(item.Attributes, itemAttr{Name: "infohash", Value: meta.InfoHash})
+	}
+	if link.Type == "magnet" {
+		item.Attributes = append(item.Attributes, itemAttr{Name: "magneturl", Value: link.URL})
+	}
+	return item
+}
+
+// sortItems 按发布时间倒序排列，没有发布时间的排在最后
+func sortItems(items []Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].publishedAt.After(items[j].publishedAt)
+	})
+}
+
+// Marshal 序列化为带XML声明的文档
+func Marshal(v interface{}) ([]byte, error) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
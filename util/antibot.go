@@ -0,0 +1,540 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	useragent "github.com/Advik-B/cloudscraper/lib/user_agent"
+
+	"pansou/config"
+	"pansou/util/json"
+)
+
+const (
+	// 反爬会话存储文件名（位于缓存目录下）
+	antiBotSessionFileName = "antibot_sessions.json"
+	// 会话变化后延迟写入文件的时间（合并短时间内的多次修改）
+	antiBotSaveDelay = 5 * time.Second
+	// 求解质询的超时时间
+	antiBotSolveTimeout = 30 * time.Second
+	// 求解失败后的冷却时间，期间遇到质询直接返回原响应
+	antiBotSolveCooldown = time.Minute
+	// 检测质询时最多读取的响应体大小
+	antiBotMaxChallengeBody = 256 << 10
+)
+
+// antiBotChallengeMarkers Cloudflare质询页面的特征
+var antiBotChallengeMarkers = [][]byte{
+	[]byte("cdn-cgi/images/trace/jsch/"),
+	[]byte("/cdn-cgi/challenge-platform/"),
+	[]byte(`data-sitekey="`),
+	[]byte("<title>Just a moment...</title>"),
+}
+
+// antiBotProfile 会话使用的浏览器特征（请求头与TLS密码套件），创建后保持不变
+type antiBotProfile struct {
+	Browser      string      `json:"browser"`
+	Headers      http.Header `json:"headers"`
+	CipherSuites []uint16    `json:"cipher_suites"`
+}
+
+// antiBotCookie 会话保存的Cookie
+type antiBotCookie struct {
+	Value   string    `json:"value"`
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// antiBotSessionData 会话的持久化结构
+type antiBotSessionData struct {
+	Profile  antiBotProfile           `json:"profile"`
+	Cookies  map[string]antiBotCookie `json:"cookies"`
+	SolvedAt time.Time                `json:"solved_at,omitempty"`
+}
+
+// antiBotSession 单个主机的反爬会话：固定的浏览器特征、TLS指纹和Cookie
+type antiBotSession struct {
+	host    string
+	manager *antiBotManager
+	tls     *http.Transport
+	routers sync.Map // 代理作用范围 -> *ProxyRouter（使用会话的TLS配置）
+
+	mu         sync.Mutex
+	profile    antiBotProfile
+	cookies    map[string]antiBotCookie
+	solvedAt   time.Time
+	failedAt   time.Time
+	lastError  string
+	generation uint64
+
+	solveMu    sync.Mutex
+	challenges int64
+	solves     int64
+	failures   int64
+}
+
+// antiBotManager 所有主机的反爬会话
+type antiBotManager struct {
+	path string
+
+	mu        sync.Mutex
+	sessions  map[string]*antiBotSession
+	saveTimer *time.Timer
+}
+
+var (
+	globalAntiBotManager     *antiBotManager
+	globalAntiBotManagerOnce sync.Once
+)
+
+// getAntiBotManager 获取全局反爬会话管理器（首次调用时从缓存目录加载）
+func getAntiBotManager() *antiBotManager {
+	globalAntiBotManagerOnce.Do(func() {
+		dir := "./cache"
+		if config.AppConfig != nil && config.AppConfig.CachePath != "" {
+			dir = config.AppConfig.CachePath
+		}
+		globalAntiBotManager = &antiBotManager{
+			path:     filepath.Join(dir, antiBotSessionFileName),
+			sessions: make(map[string]*antiBotSession),
+		}
+		globalAntiBotManager.load()
+	})
+	return globalAntiBotManager
+}
+
+// load 从文件恢复会话
+func (m *antiBotManager) load() {
+	data, err := os.ReadFile(m.path)
+	if err != nil {
+		return
+	}
+	var stored map[string]antiBotSessionData
+	if err := json.Unmarshal(data, &stored); err != nil {
+		fmt.Printf("[反爬] 解析会话文件失败: %v\n", err)
+		return
+	}
+	now := time.Now()
+	dropped := false
+	for host, item := range stored {
+		if item.Profile.Headers.Get("User-Agent") == "" {
+			continue
+		}
+		session := m.newSession(host, item.Profile)
+		for name, cookie := range item.Cookies {
+			// 旧版本会把站点的其他Cookie（如登录态）写入会话文件，加载时丢弃并重写文件
+			if !isAntiBotCookie(name) {
+				dropped = true
+				continue
+			}
+			if cookie.Expires.IsZero() || cookie.Expires.After(now) {
+				session.cookies[name] = cookie
+			}
+		}
+		session.solvedAt = item.SolvedAt
+		m.sessions[host] = session
+	}
+	if dropped {
+		fmt.Printf("[反爬] 已从会话文件中移除非Cloudflare的Cookie\n")
+		m.scheduleSave()
+	}
+}
+
+// scheduleSave 延迟写入文件
+func (m *antiBotManager) scheduleSave() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.saveTimer != nil {
+		return
+	}
+	m.saveTimer = time.AfterFunc(antiBotSaveDelay, m.save)
+}
+
+// save 写入文件
+func (m *antiBotManager) save() {
+	m.mu.Lock()
+	m.saveTimer = nil
+	sessions := make([]*antiBotSession, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		sessions = append(sessions, session)
+	}
+	m.mu.Unlock()
+
+	stored := make(map[string]antiBotSessionData, len(sessions))
+	for _, session := range sessions {
+		session.mu.Lock()
+		cookies := make(map[string]antiBotCookie, len(session.cookies))
+		for name, cookie := range session.cookies {
+			cookies[name] = cookie
+		}
+		stored[session.host] = antiBotSessionData{
+			Profile:  session.profile,
+			Cookies:  cookies,
+			SolvedAt: session.solvedAt,
+		}
+		session.mu.Unlock()
+	}
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return
+	}
+	tmpPath := m.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return
+	}
+	os.Rename(tmpPath, m.path)
+}
+
+// newSession 创建会话，TLS传输按浏览器特征设置密码套件
+func (m *antiBotManager) newSession(host string, profile antiBotProfile) *antiBotSession {
+	tlsTransport := defaultBaseTransport.Clone()
+	if len(profile.CipherSuites) > 0 {
+		tlsTransport.TLSClientConfig.CipherSuites = profile.CipherSuites
+		tlsTransport.TLSClientConfig.MinVersion = 0x0303 // TLS 1.2
+	}
+	return &antiBotSession{
+		host:    host,
+		manager: m,
+		tls:     tlsTransport,
+		profile: profile,
+		cookies: make(map[string]antiBotCookie),
+	}
+}
+
+// session 获取主机的会话，不存在时生成新的桌面浏览器特征
+func (m *antiBotManager) session(host string) (*antiBotSession, error) {
+	host = strings.ToLower(host)
+	m.mu.Lock()
+	session, ok := m.sessions[host]
+	m.mu.Unlock()
+	if ok {
+		return session, nil
+	}
+
+	agent, err := useragent.New(useragent.Config{Desktop: true})
+	if err != nil {
+		return nil, fmt.Errorf("生成浏览器特征失败: %w", err)
+	}
+	headers := agent.Headers.Clone()
+	// 由传输自动协商压缩（手动设置后不会自动解压）
+	headers.Del("Accept-Encoding")
+	created := m.newSession(host, antiBotProfile{
+		Browser:      agent.Browser,
+		Headers:      headers,
+		CipherSuites: agent.CipherSuites,
+	})
+
+	m.mu.Lock()
+	if existing, ok := m.sessions[host]; ok {
+		m.mu.Unlock()
+		return existing, nil
+	}
+	m.sessions[host] = created
+	m.mu.Unlock()
+	m.scheduleSave()
+	return created, nil
+}
+
+// router 会话在作用范围下使用的代理路由，每个作用范围只创建一次
+func (s *antiBotSession) router(scope string) *ProxyRouter {
+	if router, ok := s.routers.Load(scope); ok {
+		return router.(*ProxyRouter)
+	}
+	router, _ := s.routers.LoadOrStore(scope, newTLSProxyRouter(scope, s.tls))
+	return router.(*ProxyRouter)
+}
+
+// isAntiBotCookie 是否为Cloudflare的反爬Cookie（cf_clearance、__cf_bm、_cfuvid等）
+func isAntiBotCookie(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasPrefix(name, "cf_") || strings.HasPrefix(name, "__cf") || strings.HasPrefix(name, "_cf")
+}
+
+// Cookies 会话中对应地址的Cloudflare Cookie
+func (s *antiBotSession) Cookies(u *url.URL) []*http.Cookie {
+	if !strings.EqualFold(u.Hostname(), s.host) {
+		return nil
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.cookies))
+	for name, cookie := range s.cookies {
+		if cookie.Expires.IsZero() || cookie.Expires.After(now) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	cookies := make([]*http.Cookie, 0, len(names))
+	for _, name := range names {
+		cookies = append(cookies, &http.Cookie{Name: name, Value: s.cookies[name].Value})
+	}
+	return cookies
+}
+
+// storeCookies 保存响应中本主机的Cloudflare Cookie
+// 会话由插件的所有客户端共享并以明文写入文件，其他Cookie（如登录态）留给调用方自己的cookiejar
+func (s *antiBotSession) storeCookies(u *url.URL, cookies []*http.Cookie) {
+	if len(cookies) == 0 || !strings.EqualFold(u.Hostname(), s.host) {
+		return
+	}
+	now := time.Now()
+	changed := false
+	s.mu.Lock()
+	for _, cookie := range cookies {
+		if !isAntiBotCookie(cookie.Name) {
+			continue
+		}
+		expires := cookie.Expires
+		if cookie.MaxAge > 0 {
+			expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+		}
+		if cookie.MaxAge < 0 || (!expires.IsZero() && expires.Before(now)) {
+			if _, ok := s.cookies[cookie.Name]; ok {
+				delete(s.cookies, cookie.Name)
+				changed = true
+			}
+			continue
+		}
+		if old, ok := s.cookies[cookie.Name]; ok && old.Value == cookie.Value && old.Expires.Equal(expires) {
+			continue
+		}
+		s.cookies[cookie.Name] = antiBotCookie{Value: cookie.Value, Expires: expires}
+		changed = true
+	}
+	s.mu.Unlock()
+	if changed {
+		s.manager.scheduleSave()
+	}
+}
+
+// prepare 为请求设置会话的浏览器特征和Cloudflare Cookie
+// 请求自带的Cookie（如用户登录态）保留，其中的Cloudflare Cookie替换为会话中的
+func (s *antiBotSession) prepare(req *http.Request) {
+	s.mu.Lock()
+	profile := s.profile
+	s.mu.Unlock()
+
+	// User-Agent必须与求解质询时一致，否则cf_clearance无效
+	req.Header.Set("User-Agent", profile.Headers.Get("User-Agent"))
+	for key, values := range profile.Headers {
+		if req.Header.Get(key) == "" {
+			req.Header[key] = values
+		}
+	}
+
+	stored := s.Cookies(req.URL)
+	if len(stored) == 0 {
+		return
+	}
+	own := req.Cookies()
+	req.Header.Del("Cookie")
+	for _, cookie := range own {
+		if !isAntiBotCookie(cookie.Name) {
+			req.AddCookie(cookie)
+		}
+	}
+	for _, cookie := range stored {
+		req.AddCookie(cookie)
+	}
+}
+
+// AntiBotTransport 为Cloudflare保护的站点维护按主机共享的会话
+// 同一主机的请求使用固定的User-Agent、TLS指纹和持久化的Cookie，遇到质询时自动求解后重试
+type AntiBotTransport struct {
+	scope string
+}
+
+// NewAntiBotTransport 创建反爬传输，scope为代理路由的作用范围（如 plugin:名称）
+func NewAntiBotTransport(scope string) *AntiBotTransport {
+	return &AntiBotTransport{scope: scope}
+}
+
+// 插件反爬传输（插件名 -> 传输）
+var antiBotPluginTransports sync.Map
+
+// NewAntiBotPluginTransport 为插件创建经过反爬会话、代理路由与出站限流的传输
+func NewAntiBotPluginTransport(pluginName string) http.RoundTripper {
+	transport, ok := antiBotPluginTransports.Load(pluginName)
+	if !ok {
+		transport, _ = antiBotPluginTransports.LoadOrStore(pluginName,
+			NewOutboundTransport(NewAntiBotTransport("plugin:"+pluginName)))
+	}
+	return transport.(http.RoundTripper)
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *AntiBotTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	session, err := getAntiBotManager().session(req.URL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	router := session.router(t.scope)
+
+	session.mu.Lock()
+	generation := session.generation
+	session.mu.Unlock()
+
+	resp, err := t.send(router, session, req)
+	if err != nil || !isChallenge(resp) {
+		return resp, err
+	}
+	atomic.AddInt64(&session.challenges, 1)
+
+	retryReq, ok := rewindRequest(req)
+	if !ok || req.Context().Err() != nil {
+		return resp, nil
+	}
+	if err := session.solve(router, req.Method, req.URL, generation); err != nil {
+		fmt.Printf("[反爬] %s 求解质询失败: %v\n", session.host, err)
+		return resp, nil
+	}
+	// 重试时重新设置Cookie，带上求解得到的cf_clearance
+	resp.Body.Close()
+	resp, err = t.send(router, session, retryReq)
+	return resp, err
+}
+
+// send 发送请求并保存响应中的Cookie
+func (t *AntiBotTransport) send(router http.RoundTripper, session *antiBotSession, req *http.Request) (*http.Response, error) {
+	outReq := req.Clone(req.Context())
+	session.prepare(outReq)
+	resp, err := router.RoundTrip(outReq)
+	if err != nil {
+		return nil, err
+	}
+	// 响应中的其他Cookie由调用方的cookiejar处理
+	session.storeCookies(req.URL, resp.Cookies())
+	return resp, nil
+}
+
+// isChallenge 判断响应是否为Cloudflare质询，读取的响应体会放回以便调用方继续读取
+func isChallenge(resp *http.Response) bool {
+	if resp.Header.Get("Cf-Mitigated") == "challenge" {
+		return true
+	}
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusServiceUnavailable {
+		return false
+	}
+	if !strings.HasPrefix(strings.ToLower(resp.Header.Get("Server")), "cloudflare") {
+		return false
+	}
+	head, err := io.ReadAll(io.LimitReader(resp.Body, antiBotMaxChallengeBody))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+	if err != nil {
+		return false
+	}
+	for _, marker := range antiBotChallengeMarkers {
+		if bytes.Contains(head, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// solve 求解质询，Cookie直接写入会话
+// 同一主机同时只求解一次，等待期间其他请求已完成求解时直接重试
+func (s *antiBotSession) solve(router http.RoundTripper, method string, target *url.URL, generation uint64) error {
+	s.solveMu.Lock()
+	defer s.solveMu.Unlock()
+
+	s.mu.Lock()
+	if s.generation != generation {
+		s.mu.Unlock()
+		return nil
+	}
+	if time.Since(s.failedAt) < antiBotSolveCooldown {
+		lastError := s.lastError
+		s.mu.Unlock()
+		return fmt.Errorf("冷却中，上次失败: %s", lastError)
+	}
+	profile := s.profile
+	s.mu.Unlock()
+
+	// 质询针对整个站点，非GET请求访问站点首页求解
+	solveURL := target.String()
+	if method != http.MethodGet {
+		solveURL = target.Scheme + "://" + target.Host + "/"
+	}
+	err := newAntiBotSolver(s, router, profile).solve(solveURL)
+
+	s.mu.Lock()
+	if err != nil {
+		s.failedAt = time.Now()
+		s.lastError = err.Error()
+		s.mu.Unlock()
+		atomic.AddInt64(&s.failures, 1)
+		return err
+	}
+	s.generation++
+	s.solvedAt = time.Now()
+	s.failedAt = time.Time{}
+	s.lastError = ""
+	s.mu.Unlock()
+	atomic.AddInt64(&s.solves, 1)
+	s.manager.scheduleSave()
+	fmt.Printf("[反爬] %s 质询已通过\n", s.host)
+	return nil
+}
+
+// AntiBotSessionStats 单个主机的反爬会话统计
+type AntiBotSessionStats struct {
+	Host       string    `json:"host"`
+	Browser    string    `json:"browser"`
+	UserAgent  string    `json:"user_agent"`
+	Cookies    int       `json:"cookies"`
+	Clearance  bool      `json:"clearance"`
+	SolvedAt   time.Time `json:"solved_at,omitempty"`
+	Challenges int64     `json:"challenges"`
+	Solves     int64     `json:"solves"`
+	Failures   int64     `json:"failures"`
+	LastError  string    `json:"last_error,omitempty"`
+}
+
+// GetAntiBotStats 获取所有主机的反爬会话统计
+func GetAntiBotStats() []AntiBotSessionStats {
+	m := getAntiBotManager()
+	m.mu.Lock()
+	sessions := make([]*antiBotSession, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		sessions = append(sessions, session)
+	}
+	m.mu.Unlock()
+
+	stats := make([]AntiBotSessionStats, 0, len(sessions))
+	for _, session := range sessions {
+		session.mu.Lock()
+		_, clearance := session.cookies["cf_clearance"]
+		stats = append(stats, AntiBotSessionStats{
+			Host:       session.host,
+			Browser:    session.profile.Browser,
+			UserAgent:  session.profile.Headers.Get("User-Agent"),
+			Cookies:    len(session.cookies),
+			Clearance:  clearance,
+			SolvedAt:   session.solvedAt,
+			Challenges: atomic.LoadInt64(&session.challenges),
+			Solves:     atomic.LoadInt64(&session.solves),
+			Failures:   atomic.LoadInt64(&session.failures),
+			LastError:  session.lastError,
+		})
+		session.mu.Unlock()
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Host < stats[j].Host })
+	return stats
+}
@@ -0,0 +1,214 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Advik-B/cloudscraper/lib/js"
+)
+
+// 经典质询要求页面加载后等待一段时间再提交答案
+const antiBotChallengeDelay = 4 * time.Second
+
+// Cloudflare质询页面的结构（与cloudscraper的识别规则一致）
+var (
+	antiBotV1Pattern      = regexp.MustCompile(`(?i)cdn-cgi/images/trace/jsch/`)
+	antiBotV2Pattern      = regexp.MustCompile(`(?i)/cdn-cgi/challenge-platform/`)
+	antiBotCaptchaPattern = regexp.MustCompile(`data-sitekey="([^"]+)"`)
+	antiBotFormPattern    = regexp.MustCompile(`<form class="challenge-form" id="challenge-form" action="(.+?)" method="POST">`)
+	antiBotVcPattern      = regexp.MustCompile(`name="jschl_vc" value="(\w+)"`)
+	antiBotPassPattern    = regexp.MustCompile(`name="pass" value="(.+?)"`)
+	antiBotRPattern       = regexp.MustCompile(`name="r" value="([^"]+)"`)
+	antiBotV1Script       = regexp.MustCompile(`setTimeout\(function\(\){\s+(var s,t,o,p,b,r,e,a,k,i,n,g,f.+?a\.value =.+?)\r?\n`)
+	antiBotV1Answer       = regexp.MustCompile(`a\.value = (.+?)\.toFixed\(10\)`)
+	antiBotV2Script       = regexp.MustCompile(`(?s)<script[^>]*>(.*?window\._cf_chl_opt.*?)</script>`)
+)
+
+// antiBotSolverLogger 丢弃JS引擎的调试输出
+var antiBotSolverLogger = log.New(io.Discard, "", 0)
+
+// antiBotSolveJar 求解质询时使用的Cookie：Cloudflare Cookie写入会话，其他Cookie只在本次求解中使用
+type antiBotSolveJar struct {
+	session   *antiBotSession
+	transient http.CookieJar
+}
+
+// SetCookies 实现 http.CookieJar
+func (j *antiBotSolveJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.session.storeCookies(u, cookies)
+	j.transient.SetCookies(u, cookies)
+}
+
+// Cookies 实现 http.CookieJar，Cloudflare Cookie以会话中的为准
+func (j *antiBotSolveJar) Cookies(u *url.URL) []*http.Cookie {
+	cookies := j.session.Cookies(u)
+	for _, cookie := range j.transient.Cookies(u) {
+		if !isAntiBotCookie(cookie.Name) {
+			cookies = append(cookies, cookie)
+		}
+	}
+	return cookies
+}
+
+// antiBotSolver 使用会话的代理路由、Cookie和浏览器特征求解一次质询
+// 页面请求由自己发送（跟随重定向，Cloudflare Cookie直接写入会话），只把质询脚本交给cloudscraper的JS引擎计算
+type antiBotSolver struct {
+	profile antiBotProfile
+	client  *http.Client
+	engine  *js.OttoEngine
+}
+
+func newAntiBotSolver(session *antiBotSession, router http.RoundTripper, profile antiBotProfile) *antiBotSolver {
+	transient, _ := cookiejar.New(nil)
+	return &antiBotSolver{
+		profile: profile,
+		client: &http.Client{
+			Jar:       &antiBotSolveJar{session: session, transient: transient},
+			Transport: router,
+			Timeout:   antiBotSolveTimeout,
+		},
+		engine: js.NewOttoEngine(),
+	}
+}
+
+// solve 访问目标页面，遇到质询时计算答案并提交，最后确认页面不再是质询
+func (v *antiBotSolver) solve(target string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), antiBotSolveTimeout)
+	defer cancel()
+
+	resp, body, err := v.fetch(ctx, http.MethodGet, target, nil, "")
+	if err != nil {
+		return err
+	}
+	if !isChallenge(resp) {
+		return nil
+	}
+
+	page := resp.Request.URL
+	submitURL, form, err := v.answer(ctx, page, body)
+	if err != nil {
+		return err
+	}
+	resp, _, err = v.fetch(ctx, http.MethodPost, submitURL, form, page.String())
+	if err != nil {
+		return err
+	}
+	if isChallenge(resp) {
+		return fmt.Errorf("质询未通过，状态码: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// fetch 发送带会话浏览器特征的请求（跟随重定向），响应体读入内存后放回
+func (v *antiBotSolver) fetch(ctx context.Context, method, target string, form url.Values, referer string) (*http.Response, string, error) {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, "", err
+	}
+	for key, values := range v.profile.Headers {
+		req.Header[key] = values
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if referer != "" {
+		req.Header.Set("Referer", referer)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, antiBotMaxChallengeBody))
+	resp.Body.Close()
+	if err != nil {
+		return nil, "", err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return resp, string(data), nil
+}
+
+// answer 计算质询答案，返回提交地址和表单
+func (v *antiBotSolver) answer(ctx context.Context, page *url.URL, body string) (string, url.Values, error) {
+	var answer string
+	var err error
+	switch {
+	case antiBotV2Pattern.MatchString(body):
+		scripts := antiBotV2Script.FindAllStringSubmatch(body, -1)
+		if len(scripts) == 0 {
+			return "", nil, errors.New("未找到质询脚本")
+		}
+		answer, err = v.engine.SolveV2Challenge(body, page.Host, scripts, antiBotSolverLogger)
+	case antiBotV1Pattern.MatchString(body):
+		select {
+		case <-time.After(antiBotChallengeDelay):
+		case <-ctx.Done():
+			return "", nil, ctx.Err()
+		}
+		answer, err = v.solveV1(body, page.Host)
+	case antiBotCaptchaPattern.MatchString(body):
+		return "", nil, errors.New("质询需要人工验证（Turnstile/验证码），无法自动求解")
+	default:
+		return "", nil, errors.New("无法识别的质询类型")
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("计算质询答案失败: %w", err)
+	}
+
+	formMatch := antiBotFormPattern.FindStringSubmatch(body)
+	if len(formMatch) < 2 {
+		return "", nil, errors.New("未找到质询表单")
+	}
+	submitURL, err := page.Parse(html.UnescapeString(formMatch[1]))
+	if err != nil {
+		return "", nil, fmt.Errorf("质询表单地址无效: %w", err)
+	}
+	passMatch := antiBotPassPattern.FindStringSubmatch(body)
+	if len(passMatch) < 2 {
+		return "", nil, errors.New("未找到质询表单的pass字段")
+	}
+
+	return submitURL.String(), url.Values{
+		"r":            {firstSubmatch(antiBotRPattern, body)},
+		"jschl_vc":     {firstSubmatch(antiBotVcPattern, body)},
+		"pass":         {passMatch[1]},
+		"jschl_answer": {answer},
+	}, nil
+}
+
+// firstSubmatch 返回第一个分组的匹配内容，未匹配时返回空字符串
+func firstSubmatch(pattern *regexp.Regexp, s string) string {
+	if match := pattern.FindStringSubmatch(s); len(match) > 1 {
+		return match[1]
+	}
+	return ""
+}
+
+// solveV1 计算经典质询的答案：提取页面脚本中的算式，在JS引擎中求值
+func (v *antiBotSolver) solveV1(body, host string) (string, error) {
+	script := antiBotV1Script.FindStringSubmatch(body)
+	if len(script) < 2 {
+		return "", errors.New("未找到经典质询脚本")
+	}
+	expression := antiBotV1Answer.FindStringSubmatch(script[1])
+	if len(expression) < 2 {
+		return "", errors.New("未找到经典质询算式")
+	}
+	return v.engine.Run(fmt.Sprintf("var t = %s;\nconsole.log((%s).toFixed(10));", strconv.Quote(host), expression[1]))
+}
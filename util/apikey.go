@@ -0,0 +1,275 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pansou/config"
+	"pansou/util/json"
+)
+
+// 角色定义（权限从高到低）
+const (
+	RoleAdmin    = "admin"    // 全部权限，包括API Key管理
+	RoleSearch   = "search"   // 搜索与只读接口
+	RoleReadonly = "readonly" // 只读接口（统计、验证等）
+)
+
+// API Key 明文前缀，用于区分 Bearer 中的 JWT 与 API Key
+const APIKeyPrefix = "psk_"
+
+// API Key 存储文件名（位于缓存目录下）
+const apiKeyFileName = "api_keys.json"
+
+var roleLevels = map[string]int{
+	RoleReadonly: 1,
+	RoleSearch:   2,
+	RoleAdmin:    3,
+}
+
+// IsValidRole 判断角色是否有效
+func IsValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// RoleAllows 判断角色是否满足要求的最低角色
+func RoleAllows(role, required string) bool {
+	return roleLevels[role] >= roleLevels[required]
+}
+
+// UserRole 获取登录用户的角色，未配置的用户视为search，管理员必须在 AUTH_USER_ROLES 中显式指定
+func UserRole(username string) string {
+	if config.AppConfig != nil {
+		if role, ok := config.AppConfig.AuthUserRoles[username]; ok && IsValidRole(role) {
+			return role
+		}
+	}
+	return RoleSearch
+}
+
+// APIKey API Key 记录（只保存哈希，不保存明文）
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Hash       string     `json:"hash"`
+	Role       string     `json:"role"`
+	Plugins    []string   `json:"plugins,omitempty"`    // 限定可搜索的插件，为空表示不限制
+	CacheOnly  bool       `json:"cache_only,omitempty"` // 只允许读取缓存结果
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// APIKeyInfo 对外展示的API Key信息（不含哈希）
+type APIKeyInfo struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Role       string     `json:"role"`
+	Plugins    []string   `json:"plugins,omitempty"`
+	CacheOnly  bool       `json:"cache_only"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Revoked    bool       `json:"revoked"`
+}
+
+// Info 转换为对外展示信息
+func (k *APIKey) Info() APIKeyInfo {
+	return APIKeyInfo{
+		ID:         k.ID,
+		Name:       k.Name,
+		Role:       k.Role,
+		Plugins:    k.Plugins,
+		CacheOnly:  k.CacheOnly,
+		CreatedAt:  k.CreatedAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+		Revoked:    k.RevokedAt != nil,
+	}
+}
+
+// AllowsPlugin 判断Key是否允许搜索指定插件
+func (k *APIKey) AllowsPlugin(name string) bool {
+	if len(k.Plugins) == 0 {
+		return true
+	}
+	for _, p := range k.Plugins {
+		if strings.EqualFold(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyStore API Key 存储
+type APIKeyStore struct {
+	mu     sync.RWMutex
+	path   string
+	keys   map[string]*APIKey // ID -> Key
+	byHash map[string]*APIKey // 哈希 -> Key
+}
+
+var (
+	globalAPIKeyStore     *APIKeyStore
+	globalAPIKeyStoreOnce sync.Once
+)
+
+// ErrAPIKeyNotFound API Key 不存在
+var ErrAPIKeyNotFound = errors.New("API Key不存在")
+
+// GetAPIKeyStore 获取全局API Key存储（首次调用时从缓存目录加载）
+func GetAPIKeyStore() *APIKeyStore {
+	globalAPIKeyStoreOnce.Do(func() {
+		dir := "./cache"
+		if config.AppConfig != nil && config.AppConfig.CachePath != "" {
+			dir = config.AppConfig.CachePath
+		}
+		globalAPIKeyStore = &APIKeyStore{
+			path:   filepath.Join(dir, apiKeyFileName),
+			keys:   make(map[string]*APIKey),
+			byHash: make(map[string]*APIKey),
+		}
+		globalAPIKeyStore.load()
+	})
+	return globalAPIKeyStore
+}
+
+// hashAPIKey 计算API Key哈希（Key为高熵随机串，使用SHA-256即可）
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// load 从文件加载API Key
+func (s *APIKeyStore) load() {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return
+	}
+	var keys []*APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return
+	}
+	for _, k := range keys {
+		s.keys[k.ID] = k
+		s.byHash[k.Hash] = k
+	}
+}
+
+// saveLocked 将API Key写入文件（调用方需持有写锁）
+func (s *APIKeyStore) saveLocked() error {
+	keys := make([]*APIKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+// Create 创建API Key，返回明文Key（仅此一次可见）
+func (s *APIKeyStore) Create(name, role string, plugins []string, cacheOnly bool) (string, *APIKey, error) {
+	if !IsValidRole(role) {
+		return "", nil, errors.New("无效的角色: " + role)
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	idBytes := make([]byte, 6)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", nil, err
+	}
+
+	plainKey := APIKeyPrefix + hex.EncodeToString(secret)
+	key := &APIKey{
+		ID:        hex.EncodeToString(idBytes),
+		Name:      name,
+		Hash:      hashAPIKey(plainKey),
+		Role:      role,
+		Plugins:   plugins,
+		CacheOnly: cacheOnly,
+		CreatedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key.ID] = key
+	s.byHash[key.Hash] = key
+	if err := s.saveLocked(); err != nil {
+		delete(s.keys, key.ID)
+		delete(s.byHash, key.Hash)
+		return "", nil, err
+	}
+	return plainKey, key, nil
+}
+
+// List 列出所有API Key（按创建时间排序）
+func (s *APIKeyStore) List() []APIKeyInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	infos := make([]APIKeyInfo, 0, len(s.keys))
+	for _, k := range s.keys {
+		infos = append(infos, k.Info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].CreatedAt.Before(infos[j].CreatedAt) })
+	return infos
+}
+
+// Revoke 吊销API Key
+func (s *APIKeyStore) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	if key.RevokedAt == nil {
+		now := time.Now()
+		key.RevokedAt = &now
+	}
+	return s.saveLocked()
+}
+
+// Validate 校验明文API Key，返回有效且未吊销的Key记录
+func (s *APIKeyStore) Validate(plainKey string) (*APIKey, bool) {
+	if !strings.HasPrefix(plainKey, APIKeyPrefix) {
+		return nil, false
+	}
+	hash := hashAPIKey(plainKey)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.byHash[hash]
+	if !ok || key.RevokedAt != nil {
+		return nil, false
+	}
+
+	// 最近使用时间只保存在内存中，下次写文件时一并落盘
+	now := time.Now()
+	key.LastUsedAt = &now
+	return key, true
+}
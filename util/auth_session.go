@@ -0,0 +1,308 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"pansou/config"
+	"pansou/util/json"
+)
+
+// 会话存储文件名（位于缓存目录下）
+const authSessionFileName = "auth_sessions.json"
+
+var (
+	// ErrRefreshTokenInvalid 刷新令牌无效或已过期
+	ErrRefreshTokenInvalid = errors.New("刷新令牌无效或已过期")
+	// ErrRefreshTokenReused 已轮换的刷新令牌被再次使用（可能被盗用），整个会话已吊销
+	ErrRefreshTokenReused = errors.New("刷新令牌已被使用，会话已吊销")
+)
+
+// AuthSession 登录会话，每个会话持有一个当前有效的刷新令牌
+type AuthSession struct {
+	ID          string    `json:"id"`
+	Username    string    `json:"username"`
+	RefreshHash string    `json:"refresh_hash"`
+	PasswordFP  string    `json:"password_fp"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// retiredRefresh 已轮换的刷新令牌（用于检测重放）
+type retiredRefresh struct {
+	SessionID string    `json:"session_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// sessionStoreData 持久化结构
+type sessionStoreData struct {
+	Sessions []*AuthSession           `json:"sessions"`
+	Retired  map[string]retiredRefresh `json:"retired"`
+	Revoked  map[string]time.Time      `json:"revoked"`
+}
+
+// SessionStore 登录会话存储：管理刷新令牌轮换与访问令牌吊销列表
+type SessionStore struct {
+	mu        sync.Mutex
+	path      string
+	sessions  map[string]*AuthSession   // 会话ID -> 会话
+	byRefresh map[string]string         // 刷新令牌哈希 -> 会话ID
+	retired   map[string]retiredRefresh // 已轮换的刷新令牌哈希
+	revoked   map[string]time.Time      // 已吊销的会话ID -> 可清理时间
+}
+
+var (
+	globalSessionStore     *SessionStore
+	globalSessionStoreOnce sync.Once
+)
+
+// GetSessionStore 获取全局会话存储（首次调用时从缓存目录加载）
+func GetSessionStore() *SessionStore {
+	globalSessionStoreOnce.Do(func() {
+		dir := "./cache"
+		if config.AppConfig != nil && config.AppConfig.CachePath != "" {
+			dir = config.AppConfig.CachePath
+		}
+		globalSessionStore = &SessionStore{
+			path:      filepath.Join(dir, authSessionFileName),
+			sessions:  make(map[string]*AuthSession),
+			byRefresh: make(map[string]string),
+			retired:   make(map[string]retiredRefresh),
+			revoked:   make(map[string]time.Time),
+		}
+		globalSessionStore.load()
+	})
+	return globalSessionStore
+}
+
+// UserPasswordFingerprint 当前配置中用户密码的指纹，密码变更后旧令牌随之失效
+func UserPasswordFingerprint(username string) string {
+	if config.AppConfig == nil {
+		return ""
+	}
+	return PasswordFingerprint(config.AppConfig.AuthUsers[username], config.AppConfig.AuthJWTSecret)
+}
+
+// randomToken 生成随机令牌
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashRefreshToken 计算刷新令牌哈希
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// accessTokenTTL 访问令牌有效期（吊销记录至少保留这么久）
+func accessTokenTTL() time.Duration {
+	if config.AppConfig == nil {
+		return 15 * time.Minute
+	}
+	return config.AppConfig.AuthAccessTokenExpiry
+}
+
+// load 从文件加载会话
+func (s *SessionStore) load() {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return
+	}
+	var stored sessionStoreData
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return
+	}
+	for _, session := range stored.Sessions {
+		s.sessions[session.ID] = session
+		s.byRefresh[session.RefreshHash] = session.ID
+	}
+	for hash, r := range stored.Retired {
+		s.retired[hash] = r
+	}
+	for id, until := range stored.Revoked {
+		s.revoked[id] = until
+	}
+	s.pruneLocked(time.Now())
+}
+
+// saveLocked 写入文件（调用方需持有锁）
+func (s *SessionStore) saveLocked() {
+	stored := sessionStoreData{
+		Sessions: make([]*AuthSession, 0, len(s.sessions)),
+		Retired:  s.retired,
+		Revoked:  s.revoked,
+	}
+	for _, session := range s.sessions {
+		stored.Sessions = append(stored.Sessions, session)
+	}
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return
+	}
+	os.Rename(tmpPath, s.path)
+}
+
+// pruneLocked 清理过期会话、轮换记录与吊销记录（调用方需持有锁）
+func (s *SessionStore) pruneLocked(now time.Time) {
+	for id, session := range s.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(s.byRefresh, session.RefreshHash)
+			delete(s.sessions, id)
+		}
+	}
+	for hash, r := range s.retired {
+		if now.After(r.ExpiresAt) {
+			delete(s.retired, hash)
+		}
+	}
+	for id, until := range s.revoked {
+		if now.After(until) {
+			delete(s.revoked, id)
+		}
+	}
+}
+
+// Create 创建会话，返回会话与刷新令牌明文
+func (s *SessionStore) Create(username string, ttl time.Duration) (*AuthSession, string, error) {
+	sessionID, err := randomToken(12)
+	if err != nil {
+		return nil, "", err
+	}
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	session := &AuthSession{
+		ID:          sessionID,
+		Username:    username,
+		RefreshHash: hashRefreshToken(refreshToken),
+		PasswordFP:  UserPasswordFingerprint(username),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(now)
+	s.sessions[session.ID] = session
+	s.byRefresh[session.RefreshHash] = session.ID
+	s.saveLocked()
+	return session, refreshToken, nil
+}
+
+// Rotate 用刷新令牌换取新的刷新令牌，旧令牌立即失效
+// 已轮换的令牌再次出现时视为泄露，吊销整个会话
+func (s *SessionStore) Rotate(refreshToken string) (*AuthSession, string, error) {
+	hash := hashRefreshToken(refreshToken)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.retired[hash]; ok {
+		s.revokeLocked(r.SessionID)
+		s.saveLocked()
+		return nil, "", ErrRefreshTokenReused
+	}
+
+	sessionID, ok := s.byRefresh[hash]
+	if !ok {
+		return nil, "", ErrRefreshTokenInvalid
+	}
+	session := s.sessions[sessionID]
+	if now.After(session.ExpiresAt) || session.PasswordFP != UserPasswordFingerprint(session.Username) {
+		// 会话过期或密码已变更
+		s.revokeLocked(sessionID)
+		s.saveLocked()
+		return nil, "", ErrRefreshTokenInvalid
+	}
+
+	newToken, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	delete(s.byRefresh, hash)
+	s.retired[hash] = retiredRefresh{SessionID: sessionID, ExpiresAt: session.ExpiresAt}
+	session.RefreshHash = hashRefreshToken(newToken)
+	s.byRefresh[session.RefreshHash] = sessionID
+	s.saveLocked()
+
+	copied := *session
+	return &copied, newToken, nil
+}
+
+// Revoke 吊销会话：删除刷新令牌，并将会话加入访问令牌吊销列表
+func (s *SessionStore) Revoke(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revokeLocked(sessionID)
+	s.saveLocked()
+}
+
+// RevokeByRefreshToken 通过刷新令牌吊销会话
+func (s *SessionStore) RevokeByRefreshToken(refreshToken string) {
+	hash := hashRefreshToken(refreshToken)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sessionID, ok := s.byRefresh[hash]; ok {
+		s.revokeLocked(sessionID)
+		s.saveLocked()
+	}
+}
+
+// RevokeUser 吊销用户的全部会话
+func (s *SessionStore) RevokeUser(username string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for id, session := range s.sessions {
+		if session.Username == username {
+			s.revokeLocked(id)
+			count++
+		}
+	}
+	if count > 0 {
+		s.saveLocked()
+	}
+	return count
+}
+
+// revokeLocked 吊销会话（调用方需持有锁）
+func (s *SessionStore) revokeLocked(sessionID string) {
+	if session, ok := s.sessions[sessionID]; ok {
+		delete(s.byRefresh, session.RefreshHash)
+		delete(s.sessions, sessionID)
+	}
+	// 吊销记录保留到该会话签发的访问令牌全部过期为止
+	s.revoked[sessionID] = time.Now().Add(accessTokenTTL())
+}
+
+// IsRevoked 判断会话是否已被吊销
+func (s *SessionStore) IsRevoked(sessionID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, revoked := s.revoked[sessionID]
+	return revoked
+}
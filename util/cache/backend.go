@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"pansou/util/sharedstore"
+)
+
+// 共享缓存层级
+const (
+	SharedTierL2  = "l2"  // 内存 -> 共享缓存（不使用本地磁盘缓存）
+	SharedTierL3  = "l3"  // 内存 -> 本地磁盘 -> 共享缓存
+	SharedTierOff = "off" // 不使用共享缓存
+)
+
+// CacheBackend 可插拔的缓存后端，用于在多个实例之间共享搜索结果
+type CacheBackend interface {
+	// Name 后端名称
+	Name() string
+	// Get 读取缓存数据及其最后修改时间
+	Get(key string) ([]byte, time.Time, bool, error)
+	// Set 写入缓存数据
+	Set(key string, data []byte, lastModified time.Time, ttl time.Duration) error
+	// Delete 删除缓存
+	Delete(key string) error
+}
+
+// storeCacheBackend 基于共享键值存储的缓存后端
+// 值格式：8字节最后修改时间（UnixNano，大端）+ 缓存数据
+type storeCacheBackend struct {
+	store sharedstore.Store
+}
+
+// NewStoreCacheBackend 创建基于共享存储的缓存后端
+func NewStoreCacheBackend(store sharedstore.Store) CacheBackend {
+	return &storeCacheBackend{store: store}
+}
+
+// Name 后端名称
+func (b *storeCacheBackend) Name() string {
+	return b.store.Name()
+}
+
+// storeKey 缓存项在共享存储中的键
+func (b *storeCacheBackend) storeKey(key string) string {
+	return "cache:" + key
+}
+
+// Get 读取缓存数据
+func (b *storeCacheBackend) Get(key string) ([]byte, time.Time, bool, error) {
+	value, ok, err := b.store.Get(b.storeKey(key))
+	if err != nil || !ok {
+		return nil, time.Time{}, false, err
+	}
+	if len(value) < 8 {
+		return nil, time.Time{}, false, errors.New("共享缓存数据格式无效")
+	}
+	lastModified := time.Unix(0, int64(binary.BigEndian.Uint64(value[:8])))
+	return value[8:], lastModified, true, nil
+}
+
+// Set 写入缓存数据
+func (b *storeCacheBackend) Set(key string, data []byte, lastModified time.Time, ttl time.Duration) error {
+	value := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint64(value[:8], uint64(lastModified.UnixNano()))
+	copy(value[8:], data)
+	return b.store.Set(b.storeKey(key), value, ttl)
+}
+
+// Delete 删除缓存
+func (b *storeCacheBackend) Delete(key string) error {
+	return b.store.Delete(b.storeKey(key))
+}
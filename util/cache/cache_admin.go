@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 缓存键类型
+const (
+	CacheKindTG     = "tg"
+	CacheKindPlugin = "plugin"
+)
+
+// 缓存层级
+const (
+	CacheLevelMemory = "memory"
+	CacheLevelDisk   = "disk"
+)
+
+// 键来源信息在注册表中的保留时长（缓存项本身会保存一份副本）
+const cacheKeyInfoRetention = time.Hour
+
+// CacheKeyInfo 缓存键的来源信息：缓存键本身是MD5，需要额外记录原始关键词和插件/频道集合
+type CacheKeyInfo struct {
+	Kind    string   `json:"kind"`
+	Keyword string   `json:"keyword"`
+	Sources []string `json:"sources,omitempty"` // 插件或频道列表，为空表示全部
+}
+
+// MatchesSource 判断缓存项是否包含指定插件或频道的结果（来源为空表示全部）
+func (i *CacheKeyInfo) MatchesSource(source string) bool {
+	if len(i.Sources) == 0 {
+		return true
+	}
+	for _, s := range i.Sources {
+		if strings.EqualFold(s, source) {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheKeyInfoEntry 注册表中的键来源信息
+type cacheKeyInfoEntry struct {
+	info     *CacheKeyInfo
+	lastSeen int64
+}
+
+// cacheKeyInfoRegistry 缓存键 -> 来源信息，生成缓存键时登记，写入缓存时随缓存项保存
+type cacheKeyInfoRegistry struct {
+	entries sync.Map
+}
+
+var keyInfoRegistry = &cacheKeyInfoRegistry{}
+
+func init() {
+	// 随全局清理任务定期清理长时间未使用的登记
+	registerForCleanup(keyInfoRegistry)
+}
+
+// registerCacheKeyInfo 登记缓存键的来源信息
+func registerCacheKeyInfo(key, kind, keyword string, sources []string) {
+	now := time.Now().UnixNano()
+	if value, ok := keyInfoRegistry.entries.Load(key); ok {
+		atomic.StoreInt64(&value.(*cacheKeyInfoEntry).lastSeen, now)
+		return
+	}
+
+	var sorted []string
+	if len(sources) > 0 {
+		sorted = make([]string, len(sources))
+		copy(sorted, sources)
+		sort.Strings(sorted)
+	}
+	keyInfoRegistry.entries.Store(key, &cacheKeyInfoEntry{
+		info: &CacheKeyInfo{
+			Kind:    kind,
+			Keyword: keyword,
+			Sources: sorted,
+		},
+		lastSeen: now,
+	})
+}
+
+// LookupCacheKeyInfo 查询缓存键的来源信息
+func LookupCacheKeyInfo(key string) *CacheKeyInfo {
+	if value, ok := keyInfoRegistry.entries.Load(key); ok {
+		return value.(*cacheKeyInfoEntry).info
+	}
+	return nil
+}
+
+// CleanExpired 清理长时间未使用的登记，符合cleanupTarget接口
+func (r *cacheKeyInfoRegistry) CleanExpired() {
+	cutoff := time.Now().Add(-cacheKeyInfoRetention).UnixNano()
+	r.entries.Range(func(key, value interface{}) bool {
+		if atomic.LoadInt64(&value.(*cacheKeyInfoEntry).lastSeen) < cutoff {
+			r.entries.Delete(key)
+		}
+		return true
+	})
+}
+
+// CacheEntryInfo 缓存项概要（不含数据）
+type CacheEntryInfo struct {
+	Key          string    `json:"key"`
+	Kind         string    `json:"kind,omitempty"`
+	Keyword      string    `json:"keyword,omitempty"`
+	Sources      []string  `json:"sources,omitempty"`
+	Levels       []string  `json:"levels"`
+	Size         int       `json:"size"`
+	Expiry       time.Time `json:"expiry"`
+	LastModified time.Time `json:"last_modified"`
+	Expired      bool      `json:"expired"`
+}
+
+// newCacheEntryInfo 创建缓存项概要
+func newCacheEntryInfo(key, level string, info *CacheKeyInfo, size int, expiry, lastModified time.Time, now time.Time) CacheEntryInfo {
+	entry := CacheEntryInfo{
+		Key:          key,
+		Levels:       []string{level},
+		Size:         size,
+		Expiry:       expiry,
+		LastModified: lastModified,
+		Expired:      now.After(expiry),
+	}
+	if info != nil {
+		entry.Kind = info.Kind
+		entry.Keyword = info.Keyword
+		entry.Sources = info.Sources
+	}
+	return entry
+}
+
+// CacheEntryFilter 缓存项筛选条件，各条件同时满足才匹配，全部为空时匹配所有项
+// 来源信息未知的缓存项（如升级前写入的磁盘缓存）只能按键匹配
+type CacheEntryFilter struct {
+	Key     string
+	Keyword string
+	Kind    string
+	Plugin  string
+	Channel string
+}
+
+// IsEmpty 是否未设置任何条件
+func (f CacheEntryFilter) IsEmpty() bool {
+	return f == CacheEntryFilter{}
+}
+
+// Matches 判断缓存项是否满足筛选条件
+func (f CacheEntryFilter) Matches(entry CacheEntryInfo) bool {
+	if f.Key != "" && entry.Key != f.Key {
+		return false
+	}
+	if f.Keyword != "" && entry.Keyword != strings.ToLower(strings.TrimSpace(f.Keyword)) {
+		return false
+	}
+	if f.Kind != "" && entry.Kind != f.Kind {
+		return false
+	}
+	info := CacheKeyInfo{Kind: entry.Kind, Sources: entry.Sources}
+	if f.Plugin != "" && (entry.Kind != CacheKindPlugin || !info.MatchesSource(f.Plugin)) {
+		return false
+	}
+	if f.Channel != "" && (entry.Kind != CacheKindTG || !info.MatchesSource(f.Channel)) {
+		return false
+	}
+	return true
+}
+
+// CacheLevelStats 单层缓存统计
+type CacheLevelStats struct {
+	Items        int   `json:"items"`
+	Expired      int   `json:"expired"`
+	SizeBytes    int64 `json:"size_bytes"`
+	MaxSizeBytes int64 `json:"max_size_bytes"`
+	Hits         int64 `json:"hits"`
+	Evictions    int64 `json:"evictions,omitempty"`
+}
+
+// SharedCacheStats 共享缓存层统计
+type SharedCacheStats struct {
+	Backend string `json:"backend"`
+	Tier    string `json:"tier"`
+	Hits    int64  `json:"hits"`
+	Errors  int64  `json:"errors"`
+}
+
+// TwoLevelCacheStats 两级缓存统计
+type TwoLevelCacheStats struct {
+	Memory  CacheLevelStats   `json:"memory"`
+	Disk    CacheLevelStats   `json:"disk"`
+	Shared  *SharedCacheStats `json:"shared,omitempty"`
+	Misses  int64             `json:"misses"`
+	HitRate float64           `json:"hit_rate"`
+}
+
+// sortCacheEntries 按最后修改时间倒序排列
+func sortCacheEntries(entries []CacheEntryInfo) {
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].LastModified.Equal(entries[j].LastModified) {
+			return entries[i].LastModified.After(entries[j].LastModified)
+		}
+		return entries[i].Key < entries[j].Key
+	})
+}
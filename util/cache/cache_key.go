@@ -57,7 +57,11 @@ func GenerateTGCacheKey(keyword string, channels []string) string {
 	// 生成TG搜索特定的缓存键
 	keyStr := fmt.Sprintf("tg:%s:%s", normalizedKeyword, channelsHash)
 	hash := md5.Sum([]byte(keyStr))
-	return hex.EncodeToString(hash[:])
+	key := hex.EncodeToString(hash[:])
+	
+	// 登记原始关键词和频道列表，供缓存管理接口查询
+	registerCacheKeyInfo(key, CacheKindTG, normalizedKeyword, channels)
+	return key
 }
 
 // GeneratePluginCacheKey 为插件搜索生成缓存键
@@ -71,7 +75,38 @@ func GeneratePluginCacheKey(keyword string, plugins []string) string {
 	// 生成插件搜索特定的缓存键
 	keyStr := fmt.Sprintf("plugin:%s:%s", normalizedKeyword, pluginsHash)
 	hash := md5.Sum([]byte(keyStr))
-	return hex.EncodeToString(hash[:])
+	key := hex.EncodeToString(hash[:])
+	
+	// 登记原始关键词和插件列表，供缓存管理接口查询
+	registerCacheKeyInfo(key, CacheKindPlugin, normalizedKeyword, plugins)
+	return key
+}
+
+// GenerateTGChannelCacheKey 为单个TG频道的搜索结果生成缓存键
+// 搜索请求按频道组合这些缓存项，不同频道组合之间可以共享
+func GenerateTGChannelCacheKey(keyword string, channel string) string {
+	normalizedKeyword := strings.ToLower(strings.TrimSpace(keyword))
+
+	keyStr := fmt.Sprintf("tg_channel:%s:%s", normalizedKeyword, channel)
+	hash := md5.Sum([]byte(keyStr))
+	key := hex.EncodeToString(hash[:])
+
+	registerCacheKeyInfo(key, CacheKindTG, normalizedKeyword, []string{channel})
+	return key
+}
+
+// GeneratePluginSourceCacheKey 为单个插件的搜索结果生成缓存键
+// 搜索请求按插件组合这些缓存项，异步插件后台完成时也只更新自己的缓存项
+func GeneratePluginSourceCacheKey(keyword string, pluginName string) string {
+	normalizedKeyword := strings.ToLower(strings.TrimSpace(keyword))
+	normalizedPlugin := strings.ToLower(pluginName)
+
+	keyStr := fmt.Sprintf("plugin_source:%s:%s", normalizedKeyword, normalizedPlugin)
+	hash := md5.Sum([]byte(keyStr))
+	key := hex.EncodeToString(hash[:])
+
+	registerCacheKeyInfo(key, CacheKindPlugin, normalizedKeyword, []string{normalizedPlugin})
+	return key
 }
 
 // GenerateCacheKey 根据所有影响搜索结果的参数生成缓存键
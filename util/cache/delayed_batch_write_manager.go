@@ -274,7 +274,7 @@ type DelayedBatchWriteManager struct {
 	mainCacheUpdater  func(string, []byte, time.Duration) error
 	
 	// 序列化器
-	serializer        *GobSerializer
+	serializer        Serializer
 	
 	// 初始化标志
 	initialized       int32
@@ -334,7 +334,7 @@ func NewDelayedBatchWriteManager() (*DelayedBatchWriteManager, error) {
 		stats: &WriteManagerStats{
 			WindowStart: time.Now(),
 		},
-		serializer: NewGobSerializer(),
+		serializer: NewVersionedSerializer(NewGobSerializer()),
 	}
 	
 	return manager, nil
@@ -508,7 +508,7 @@ func (m *DelayedBatchWriteManager) updateMemoryCache(op *CacheOperation) error {
 	// 如果有主缓存更新函数，立即更新内存层
 	if m.mainCacheUpdater != nil {
 		// 序列化数据
-		_, err := m.serializer.Serialize(op.Data)
+		_, err := m.serializer.Serialize(op.sourceEntry())
 		if err != nil {
 			return fmt.Errorf("内存缓存数据序列化失败: %v", err)
 		}
@@ -526,7 +526,7 @@ func (m *DelayedBatchWriteManager) immediateWriteToDisk(op *CacheOperation) erro
 	}
 	
 	// 序列化数据
-	data, err := m.serializer.Serialize(op.Data)
+	data, err := m.serializer.Serialize(op.sourceEntry())
 	if err != nil {
 		return fmt.Errorf("数据序列化失败: %v", err)
 	}
@@ -869,7 +869,7 @@ func (m *DelayedBatchWriteManager) batchWriteToDisk(operations []*CacheOperation
 	// 批量处理所有操作
 	for _, op := range operations {
 		// 序列化数据
-		data, err := m.serializer.Serialize(op.Data)
+		data, err := m.serializer.Serialize(op.sourceEntry())
 		if err != nil {
 			return fmt.Errorf("数据序列化失败: %v", err)
 		}
@@ -20,6 +20,19 @@ type diskCacheMetadata struct {
 	LastUsed    time.Time `json:"last_used"`
 	Size        int       `json:"size"`
 	LastModified time.Time `json:"last_modified"` // 添加最后修改时间字段
+	
+	// 缓存键来源信息（缓存键为MD5，需单独记录原始关键词和插件/频道集合）
+	Kind    string   `json:"kind,omitempty"`
+	Keyword string   `json:"keyword,omitempty"`
+	Sources []string `json:"sources,omitempty"`
+}
+
+// keyInfo 获取元数据中的缓存键来源信息
+func (m *diskCacheMetadata) keyInfo() *CacheKeyInfo {
+	if m.Kind == "" {
+		return nil
+	}
+	return &CacheKeyInfo{Kind: m.Kind, Keyword: m.Keyword, Sources: m.Sources}
 }
 
 // DiskCache 磁盘缓存
@@ -116,7 +129,11 @@ func (c *DiskCache) Set(key string, data []byte, ttl time.Duration) error {
 	defer c.mutex.Unlock()
 
 	// 如果已存在，先减去旧项的大小
+	info := LookupCacheKeyInfo(key)
 	if meta, exists := c.metadata[key]; exists {
+		if info == nil {
+			info = meta.keyInfo()
+		}
 		c.currSize -= int64(meta.Size)
 		// 删除旧文件
 		filename := c.getFilename(key)
@@ -154,6 +171,11 @@ func (c *DiskCache) Set(key string, data []byte, ttl time.Duration) error {
 		LastModified: now, // 设置最后修改时间
 		Size:        len(data),
 	}
+	if info != nil {
+		meta.Kind = info.Kind
+		meta.Keyword = info.Keyword
+		meta.Sources = info.Sources
+	}
 
 	// 保存元数据
 	if err := c.saveMetadata(key, meta); err != nil {
@@ -249,12 +271,13 @@ func (c *DiskCache) Has(key string) bool {
 	return true
 }
 
-// 清理过期项
-func (c *DiskCache) cleanExpired() {
+// 清理过期项，返回清理数量
+func (c *DiskCache) cleanExpired() int {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
 	now := time.Now()
+	purged := 0
 	for key, meta := range c.metadata {
 		if now.After(meta.Expiry) {
 			// 删除文件
@@ -264,9 +287,11 @@ func (c *DiskCache) cleanExpired() {
 				os.Remove(filepath.Join(c.path, filename+".meta"))
 				c.currSize -= int64(meta.Size)
 				delete(c.metadata, key)
+				purged++
 			}
 		}
 	}
+	return purged
 }
 
 // 驱逐策略 - LRU
@@ -360,4 +385,49 @@ func (c *DiskCache) GetLastModified(key string) (time.Time, bool) {
 	}
 
 	return meta.LastModified, true
-} 
\ No newline at end of file
+}
+
+// GetExpiry 获取缓存项的过期时间
+func (c *DiskCache) GetExpiry(key string) (time.Time, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	meta, exists := c.metadata[key]
+	if !exists {
+		return time.Time{}, false
+	}
+
+	return meta.Expiry, true
+}
+
+// Entries 获取所有缓存项概要（包括已过期但尚未清理的项）
+func (c *DiskCache) Entries() []CacheEntryInfo {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	now := time.Now()
+	entries := make([]CacheEntryInfo, 0, len(c.metadata))
+	for key, meta := range c.metadata {
+		entries = append(entries, newCacheEntryInfo(key, CacheLevelDisk, meta.keyInfo(), meta.Size, meta.Expiry, meta.LastModified, now))
+	}
+	return entries
+}
+
+// Stats 获取磁盘缓存统计
+func (c *DiskCache) Stats() CacheLevelStats {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	now := time.Now()
+	stats := CacheLevelStats{
+		Items:        len(c.metadata),
+		SizeBytes:    c.currSize,
+		MaxSizeBytes: int64(c.maxSizeMB) * 1024 * 1024,
+	}
+	for _, meta := range c.metadata {
+		if now.After(meta.Expiry) {
+			stats.Expired++
+		}
+	}
+	return stats
+}
@@ -3,17 +3,31 @@ package cache
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"pansou/config"
+	"pansou/util/sharedstore"
 )
 
 // EnhancedTwoLevelCache 改进的两级缓存
+// 配置共享存储后增加共享缓存层：作为L2时替代本地磁盘，作为L3时位于本地磁盘之后
 type EnhancedTwoLevelCache struct {
 	memory     *ShardedMemoryCache
 	disk       *ShardedDiskCache
 	mutex      sync.RWMutex
 	serializer Serializer
+	
+	// 共享缓存层（未配置时为nil）
+	shared     CacheBackend
+	sharedTier string
+	
+	// 命中统计
+	memoryHits   int64
+	diskHits     int64
+	sharedHits   int64
+	sharedErrors int64
+	misses       int64
 }
 
 // NewEnhancedTwoLevelCache 创建新的改进两级缓存
@@ -26,22 +40,63 @@ func NewEnhancedTwoLevelCache() (*EnhancedTwoLevelCache, error) {
 	memCache.StartCleanupTask()
 
 	// 创建优化的分片磁盘缓存，使用动态分片数量
-	diskCache, err := NewOptimizedShardedDiskCache(config.AppConfig.CachePath, config.AppConfig.CacheMaxSizeMB)
+	diskCache, err := NewShardedDiskCacheWithEngine(config.AppConfig.CachePath, config.AppConfig.CacheMaxSizeMB, config.AppConfig.CacheDiskEngine)
 	if err != nil {
 		return nil, err
 	}
 
-	// 创建序列化器
-	serializer := NewGobSerializer()
+	// 创建序列化器（带版本头，旧格式在读取时升级）
+	serializer := NewVersionedSerializer(NewGobSerializer())
 
-	// 设置内存缓存的磁盘缓存引用，用于LRU淘汰时的备份
-	memCache.SetDiskCacheReference(diskCache)
-
-	return &EnhancedTwoLevelCache{
+	c := &EnhancedTwoLevelCache{
 		memory:     memCache,
 		disk:       diskCache,
 		serializer: serializer,
-	}, nil
+	}
+
+	// 配置了共享存储时启用共享缓存层
+	if store := sharedstore.Default(); store != nil && config.AppConfig.SharedCacheTier != SharedTierOff {
+		c.SetSharedBackend(NewStoreCacheBackend(store), config.AppConfig.SharedCacheTier)
+		fmt.Printf("共享缓存已启用: %s (%s)\n", store.Name(), c.sharedTier)
+	}
+
+	// 设置内存缓存的磁盘缓存引用，用于LRU淘汰时的备份
+	if c.useDisk() {
+		memCache.SetDiskCacheReference(diskCache)
+	}
+
+	// 后台统计磁盘缓存中各格式版本的缓存项数量
+	if c.useDisk() {
+		go c.reportFormatVersions()
+	}
+
+	return c, nil
+}
+
+// SetSharedBackend 设置共享缓存后端及其层级（l2或l3）
+func (c *EnhancedTwoLevelCache) SetSharedBackend(backend CacheBackend, tier string) {
+	c.shared = backend
+	c.sharedTier = tier
+	if tier != SharedTierL2 {
+		c.sharedTier = SharedTierL3
+	}
+}
+
+// useDisk 是否使用本地磁盘缓存（共享缓存作为L2时不使用）
+func (c *EnhancedTwoLevelCache) useDisk() bool {
+	return c.shared == nil || c.sharedTier != SharedTierL2
+}
+
+// setShared 写入共享缓存，失败时只记录统计
+func (c *EnhancedTwoLevelCache) setShared(key string, data []byte, lastModified time.Time, ttl time.Duration) error {
+	if c.shared == nil {
+		return nil
+	}
+	err := c.shared.Set(key, data, lastModified, ttl)
+	if err != nil {
+		atomic.AddInt64(&c.sharedErrors, 1)
+	}
+	return err
 }
 
 // Set 设置缓存
@@ -50,12 +105,19 @@ func (c *EnhancedTwoLevelCache) Set(key string, data []byte, ttl time.Duration)
 	now := time.Now()
 	
 	// 先设置内存缓存（这是快速操作，直接在当前goroutine中执行）
+	changed := c.sourceChanged(key, data)
 	c.memory.SetWithTimestamp(key, data, ttl, now)
+	if changed {
+		notifySourceChange(key)
+	}
 	
-	// 异步设置磁盘缓存（这是IO操作，可能较慢）
+	// 异步设置磁盘缓存和共享缓存（这是IO操作，可能较慢）
 	go func(k string, d []byte, t time.Duration) {
 		// 使用独立的goroutine写入磁盘，避免阻塞调用者
-		_ = c.disk.Set(k, d, t)
+		if c.useDisk() {
+			_ = c.disk.Set(k, d, t)
+		}
+		_ = c.setShared(k, d, now, t)
 	}(key, data, ttl)
 	
 	return nil
@@ -66,7 +128,11 @@ func (c *EnhancedTwoLevelCache) SetMemoryOnly(key string, data []byte, ttl time.
 	now := time.Now()
 	
 	// 只更新内存缓存，不触发磁盘写入
+	changed := c.sourceChanged(key, data)
 	c.memory.SetWithTimestamp(key, data, ttl, now)
+	if changed {
+		notifySourceChange(key)
+	}
 	
 	return nil
 }
@@ -76,10 +142,20 @@ func (c *EnhancedTwoLevelCache) SetBothLevels(key string, data []byte, ttl time.
 	now := time.Now()
 	
 	// 同步更新内存缓存
+	changed := c.sourceChanged(key, data)
 	c.memory.SetWithTimestamp(key, data, ttl, now)
+	if changed {
+		notifySourceChange(key)
+	}
+	
+	// 同步更新共享缓存
+	sharedErr := c.setShared(key, data, now, ttl)
 	
 	// 同步更新磁盘缓存，确保数据立即写入
-	return c.disk.Set(key, data, ttl)
+	if c.useDisk() {
+		return c.disk.Set(key, data, ttl)
+	}
+	return sharedErr
 }
 
 // SetWithFinalFlag 根据结果状态选择更新策略
@@ -97,19 +173,40 @@ func (c *EnhancedTwoLevelCache) Get(key string) ([]byte, bool, error) {
 	// 检查内存缓存
 	data, _, memHit := c.memory.GetWithTimestamp(key)
 	if memHit {
+		atomic.AddInt64(&c.memoryHits, 1)
 		return data, true, nil
 	}
 
     // 尝试从磁盘读取数据
-	diskData, diskHit, diskErr := c.disk.Get(key)
-	if diskErr == nil && diskHit {
-		// 磁盘缓存命中，更新内存缓存
-		diskLastModified, _ := c.disk.GetLastModified(key)
-		ttl := time.Duration(config.AppConfig.CacheTTLMinutes) * time.Minute
-		c.memory.SetWithTimestamp(key, diskData, ttl, diskLastModified)
-		return diskData, true, nil
+	ttl := time.Duration(config.AppConfig.CacheTTLMinutes) * time.Minute
+	if c.useDisk() {
+		diskData, diskHit, diskErr := c.disk.Get(key)
+		if diskErr == nil && diskHit {
+			// 磁盘缓存命中，更新内存缓存
+			diskLastModified, _ := c.disk.GetLastModified(key)
+			c.memory.SetWithTimestamp(key, diskData, ttl, diskLastModified)
+			atomic.AddInt64(&c.diskHits, 1)
+			return diskData, true, nil
+		}
 	}
 	
+	// 尝试从共享缓存读取数据（其他实例写入的结果）
+	if c.shared != nil {
+		sharedData, lastModified, sharedHit, sharedErr := c.shared.Get(key)
+		if sharedErr != nil {
+			atomic.AddInt64(&c.sharedErrors, 1)
+		} else if sharedHit {
+			// 共享缓存命中，回填本地缓存
+			c.memory.SetWithTimestamp(key, sharedData, ttl, lastModified)
+			if c.useDisk() {
+				go c.disk.Set(key, sharedData, ttl)
+			}
+			atomic.AddInt64(&c.sharedHits, 1)
+			return sharedData, true, nil
+		}
+	}
+	
+	atomic.AddInt64(&c.misses, 1)
 	return nil, false, nil
 }
 
@@ -118,8 +215,17 @@ func (c *EnhancedTwoLevelCache) Delete(key string) error {
 	// 从内存缓存删除
 	c.memory.Delete(key)
 	
+	// 从共享缓存删除
+	if c.shared != nil {
+		if err := c.shared.Delete(key); err != nil {
+			atomic.AddInt64(&c.sharedErrors, 1)
+		}
+	}
+	
 	// 从磁盘缓存删除
-	return c.disk.Delete(key)
+	err := c.disk.Delete(key)
+	notifySourceChange(key)
+	return err
 }
 
 // Clear 清空所有缓存
@@ -128,7 +234,9 @@ func (c *EnhancedTwoLevelCache) Clear() error {
 	c.memory.Clear()
 	
 	// 清空磁盘缓存
-	return c.disk.Clear()
+	err := c.disk.Clear()
+	touchAllSourceVersions()
+	return err
 }
 
 // 设置序列化器
@@ -153,6 +261,14 @@ func (c *EnhancedTwoLevelCache) FlushMemoryToDisk() error {
 	var lastErr error
 	
 	for key, item := range allItems {
+		// 共享缓存作为L2时写入共享缓存
+		if !c.useDisk() {
+			if err := c.setShared(key, item.Data, time.Now(), item.TTL); err != nil {
+				lastErr = err
+			}
+			continue
+		}
+		
 		// 同步写入到磁盘缓存
 		if err := c.disk.Set(key, item.Data, item.TTL); err != nil {
 			fmt.Printf("[内存同步] 同步失败: %s -> %v\n", key, err)
@@ -162,4 +278,91 @@ func (c *EnhancedTwoLevelCache) FlushMemoryToDisk() error {
 	}
 	
 	return lastErr
-} 
\ No newline at end of file
+}
+
+// Entries 获取满足筛选条件的缓存项概要，同一个键在两级中的记录合并为一项
+func (c *EnhancedTwoLevelCache) Entries(filter CacheEntryFilter) []CacheEntryInfo {
+	merged := make(map[string]*CacheEntryInfo)
+	all := append(c.memory.Entries(), c.disk.Entries()...)
+	
+	for i := range all {
+		entry := all[i]
+		existing, ok := merged[entry.Key]
+		if !ok {
+			merged[entry.Key] = &entry
+			continue
+		}
+		existing.Levels = append(existing.Levels, entry.Levels...)
+		// 任一层级记录了来源信息即可
+		if existing.Kind == "" && entry.Kind != "" {
+			existing.Kind = entry.Kind
+			existing.Keyword = entry.Keyword
+			existing.Sources = entry.Sources
+		}
+		// 以较新的一份为准
+		if entry.LastModified.After(existing.LastModified) {
+			existing.Size = entry.Size
+			existing.LastModified = entry.LastModified
+		}
+		if entry.Expiry.After(existing.Expiry) {
+			existing.Expiry = entry.Expiry
+			existing.Expired = entry.Expired
+		}
+	}
+	
+	entries := make([]CacheEntryInfo, 0, len(merged))
+	for _, entry := range merged {
+		if filter.Matches(*entry) {
+			entries = append(entries, *entry)
+		}
+	}
+	sortCacheEntries(entries)
+	return entries
+}
+
+// Invalidate 删除满足筛选条件的缓存项（内存和磁盘），返回删除的键数量
+func (c *EnhancedTwoLevelCache) Invalidate(filter CacheEntryFilter) int {
+	if filter.IsEmpty() {
+		return 0
+	}
+	
+	entries := c.Entries(filter)
+	for _, entry := range entries {
+		if err := c.Delete(entry.Key); err != nil {
+			fmt.Printf("[缓存管理] 删除缓存失败: %s -> %v\n", entry.Key, err)
+		}
+	}
+	return len(entries)
+}
+
+// PurgeExpired 立即清理两级缓存中的过期项
+func (c *EnhancedTwoLevelCache) PurgeExpired() (memoryPurged int, diskPurged int) {
+	return c.memory.PurgeExpired(), c.disk.PurgeExpired()
+}
+
+// Stats 获取两级缓存统计
+func (c *EnhancedTwoLevelCache) Stats() TwoLevelCacheStats {
+	stats := TwoLevelCacheStats{
+		Memory: c.memory.Stats(),
+		Disk:   c.disk.Stats(),
+		Misses: atomic.LoadInt64(&c.misses),
+	}
+	stats.Memory.Hits = atomic.LoadInt64(&c.memoryHits)
+	stats.Disk.Hits = atomic.LoadInt64(&c.diskHits)
+	hits := stats.Memory.Hits + stats.Disk.Hits
+	
+	if c.shared != nil {
+		stats.Shared = &SharedCacheStats{
+			Backend: c.shared.Name(),
+			Tier:    c.sharedTier,
+			Hits:    atomic.LoadInt64(&c.sharedHits),
+			Errors:  atomic.LoadInt64(&c.sharedErrors),
+		}
+		hits += stats.Shared.Hits
+	}
+	
+	if total := hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(hits) / float64(total)
+	}
+	return stats
+}
@@ -0,0 +1,300 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"pansou/config"
+)
+
+// 缓存值格式：
+//
+//	魔数(3, "PSC") | 格式版本(1) | 序列化器ID(1) | 标志(1) | 创建时间(8, UnixNano大端) | 载荷
+//
+// 没有魔数的数据视为格式版本0，即引入版本头之前写入的旧格式
+const (
+	// CacheFormatVersion 当前缓存格式版本
+	// 修改缓存的数据结构（如model.SearchResult、SourceCacheEntry）且旧数据无法正确解码时递增，
+	// 并通过RegisterCacheUpgrader注册旧版本的升级函数，未注册的旧版本在读取时直接失效
+	CacheFormatVersion uint8 = 1
+	// LegacyCacheFormatVersion 没有版本头的旧格式
+	LegacyCacheFormatVersion uint8 = 0
+)
+
+// 序列化器ID
+const (
+	SerializerUnknown uint8 = 0
+	SerializerGob     uint8 = 1
+	SerializerJSON    uint8 = 2
+)
+
+const (
+	envelopeHeaderSize = 14
+	envelopeFlagGzip   = 1 << 0
+)
+
+var envelopeMagic = []byte("PSC")
+
+// CacheEnvelope 缓存值的版本头
+type CacheEnvelope struct {
+	Version      uint8
+	SerializerID uint8
+	Compressed   bool
+	CreatedAt    time.Time
+}
+
+// CacheVersionError 缓存格式版本不受支持（比当前版本新，或旧版本没有注册升级函数）
+type CacheVersionError struct {
+	Version uint8
+}
+
+func (e *CacheVersionError) Error() string {
+	return fmt.Sprintf("缓存格式版本 v%d 不受支持（当前版本 v%d）", e.Version, CacheFormatVersion)
+}
+
+// CacheUpgrader 将旧版本的缓存载荷解码为当前版本的数据结构
+type CacheUpgrader func(payload []byte, serializer Serializer, v interface{}) error
+
+// cacheUpgraders 旧版本 -> 升级函数
+var cacheUpgraders = map[uint8]CacheUpgrader{
+	// 版本0与版本1的载荷相同，只是没有版本头
+	LegacyCacheFormatVersion: func(payload []byte, serializer Serializer, v interface{}) error {
+		return serializer.Deserialize(payload, v)
+	},
+}
+
+// RegisterCacheUpgrader 注册旧版本缓存的升级函数（应在init中调用）
+func RegisterCacheUpgrader(version uint8, upgrader CacheUpgrader) {
+	cacheUpgraders[version] = upgrader
+}
+
+// ParseEnvelope 解析缓存值的版本头，返回头信息和载荷；没有版本头时返回旧格式版本和原始数据
+func ParseEnvelope(data []byte) (CacheEnvelope, []byte) {
+	if len(data) < envelopeHeaderSize || !bytes.Equal(data[:3], envelopeMagic) {
+		return CacheEnvelope{Version: LegacyCacheFormatVersion}, data
+	}
+	env := CacheEnvelope{
+		Version:      data[3],
+		SerializerID: data[4],
+		Compressed:   data[5]&envelopeFlagGzip != 0,
+		CreatedAt:    time.Unix(0, int64(binary.BigEndian.Uint64(data[6:14]))),
+	}
+	return env, data[envelopeHeaderSize:]
+}
+
+// cacheCompressMinSize 缓存值压缩阈值（0表示不压缩）
+func cacheCompressMinSize() int {
+	if config.AppConfig == nil {
+		return 0
+	}
+	return config.AppConfig.CacheCompressMinSize
+}
+
+// VersionedSerializer 带版本头的序列化器：写入时添加版本头（可选gzip压缩），读取时校验版本并升级旧格式
+type VersionedSerializer struct {
+	inner Serializer
+	id    uint8
+}
+
+// NewVersionedSerializer 创建带版本头的序列化器
+func NewVersionedSerializer(inner Serializer) *VersionedSerializer {
+	return &VersionedSerializer{
+		inner: inner,
+		id:    serializerID(inner),
+	}
+}
+
+// serializerID 序列化器对应的ID
+func serializerID(s Serializer) uint8 {
+	switch s.(type) {
+	case *GobSerializer:
+		return SerializerGob
+	case *JSONSerializer:
+		return SerializerJSON
+	default:
+		return SerializerUnknown
+	}
+}
+
+// serializerFor 按ID选择解码载荷的序列化器（ID未知时使用当前序列化器）
+func (s *VersionedSerializer) serializerFor(id uint8) Serializer {
+	if id == s.id || id == SerializerUnknown {
+		return s.inner
+	}
+	switch id {
+	case SerializerGob:
+		return NewGobSerializer()
+	case SerializerJSON:
+		return NewJSONSerializer()
+	default:
+		return s.inner
+	}
+}
+
+// Serialize 序列化数据并添加版本头
+func (s *VersionedSerializer) Serialize(v interface{}) ([]byte, error) {
+	payload, err := s.inner.Serialize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var flags uint8
+	if minSize := cacheCompressMinSize(); minSize > 0 && len(payload) >= minSize {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(payload); err == nil && writer.Close() == nil && buf.Len() < len(payload) {
+			payload = buf.Bytes()
+			flags |= envelopeFlagGzip
+		}
+	}
+
+	data := make([]byte, envelopeHeaderSize+len(payload))
+	copy(data, envelopeMagic)
+	data[3] = CacheFormatVersion
+	data[4] = s.id
+	data[5] = flags
+	binary.BigEndian.PutUint64(data[6:14], uint64(time.Now().UnixNano()))
+	copy(data[envelopeHeaderSize:], payload)
+	return data, nil
+}
+
+// Deserialize 反序列化数据
+func (s *VersionedSerializer) Deserialize(data []byte, v interface{}) error {
+	_, err := s.DeserializeVersioned(data, v)
+	return err
+}
+
+// DeserializeVersioned 反序列化数据并返回版本头
+// 旧版本数据通过注册的升级函数解码，调用方可根据返回的版本决定是否重新写入；
+// 不受支持的版本返回CacheVersionError
+func (s *VersionedSerializer) DeserializeVersioned(data []byte, v interface{}) (CacheEnvelope, error) {
+	env, payload := ParseEnvelope(data)
+
+	if env.Compressed {
+		reader, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return env, err
+		}
+		payload, err = ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return env, err
+		}
+	}
+
+	serializer := s.serializerFor(env.SerializerID)
+	if env.Version == CacheFormatVersion {
+		return env, serializer.Deserialize(payload, v)
+	}
+	upgrader, ok := cacheUpgraders[env.Version]
+	if !ok || env.Version > CacheFormatVersion {
+		return env, &CacheVersionError{Version: env.Version}
+	}
+	return env, upgrader(payload, serializer, v)
+}
+
+// GetDecoded 读取并反序列化缓存项
+// 旧版本的缓存项解码后按当前版本重新写入；无法解码或版本不受支持的缓存项直接删除，视为未命中
+func (c *EnhancedTwoLevelCache) GetDecoded(key string, v interface{}) (bool, error) {
+	data, hit, err := c.Get(key)
+	if err != nil || !hit {
+		return false, err
+	}
+
+	versioned, ok := c.serializer.(*VersionedSerializer)
+	if !ok {
+		return true, c.serializer.Deserialize(data, v)
+	}
+
+	env, err := versioned.DeserializeVersioned(data, v)
+	if err != nil {
+		c.Delete(key)
+		fmt.Printf("[缓存] 缓存项无法解码，已删除: %s | 格式版本: v%d | 错误: %v\n", key, env.Version, err)
+		return false, nil
+	}
+
+	if env.Version < CacheFormatVersion {
+		// 延迟升级：按当前版本重新写入，保留缓存项剩余的有效期
+		if ttl, ok := c.remainingTTL(key); ok {
+			if upgraded, err := c.serializer.Serialize(v); err == nil {
+				go c.SetBothLevels(key, upgraded, ttl)
+			}
+		}
+	}
+	return true, nil
+}
+
+// remainingTTL 缓存项剩余的有效期（先查内存缓存，再查磁盘缓存），未找到或已过期时返回false
+func (c *EnhancedTwoLevelCache) remainingTTL(key string) (time.Duration, bool) {
+	expiry, ok := c.memory.GetExpiry(key)
+	if !ok && c.useDisk() {
+		expiry, ok = c.disk.GetExpiry(key)
+	}
+	if !ok {
+		return 0, false
+	}
+	ttl := time.Until(expiry)
+	return ttl, ttl > 0
+}
+
+// formatVersionSampleSize 启动时统计格式版本抽样读取的缓存项数
+const formatVersionSampleSize = 200
+
+// FormatVersionReport 抽样统计磁盘缓存中各格式版本的缓存项数量（键为 v<版本>）
+// 最多读取sampleSize个未过期的缓存项（<=0表示读取全部），同时返回未过期缓存项的总数
+func (c *EnhancedTwoLevelCache) FormatVersionReport(sampleSize int) (map[string]int, int) {
+	report := make(map[string]int)
+	total, sampled := 0, 0
+	for _, entry := range c.disk.Entries() {
+		if entry.Expired {
+			continue
+		}
+		total++
+		if sampleSize > 0 && sampled >= sampleSize {
+			continue
+		}
+		data, hit, err := c.disk.Get(entry.Key)
+		if err != nil || !hit {
+			continue
+		}
+		sampled++
+		env, _ := ParseEnvelope(data)
+		report[fmt.Sprintf("v%d", env.Version)]++
+	}
+	return report, total
+}
+
+// reportFormatVersions 启动时输出缓存格式版本的抽样统计
+func (c *EnhancedTwoLevelCache) reportFormatVersions() {
+	report, total := c.FormatVersionReport(formatVersionSampleSize)
+	if len(report) == 0 {
+		return
+	}
+
+	versions := make([]string, 0, len(report))
+	for version := range report {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	parts := make([]string, 0, len(versions))
+	sampled, outdated := 0, 0
+	for _, version := range versions {
+		parts = append(parts, fmt.Sprintf("%s=%d", version, report[version]))
+		sampled += report[version]
+		if version != fmt.Sprintf("v%d", CacheFormatVersion) {
+			outdated += report[version]
+		}
+	}
+	fmt.Printf("[缓存] 格式版本统计（抽样 %d/%d 项）: %s（当前版本 v%d，旧版本的缓存项将在读取时升级或失效）\n",
+		sampled, total, strings.Join(parts, ", "), CacheFormatVersion)
+	if outdated > 0 {
+		fmt.Printf("[缓存] 抽样中有 %d 项为旧版本格式\n", outdated)
+	}
+}
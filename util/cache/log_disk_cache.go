@@ -0,0 +1,744 @@
+package cache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"pansou/util/json"
+)
+
+// 日志存储目录名（位于缓存目录下）
+const logStoreDirName = "logstore"
+
+// 日志文件格式：文件头 + 若干条记录
+// 记录：CRC32C(4) | 类型(1) | 键长度(2) | 元数据长度(2) | 数据长度(4) | 过期时间(8) | 最后修改时间(8) | 键 | 元数据 | 数据
+// CRC覆盖类型字段之后的全部内容。恢复时遇到校验失败的记录会向后查找下一条有效记录继续扫描，
+// 只有文件末尾之后再无有效记录的部分（写入中断留下的不完整尾部）才会被截断
+const (
+	logFileMagic     = "PSCLOG01"
+	logHeaderSize    = 29
+	logRecordPut     = byte(1)
+	logRecordDelete  = byte(2)
+	logMaxValueSize  = 256 << 20
+	logCompactMinLen = 4 << 20 // 文件小于该大小时不压缩
+	logResyncChunk   = 1 << 20 // 查找下一条有效记录时每次读取的大小
+)
+
+// logMinTimestamp 记录时间戳的下限（2020-01-01），早于该时间的记录头视为无效
+var logMinTimestamp = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+
+var logCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrLogRecordCorrupted 日志记录校验失败
+var ErrLogRecordCorrupted = errors.New("缓存日志记录校验失败")
+
+// logIndexEntry 内存索引项
+type logIndexEntry struct {
+	offset       int64 // 记录在文件中的起始位置
+	length       int64 // 记录总长度
+	size         int   // 数据长度
+	expiry       time.Time
+	lastModified time.Time
+	lastUsed     int64
+	info         *CacheKeyInfo
+}
+
+// LogDiskCache 基于追加写日志的磁盘缓存分片
+// 所有写入追加到单个文件，内存中保存键到记录位置的索引，无效记录由定期压缩清除
+type LogDiskCache struct {
+	path     string
+	maxSize  int64
+	file     *os.File
+	fileSize int64
+	liveLen  int64 // 有效记录的总长度
+	dataSize int64 // 有效数据的总大小
+	index    map[string]*logIndexEntry
+	mutex    sync.RWMutex
+
+	compactMutex sync.Mutex // 保证同时只有一个压缩任务
+	generation   int64      // 清空缓存时递增，压缩期间被清空则放弃本次压缩
+
+	compacting int32
+	corrupted  int64 // 发现的损坏记录数
+	compacted  int64 // 压缩次数
+}
+
+// NewLogDiskCache 打开或创建日志磁盘缓存，并从日志恢复索引
+func NewLogDiskCache(path string, maxSizeMB int) (*LogDiskCache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	// 清理上次压缩中断留下的临时文件
+	os.Remove(path + ".compact")
+
+	c := &LogDiskCache{
+		path:    path,
+		maxSize: int64(maxSizeMB) * 1024 * 1024,
+		index:   make(map[string]*logIndexEntry),
+	}
+	if err := c.open(); err != nil {
+		return nil, err
+	}
+
+	go c.startCleanupTask()
+	return c, nil
+}
+
+// existingLogShardCount 已有日志分片文件的数量（按最大分片编号计算）
+func existingLogShardCount(dir string) int {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasPrefix(name, "shard_") || !strings.HasSuffix(name, ".log") {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "shard_"), ".log"))
+		if err == nil && n+1 > count {
+			count = n + 1
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return nextPowerOfTwoDisk(count)
+}
+
+// open 打开日志文件并恢复索引
+func (c *LogDiskCache) open() error {
+	file, err := os.OpenFile(c.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	magic := make([]byte, len(logFileMagic))
+	n, err := io.ReadFull(file, magic)
+	if n == 0 {
+		// 新文件，写入文件头
+		if _, err := file.WriteAt([]byte(logFileMagic), 0); err != nil {
+			file.Close()
+			return err
+		}
+		c.file = file
+		c.fileSize = int64(len(logFileMagic))
+		return nil
+	}
+	if err != nil || string(magic) != logFileMagic {
+		// 无法识别的文件，保留备份后重新创建
+		file.Close()
+		backup := c.path + ".bad"
+		os.Rename(c.path, backup)
+		fmt.Printf("[日志缓存] 无法识别的缓存文件，已重命名为 %s\n", backup)
+		return c.open()
+	}
+
+	c.file = file
+	return c.recover()
+}
+
+// recover 顺序扫描日志重建索引
+// 遇到损坏的记录时跳到下一条有效记录继续扫描，损坏的部分留待压缩时清除；
+// 之后再无有效记录时视为写入中断的尾部，截断到最后一条有效记录的末尾
+func (c *LogDiskCache) recover() error {
+	stat, err := c.file.Stat()
+	if err != nil {
+		return err
+	}
+	size := stat.Size()
+	now := time.Now()
+
+	offset := int64(len(logFileMagic))
+	validEnd := offset
+	skipped := int64(0)
+	for offset < size {
+		offset = c.scanRecords(offset, size, now)
+		validEnd = offset
+		if offset >= size {
+			break
+		}
+
+		next, found := c.findNextRecord(offset+1, size)
+		if !found {
+			break
+		}
+		c.corrupted++
+		skipped += next - offset
+		offset = next
+	}
+
+	if skipped > 0 {
+		fmt.Printf("[日志缓存] %s 跳过 %d 字节损坏的记录\n", filepath.Base(c.path), skipped)
+	}
+	// 截断不完整的尾部
+	if size > validEnd {
+		c.corrupted++
+		fmt.Printf("[日志缓存] %s 存在不完整的尾部记录，已截断 %d 字节\n", filepath.Base(c.path), size-validEnd)
+		if err := c.file.Truncate(validEnd); err != nil {
+			return err
+		}
+	}
+	c.fileSize = validEnd
+	return nil
+}
+
+// scanRecords 从offset开始顺序读取有效记录并更新索引，返回第一条无效记录的位置（全部有效时返回size）
+func (c *LogDiskCache) scanRecords(offset, size int64, now time.Time) int64 {
+	reader := bufio.NewReaderSize(io.NewSectionReader(c.file, offset, size-offset), 256*1024)
+	header := make([]byte, logHeaderSize)
+	for offset < size {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return offset
+		}
+		bodyLen, ok := logRecordBodyLen(header, size-offset)
+		if !ok {
+			return offset
+		}
+		body := make([]byte, bodyLen)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			return offset
+		}
+		if !logRecordValid(header, body) {
+			return offset
+		}
+		c.applyRecord(offset, header, body, now)
+		offset += int64(logHeaderSize + bodyLen)
+	}
+	return offset
+}
+
+// findNextRecord 从from开始逐字节查找下一条完整且校验通过的记录
+// 先用类型、长度和时间戳排除绝大多数位置，只对可能的记录头读取记录体并校验CRC
+func (c *LogDiskCache) findNextRecord(from, size int64) (int64, bool) {
+	buf := make([]byte, logResyncChunk+logHeaderSize)
+	var body []byte
+	for base := from; base+logHeaderSize <= size; base += logResyncChunk {
+		n, err := c.file.ReadAt(buf, base)
+		if err != nil && err != io.EOF {
+			return 0, false
+		}
+		for i := 0; i+logHeaderSize <= n && i < logResyncChunk; i++ {
+			header := buf[i : i+logHeaderSize]
+			pos := base + int64(i)
+			bodyLen, ok := logRecordBodyLen(header, size-pos)
+			if !ok || !logRecordTimesPlausible(header) {
+				continue
+			}
+			if cap(body) < bodyLen {
+				body = make([]byte, bodyLen)
+			}
+			body = body[:bodyLen]
+			if _, err := c.file.ReadAt(body, pos+logHeaderSize); err != nil {
+				continue
+			}
+			if logRecordValid(header, body) {
+				return pos, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// logRecordBodyLen 根据记录头计算记录体长度，类型未知或超出剩余长度时返回false
+func logRecordBodyLen(header []byte, remaining int64) (int, bool) {
+	if header[4] != logRecordPut && header[4] != logRecordDelete {
+		return 0, false
+	}
+	keyLen := int(binary.BigEndian.Uint16(header[5:]))
+	metaLen := int(binary.BigEndian.Uint16(header[7:]))
+	valueLen := int(binary.BigEndian.Uint32(header[9:]))
+	if valueLen > logMaxValueSize {
+		return 0, false
+	}
+	bodyLen := keyLen + metaLen + valueLen
+	if int64(logHeaderSize+bodyLen) > remaining {
+		return 0, false
+	}
+	return bodyLen, true
+}
+
+// logRecordTimesPlausible 记录头中的最后修改时间是否在合理范围内（用于查找下一条记录时快速排除）
+func logRecordTimesPlausible(header []byte) bool {
+	lastModified := int64(binary.BigEndian.Uint64(header[21:]))
+	return lastModified > logMinTimestamp && lastModified < time.Now().Add(24*time.Hour).UnixNano()
+}
+
+// logRecordValid 校验记录的CRC
+func logRecordValid(header, body []byte) bool {
+	crc := crc32.Update(crc32.Checksum(header[4:], logCRCTable), logCRCTable, body)
+	return crc == binary.BigEndian.Uint32(header[:4])
+}
+
+// applyRecord 将一条有效记录应用到索引
+func (c *LogDiskCache) applyRecord(offset int64, header, body []byte, now time.Time) {
+	keyLen := int(binary.BigEndian.Uint16(header[5:]))
+	metaLen := int(binary.BigEndian.Uint16(header[7:]))
+	valueLen := int(binary.BigEndian.Uint32(header[9:]))
+	length := int64(logHeaderSize + len(body))
+
+	key := string(body[:keyLen])
+	if old, ok := c.index[key]; ok {
+		c.liveLen -= old.length
+		c.dataSize -= int64(old.size)
+		delete(c.index, key)
+	}
+
+	expiry := time.Unix(0, int64(binary.BigEndian.Uint64(header[13:])))
+	if header[4] != logRecordPut || !now.Before(expiry) {
+		return
+	}
+	entry := &logIndexEntry{
+		offset:       offset,
+		length:       length,
+		size:         valueLen,
+		expiry:       expiry,
+		lastModified: time.Unix(0, int64(binary.BigEndian.Uint64(header[21:]))),
+	}
+	entry.lastUsed = entry.lastModified.UnixNano()
+	if metaLen > 0 {
+		var info CacheKeyInfo
+		if json.Unmarshal(body[keyLen:keyLen+metaLen], &info) == nil {
+			entry.info = &info
+		}
+	}
+	c.index[key] = entry
+	c.liveLen += length
+	c.dataSize += int64(valueLen)
+}
+
+// encodeLogRecord 编码一条日志记录
+func encodeLogRecord(kind byte, key string, meta, value []byte, expiry, lastModified time.Time) []byte {
+	buf := make([]byte, logHeaderSize+len(key)+len(meta)+len(value))
+	buf[4] = kind
+	binary.BigEndian.PutUint16(buf[5:], uint16(len(key)))
+	binary.BigEndian.PutUint16(buf[7:], uint16(len(meta)))
+	binary.BigEndian.PutUint32(buf[9:], uint32(len(value)))
+	binary.BigEndian.PutUint64(buf[13:], uint64(expiry.UnixNano()))
+	binary.BigEndian.PutUint64(buf[21:], uint64(lastModified.UnixNano()))
+	n := logHeaderSize
+	n += copy(buf[n:], key)
+	n += copy(buf[n:], meta)
+	copy(buf[n:], value)
+	binary.BigEndian.PutUint32(buf[:4], crc32.Checksum(buf[4:], logCRCTable))
+	return buf
+}
+
+// readRecordLocked 读取并校验索引项对应的记录，返回数据部分（调用方需持有读锁）
+func (c *LogDiskCache) readRecordLocked(entry *logIndexEntry) ([]byte, []byte, error) {
+	buf := make([]byte, entry.length)
+	if _, err := c.file.ReadAt(buf, entry.offset); err != nil {
+		return nil, nil, err
+	}
+	if crc32.Checksum(buf[4:], logCRCTable) != binary.BigEndian.Uint32(buf[:4]) {
+		return nil, nil, ErrLogRecordCorrupted
+	}
+	keyLen := int(binary.BigEndian.Uint16(buf[5:]))
+	metaLen := int(binary.BigEndian.Uint16(buf[7:]))
+	return buf, buf[logHeaderSize+keyLen+metaLen:], nil
+}
+
+// appendLocked 追加一条记录，返回记录起始位置（调用方需持有写锁）
+func (c *LogDiskCache) appendLocked(record []byte) (int64, error) {
+	offset := c.fileSize
+	if _, err := c.file.WriteAt(record, offset); err != nil {
+		// 写入失败时截回原长度，避免留下半条记录
+		c.file.Truncate(offset)
+		return 0, err
+	}
+	c.fileSize += int64(len(record))
+	return offset, nil
+}
+
+// removeLocked 从索引中移除键，tombstone为true时追加删除记录（调用方需持有写锁）
+func (c *LogDiskCache) removeLocked(key string, tombstone bool) {
+	entry, ok := c.index[key]
+	if !ok {
+		return
+	}
+	c.liveLen -= entry.length
+	c.dataSize -= int64(entry.size)
+	delete(c.index, key)
+
+	if tombstone {
+		now := time.Now()
+		c.appendLocked(encodeLogRecord(logRecordDelete, key, nil, nil, now, now))
+	}
+}
+
+// Set 设置缓存
+func (c *LogDiskCache) Set(key string, data []byte, ttl time.Duration) error {
+	now := time.Now()
+	return c.put(key, data, now.Add(ttl), now, LookupCacheKeyInfo(key))
+}
+
+// put 写入一条记录并更新索引
+func (c *LogDiskCache) put(key string, data []byte, expiry, lastModified time.Time, info *CacheKeyInfo) error {
+	if len(key) > 0xFFFF || len(data) > logMaxValueSize {
+		return fmt.Errorf("缓存键或数据过大: %s", key)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if old, ok := c.index[key]; ok {
+		if info == nil {
+			info = old.info
+		}
+		c.removeLocked(key, false)
+	}
+
+	// 检查空间
+	if c.dataSize+int64(len(data)) > c.maxSize {
+		c.evictLocked(int64(len(data)))
+	}
+
+	var meta []byte
+	if info != nil {
+		if encoded, err := json.Marshal(info); err == nil && len(encoded) <= 0xFFFF {
+			meta = encoded
+		}
+	}
+	record := encodeLogRecord(logRecordPut, key, meta, data, expiry, lastModified)
+	offset, err := c.appendLocked(record)
+	if err != nil {
+		return err
+	}
+
+	c.index[key] = &logIndexEntry{
+		offset:       offset,
+		length:       int64(len(record)),
+		size:         len(data),
+		expiry:       expiry,
+		lastModified: lastModified,
+		lastUsed:     time.Now().UnixNano(),
+		info:         info,
+	}
+	c.liveLen += int64(len(record))
+	c.dataSize += int64(len(data))
+
+	c.maybeCompact()
+	return nil
+}
+
+// Get 获取缓存
+func (c *LogDiskCache) Get(key string) ([]byte, bool, error) {
+	c.mutex.RLock()
+	entry, exists := c.index[key]
+	if !exists {
+		c.mutex.RUnlock()
+		return nil, false, nil
+	}
+	if time.Now().After(entry.expiry) {
+		c.mutex.RUnlock()
+		c.dropEntry(key, entry)
+		return nil, false, nil
+	}
+	_, data, err := c.readRecordLocked(entry)
+	c.mutex.RUnlock()
+
+	if err != nil {
+		if err == ErrLogRecordCorrupted {
+			atomic.AddInt64(&c.corrupted, 1)
+			fmt.Printf("[日志缓存] 记录校验失败，已丢弃: %s\n", key)
+		}
+		c.dropEntry(key, entry)
+		return nil, false, err
+	}
+
+	atomic.StoreInt64(&entry.lastUsed, time.Now().UnixNano())
+	return data, true, nil
+}
+
+// dropEntry 移除过期或损坏的索引项（索引项未被替换时）
+func (c *LogDiskCache) dropEntry(key string, entry *logIndexEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.index[key] == entry {
+		c.removeLocked(key, time.Now().Before(entry.expiry))
+	}
+}
+
+// Delete 删除缓存
+func (c *LogDiskCache) Delete(key string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.removeLocked(key, true)
+	return nil
+}
+
+// Has 检查缓存是否存在
+func (c *LogDiskCache) Has(key string) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	entry, exists := c.index[key]
+	return exists && time.Now().Before(entry.expiry)
+}
+
+// GetLastModified 获取缓存项的最后修改时间
+func (c *LogDiskCache) GetLastModified(key string) (time.Time, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	entry, exists := c.index[key]
+	if !exists {
+		return time.Time{}, false
+	}
+	return entry.lastModified, true
+}
+
+// GetExpiry 获取缓存项的过期时间
+func (c *LogDiskCache) GetExpiry(key string) (time.Time, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	entry, exists := c.index[key]
+	if !exists {
+		return time.Time{}, false
+	}
+	return entry.expiry, true
+}
+
+// Clear 清空缓存
+func (c *LogDiskCache) Clear() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err := c.file.Truncate(int64(len(logFileMagic))); err != nil {
+		return err
+	}
+	atomic.AddInt64(&c.generation, 1)
+	c.fileSize = int64(len(logFileMagic))
+	c.liveLen = 0
+	c.dataSize = 0
+	c.index = make(map[string]*logIndexEntry)
+	return nil
+}
+
+// evictLocked 按最后使用时间淘汰，直到有足够空间（调用方需持有写锁）
+func (c *LogDiskCache) evictLocked(requiredSpace int64) {
+	keys := make([]string, 0, len(c.index))
+	for key := range c.index {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return atomic.LoadInt64(&c.index[keys[i]].lastUsed) < atomic.LoadInt64(&c.index[keys[j]].lastUsed)
+	})
+
+	for _, key := range keys {
+		if c.dataSize+requiredSpace <= c.maxSize {
+			break
+		}
+		c.removeLocked(key, true)
+	}
+}
+
+// 清理过期项，返回清理数量（过期记录在恢复时会被忽略，无需写入删除记录）
+func (c *LogDiskCache) cleanExpired() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	purged := 0
+	for key, entry := range c.index {
+		if now.After(entry.expiry) {
+			c.removeLocked(key, false)
+			purged++
+		}
+	}
+	c.file.Sync()
+	c.maybeCompact()
+	return purged
+}
+
+// maybeCompact 无效记录超过一半时在后台压缩
+func (c *LogDiskCache) maybeCompact() {
+	if c.fileSize < logCompactMinLen || c.fileSize < c.liveLen*2 {
+		return
+	}
+	if atomic.CompareAndSwapInt32(&c.compacting, 0, 1) {
+		go func() {
+			defer atomic.StoreInt32(&c.compacting, 0)
+			if err := c.Compact(); err != nil {
+				fmt.Printf("[日志缓存] 压缩失败: %s -> %v\n", filepath.Base(c.path), err)
+			}
+		}()
+	}
+}
+
+// Compact 将有效记录重写到新文件并原子替换旧文件
+// 按索引快照复制有效记录时不持有写锁，只在复制压缩期间新追加的记录和替换文件时持有写锁
+func (c *LogDiskCache) Compact() error {
+	c.compactMutex.Lock()
+	defer c.compactMutex.Unlock()
+
+	// 索引快照（按原文件顺序重写，保持顺序读取）
+	type liveRecord struct {
+		entry  *logIndexEntry
+		offset int64
+		length int64
+		expiry time.Time
+	}
+	c.mutex.RLock()
+	snapshotEnd := c.fileSize
+	generation := atomic.LoadInt64(&c.generation)
+	records := make([]liveRecord, 0, len(c.index))
+	for _, entry := range c.index {
+		records = append(records, liveRecord{entry: entry, offset: entry.offset, length: entry.length, expiry: entry.expiry})
+	}
+	file := c.file
+	c.mutex.RUnlock()
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].offset < records[j].offset
+	})
+
+	tmpPath := c.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	fail := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	writer := bufio.NewWriterSize(tmp, 256*1024)
+	if _, err := writer.WriteString(logFileMagic); err != nil {
+		return fail(err)
+	}
+	now := time.Now()
+	offset := int64(len(logFileMagic))
+	offsets := make(map[*logIndexEntry]int64, len(records))
+	for _, r := range records {
+		if now.After(r.expiry) {
+			continue
+		}
+		record := make([]byte, r.length)
+		if _, err := file.ReadAt(record, r.offset); err != nil {
+			continue
+		}
+		if crc32.Checksum(record[4:], logCRCTable) != binary.BigEndian.Uint32(record[:4]) {
+			atomic.AddInt64(&c.corrupted, 1)
+			continue
+		}
+		if _, err := writer.Write(record); err != nil {
+			return fail(err)
+		}
+		offsets[r.entry] = offset
+		offset += r.length
+	}
+	if err := writer.Flush(); err != nil {
+		return fail(err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if atomic.LoadInt64(&c.generation) != generation {
+		return fail(errors.New("缓存在压缩期间被清空"))
+	}
+
+	// 复制压缩期间追加的记录（新写入与删除记录）
+	tailLen := c.fileSize - snapshotEnd
+	if tailLen > 0 {
+		if _, err := io.Copy(tmp, io.NewSectionReader(c.file, snapshotEnd, tailLen)); err != nil {
+			return fail(err)
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		return fail(err)
+	}
+
+	// 替换文件并更新索引
+	delta := offset - snapshotEnd
+	c.file.Close()
+	c.file = tmp
+	c.fileSize = offset + tailLen
+	c.liveLen = 0
+	c.dataSize = 0
+	for key, entry := range c.index {
+		if entry.offset >= snapshotEnd {
+			entry.offset += delta
+		} else if newOffset, ok := offsets[entry]; ok {
+			entry.offset = newOffset
+		} else {
+			delete(c.index, key)
+			continue
+		}
+		c.liveLen += entry.length
+		c.dataSize += int64(entry.size)
+	}
+	atomic.AddInt64(&c.compacted, 1)
+	return nil
+}
+
+// 启动定期清理任务
+func (c *LogDiskCache) startCleanupTask() {
+	ticker := time.NewTicker(10 * time.Minute)
+	for range ticker.C {
+		c.cleanExpired()
+	}
+}
+
+// Close 同步并关闭日志文件
+func (c *LogDiskCache) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.file.Sync()
+	return c.file.Close()
+}
+
+// Entries 获取所有缓存项概要
+func (c *LogDiskCache) Entries() []CacheEntryInfo {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	now := time.Now()
+	entries := make([]CacheEntryInfo, 0, len(c.index))
+	for key, entry := range c.index {
+		entries = append(entries, newCacheEntryInfo(key, CacheLevelDisk, entry.info, entry.size, entry.expiry, entry.lastModified, now))
+	}
+	return entries
+}
+
+// Stats 获取日志磁盘缓存统计
+func (c *LogDiskCache) Stats() CacheLevelStats {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	now := time.Now()
+	stats := CacheLevelStats{
+		Items:        len(c.index),
+		SizeBytes:    c.dataSize,
+		MaxSizeBytes: c.maxSize,
+	}
+	for _, entry := range c.index {
+		if now.After(entry.expiry) {
+			stats.Expired++
+		}
+	}
+	return stats
+}
+
+// LogStats 日志文件统计：文件大小、有效记录长度、损坏记录数、压缩次数
+func (c *LogDiskCache) LogStats() (int64, int64, int64, int64) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.fileSize, c.liveLen, atomic.LoadInt64(&c.corrupted), atomic.LoadInt64(&c.compacted)
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"pansou/util/json"
+)
+
+// MigrationResult 磁盘缓存迁移结果
+type MigrationResult struct {
+	Imported int   // 导入的缓存项
+	Expired  int   // 已过期而跳过的缓存项
+	Failed   int   // 读取或写入失败的缓存项
+	Bytes    int64 // 导入的数据大小
+}
+
+// MigrateFileCacheToLog 将每个键一个文件的磁盘缓存（baseDir/shard_N/）导入日志存储引擎（baseDir/logstore/）
+// 保留原有的过期时间、最后修改时间和来源信息；removeOld为true时导入成功后删除旧文件
+func MigrateFileCacheToLog(baseDir string, maxSizeMB int, removeOld bool) (MigrationResult, error) {
+	var result MigrationResult
+
+	target, err := NewShardedDiskCacheWithEngine(baseDir, maxSizeMB, DiskEngineLog)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		for _, shard := range target.GetShards() {
+			if logShard, ok := shard.(*LogDiskCache); ok {
+				logShard.Close()
+			}
+		}
+	}()
+
+	shardDirs, err := filepath.Glob(filepath.Join(baseDir, "shard_*"))
+	if err != nil {
+		return result, err
+	}
+
+	now := time.Now()
+	for _, dir := range shardDirs {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, file := range files {
+			if file.IsDir() || !strings.HasSuffix(file.Name(), ".meta") {
+				continue
+			}
+			metaPath := filepath.Join(dir, file.Name())
+			dataPath := strings.TrimSuffix(metaPath, ".meta")
+
+			metaData, err := ioutil.ReadFile(metaPath)
+			if err != nil {
+				result.Failed++
+				continue
+			}
+			var meta diskCacheMetadata
+			if err := json.Unmarshal(metaData, &meta); err != nil || meta.Key == "" {
+				result.Failed++
+				continue
+			}
+
+			if now.After(meta.Expiry) {
+				result.Expired++
+			} else {
+				data, err := ioutil.ReadFile(dataPath)
+				if err != nil {
+					result.Failed++
+					continue
+				}
+				lastModified := meta.LastModified
+				if lastModified.IsZero() {
+					lastModified = file.ModTime()
+				}
+				shard := target.getShard(meta.Key).(*LogDiskCache)
+				if err := shard.put(meta.Key, data, meta.Expiry, lastModified, meta.keyInfo()); err != nil {
+					result.Failed++
+					continue
+				}
+				result.Imported++
+				result.Bytes += int64(len(data))
+			}
+
+			if removeOld {
+				os.Remove(dataPath)
+				os.Remove(metaPath)
+			}
+		}
+
+		if removeOld {
+			// 目录为空时删除
+			os.Remove(dir)
+		}
+	}
+
+	return result, nil
+}
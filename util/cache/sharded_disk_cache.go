@@ -6,15 +6,37 @@ import (
 	"path/filepath"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// 磁盘缓存存储引擎
+const (
+	DiskEngineFiles = "files" // 每个键一个数据文件和一个元数据文件
+	DiskEngineLog   = "log"   // 每个分片一个追加写日志文件，内存索引
+)
+
+// DiskShard 磁盘缓存分片
+type DiskShard interface {
+	Set(key string, data []byte, ttl time.Duration) error
+	Get(key string) ([]byte, bool, error)
+	Delete(key string) error
+	Has(key string) bool
+	Clear() error
+	GetLastModified(key string) (time.Time, bool)
+	GetExpiry(key string) (time.Time, bool)
+	Entries() []CacheEntryInfo
+	Stats() CacheLevelStats
+	cleanExpired() int
+}
+
 // ShardedDiskCache 分片磁盘缓存
 type ShardedDiskCache struct {
 	baseDir     string
+	engine      string
 	shardCount  int
 	shardMask   uint32 // 用于快速取模的掩码
-	shards      []*DiskCache
+	shards      []DiskShard
 	maxSizeMB   int
 	mutex       sync.RWMutex
 }
@@ -26,6 +48,46 @@ func NewShardedDiskCache(baseDir string, shardCount, maxSizeMB int) (*ShardedDis
 
 // NewOptimizedShardedDiskCache 创建优化的分片磁盘缓存（动态分片数）
 func NewOptimizedShardedDiskCache(baseDir string, maxSizeMB int) (*ShardedDiskCache, error) {
+	return newShardedDiskCacheWithCount(baseDir, optimalDiskShardCount(), maxSizeMB)
+}
+
+// NewShardedDiskCacheWithEngine 使用指定存储引擎创建分片磁盘缓存（files或log）
+func NewShardedDiskCacheWithEngine(baseDir string, maxSizeMB int, engine string) (*ShardedDiskCache, error) {
+	if engine != DiskEngineLog {
+		return NewOptimizedShardedDiskCache(baseDir, maxSizeMB)
+	}
+	
+	// 日志引擎沿用已有的分片数，避免CPU数量变化后键被分到其他分片
+	logDir := filepath.Join(baseDir, logStoreDirName)
+	shardCount := existingLogShardCount(logDir)
+	if shardCount == 0 {
+		shardCount = optimalDiskShardCount()
+	}
+	
+	shardSize := maxSizeMB / shardCount
+	if shardSize < 1 {
+		shardSize = 1
+	}
+	cache := &ShardedDiskCache{
+		baseDir:    logDir,
+		engine:     DiskEngineLog,
+		shardCount: shardCount,
+		shardMask:  uint32(shardCount - 1),
+		shards:     make([]DiskShard, shardCount),
+		maxSizeMB:  maxSizeMB,
+	}
+	for i := 0; i < shardCount; i++ {
+		shard, err := NewLogDiskCache(filepath.Join(logDir, fmt.Sprintf("shard_%d.log", i)), shardSize)
+		if err != nil {
+			return nil, err
+		}
+		cache.shards[i] = shard
+	}
+	return cache, nil
+}
+
+// optimalDiskShardCount 根据CPU核心数确定磁盘缓存分片数
+func optimalDiskShardCount() int {
 	// 动态确定分片数量：与内存缓存保持一致的策略
 	shardCount := runtime.NumCPU() * 2
 	if shardCount < 4 {
@@ -36,9 +98,7 @@ func NewOptimizedShardedDiskCache(baseDir string, maxSizeMB int) (*ShardedDiskCa
 	}
 	
 	// 确保分片数是2的幂，便于使用掩码进行快速取模
-	shardCount = nextPowerOfTwoDisk(shardCount)
-	
-	return newShardedDiskCacheWithCount(baseDir, shardCount, maxSizeMB)
+	return nextPowerOfTwoDisk(shardCount)
 }
 
 // 获取下一个2的幂（磁盘缓存版本）
@@ -65,9 +125,10 @@ func newShardedDiskCacheWithCount(baseDir string, shardCount, maxSizeMB int) (*S
 	
 	cache := &ShardedDiskCache{
 		baseDir:    baseDir,
+		engine:     DiskEngineFiles,
 		shardCount: shardCount,
 		shardMask:  uint32(shardCount - 1), // 用于快速取模
-		shards:     make([]*DiskCache, shardCount),
+		shards:     make([]DiskShard, shardCount),
 		maxSizeMB:  maxSizeMB,
 	}
 	
@@ -85,7 +146,7 @@ func newShardedDiskCacheWithCount(baseDir string, shardCount, maxSizeMB int) (*S
 }
 
 // 获取键对应的分片
-func (c *ShardedDiskCache) getShard(key string) *DiskCache {
+func (c *ShardedDiskCache) getShard(key string) DiskShard {
 	// 计算哈希值决定分片
 	h := fnv.New32a()
 	h.Write([]byte(key))
@@ -138,16 +199,59 @@ func (c *ShardedDiskCache) GetLastModified(key string) (time.Time, bool) {
 	return shard.GetLastModified(key)
 }
 
+// GetExpiry 获取缓存项的过期时间
+func (c *ShardedDiskCache) GetExpiry(key string) (time.Time, bool) {
+	shard := c.getShard(key)
+	return shard.GetExpiry(key)
+}
+
 // cleanExpired 清理所有分片中的过期项
 func (c *ShardedDiskCache) cleanExpired() {
 	// 并行清理所有分片中的过期项
 	for _, shard := range c.shards {
-		go func(s *DiskCache) {
+		go func(s DiskShard) {
 			s.cleanExpired()
 		}(shard)
 	}
 }
 
+// PurgeExpired 立即清理所有分片中的过期项，等待完成并返回清理数量
+func (c *ShardedDiskCache) PurgeExpired() int {
+	var wg sync.WaitGroup
+	var purged int64
+	for _, shard := range c.shards {
+		wg.Add(1)
+		go func(s DiskShard) {
+			defer wg.Done()
+			atomic.AddInt64(&purged, int64(s.cleanExpired()))
+		}(shard)
+	}
+	wg.Wait()
+	return int(purged)
+}
+
+// Entries 获取所有分片的缓存项概要
+func (c *ShardedDiskCache) Entries() []CacheEntryInfo {
+	entries := make([]CacheEntryInfo, 0)
+	for _, shard := range c.shards {
+		entries = append(entries, shard.Entries()...)
+	}
+	return entries
+}
+
+// Stats 汇总所有分片的统计
+func (c *ShardedDiskCache) Stats() CacheLevelStats {
+	var stats CacheLevelStats
+	for _, shard := range c.shards {
+		shardStats := shard.Stats()
+		stats.Items += shardStats.Items
+		stats.Expired += shardStats.Expired
+		stats.SizeBytes += shardStats.SizeBytes
+		stats.MaxSizeBytes += shardStats.MaxSizeBytes
+	}
+	return stats
+}
+
 // CleanExpired 公开的清理方法，符合cleanupTarget接口
 func (c *ShardedDiskCache) CleanExpired() {
 	c.cleanExpired()
@@ -161,10 +265,15 @@ func (c *ShardedDiskCache) StartCleanupTask() {
 }
 
 // GetShards 获取所有分片（用于测试和调试）
-func (c *ShardedDiskCache) GetShards() []*DiskCache {
+func (c *ShardedDiskCache) GetShards() []DiskShard {
 	return c.shards
 }
 
+// Engine 存储引擎名称
+func (c *ShardedDiskCache) Engine() string {
+	return c.engine
+}
+
 // GetShardIndex 获取指定键对应的分片索引（用于测试和调试）
 func (c *ShardedDiskCache) GetShardIndex(key string) int {
 	h := fnv.New32a()
@@ -28,6 +28,7 @@ type shardedMemoryCacheItem struct {
 	lastUsed     int64 // 使用原子操作的时间戳
 	lastModified time.Time
 	size         int
+	info         *CacheKeyInfo // 缓存键来源信息
 }
 
 // 单个分片
@@ -47,6 +48,7 @@ type ShardedMemoryCache struct {
 	sizePerShard  int64
 	diskCache     *ShardedDiskCache // 磁盘缓存引用
 	diskCacheMutex sync.RWMutex     // 磁盘缓存引用的保护锁
+	evictions     int64             // 容量淘汰次数
 }
 
 // 创建新的分片内存缓存
@@ -118,8 +120,12 @@ func (c *ShardedMemoryCache) SetWithTimestamp(key string, data []byte, ttl time.
 	defer shard.mutex.Unlock()
 	
 	// 如果已存在，先减去旧项的大小
+	info := LookupCacheKeyInfo(key)
 	if item, exists := shard.items[key]; exists {
 		atomic.AddInt64(&shard.currSize, -int64(item.size))
+		if info == nil {
+			info = item.info
+		}
 	}
 	
 	// 创建新的缓存项
@@ -130,6 +136,7 @@ func (c *ShardedMemoryCache) SetWithTimestamp(key string, data []byte, ttl time.
 		lastUsed:     now.UnixNano(),
 		lastModified: lastModified,
 		size:         len(data),
+		info:         info,
 	}
 	
 	// 检查是否需要清理空间
@@ -213,6 +220,20 @@ func (c *ShardedMemoryCache) GetLastModified(key string) (time.Time, bool) {
 	return item.lastModified, true
 }
 
+// GetExpiry 获取缓存项的过期时间
+func (c *ShardedMemoryCache) GetExpiry(key string) (time.Time, bool) {
+	shard := c.getShard(key)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+	
+	item, exists := shard.items[key]
+	if !exists {
+		return time.Time{}, false
+	}
+	
+	return item.expiry, true
+}
+
 // 从指定分片中驱逐最久未使用的项（带磁盘备份）
 func (c *ShardedMemoryCache) evictFromShard(shard *memoryCacheShard) {
 	var oldestKey string
@@ -245,12 +266,19 @@ func (c *ShardedMemoryCache) evictFromShard(shard *memoryCacheShard) {
 		// 从内存中删除
 		atomic.AddInt64(&shard.currSize, -int64(oldestItem.size))
 		delete(shard.items, oldestKey)
+		atomic.AddInt64(&c.evictions, 1)
 	}
 }
 
 // 清理过期项
 func (c *ShardedMemoryCache) CleanExpired() {
+	c.PurgeExpired()
+}
+
+// PurgeExpired 立即清理过期项，返回清理数量
+func (c *ShardedMemoryCache) PurgeExpired() int {
 	now := time.Now()
+	var purged int64
 	
 	// 并行清理所有分片
 	var wg sync.WaitGroup
@@ -265,11 +293,14 @@ func (c *ShardedMemoryCache) CleanExpired() {
 				if now.After(v.expiry) {
 					atomic.AddInt64(&s.currSize, -int64(v.size))
 					delete(s.items, k)
+					atomic.AddInt64(&purged, 1)
 				}
 			}
 		}(shard)
 	}
 	wg.Wait()
+	
+	return int(purged)
 }
 
 // Delete 删除指定键的缓存项
@@ -387,4 +418,43 @@ func (c *ShardedMemoryCache) GetAllItems() map[string]*MemoryCacheItem {
 	}
 	
 	return result
-}
\ No newline at end of file
+}
+
+// Entries 获取所有缓存项概要（包括已过期但尚未清理的项）
+func (c *ShardedMemoryCache) Entries() []CacheEntryInfo {
+	now := time.Now()
+	entries := make([]CacheEntryInfo, 0)
+	
+	for _, shard := range c.shards {
+		shard.mutex.RLock()
+		for key, item := range shard.items {
+			entries = append(entries, newCacheEntryInfo(key, CacheLevelMemory, item.info, item.size, item.expiry, item.lastModified, now))
+		}
+		shard.mutex.RUnlock()
+	}
+	
+	return entries
+}
+
+// Stats 获取内存缓存统计（命中次数由两级缓存统计）
+func (c *ShardedMemoryCache) Stats() CacheLevelStats {
+	now := time.Now()
+	stats := CacheLevelStats{
+		MaxSizeBytes: c.maxSize,
+		Evictions:    atomic.LoadInt64(&c.evictions),
+	}
+	
+	for _, shard := range c.shards {
+		shard.mutex.RLock()
+		stats.Items += len(shard.items)
+		stats.SizeBytes += atomic.LoadInt64(&shard.currSize)
+		for _, item := range shard.items {
+			if now.After(item.expiry) {
+				stats.Expired++
+			}
+		}
+		shard.mutex.RUnlock()
+	}
+	
+	return stats
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"encoding/gob"
+	"reflect"
+	"time"
+
+	"pansou/model"
+)
+
+func init() {
+	gob.Register(SourceCacheEntry{})
+}
+
+// SourceCacheEntry 单个来源（插件或TG频道）针对某个关键词的缓存项
+// 搜索请求由多个来源的缓存项组合而成，每个来源单独记录新鲜度和是否为最终结果
+type SourceCacheEntry struct {
+	Results   []model.SearchResult
+	IsFinal   bool      // 是否为完整的最终结果，false表示插件仍在后台搜索
+	UpdatedAt time.Time // 结果更新时间
+}
+
+// GetSourceEntry 读取来源缓存项
+func (c *EnhancedTwoLevelCache) GetSourceEntry(key string) (SourceCacheEntry, bool) {
+	var entry SourceCacheEntry
+	hit, err := c.GetDecoded(key, &entry)
+	if err != nil || !hit {
+		return entry, false
+	}
+	return entry, true
+}
+
+// SetSourceEntry 写入来源缓存项（同时写入内存和磁盘）
+func (c *EnhancedTwoLevelCache) SetSourceEntry(key string, entry SourceCacheEntry, ttl time.Duration) error {
+	if entry.UpdatedAt.IsZero() {
+		entry.UpdatedAt = time.Now()
+	}
+	data, err := c.serializer.Serialize(entry)
+	if err != nil {
+		return err
+	}
+	return c.SetBothLevels(key, data, ttl)
+}
+
+// sourceEntry 操作对应的来源缓存项
+func (op *CacheOperation) sourceEntry() SourceCacheEntry {
+	return SourceCacheEntry{
+		Results:   op.Data,
+		IsFinal:   op.IsFinal,
+		UpdatedAt: op.Timestamp,
+	}
+}
+
+// sourceChanged 判断写入是否改变了已缓存响应所依赖的来源数据
+// 响应只有在其包含的来源都为最终结果时才会被缓存，因此写入缺失或非最终的来源缓存项、
+// 以及以相同结果重写最终结果（补写、延迟刷盘、格式升级）都不影响已缓存的响应，不需要更新来源版本
+func (c *EnhancedTwoLevelCache) sourceChanged(key string, data []byte) bool {
+	if info := LookupCacheKeyInfo(key); info == nil || len(info.Sources) != 1 {
+		return true
+	}
+	var next SourceCacheEntry
+	if err := c.serializer.Deserialize(data, &next); err != nil {
+		return true
+	}
+	previous, ok := c.GetSourceEntry(key)
+	if !ok || !previous.IsFinal {
+		return false
+	}
+	return next.IsFinal != previous.IsFinal || !reflect.DeepEqual(next.Results, previous.Results)
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 来源版本记录在最后一次变更后的保留时长，响应缓存项的有效期不能超过该时长
+const SourceVersionRetention = time.Hour
+
+// SourceVersion 某个关键词下所有来源缓存项的版本
+// 任何来源缓存项被写入或删除时版本改变，依赖这些来源的响应缓存随之失效
+type SourceVersion struct {
+	Global  uint64 // 清空缓存、或无法确定关键词的缓存项变更时改变
+	Keyword uint64 // 该关键词的来源缓存项变更时改变
+}
+
+// sourceVersionRegistry 关键词 -> 最后一次变更的序号
+// 使用全局递增序号而不是每个关键词单独计数，清理后重新登记的关键词不会与旧版本重复
+type sourceVersionRegistry struct {
+	seq      uint64
+	global   uint64
+	keywords sync.Map // 关键词 -> *sourceVersionEntry
+}
+
+// sourceVersionEntry 关键词的最后一次变更
+type sourceVersionEntry struct {
+	seq       uint64
+	changedAt int64
+}
+
+var sourceVersions = &sourceVersionRegistry{}
+
+func init() {
+	registerForCleanup(sourceVersions)
+}
+
+// normalizeSourceKeyword 来源版本按不区分大小写的关键词记录
+func normalizeSourceKeyword(keyword string) string {
+	return strings.ToLower(strings.TrimSpace(keyword))
+}
+
+// CurrentSourceVersion 获取关键词当前的来源版本
+func CurrentSourceVersion(keyword string) SourceVersion {
+	version := SourceVersion{Global: atomic.LoadUint64(&sourceVersions.global)}
+	if value, ok := sourceVersions.keywords.Load(normalizeSourceKeyword(keyword)); ok {
+		version.Keyword = value.(*sourceVersionEntry).seq
+	}
+	return version
+}
+
+// touchSourceVersion 标记关键词的来源已改变
+func touchSourceVersion(keyword string) {
+	sourceVersions.keywords.Store(normalizeSourceKeyword(keyword), &sourceVersionEntry{
+		seq:       atomic.AddUint64(&sourceVersions.seq, 1),
+		changedAt: time.Now().UnixNano(),
+	})
+}
+
+// touchAllSourceVersions 标记所有关键词的来源已改变
+func touchAllSourceVersions() {
+	atomic.StoreUint64(&sourceVersions.global, atomic.AddUint64(&sourceVersions.seq, 1))
+}
+
+// notifySourceChange 缓存项被删除、或写入改变了来源数据时更新对应关键词的来源版本
+func notifySourceChange(key string) {
+	if info := LookupCacheKeyInfo(key); info != nil {
+		touchSourceVersion(info.Keyword)
+		return
+	}
+	touchAllSourceVersions()
+}
+
+// CleanExpired 清理长时间没有变更的关键词
+func (r *sourceVersionRegistry) CleanExpired() {
+	cutoff := time.Now().Add(-SourceVersionRetention).UnixNano()
+	r.keywords.Range(func(key, value interface{}) bool {
+		if value.(*sourceVersionEntry).changedAt < cutoff {
+			r.keywords.Delete(key)
+		}
+		return true
+	})
+}
@@ -31,6 +31,9 @@ func (g *gzipResponseWriter) Close() {
 	g.gzipWriter.Close()
 }
 
+// PrecompressedKey 处理函数已直接输出gzip压缩内容时在上下文中设置该键，中间件不再压缩
+const PrecompressedKey = "precompressed"
+
 // GzipMiddleware 返回一个Gin中间件，用于压缩HTTP响应
 func GzipMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -54,6 +57,11 @@ func GzipMiddleware() gin.HandlerFunc {
 		// 处理请求
 		c.Next()
 		
+		// 处理函数已输出压缩内容
+		if c.GetBool(PrecompressedKey) {
+			return
+		}
+		
 		// 获取响应内容
 		responseData := buffer.Bytes()
 		
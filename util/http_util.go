@@ -64,11 +64,13 @@ func InitHTTPClient() {
 		}
 	}
 
-	// 创建客户端
+	// 创建客户端（经过Telegram代理路由与共享出站限流层）
 	httpClient = &http.Client{
-		Transport: transport,
+		Transport: NewOutboundTransport(newProxyRouter(ProxyScopeTelegram, transport)),
 		Timeout:   time.Duration(60) * time.Second,
 	}
+
+	InitProxyRouter()
 }
 
 // GetHTTPClient 获取HTTP客户端
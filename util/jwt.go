@@ -9,12 +9,15 @@ import (
 
 // Claims JWT载荷结构
 type Claims struct {
-	Username string `json:"username"`
+	Username   string `json:"username"`
+	Role       string `json:"role,omitempty"`
+	SessionID  string `json:"sid,omitempty"` // 所属登录会话，会话吊销后令牌失效
+	PasswordFP string `json:"pwv,omitempty"` // 签发时的密码指纹，密码变更后令牌失效
 	jwt.RegisteredClaims
 }
 
-// GenerateToken 生成JWT token
-func GenerateToken(username string, secret string, expiry time.Duration) (string, error) {
+// GenerateToken 生成JWT访问令牌
+func GenerateToken(username, role, sessionID, secret string, expiry time.Duration) (string, error) {
 	if username == "" {
 		return "", errors.New("username cannot be empty")
 	}
@@ -22,10 +25,19 @@ func GenerateToken(username string, secret string, expiry time.Duration) (string
 		return "", errors.New("secret cannot be empty")
 	}
 
+	tokenID, err := randomToken(8)
+	if err != nil {
+		return "", err
+	}
+
 	expirationTime := time.Now().Add(expiry)
 	claims := &Claims{
-		Username: username,
+		Username:   username,
+		Role:       role,
+		SessionID:  sessionID,
+		PasswordFP: UserPasswordFingerprint(username),
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "pansou",
@@ -65,3 +77,21 @@ func ValidateToken(tokenString string, secret string) (*Claims, error) {
 
 	return claims, nil
 }
+
+// ValidateAccessToken 验证访问令牌，并检查会话吊销列表与密码指纹
+func ValidateAccessToken(tokenString string, secret string) (*Claims, error) {
+	claims, err := ValidateToken(tokenString, secret)
+	if err != nil {
+		return nil, err
+	}
+	if claims.SessionID == "" {
+		return nil, errors.New("token has no session")
+	}
+	if GetSessionStore().IsRevoked(claims.SessionID) {
+		return nil, errors.New("token has been revoked")
+	}
+	if claims.PasswordFP != UserPasswordFingerprint(claims.Username) {
+		return nil, errors.New("password has changed")
+	}
+	return claims, nil
+}
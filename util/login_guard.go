@@ -0,0 +1,103 @@
+package util
+
+import (
+	"sync"
+	"time"
+
+	"pansou/config"
+)
+
+// loginFailure 登录失败记录
+type loginFailure struct {
+	count       int
+	firstFailAt time.Time
+	lockedUntil time.Time
+}
+
+// LoginGuard 登录防爆破：按用户名和IP分别统计连续失败次数，超过阈值后锁定
+type LoginGuard struct {
+	mu    sync.Mutex
+	users map[string]*loginFailure
+	ips   map[string]*loginFailure
+}
+
+var globalLoginGuard = &LoginGuard{
+	users: make(map[string]*loginFailure),
+	ips:   make(map[string]*loginFailure),
+}
+
+// GetLoginGuard 获取全局登录防爆破实例
+func GetLoginGuard() *LoginGuard {
+	return globalLoginGuard
+}
+
+// loginGuardLimits 获取锁定阈值与时长
+func loginGuardLimits() (int, int, time.Duration) {
+	if config.AppConfig == nil {
+		return 5, 20, 15 * time.Minute
+	}
+	return config.AppConfig.AuthMaxLoginAttempts, config.AppConfig.AuthMaxLoginAttemptsIP, config.AppConfig.AuthLockoutDuration
+}
+
+// Check 检查用户名或IP是否处于锁定状态，返回剩余锁定时间
+func (g *LoginGuard) Check(username, ip string) (bool, time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	var remaining time.Duration
+	for _, f := range []*loginFailure{g.users[username], g.ips[ip]} {
+		if f != nil && now.Before(f.lockedUntil) {
+			if left := f.lockedUntil.Sub(now); left > remaining {
+				remaining = left
+			}
+		}
+	}
+	return remaining > 0, remaining
+}
+
+// RecordFailure 记录一次登录失败，达到阈值时锁定
+func (g *LoginGuard) RecordFailure(username, ip string) {
+	maxUser, maxIP, lockout := loginGuardLimits()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	g.users[username] = recordLoginFailure(g.users[username], now, maxUser, lockout)
+	g.ips[ip] = recordLoginFailure(g.ips[ip], now, maxIP, lockout)
+	g.pruneLocked(now, lockout)
+}
+
+// RecordSuccess 登录成功后清除该用户的失败记录
+// IP的失败记录不清除，只随统计窗口过期，避免持有一个有效账号的攻击者反复重置IP计数来爆破其他用户
+func (g *LoginGuard) RecordSuccess(username string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.users, username)
+}
+
+// recordLoginFailure 累加失败次数，统计窗口与锁定时长相同
+func recordLoginFailure(f *loginFailure, now time.Time, max int, lockout time.Duration) *loginFailure {
+	if f == nil || now.Sub(f.firstFailAt) > lockout {
+		f = &loginFailure{firstFailAt: now}
+	}
+	f.count++
+	if f.count >= max {
+		f.lockedUntil = now.Add(lockout)
+		f.count = 0
+		f.firstFailAt = now
+	}
+	return f
+}
+
+// pruneLocked 清理过期的失败记录（调用方需持有锁）
+func (g *LoginGuard) pruneLocked(now time.Time, lockout time.Duration) {
+	for _, records := range []map[string]*loginFailure{g.users, g.ips} {
+		for key, f := range records {
+			if now.After(f.lockedUntil) && now.Sub(f.firstFailAt) > lockout {
+				delete(records, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,513 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"pansou/config"
+	"pansou/util/sharedstore"
+)
+
+// ErrSearchBudgetExceeded 单次搜索的出站请求预算已耗尽
+var ErrSearchBudgetExceeded = errors.New("单次搜索出站请求预算已耗尽")
+
+// RequestBudgetExtKey 出站请求预算在插件ext参数中的键名
+const RequestBudgetExtKey = "_request_budget"
+
+// 按主机的限流器注册表
+var (
+	hostLimiters        sync.Map // host -> *hostLimiter
+	budgetExhaustedHits int64    // 因预算耗尽被拒绝的请求数
+)
+
+// hostLimiter 单个主机的并发、速率和退避控制
+type hostLimiter struct {
+	host        string
+	concurrency int
+	rps         float64
+	sem         chan struct{}
+
+	mutex         sync.Mutex
+	tokens        float64
+	lastRefill    time.Time
+	cooldownUntil time.Time
+
+	// 统计数据
+	inFlight  int64
+	total     int64
+	throttled int64
+	retries   int64
+	waitNanos int64
+
+	sharedThrottled int64 // 因多实例共享限流而等待的次数
+}
+
+// HostLimitStats 单个主机的出站请求统计
+type HostLimitStats struct {
+	Host              string  `json:"host"`
+	Concurrency       int     `json:"concurrency"`
+	RPS               float64 `json:"rps"`
+	InFlight          int64   `json:"in_flight"`
+	TotalRequests     int64   `json:"total_requests"`
+	Throttled         int64   `json:"throttled"`
+	Retries           int64   `json:"retries"`
+	SharedThrottled   int64   `json:"shared_throttled,omitempty"`
+	AvgWaitMs         float64 `json:"avg_wait_ms"`
+	CooldownRemaining float64 `json:"cooldown_remaining_seconds"`
+}
+
+// resolveHostLimit 根据配置确定主机的限流规则（精确匹配优先，其次 *.example.com 后缀匹配）
+func resolveHostLimit(host string) (int, float64) {
+	concurrency := 16
+	var rps float64
+	if config.AppConfig == nil {
+		return concurrency, rps
+	}
+
+	concurrency = config.AppConfig.OutboundDefaultConcurrency
+	rps = config.AppConfig.OutboundDefaultRPS
+
+	limit, ok := config.AppConfig.OutboundHostLimits[host]
+	if !ok {
+		bestLen := 0
+		for pattern, l := range config.AppConfig.OutboundHostLimits {
+			if !strings.HasPrefix(pattern, "*.") {
+				continue
+			}
+			suffix := pattern[2:]
+			if (host == suffix || strings.HasSuffix(host, "."+suffix)) && len(suffix) > bestLen {
+				limit, ok, bestLen = l, true, len(suffix)
+			}
+		}
+	}
+	if ok {
+		if limit.Concurrency > 0 {
+			concurrency = limit.Concurrency
+		}
+		if limit.RPS > 0 {
+			rps = limit.RPS
+		}
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return concurrency, rps
+}
+
+// getHostLimiter 获取或创建主机对应的限流器
+func getHostLimiter(host string) *hostLimiter {
+	host = strings.ToLower(host)
+	if limiter, ok := hostLimiters.Load(host); ok {
+		return limiter.(*hostLimiter)
+	}
+
+	concurrency, rps := resolveHostLimit(host)
+	limiter := &hostLimiter{
+		host:        host,
+		concurrency: concurrency,
+		rps:         rps,
+		sem:         make(chan struct{}, concurrency),
+		tokens:      burstOf(rps),
+		lastRefill:  time.Now(),
+	}
+	actual, _ := hostLimiters.LoadOrStore(host, limiter)
+	return actual.(*hostLimiter)
+}
+
+// burstOf 令牌桶容量，至少为1
+func burstOf(rps float64) float64 {
+	if rps < 1 {
+		return 1
+	}
+	return rps
+}
+
+// reserve 预留一个请求名额，返回需要等待的时间（冷却期与速率限制取较大者）
+func (h *hostLimiter) reserve() time.Duration {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	now := time.Now()
+	var wait time.Duration
+	if now.Before(h.cooldownUntil) {
+		wait = h.cooldownUntil.Sub(now)
+	}
+
+	if h.rps > 0 {
+		elapsed := now.Sub(h.lastRefill).Seconds()
+		h.tokens += elapsed * h.rps
+		if burst := burstOf(h.rps); h.tokens > burst {
+			h.tokens = burst
+		}
+		h.lastRefill = now
+
+		h.tokens--
+		if h.tokens < 0 {
+			tokenWait := time.Duration(-h.tokens / h.rps * float64(time.Second))
+			if tokenWait > wait {
+				wait = tokenWait
+			}
+		}
+	}
+	return wait
+}
+
+// acquire 等待速率令牌和并发槽位
+func (h *hostLimiter) acquire(ctx context.Context) error {
+	start := time.Now()
+	defer func() {
+		atomic.AddInt64(&h.waitNanos, int64(time.Since(start)))
+	}()
+
+	if wait := h.reserve(); wait > 0 {
+		if err := sleepWithContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+	if err := h.acquireShared(ctx); err != nil {
+		return err
+	}
+
+	select {
+	case h.sem <- struct{}{}:
+		atomic.AddInt64(&h.inFlight, 1)
+		atomic.AddInt64(&h.total, 1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// acquireShared 多实例部署时在共享存储中按固定窗口计数，使所有实例合计不超过主机的速率限制
+// 共享存储不可用时只使用本地限流
+func (h *hostLimiter) acquireShared(ctx context.Context) error {
+	store := sharedstore.RateLimit()
+	if store == nil || h.rps <= 0 {
+		return nil
+	}
+
+	// 窗口至少1秒，速率低于1时按 1/rps 秒放行一个请求
+	window := time.Second
+	if h.rps < 1 {
+		window = time.Duration(float64(time.Second) / h.rps)
+	}
+	limit := int64(h.rps * window.Seconds())
+	if limit < 1 {
+		limit = 1
+	}
+
+	for {
+		now := time.Now()
+		slot := now.UnixNano() / int64(window)
+		key := "ratelimit:" + h.host + ":" + strconv.FormatInt(slot, 10)
+		count, err := store.IncrWindow(key, window*2)
+		if err != nil || count <= limit {
+			return nil
+		}
+
+		atomic.AddInt64(&h.sharedThrottled, 1)
+		next := time.Unix(0, (slot+1)*int64(window))
+		if err := sleepWithContext(ctx, next.Sub(now)); err != nil {
+			return err
+		}
+	}
+}
+
+// release 释放并发槽位
+func (h *hostLimiter) release() {
+	atomic.AddInt64(&h.inFlight, -1)
+	<-h.sem
+}
+
+// setCooldown 设置主机冷却期，冷却期内该主机的所有请求都会等待
+func (h *hostLimiter) setCooldown(d time.Duration) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	until := time.Now().Add(d)
+	if until.After(h.cooldownUntil) {
+		h.cooldownUntil = until
+	}
+}
+
+// stats 导出统计数据
+func (h *hostLimiter) stats() HostLimitStats {
+	total := atomic.LoadInt64(&h.total)
+	avgWait := 0.0
+	if total > 0 {
+		avgWait = float64(atomic.LoadInt64(&h.waitNanos)) / float64(total) / float64(time.Millisecond)
+	}
+
+	h.mutex.Lock()
+	cooldown := time.Until(h.cooldownUntil).Seconds()
+	h.mutex.Unlock()
+	if cooldown < 0 {
+		cooldown = 0
+	}
+
+	return HostLimitStats{
+		Host:              h.host,
+		Concurrency:       h.concurrency,
+		RPS:               h.rps,
+		InFlight:          atomic.LoadInt64(&h.inFlight),
+		TotalRequests:     total,
+		Throttled:         atomic.LoadInt64(&h.throttled),
+		Retries:           atomic.LoadInt64(&h.retries),
+		SharedThrottled:   atomic.LoadInt64(&h.sharedThrottled),
+		AvgWaitMs:         avgWait,
+		CooldownRemaining: cooldown,
+	}
+}
+
+// OutboundTransport 共享出站HTTP层：按主机限制并发与速率，并处理429/Retry-After退避
+type OutboundTransport struct {
+	base http.RoundTripper
+}
+
+// NewOutboundTransport 用出站限流层包装底层传输
+func NewOutboundTransport(base http.RoundTripper) *OutboundTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &OutboundTransport{base: base}
+}
+
+// Base 返回被包装的底层传输
+func (t *OutboundTransport) Base() http.RoundTripper {
+	return t.base
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *OutboundTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if config.AppConfig != nil && !config.AppConfig.OutboundLimitEnabled {
+		return t.base.RoundTrip(req)
+	}
+
+	maxRetries := 2
+	maxWait := 10 * time.Second
+	if config.AppConfig != nil {
+		maxRetries = config.AppConfig.OutboundMaxRetries
+		maxWait = config.AppConfig.OutboundMaxRetryWait
+	}
+
+	limiter := getHostLimiter(req.URL.Hostname())
+	for attempt := 0; ; attempt++ {
+		if err := limiter.acquire(req.Context()); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			limiter.release()
+			return nil, err
+		}
+
+		if isThrottledResponse(resp) {
+			atomic.AddInt64(&limiter.throttled, 1)
+			wait := retryAfterDelay(resp, attempt)
+			limiter.setCooldown(wait)
+
+			if attempt < maxRetries && wait <= maxWait {
+				if retryReq, ok := rewindRequest(req); ok {
+					io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+					resp.Body.Close()
+					limiter.release()
+					atomic.AddInt64(&limiter.retries, 1)
+
+					if err := sleepWithContext(req.Context(), wait); err != nil {
+						return nil, err
+					}
+					req = retryReq
+					continue
+				}
+			}
+		}
+
+		resp.Body = &releaseOnCloseBody{ReadCloser: resp.Body, release: limiter.release}
+		return resp, nil
+	}
+}
+
+// isThrottledResponse 判断响应是否为限流响应（429，或携带Retry-After的503）
+func isThrottledResponse(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return resp.StatusCode == http.StatusServiceUnavailable && resp.Header.Get("Retry-After") != ""
+}
+
+// retryAfterDelay 解析Retry-After头，缺失时使用指数退避
+func retryAfterDelay(resp *http.Response, attempt int) time.Duration {
+	if value := strings.TrimSpace(resp.Header.Get("Retry-After")); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			if d := time.Until(at); d > 0 {
+				return d
+			}
+			return 0
+		}
+	}
+	return time.Second << uint(attempt)
+}
+
+// rewindRequest 为重试准备请求副本，无法重放请求体时返回false
+func rewindRequest(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, true
+}
+
+// sleepWithContext 可被上下文取消的等待
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// releaseOnCloseBody 响应体关闭时释放并发槽位
+type releaseOnCloseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+// Close 关闭响应体并释放槽位（只释放一次）
+func (b *releaseOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// RequestBudget 单次用户搜索的出站请求预算
+type RequestBudget struct {
+	limit int64
+	used  int64
+}
+
+// NewRequestBudget 创建请求预算，limit<=0 时返回nil（表示不限制）
+func NewRequestBudget(limit int) *RequestBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &RequestBudget{limit: int64(limit)}
+}
+
+// NewSearchRequestBudget 按配置创建单次搜索的请求预算
+func NewSearchRequestBudget() *RequestBudget {
+	if config.AppConfig == nil || !config.AppConfig.OutboundLimitEnabled {
+		return nil
+	}
+	return NewRequestBudget(config.AppConfig.OutboundSearchBudget)
+}
+
+// Take 消耗一个请求名额，预算耗尽时返回false
+func (b *RequestBudget) Take() bool {
+	if b == nil {
+		return true
+	}
+	if atomic.AddInt64(&b.used, 1) > b.limit {
+		atomic.AddInt64(&budgetExhaustedHits, 1)
+		return false
+	}
+	return true
+}
+
+// Used 返回已使用的请求数
+func (b *RequestBudget) Used() int64 {
+	if b == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&b.used)
+}
+
+// RequestBudgetFromExt 从插件ext参数中取出请求预算
+func RequestBudgetFromExt(ext map[string]interface{}) *RequestBudget {
+	if ext == nil {
+		return nil
+	}
+	budget, _ := ext[RequestBudgetExtKey].(*RequestBudget)
+	return budget
+}
+
+// budgetTransport 每次请求消耗一个预算名额
+type budgetTransport struct {
+	base   http.RoundTripper
+	budget *RequestBudget
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.budget.Take() {
+		return nil, ErrSearchBudgetExceeded
+	}
+	return t.base.RoundTrip(req)
+}
+
+// WithRequestBudget 返回受请求预算约束的客户端副本，budget为nil时原样返回
+func WithRequestBudget(client *http.Client, budget *RequestBudget) *http.Client {
+	if client == nil || budget == nil {
+		return client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	budgeted := *client
+	budgeted.Transport = &budgetTransport{base: base, budget: budget}
+	return &budgeted
+}
+
+// BudgetedClient 返回受ext中请求预算约束的客户端副本，插件使用自己创建的客户端时应通过它发起请求
+func BudgetedClient(client *http.Client, ext map[string]interface{}) *http.Client {
+	return WithRequestBudget(client, RequestBudgetFromExt(ext))
+}
+
+// GetOutboundStats 获取出站请求统计（按请求总数降序）
+func GetOutboundStats() map[string]interface{} {
+	hosts := make([]HostLimitStats, 0)
+	hostLimiters.Range(func(_, value interface{}) bool {
+		hosts = append(hosts, value.(*hostLimiter).stats())
+		return true
+	})
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].TotalRequests > hosts[j].TotalRequests
+	})
+
+	enabled := true
+	searchBudget := 0
+	if config.AppConfig != nil {
+		enabled = config.AppConfig.OutboundLimitEnabled
+		searchBudget = config.AppConfig.OutboundSearchBudget
+	}
+
+	return map[string]interface{}{
+		"enabled":          enabled,
+		"search_budget":    searchBudget,
+		"budget_exhausted": atomic.LoadInt64(&budgetExhaustedHits),
+		"hosts":            hosts,
+	}
+}
@@ -0,0 +1,504 @@
+package util
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"pansou/config"
+)
+
+// ProxyScopeTelegram Telegram频道抓取使用的代理作用范围
+const ProxyScopeTelegram = "telegram"
+
+// 代理路由目标：直连
+const proxyTargetDirect = "direct"
+
+// defaultBaseTransport 插件与Telegram客户端的默认底层传输（不修改进程全局的 http.DefaultTransport）
+var defaultBaseTransport = http.DefaultTransport.(*http.Transport).Clone()
+
+// directTransport 显式直连使用的传输（忽略环境变量代理）
+var directTransport = newRouteTransport(nil)
+
+// proxyMember 代理池中的单个代理
+type proxyMember struct {
+	rawURL    string
+	transport *http.Transport
+
+	consecutiveFailures int64
+	totalFailures       int64
+	successes           int64
+	evicted             int32
+	lastError           atomic.Value // string
+}
+
+// ProxyPool 代理池
+type ProxyPool struct {
+	name     string
+	strategy string
+	members  []*proxyMember
+	next     uint64
+}
+
+// ProxyMemberStats 单个代理的统计信息
+type ProxyMemberStats struct {
+	URL                 string `json:"url"`
+	Healthy             bool   `json:"healthy"`
+	ConsecutiveFailures int64  `json:"consecutive_failures"`
+	TotalFailures       int64  `json:"total_failures"`
+	Successes           int64  `json:"successes"`
+	LastError           string `json:"last_error,omitempty"`
+}
+
+// ProxyPoolStats 代理池统计信息
+type ProxyPoolStats struct {
+	Name     string             `json:"name"`
+	Strategy string             `json:"strategy"`
+	Members  []ProxyMemberStats `json:"members"`
+}
+
+// proxyRegistry 代理池与路由规则（配置加载后延迟构建）
+type proxyRegistry struct {
+	pools      map[string]*ProxyPool
+	scopeRules map[string]string // telegram / plugin:名称 -> 目标
+	hostRules  map[string]string // 主机名或 *.后缀 -> 目标
+}
+
+var (
+	globalProxyRegistry     *proxyRegistry
+	globalProxyRegistryOnce sync.Once
+	proxyHealthCheckOnce    sync.Once
+)
+
+// newRouteTransport 创建路由使用的传输，proxyURL为nil表示直连
+func newRouteTransport(proxyURL *url.URL) *http.Transport {
+	transport := &http.Transport{
+		ForceAttemptHTTP2:     true,
+		TLSClientConfig:       &tls.Config{},
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   20,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+	}
+	if proxyURL != nil {
+		// net/http 原生支持 http、https、socks5 代理
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return transport
+}
+
+// parseProxyURL 校验代理地址
+func parseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return u, nil
+	default:
+		return nil, fmt.Errorf("不支持的代理协议: %s", u.Scheme)
+	}
+}
+
+// newProxyPool 根据地址列表创建代理池，忽略无效地址
+func newProxyPool(name string, urls []string, strategy string) *ProxyPool {
+	pool := &ProxyPool{name: name, strategy: strategy}
+	for _, raw := range urls {
+		u, err := parseProxyURL(raw)
+		if err != nil {
+			fmt.Printf("[代理] 忽略无效代理 %s: %v\n", raw, err)
+			continue
+		}
+		pool.members = append(pool.members, &proxyMember{
+			rawURL:    raw,
+			transport: newRouteTransport(u),
+		})
+	}
+	if len(pool.members) == 0 {
+		return nil
+	}
+	return pool
+}
+
+// getProxyRegistry 获取代理注册表，配置未加载时返回nil
+func getProxyRegistry() *proxyRegistry {
+	if config.AppConfig == nil {
+		return nil
+	}
+	globalProxyRegistryOnce.Do(func() {
+		globalProxyRegistry = buildProxyRegistry()
+	})
+	return globalProxyRegistry
+}
+
+// buildProxyRegistry 根据配置构建代理池和路由规则
+func buildProxyRegistry() *proxyRegistry {
+	registry := &proxyRegistry{
+		pools:      make(map[string]*ProxyPool),
+		scopeRules: make(map[string]string),
+		hostRules:  make(map[string]string),
+	}
+
+	for name, poolConfig := range config.AppConfig.ProxyPools {
+		if pool := newProxyPool(name, poolConfig.URLs, poolConfig.Strategy); pool != nil {
+			registry.pools[name] = pool
+		}
+	}
+
+	for _, rule := range config.AppConfig.ProxyRules {
+		target := rule.Target
+		// 规则目标既不是direct也不是已定义的池时，视为单个代理地址
+		if target != proxyTargetDirect {
+			if _, ok := registry.pools[target]; !ok {
+				pool := newProxyPool(target, []string{target}, "round_robin")
+				if pool == nil {
+					continue
+				}
+				registry.pools[target] = pool
+			}
+		}
+
+		if strings.HasPrefix(rule.Scope, "host:") {
+			registry.hostRules[strings.TrimPrefix(rule.Scope, "host:")] = target
+		} else {
+			registry.scopeRules[rule.Scope] = target
+		}
+	}
+
+	return registry
+}
+
+// resolve 按 作用范围规则 > 主机规则 的顺序确定路由目标，未命中返回空字符串
+func (r *proxyRegistry) resolve(scope, host string) string {
+	if scope != "" {
+		if target, ok := r.scopeRules[scope]; ok {
+			return target
+		}
+	}
+
+	host = strings.ToLower(host)
+	if target, ok := r.hostRules[host]; ok {
+		return target
+	}
+	bestTarget, bestLen := "", 0
+	for pattern, target := range r.hostRules {
+		if !strings.HasPrefix(pattern, "*.") {
+			continue
+		}
+		suffix := pattern[2:]
+		if (host == suffix || strings.HasSuffix(host, "."+suffix)) && len(suffix) > bestLen {
+			bestTarget, bestLen = target, len(suffix)
+		}
+	}
+	return bestTarget
+}
+
+// pick 按策略选择一个健康代理，全部被剔除时选择连续失败最少的代理
+func (p *ProxyPool) pick() *proxyMember {
+	healthy := make([]*proxyMember, 0, len(p.members))
+	for _, m := range p.members {
+		if atomic.LoadInt32(&m.evicted) == 0 {
+			healthy = append(healthy, m)
+		}
+	}
+	candidates := healthy
+	if len(candidates) == 0 {
+		candidates = p.members
+	}
+
+	if p.strategy == "least_failures" || len(healthy) == 0 {
+		best := candidates[0]
+		for _, m := range candidates[1:] {
+			if atomic.LoadInt64(&m.consecutiveFailures) < atomic.LoadInt64(&best.consecutiveFailures) ||
+				(atomic.LoadInt64(&m.consecutiveFailures) == atomic.LoadInt64(&best.consecutiveFailures) &&
+					atomic.LoadInt64(&m.totalFailures) < atomic.LoadInt64(&best.totalFailures)) {
+				best = m
+			}
+		}
+		return best
+	}
+
+	idx := atomic.AddUint64(&p.next, 1) - 1
+	return candidates[idx%uint64(len(candidates))]
+}
+
+// reportFailure 记录代理失败，连续失败达到阈值时剔除
+func (m *proxyMember) reportFailure(err error) {
+	failures := atomic.AddInt64(&m.consecutiveFailures, 1)
+	atomic.AddInt64(&m.totalFailures, 1)
+	if err != nil {
+		m.lastError.Store(err.Error())
+	}
+
+	maxFailures := 3
+	if config.AppConfig != nil {
+		maxFailures = config.AppConfig.ProxyMaxFailures
+	}
+	if failures >= int64(maxFailures) && atomic.CompareAndSwapInt32(&m.evicted, 0, 1) {
+		fmt.Printf("[代理] 连续失败%d次，剔除代理: %s\n", failures, m.rawURL)
+	}
+}
+
+// reportSuccess 记录代理成功，恢复被剔除的代理
+func (m *proxyMember) reportSuccess() {
+	atomic.StoreInt64(&m.consecutiveFailures, 0)
+	atomic.AddInt64(&m.successes, 1)
+	if atomic.CompareAndSwapInt32(&m.evicted, 1, 0) {
+		fmt.Printf("[代理] 代理恢复可用: %s\n", m.rawURL)
+	}
+}
+
+// ProxyRouter 按作用范围（插件/Telegram）与目标主机选择直连或代理池的传输
+type ProxyRouter struct {
+	scope string
+	base  http.RoundTripper
+	tls   *tls.Config // 不为nil时直连和代理传输都使用该TLS配置（保持浏览器TLS指纹）
+}
+
+// newProxyRouter 创建代理路由，未命中任何规则时使用base
+func newProxyRouter(scope string, base http.RoundTripper) *ProxyRouter {
+	if base == nil {
+		base = defaultBaseTransport
+	}
+	return &ProxyRouter{scope: strings.ToLower(scope), base: base}
+}
+
+// newTLSProxyRouter 创建使用base的TLS配置的代理路由，命中直连或代理池规则时同样使用该配置
+func newTLSProxyRouter(scope string, base *http.Transport) *ProxyRouter {
+	router := newProxyRouter(scope, base)
+	router.tls = base.TLSClientConfig
+	return router
+}
+
+// tlsTransportKey 按TLS配置复用的传输副本的键
+type tlsTransportKey struct {
+	base *http.Transport
+	tls  *tls.Config
+}
+
+// 使用指定TLS配置的传输副本（tlsTransportKey -> *http.Transport）
+var tlsTransports sync.Map
+
+// transport 返回使用路由TLS配置的传输副本，未设置TLS配置时返回原传输
+func (r *ProxyRouter) transport(base *http.Transport) *http.Transport {
+	if r.tls == nil {
+		return base
+	}
+	key := tlsTransportKey{base: base, tls: r.tls}
+	if transport, ok := tlsTransports.Load(key); ok {
+		return transport.(*http.Transport)
+	}
+	transport := base.Clone()
+	transport.TLSClientConfig = r.tls.Clone()
+	actual, _ := tlsTransports.LoadOrStore(key, transport)
+	return actual.(*http.Transport)
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (r *ProxyRouter) RoundTrip(req *http.Request) (*http.Response, error) {
+	registry := getProxyRegistry()
+	if registry == nil {
+		return r.base.RoundTrip(req)
+	}
+
+	target := registry.resolve(r.scope, req.URL.Hostname())
+	switch target {
+	case "":
+		return r.base.RoundTrip(req)
+	case proxyTargetDirect:
+		return r.transport(directTransport).RoundTrip(req)
+	}
+
+	pool := registry.pools[target]
+	if pool == nil {
+		return r.base.RoundTrip(req)
+	}
+
+	member := pool.pick()
+	resp, err := r.transport(member.transport).RoundTrip(req)
+	if err == nil && resp.StatusCode != http.StatusProxyAuthRequired {
+		member.reportSuccess()
+		return resp, nil
+	}
+	if err == nil {
+		member.reportFailure(fmt.Errorf("代理认证失败: %s", resp.Status))
+		return resp, nil
+	}
+	member.reportFailure(err)
+
+	// 连接失败且请求可重放时，换一个代理重试一次
+	if req.Context().Err() == nil && len(pool.members) > 1 {
+		if retryReq, ok := rewindRequest(req); ok {
+			if next := pool.pick(); next != member {
+				retryResp, retryErr := r.transport(next.transport).RoundTrip(retryReq)
+				if retryErr == nil {
+					next.reportSuccess()
+					return retryResp, nil
+				}
+				next.reportFailure(retryErr)
+			}
+		}
+	}
+	return nil, err
+}
+
+// NewPluginTransport 为插件创建经过代理路由与出站限流的传输
+// base为插件自定义的传输（未命中代理规则时使用），为nil时使用默认传输
+func NewPluginTransport(pluginName string, base http.RoundTripper) http.RoundTripper {
+	return NewOutboundTransport(newProxyRouter("plugin:"+pluginName, base))
+}
+
+// 插件基础客户端的共享传输（插件名 -> 传输）
+var pluginTransports sync.Map
+
+// NewPluginClient 创建插件使用的HTTP客户端（代理路由 + 出站限流）
+func NewPluginClient(pluginName string, timeout time.Duration) *http.Client {
+	transport, ok := pluginTransports.Load(pluginName)
+	if !ok {
+		transport, _ = pluginTransports.LoadOrStore(pluginName, NewPluginTransport(pluginName, nil))
+	}
+	return &http.Client{
+		Transport: transport.(http.RoundTripper),
+		Timeout:   timeout,
+	}
+}
+
+// ProxyURLsFor 返回作用范围与主机对应的代理地址列表（直连或未配置时返回nil）
+// 供无法替换传输的客户端（如cloudscraper）使用
+func ProxyURLsFor(scope, host string) []string {
+	registry := getProxyRegistry()
+	if registry == nil {
+		return nil
+	}
+	pool := registry.pools[registry.resolve(strings.ToLower(scope), host)]
+	if pool == nil {
+		return nil
+	}
+
+	urls := make([]string, 0, len(pool.members))
+	for _, m := range pool.members {
+		if atomic.LoadInt32(&m.evicted) == 0 {
+			urls = append(urls, m.rawURL)
+		}
+	}
+	if len(urls) == 0 {
+		urls = append(urls, pool.pick().rawURL)
+	}
+	return urls
+}
+
+// InitProxyRouter 构建代理路由并启动健康检查（在配置加载后调用）
+func InitProxyRouter() {
+	registry := getProxyRegistry()
+	if registry == nil || len(registry.pools) == 0 {
+		return
+	}
+	interval := config.AppConfig.ProxyHealthCheckInterval
+	if interval <= 0 {
+		return
+	}
+	proxyHealthCheckOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for range ticker.C {
+				checkProxyHealth(registry)
+			}
+		}()
+	})
+}
+
+// checkProxyHealth 对所有代理执行一次健康检查
+func checkProxyHealth(registry *proxyRegistry) {
+	checkURL := config.AppConfig.ProxyHealthCheckURL
+	var wg sync.WaitGroup
+	for _, pool := range registry.pools {
+		for _, member := range pool.members {
+			wg.Add(1)
+			go func(m *proxyMember) {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+
+				req, err := http.NewRequestWithContext(ctx, "GET", checkURL, nil)
+				if err != nil {
+					return
+				}
+				resp, err := m.transport.RoundTrip(req)
+				if err != nil {
+					m.reportFailure(err)
+					return
+				}
+				resp.Body.Close()
+				if resp.StatusCode >= 500 || resp.StatusCode == http.StatusProxyAuthRequired {
+					m.reportFailure(fmt.Errorf("健康检查返回状态码: %d", resp.StatusCode))
+					return
+				}
+				m.reportSuccess()
+			}(member)
+		}
+	}
+	wg.Wait()
+}
+
+// GetProxyStats 获取代理池统计信息
+func GetProxyStats() map[string]interface{} {
+	registry := getProxyRegistry()
+	if registry == nil {
+		return map[string]interface{}{"pools": []ProxyPoolStats{}}
+	}
+
+	pools := make([]ProxyPoolStats, 0, len(registry.pools))
+	for _, pool := range registry.pools {
+		stats := ProxyPoolStats{Name: pool.name, Strategy: pool.strategy}
+		for _, m := range pool.members {
+			lastError, _ := m.lastError.Load().(string)
+			stats.Members = append(stats.Members, ProxyMemberStats{
+				URL:                 redactProxyURL(m.rawURL),
+				Healthy:             atomic.LoadInt32(&m.evicted) == 0,
+				ConsecutiveFailures: atomic.LoadInt64(&m.consecutiveFailures),
+				TotalFailures:       atomic.LoadInt64(&m.totalFailures),
+				Successes:           atomic.LoadInt64(&m.successes),
+				LastError:           lastError,
+			})
+		}
+		pools = append(pools, stats)
+	}
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Name < pools[j].Name })
+
+	rules := make(map[string]string)
+	for scope, target := range registry.scopeRules {
+		rules[scope] = redactProxyURL(target)
+	}
+	for host, target := range registry.hostRules {
+		rules["host:"+host] = redactProxyURL(target)
+	}
+
+	return map[string]interface{}{
+		"pools": pools,
+		"rules": rules,
+	}
+}
+
+// redactProxyURL 隐藏代理地址中的密码
+func redactProxyURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,84 @@
+package sharedstore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SyncAccountFiles 启动时同步插件账号文件（每个账号一个 <ID>.json）与共享存储
+// 共享存储中的账号覆盖本地文件，本地独有的账号上传到共享存储；未启用共享存储时不做任何操作
+func SyncAccountFiles(pluginName, dir string) {
+	store := Accounts()
+	if store == nil {
+		return
+	}
+
+	key := AccountsKey(pluginName)
+	remote, err := store.HGetAll(key)
+	if err != nil {
+		fmt.Printf("[%s] 读取共享账号数据失败，使用本地文件: %v\n", pluginName, err)
+		return
+	}
+
+	uploaded := 0
+	files, _ := ioutil.ReadDir(dir)
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		id := strings.TrimSuffix(file.Name(), ".json")
+		if _, ok := remote[id]; ok {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			continue
+		}
+		if err := store.HSet(key, id, data); err == nil {
+			uploaded++
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	for id, data := range remote {
+		ioutil.WriteFile(filepath.Join(dir, id+".json"), data, 0644)
+	}
+
+	fmt.Printf("[%s] 共享账号同步完成: 下载=%d, 上传=%d\n", pluginName, len(remote), uploaded)
+}
+
+// SaveAccount 保存账号数据到共享存储（未启用时忽略）
+func SaveAccount(pluginName, id string, data []byte) error {
+	store := Accounts()
+	if store == nil {
+		return nil
+	}
+	return store.HSet(AccountsKey(pluginName), id, data)
+}
+
+// LoadAccount 从共享存储读取账号数据（未启用或不存在时返回false）
+func LoadAccount(pluginName, id string) ([]byte, bool) {
+	store := Accounts()
+	if store == nil {
+		return nil, false
+	}
+	data, ok, err := store.HGet(AccountsKey(pluginName), id)
+	if err != nil {
+		return nil, false
+	}
+	return data, ok
+}
+
+// DeleteAccount 从共享存储删除账号数据（未启用时忽略）
+func DeleteAccount(pluginName, id string) error {
+	store := Accounts()
+	if store == nil {
+		return nil
+	}
+	return store.HDel(AccountsKey(pluginName), id)
+}
@@ -0,0 +1,435 @@
+package sharedstore
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// 连接池与超时参数
+const (
+	redisPoolSize    = 16
+	redisDialTimeout = 3 * time.Second
+	redisIOTimeout   = 3 * time.Second
+	// 连接失败后暂停访问共享存储的时间，期间命令直接失败，避免每个请求都等待连接超时
+	redisBreakerCooldown = 5 * time.Second
+)
+
+// ErrUnavailable 共享存储连接失败后处于熔断期间时返回的错误
+var ErrUnavailable = errors.New("redis: 共享存储暂时不可用")
+
+// RedisError Redis返回的错误回复
+type RedisError string
+
+func (e RedisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// RedisStore 基于RESP协议的共享存储，兼容Redis、KeyDB、Valkey、Dragonfly等
+type RedisStore struct {
+	addr     string
+	username string
+	password string
+	db       int
+	useTLS   bool
+	prefix   string
+
+	pool chan *redisConn
+
+	// 熔断：连接失败后到该时间（UnixNano）之前不再访问共享存储
+	brokenUntil int64
+
+	// 统计数据
+	commands int64
+	errors   int64
+}
+
+// redisConn 单个连接
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	writer *bufio.Writer
+}
+
+// NewRedisStore 根据地址创建存储，格式为 redis://[用户名:密码@]主机:端口[/库] 或 rediss://（TLS）
+func NewRedisStore(rawURL, prefix string) (*RedisStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("不支持的共享存储协议: %s", u.Scheme)
+	}
+
+	store := &RedisStore{
+		addr:   u.Host,
+		useTLS: u.Scheme == "rediss",
+		prefix: prefix,
+		pool:   make(chan *redisConn, redisPoolSize),
+	}
+	if store.addr == "" {
+		return nil, errors.New("共享存储地址缺少主机名")
+	}
+	if u.Port() == "" {
+		store.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		store.password, _ = u.User.Password()
+		store.username = u.User.Username()
+		if _, hasPassword := u.User.Password(); !hasPassword {
+			// redis://密码@主机 的简写形式
+			store.password, store.username = store.username, ""
+		}
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if store.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("无效的库编号: %s", db)
+		}
+	}
+	return store, nil
+}
+
+// Name 存储类型名称
+func (s *RedisStore) Name() string {
+	return "redis"
+}
+
+// Addr 连接地址
+func (s *RedisStore) Addr() string {
+	return s.addr
+}
+
+// Stats 命令统计
+func (s *RedisStore) Stats() (int64, int64) {
+	return atomic.LoadInt64(&s.commands), atomic.LoadInt64(&s.errors)
+}
+
+// dial 建立新连接并完成认证和选库
+func (s *RedisStore) dial() (*redisConn, error) {
+	dialer := &net.Dialer{Timeout: redisDialTimeout}
+	var conn net.Conn
+	var err error
+	if s.useTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.addr, &tls.Config{})
+	} else {
+		conn, err = dialer.Dial("tcp", s.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &redisConn{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		writer: bufio.NewWriter(conn),
+	}
+	if s.password != "" {
+		args := []string{"AUTH", s.password}
+		if s.username != "" {
+			args = []string{"AUTH", s.username, s.password}
+		}
+		if _, err := rc.do(args...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if s.db != 0 {
+		if _, err := rc.do("SELECT", strconv.Itoa(s.db)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return rc, nil
+}
+
+// allow 熔断期间拒绝命令；熔断到期后只放行一个请求探测连接，其余请求继续直接失败
+func (s *RedisStore) allow() bool {
+	until := atomic.LoadInt64(&s.brokenUntil)
+	if until == 0 {
+		return true
+	}
+	now := time.Now().UnixNano()
+	if now < until {
+		return false
+	}
+	return atomic.CompareAndSwapInt64(&s.brokenUntil, until, now+int64(redisBreakerCooldown))
+}
+
+// trip 连接失败时开启熔断
+func (s *RedisStore) trip() {
+	until := time.Now().Add(redisBreakerCooldown).UnixNano()
+	if atomic.SwapInt64(&s.brokenUntil, until) == 0 {
+		fmt.Printf("⚠️ 共享存储连接失败，%v 内暂停访问: %s\n", redisBreakerCooldown, s.addr)
+	}
+}
+
+// do 从连接池取连接执行命令，网络错误时丢弃连接
+func (s *RedisStore) do(args ...string) (interface{}, error) {
+	replies, err := s.pipeline([][]string{args})
+	if replies == nil {
+		return nil, err
+	}
+	return replies[0], err
+}
+
+// pipeline 在同一个连接上依次发送多条命令并按顺序读取回复（用于MULTI/EXEC事务）
+// 返回最后一个错误回复；网络错误时丢弃连接并开启熔断
+func (s *RedisStore) pipeline(cmds [][]string) ([]interface{}, error) {
+	atomic.AddInt64(&s.commands, 1)
+	if !s.allow() {
+		atomic.AddInt64(&s.errors, 1)
+		return nil, ErrUnavailable
+	}
+
+	var rc *redisConn
+	select {
+	case rc = <-s.pool:
+	default:
+		var err error
+		if rc, err = s.dial(); err != nil {
+			atomic.AddInt64(&s.errors, 1)
+			if _, ok := err.(RedisError); !ok {
+				s.trip()
+			}
+			return nil, err
+		}
+	}
+
+	replies, err := rc.pipeline(cmds)
+	if err != nil {
+		atomic.AddInt64(&s.errors, 1)
+		if _, ok := err.(RedisError); !ok {
+			rc.conn.Close()
+			s.trip()
+			return nil, err
+		}
+	}
+	atomic.StoreInt64(&s.brokenUntil, 0)
+
+	select {
+	case s.pool <- rc:
+	default:
+		rc.conn.Close()
+	}
+	return replies, err
+}
+
+// do 发送命令并读取回复
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	replies, err := c.pipeline([][]string{args})
+	if replies == nil {
+		return nil, err
+	}
+	return replies[0], err
+}
+
+// pipeline 发送多条命令后依次读取回复，错误回复不中断读取，返回最后一个错误回复
+func (c *redisConn) pipeline(cmds [][]string) ([]interface{}, error) {
+	c.conn.SetDeadline(time.Now().Add(redisIOTimeout))
+
+	for _, args := range cmds {
+		fmt.Fprintf(c.writer, "*%d\r\n", len(args))
+		for _, arg := range args {
+			fmt.Fprintf(c.writer, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	if err := c.writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	replies := make([]interface{}, len(cmds))
+	var replyErr error
+	for i := range cmds {
+		reply, err := readReply(c.reader)
+		if err != nil {
+			if _, ok := err.(RedisError); !ok {
+				return nil, err
+			}
+			replyErr = err
+		}
+		replies[i] = reply
+	}
+	return replies, replyErr
+}
+
+// readReply 读取一个RESP回复：简单字符串、错误、整数、批量字符串（nil表示不存在）或数组
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("redis: 无效的回复: %q", line)
+	}
+	payload := line[1 : len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, RedisError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:size], nil
+	case '*':
+		count, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		// 数组中的错误回复（如EXEC中失败的命令）作为元素返回，保证读完整个数组
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				if replyErr, ok := err.(RedisError); ok {
+					items[i] = replyErr
+					continue
+				}
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: 未知的回复类型: %q", line)
+	}
+}
+
+// Get 读取值
+func (s *RedisStore) Get(key string) ([]byte, bool, error) {
+	reply, err := s.do("GET", s.prefix+key)
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+	data, ok := reply.([]byte)
+	return data, ok, nil
+}
+
+// Set 写入值
+func (s *RedisStore) Set(key string, value []byte, ttl time.Duration) error {
+	if ttl > 0 {
+		_, err := s.do("SET", s.prefix+key, string(value), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+		return err
+	}
+	_, err := s.do("SET", s.prefix+key, string(value))
+	return err
+}
+
+// Delete 删除键
+func (s *RedisStore) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := make([]string, 0, len(keys)+1)
+	args = append(args, "DEL")
+	for _, key := range keys {
+		args = append(args, s.prefix+key)
+	}
+	_, err := s.do(args...)
+	return err
+}
+
+// IncrWindow 计数器加一，首次创建时设置过期时间
+// 在同一个事务中先以 SET NX PX 创建带过期时间的计数器再INCR，保证计数器一定会过期
+func (s *RedisStore) IncrWindow(key string, window time.Duration) (int64, error) {
+	key = s.prefix + key
+	replies, err := s.pipeline([][]string{
+		{"MULTI"},
+		{"SET", key, "0", "PX", strconv.FormatInt(window.Milliseconds(), 10), "NX"},
+		{"INCR", key},
+		{"EXEC"},
+	})
+	if err != nil {
+		return 0, err
+	}
+	results, ok := replies[3].([]interface{})
+	if !ok || len(results) != 2 {
+		return 0, fmt.Errorf("redis: 事务执行失败")
+	}
+	count, ok := results[1].(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: 无效的计数回复: %v", results[1])
+	}
+	return count, nil
+}
+
+// HSet 写入哈希字段
+func (s *RedisStore) HSet(key, field string, value []byte) error {
+	_, err := s.do("HSET", s.prefix+key, field, string(value))
+	return err
+}
+
+// HGet 读取哈希字段
+func (s *RedisStore) HGet(key, field string) ([]byte, bool, error) {
+	reply, err := s.do("HGET", s.prefix+key, field)
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+	data, ok := reply.([]byte)
+	return data, ok, nil
+}
+
+// HGetAll 读取哈希的全部字段
+func (s *RedisStore) HGetAll(key string) (map[string][]byte, error) {
+	reply, err := s.do("HGETALL", s.prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	items, _ := reply.([]interface{})
+	result := make(map[string][]byte, len(items)/2)
+	for i := 0; i+1 < len(items); i += 2 {
+		field, _ := items[i].([]byte)
+		value, _ := items[i+1].([]byte)
+		result[string(field)] = value
+	}
+	return result, nil
+}
+
+// HDel 删除哈希字段
+func (s *RedisStore) HDel(key string, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	args := append([]string{"HDEL", s.prefix + key}, fields...)
+	_, err := s.do(args...)
+	return err
+}
+
+// Ping 检查连接
+func (s *RedisStore) Ping() error {
+	_, err := s.do("PING")
+	return err
+}
+
+// Close 关闭连接池中的所有连接
+func (s *RedisStore) Close() error {
+	for {
+		select {
+		case rc := <-s.pool:
+			rc.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package sharedstore_test
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pansou/config"
+	"pansou/util/cache"
+	"pansou/util/sharedstore"
+)
+
+// respServer 进程内的最小RESP服务器，只实现共享存储用到的命令
+type respServer struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	values  map[string][]byte
+	hashes  map[string]map[string][]byte
+	expires map[string]time.Time
+	cmds    []string
+}
+
+func newRESPServer(t *testing.T) *respServer {
+	t.Helper()
+	s, err := startRESPServer()
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { s.listener.Close() })
+	return s
+}
+
+func startRESPServer() (*respServer, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &respServer{
+		listener: ln,
+		values:   make(map[string][]byte),
+		hashes:   make(map[string]map[string][]byte),
+		expires:  make(map[string]time.Time),
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s, nil
+}
+
+func (s *respServer) url() string {
+	return "redis://" + s.listener.Addr().String()
+}
+
+// commands 返回收到的命令名（不含参数）
+func (s *respServer) commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.cmds...)
+}
+
+func (s *respServer) value(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireLocked(key)
+	v, ok := s.values[key]
+	return v, ok
+}
+
+func (s *respServer) ttl(key string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if at, ok := s.expires[key]; ok {
+		return time.Until(at)
+	}
+	return -1
+}
+
+func (s *respServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	var queued [][]string
+	inMulti := false
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		var out bytes.Buffer
+		name := strings.ToUpper(args[0])
+		switch {
+		case name == "MULTI":
+			inMulti = true
+			queued = nil
+			out.WriteString("+OK\r\n")
+		case name == "EXEC":
+			inMulti = false
+			fmt.Fprintf(&out, "*%d\r\n", len(queued))
+			for _, cmd := range queued {
+				out.Write(s.exec(cmd))
+			}
+		case inMulti:
+			queued = append(queued, args)
+			out.WriteString("+QUEUED\r\n")
+		default:
+			out.Write(s.exec(args))
+		}
+		if _, err := conn.Write(out.Bytes()); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[0] != '*' {
+		return nil, fmt.Errorf("bad command: %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(header[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (s *respServer) expireLocked(key string) {
+	if at, ok := s.expires[key]; ok && time.Now().After(at) {
+		delete(s.values, key)
+		delete(s.hashes, key)
+		delete(s.expires, key)
+	}
+}
+
+func bulk(v []byte) []byte {
+	if v == nil {
+		return []byte("$-1\r\n")
+	}
+	return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(v), v))
+}
+
+// exec 执行单条命令并返回编码后的回复
+func (s *respServer) exec(args []string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := strings.ToUpper(args[0])
+	s.cmds = append(s.cmds, name)
+	if len(args) > 1 {
+		s.expireLocked(args[1])
+	}
+
+	switch name {
+	case "PING":
+		return []byte("+PONG\r\n")
+	case "GET":
+		return bulk(s.values[args[1]])
+	case "SET":
+		key := args[1]
+		var ttl time.Duration
+		nx := false
+		for i := 3; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "PX":
+				ms, _ := strconv.Atoi(args[i+1])
+				ttl = time.Duration(ms) * time.Millisecond
+				i++
+			case "NX":
+				nx = true
+			}
+		}
+		if _, exists := s.values[key]; exists && nx {
+			return []byte("$-1\r\n")
+		}
+		s.values[key] = []byte(args[2])
+		delete(s.expires, key)
+		if ttl > 0 {
+			s.expires[key] = time.Now().Add(ttl)
+		}
+		return []byte("+OK\r\n")
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := s.values[key]; ok {
+				deleted++
+			}
+			delete(s.values, key)
+			delete(s.hashes, key)
+			delete(s.expires, key)
+		}
+		return []byte(fmt.Sprintf(":%d\r\n", deleted))
+	case "INCR":
+		n, _ := strconv.ParseInt(string(s.values[args[1]]), 10, 64)
+		n++
+		s.values[args[1]] = []byte(strconv.FormatInt(n, 10))
+		return []byte(fmt.Sprintf(":%d\r\n", n))
+	case "HSET":
+		h := s.hashes[args[1]]
+		if h == nil {
+			h = make(map[string][]byte)
+			s.hashes[args[1]] = h
+		}
+		h[args[2]] = []byte(args[3])
+		return []byte(":1\r\n")
+	case "HGET":
+		return bulk(s.hashes[args[1]][args[2]])
+	case "HGETALL":
+		h := s.hashes[args[1]]
+		var out bytes.Buffer
+		fmt.Fprintf(&out, "*%d\r\n", len(h)*2)
+		for field, value := range h {
+			out.Write(bulk([]byte(field)))
+			out.Write(bulk(value))
+		}
+		return out.Bytes()
+	case "HDEL":
+		for _, field := range args[2:] {
+			delete(s.hashes[args[1]], field)
+		}
+		return []byte(":1\r\n")
+	default:
+		return []byte("-ERR unknown command '" + args[0] + "'\r\n")
+	}
+}
+
+func TestRedisStoreGetSetDelete(t *testing.T) {
+	srv := newRESPServer(t)
+	store, err := sharedstore.NewRedisStore(srv.url(), "t:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if _, ok, err := store.Get("missing"); err != nil || ok {
+		t.Fatalf("Get(missing) = %v, %v", ok, err)
+	}
+	if err := store.Set("k", []byte("v\r\nwith binary \x00"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := store.Get("k")
+	if err != nil || !ok || string(got) != "v\r\nwith binary \x00" {
+		t.Fatalf("Get(k) = %q, %v, %v", got, ok, err)
+	}
+	if ttl := srv.ttl("t:k"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("ttl = %v, want within 1m", ttl)
+	}
+
+	if err := store.HSet("h", "a", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	all, err := store.HGetAll("h")
+	if err != nil || string(all["a"]) != "1" {
+		t.Fatalf("HGetAll = %v, %v", all, err)
+	}
+
+	if err := store.Delete("k"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := store.Get("k"); ok {
+		t.Fatal("key still present after Delete")
+	}
+}
+
+func TestRedisStoreIncrWindowSetsExpiryAtomically(t *testing.T) {
+	srv := newRESPServer(t)
+	store, err := sharedstore.NewRedisStore(srv.url(), "t:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	for want := int64(1); want <= 3; want++ {
+		got, err := store.IncrWindow("rl", 200*time.Millisecond)
+		if err != nil || got != want {
+			t.Fatalf("IncrWindow = %d, %v, want %d", got, err, want)
+		}
+	}
+	// 计数器创建时即带过期时间，且后续计数不会延长窗口
+	if ttl := srv.ttl("t:rl"); ttl <= 0 || ttl > 200*time.Millisecond {
+		t.Fatalf("ttl = %v, want within window", ttl)
+	}
+	for _, cmd := range srv.commands() {
+		if cmd == "PEXPIRE" {
+			t.Fatal("IncrWindow must not set the expiry with a separate PEXPIRE")
+		}
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	if got, err := store.IncrWindow("rl", 200*time.Millisecond); err != nil || got != 1 {
+		t.Fatalf("IncrWindow after window = %d, %v, want 1", got, err)
+	}
+}
+
+func TestRedisStoreBreakerFailsFast(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	store, err := sharedstore.NewRedisStore("redis://"+addr, "t:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Get("k"); err == nil {
+		t.Fatal("expected connection error")
+	}
+
+	start := time.Now()
+	_, err = store.IncrWindow("rl", time.Second)
+	if err != sharedstore.ErrUnavailable {
+		t.Fatalf("err = %v, want ErrUnavailable", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("breaker did not fail fast: %v", elapsed)
+	}
+}
+
+// 全局共享存储（sharedstore.Default）只初始化一次，L2测试在多次运行之间共用同一个服务器
+var (
+	l2Server     *respServer
+	l2ServerOnce sync.Once
+)
+
+func TestSharedCacheL2Tier(t *testing.T) {
+	l2ServerOnce.Do(func() {
+		var err error
+		if l2Server, err = startRESPServer(); err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+	})
+	srv := l2Server
+	config.AppConfig = &config.Config{
+		CachePath:         t.TempDir(),
+		CacheMaxSizeMB:    10,
+		CacheTTLMinutes:   60,
+		SharedStoreURL:    srv.url(),
+		SharedStorePrefix: "t:",
+		SharedCacheTier:   cache.SharedTierL2,
+	}
+
+	// 两个缓存实例模拟两个PanSou实例，只通过共享存储交换数据
+	first, err := cache.NewEnhancedTwoLevelCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.AppConfig.CachePath = t.TempDir()
+	second, err := cache.NewEnhancedTwoLevelCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := fmt.Sprintf("kw-%d", time.Now().UnixNano())
+	if err := first.Set(key, []byte("results"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := srv.value("t:cache:" + key); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("entry was not written to the shared store")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	data, ok, err := second.Get(key)
+	if err != nil || !ok || string(data) != "results" {
+		t.Fatalf("second.Get = %q, %v, %v", data, ok, err)
+	}
+}
@@ -0,0 +1,85 @@
+package sharedstore
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"pansou/config"
+)
+
+// Store 多实例共享的键值存储
+// 键名由实现统一加上配置的前缀，调用方只需传入业务键
+type Store interface {
+	// Name 存储类型名称
+	Name() string
+	// Get 读取值，不存在时返回false
+	Get(key string) ([]byte, bool, error)
+	// Set 写入值，ttl为0表示不过期
+	Set(key string, value []byte, ttl time.Duration) error
+	// Delete 删除键
+	Delete(keys ...string) error
+	// IncrWindow 计数器加一并返回当前值，计数器在首次创建后window时间过期（用于固定窗口限流）
+	IncrWindow(key string, window time.Duration) (int64, error)
+	// HSet 写入哈希字段
+	HSet(key, field string, value []byte) error
+	// HGet 读取哈希字段，不存在时返回false
+	HGet(key, field string) ([]byte, bool, error)
+	// HGetAll 读取哈希的全部字段
+	HGetAll(key string) (map[string][]byte, error)
+	// HDel 删除哈希字段
+	HDel(key string, fields ...string) error
+	// Ping 检查连接
+	Ping() error
+	// Close 关闭存储
+	Close() error
+}
+
+var (
+	defaultStore     Store
+	defaultStoreOnce sync.Once
+)
+
+// Default 获取配置的全局共享存储，未配置SHARED_STORE_URL时返回nil（单机模式）
+func Default() Store {
+	defaultStoreOnce.Do(func() {
+		if config.AppConfig == nil || config.AppConfig.SharedStoreURL == "" {
+			return
+		}
+
+		store, err := NewRedisStore(config.AppConfig.SharedStoreURL, config.AppConfig.SharedStorePrefix)
+		if err != nil {
+			fmt.Printf("⚠️ 共享存储配置无效，使用单机模式: %v\n", err)
+			return
+		}
+		if err := store.Ping(); err != nil {
+			// 连接失败不影响启动，后续请求会自动重连
+			fmt.Printf("⚠️ 共享存储暂时无法连接: %v\n", err)
+		} else {
+			fmt.Printf("共享存储已连接: %s\n", store.Addr())
+		}
+		defaultStore = store
+	})
+	return defaultStore
+}
+
+// Accounts 获取用于保存插件账号数据的共享存储，未启用时返回nil
+func Accounts() Store {
+	if config.AppConfig == nil || !config.AppConfig.SharedStoreAccounts {
+		return nil
+	}
+	return Default()
+}
+
+// RateLimit 获取用于维护限流计数的共享存储，未启用时返回nil
+func RateLimit() Store {
+	if config.AppConfig == nil || !config.AppConfig.SharedStoreRateLimit {
+		return nil
+	}
+	return Default()
+}
+
+// AccountsKey 插件账号数据在共享存储中的哈希键
+func AccountsKey(pluginName string) string {
+	return "accounts:" + pluginName
+}
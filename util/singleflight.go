@@ -0,0 +1,134 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrFlightTimeout 等待合并请求的结果超时
+var ErrFlightTimeout = errors.New("等待合并请求的结果超时")
+
+// FlightGroup 请求合并：相同键的并发调用只有一个实际执行，其余调用等待并共享同一结果
+// 结果（包括错误）不会被保留，调用结束后相同键的下一次调用会重新执行
+type FlightGroup struct {
+	mu        sync.Mutex
+	calls     map[string]*flightCall
+	executed  int64
+	coalesced int64
+	timeouts  int64
+}
+
+// flightCall 正在执行的调用
+type flightCall struct {
+	done chan struct{}
+	val  interface{}
+	err  error
+}
+
+// FlightStats 请求合并统计
+type FlightStats struct {
+	Executed  int64 `json:"executed"`  // 实际执行的调用数
+	Coalesced int64 `json:"coalesced"` // 等待其他调用结果的次数
+	Timeouts  int64 `json:"timeouts"`  // 等待超时的次数
+	InFlight  int   `json:"in_flight"` // 正在执行的调用数
+}
+
+// NewFlightGroup 创建请求合并组
+func NewFlightGroup() *FlightGroup {
+	return &FlightGroup{calls: make(map[string]*flightCall)}
+}
+
+// Do 执行fn；相同键已有调用在执行时不再执行，而是等待其结果，shared为true表示结果来自其他调用
+// timeout>0时等待者最多等待timeout，超时返回ErrFlightTimeout，正在执行的调用不受影响
+func (g *FlightGroup) Do(key string, timeout time.Duration, fn func() (interface{}, error)) (val interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		atomic.AddInt64(&g.coalesced, 1)
+		return g.wait(call, timeout)
+	}
+	call := &flightCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+	atomic.AddInt64(&g.executed, 1)
+
+	defer func() {
+		r := recover()
+		if r != nil {
+			call.err = fmt.Errorf("合并请求执行异常: %v", r)
+		}
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	call.val, call.err = fn()
+	return call.val, call.err, false
+}
+
+// wait 等待正在执行的调用完成
+func (g *FlightGroup) wait(call *flightCall, timeout time.Duration) (interface{}, error, bool) {
+	if timeout <= 0 {
+		<-call.done
+		return call.val, call.err, true
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-call.done:
+		return call.val, call.err, true
+	case <-timer.C:
+		atomic.AddInt64(&g.timeouts, 1)
+		return nil, ErrFlightTimeout, true
+	}
+}
+
+// Stats 获取请求合并统计
+func (g *FlightGroup) Stats() FlightStats {
+	g.mu.Lock()
+	inFlight := len(g.calls)
+	g.mu.Unlock()
+
+	return FlightStats{
+		Executed:  atomic.LoadInt64(&g.executed),
+		Coalesced: atomic.LoadInt64(&g.coalesced),
+		Timeouts:  atomic.LoadInt64(&g.timeouts),
+		InFlight:  inFlight,
+	}
+}
+
+// FlightKey 由基础键和ext参数生成请求合并键
+// 以下划线开头的内部参数（如请求预算）不影响搜索结果，不参与生成键
+func FlightKey(base string, ext map[string]interface{}) string {
+	if len(ext) == 0 {
+		return base
+	}
+
+	keys := make([]string, 0, len(ext))
+	for k := range ext {
+		if !strings.HasPrefix(k, "_") {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return base
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(base)
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "|%s=%v", k, ext[k])
+	}
+	return sb.String()
+}